@@ -0,0 +1,17 @@
+package users
+
+import "context"
+
+type actorContextKey struct{}
+
+// WithActor returns a copy of ctx carrying the ID of the user making the changes.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns the ID of the user making the changes carried by ctx,
+// or an empty string for the changes made without an authenticated user.
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorContextKey{}).(string)
+	return actor
+}
@@ -0,0 +1,32 @@
+package users
+
+import (
+	"context"
+	"io"
+)
+
+// AvatarSize is the name of one of the fixed thumbnails an avatar is resized to.
+type AvatarSize string
+
+const (
+	// AvatarSmall is the size of the avatar shown next to the user's name.
+	AvatarSmall AvatarSize = "small"
+	// AvatarMedium is the default size of the avatar.
+	AvatarMedium AvatarSize = "medium"
+	// AvatarLarge is the size of the avatar shown on the user's profile.
+	AvatarLarge AvatarSize = "large"
+)
+
+// AvatarSizes maps every avatar size to the width and height in pixels of its square thumbnail.
+var AvatarSizes = map[AvatarSize]int{
+	AvatarSmall:  64,
+	AvatarMedium: 128,
+	AvatarLarge:  256,
+}
+
+// AvatarService is interface of avatar service.
+// Upload replaces the avatar of the user with the image read from r, resized to every AvatarSizes.
+type AvatarService interface {
+	Upload(ctx context.Context, userID string, r io.Reader) error
+	Get(ctx context.Context, userID string, size AvatarSize) (Blob, error)
+}
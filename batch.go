@@ -0,0 +1,65 @@
+package users
+
+import "context"
+
+// BatchOperationType is the type of an operation of a batch.
+type BatchOperationType string
+
+const (
+	// BatchCreate creates the user.
+	BatchCreate BatchOperationType = "create"
+	// BatchUpdate replaces the editable fields of the user.
+	BatchUpdate BatchOperationType = "update"
+	// BatchDelete soft-deletes the user.
+	BatchDelete BatchOperationType = "delete"
+)
+
+// BatchMode is how a batch handles the failure of one of its operations.
+type BatchMode string
+
+const (
+	// BatchAbortOnError applies the operations in one unit of work, rolled back as a whole on the first failure.
+	BatchAbortOnError BatchMode = "abort"
+	// BatchContinueOnError applies every operation on its own, reporting the failures while the others are kept.
+	BatchContinueOnError BatchMode = "continue"
+)
+
+// BatchOperation is the struct represent an operation of a batch.
+// The ID of the user to update or delete, and its expected version, if any, are given in User.
+type BatchOperation struct {
+	Type BatchOperationType
+	User User
+}
+
+// BatchResult is the struct represent the outcome of an operation of a batch, indexed from 0 in the order of the batch.
+// Applied tells whether the operation is kept, and Error why it failed. The operations rolled back,
+// or never run, after the failure of another one have neither.
+type BatchResult struct {
+	Index   int    `json:"index"`
+	ID      string `json:"id,omitempty"`
+	Applied bool   `json:"applied"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BatchReport is the struct represent the outcome of a batch.
+// Committed is false when a batch aborting on error failed, and nothing of it was kept.
+type BatchReport struct {
+	Mode      BatchMode     `json:"mode"`
+	Committed bool          `json:"committed"`
+	Applied   int           `json:"applied"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// UnitOfWork is interface of the unit of work over the user repository.
+// The writes made through the repository given to fn are committed together when fn succeeds,
+// and all rolled back when it fails.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context, repo UserRepository) error) error
+}
+
+// BatchService is interface of the batch service.
+// The failures of the operations are reported in the batch report, and only the unexpected errors are returned.
+type BatchService interface {
+	Execute(ctx context.Context, operations []BatchOperation, mode BatchMode) (BatchReport, error)
+}
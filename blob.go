@@ -0,0 +1,22 @@
+package users
+
+import (
+	"context"
+	"io"
+)
+
+// Blob is the struct represent a binary object read from a BlobStore.
+// The caller must close the body.
+type Blob struct {
+	Body        io.ReadCloser
+	ContentType string
+	Size        int64
+}
+
+// BlobStore is interface of a store of binary objects addressed by a slash-separated key.
+// Get returns ErrNotFound if no object is stored under the key.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (Blob, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,37 @@
+package users
+
+import (
+	"context"
+	"io"
+)
+
+// BulkExportFormat is the format of the files users are exported to.
+type BulkExportFormat string
+
+const (
+	// BulkExportCSV is the format of CSV files with a header row naming the columns.
+	BulkExportCSV BulkExportFormat = "csv"
+	// BulkExportNDJSON is the format of files with one JSON object per line.
+	BulkExportNDJSON BulkExportFormat = "ndjson"
+	// BulkExportParquet is the format of Apache Parquet files.
+	BulkExportParquet BulkExportFormat = "parquet"
+)
+
+// BulkExportColumns are the columns users can be exported with, in their default order.
+// The password is not one of them, so its hash never leaves the service.
+var BulkExportColumns = []string{"id", "email", "username", "address", "status", "version", "created_time", "updated_time", "deleted_time"}
+
+// BulkExportOptions is the struct represent the options of a bulk export.
+// All the columns are exported when Columns is empty. The Limit and After of the filter are not used.
+type BulkExportOptions struct {
+	Format  BulkExportFormat
+	Columns []string
+	Filter  UserFilter
+}
+
+// BulkExportService is interface of the bulk export service.
+// The users are written as they are read from the repository, so they are never all held in memory.
+// Nothing is written when the options are invalid.
+type BulkExportService interface {
+	Export(ctx context.Context, w io.Writer, options BulkExportOptions) (int64, error)
+}
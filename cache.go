@@ -0,0 +1,36 @@
+package users
+
+import (
+	"context"
+	"time"
+)
+
+// Cache is interface of a store of values expiring once their time to live is over, if not zero.
+// Get returns ErrNotFound if no value is cached under the key, or if it expired.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+type passwordReadsContextKey struct{}
+
+// WithPasswordReads returns a copy of ctx whose reads of the users return their password hash.
+// The caches keep the hashes apart from the users, and only return them to the reads made with it.
+func WithPasswordReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, passwordReadsContextKey{}, true)
+}
+
+// ReadsPassword tells whether the reads made with ctx must return the password hash of the users.
+func ReadsPassword(ctx context.Context) bool {
+	reads, _ := ctx.Value(passwordReadsContextKey{}).(bool)
+	return reads
+}
+
+// CachingUserRepository is interface of a UserRepository reading the users by ID, email and username through a Cache.
+// The users read through the cache have no password hash, unless read with WithPasswordReads.
+// It handles the user events to drop the cached users changed without going through it.
+type CachingUserRepository interface {
+	UserRepository
+	UserEventHandler
+}
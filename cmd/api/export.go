@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/arnaz06/users"
+)
+
+var (
+	exportOutput      string
+	exportFormat      string
+	exportColumns     []string
+	exportEmail       string
+	exportStatus      string
+	exportDeleted     string
+	exportSort        string
+	exportCreatedFrom string
+	exportCreatedTo   string
+	exportTenant      string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export users to a CSV, NDJSON or Parquet file",
+	Long: `Export the users matching the filters to a CSV, NDJSON or Parquet file, or to the standard output.
+The format is inferred from the extension of the output file unless given with --format.
+The password hashes are never exported.`,
+	Args: cobra.NoArgs,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initApp()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		filter := users.UserFilter{
+			EmailPrefix: exportEmail,
+			Status:      users.Status(exportStatus),
+			Deleted:     users.DeletedFilter(exportDeleted),
+			Sort:        users.UserSort(strings.TrimPrefix(exportSort, "-")),
+			Desc:        strings.HasPrefix(exportSort, "-"),
+		}
+		var err error
+		if exportCreatedFrom != "" {
+			filter.CreatedFrom, err = time.Parse(time.RFC3339, exportCreatedFrom)
+			if err != nil {
+				log.Fatalf("invalid --created-from: %s", exportCreatedFrom)
+			}
+		}
+		if exportCreatedTo != "" {
+			filter.CreatedTo, err = time.Parse(time.RFC3339, exportCreatedTo)
+			if err != nil {
+				log.Fatalf("invalid --created-to: %s", exportCreatedTo)
+			}
+		}
+
+		format := users.BulkExportFormat(exportFormat)
+		if format == "" {
+			format = users.BulkExportCSV
+			if ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(exportOutput)), "."); ext != "" {
+				format = users.BulkExportFormat(ext)
+			}
+		}
+
+		var w io.Writer = os.Stdout
+		var f *os.File
+		if exportOutput != "" && exportOutput != "-" {
+			f, err = os.Create(exportOutput)
+			if err != nil {
+				log.Fatalf("Can't create %s: %+v", exportOutput, err)
+			}
+			w = f
+		}
+		buffered := bufio.NewWriter(w)
+
+		ctx := users.WithTenant(context.Background(), exportTenant)
+		exported, err := bulkExportService.Export(ctx, buffered, users.BulkExportOptions{
+			Format:  format,
+			Columns: exportColumns,
+			Filter:  filter,
+		})
+		if err == nil {
+			err = buffered.Flush()
+		}
+		if err == nil && f != nil {
+			err = f.Close()
+		}
+		if err != nil {
+			if f != nil {
+				_ = f.Close()
+				_ = os.Remove(exportOutput)
+			}
+			log.Fatalf("Failed to export users: %+v", err)
+		}
+		log.Infof("Exported %d users", exported)
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "file to export the users to, the standard output when empty or -")
+	exportCmd.Flags().StringVar(&exportFormat, "format", "", "format of the file, csv, ndjson or parquet")
+	exportCmd.Flags().StringSliceVar(&exportColumns, "columns", nil, "columns to export, all of them when empty")
+	exportCmd.Flags().StringVar(&exportEmail, "email", "", "only export the users with an email starting with this prefix")
+	exportCmd.Flags().StringVar(&exportStatus, "status", "", "only export the users with this status")
+	exportCmd.Flags().StringVar(&exportDeleted, "deleted", "", "exclude, only or include the deleted users, excluded by default")
+	exportCmd.Flags().StringVar(&exportSort, "sort", "", "sort of the users, created_time or email, descending with a - prefix")
+	exportCmd.Flags().StringVar(&exportCreatedFrom, "created-from", "", "only export the users created from this RFC3339 time")
+	exportCmd.Flags().StringVar(&exportCreatedTo, "created-to", "", "only export the users created before this RFC3339 time")
+	exportCmd.Flags().StringVar(&exportTenant, "tenant", users.DefaultTenant, "tenant to export the users of")
+	rootCmd.AddCommand(exportCmd)
+}
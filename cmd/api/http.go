@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4/middleware"
 
@@ -9,32 +11,68 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
-	"github.com/arnaz06/users/internal"
 	handler "github.com/arnaz06/users/internal/http"
 )
 
-const address = ":7723"
+const (
+	address = ":7723"
+	// autoMigrateTimeout bounds the wait for the other servers applying the migrations, and their own run.
+	autoMigrateTimeout = 10 * time.Minute
+)
+
+var migrateOnStart bool
 
 var serverCmd = &cobra.Command{
 	Use:   "http",
 	Short: "Turn the server on",
+	Long: `Turn the server on, configured by the environment (see .env.example).
+With KEYRING_DIR set, USER_CACHE and SEARCH_INDEX_DIR are refused, as they would hold the users decrypted,
+so every login and search is served by the database.`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initApp()
+	},
 	Run: func(cmd *cobra.Command, args []string) {
+		if migrateOnStart {
+			ctx, cancel := context.WithTimeout(context.Background(), autoMigrateTimeout)
+			autoMigrate(ctx)
+			cancel()
+		}
+
 		e := echo.New()
-		e.Validator = internal.NewValidator()
+		e.Validator = validator
 		e.Use(
-			handler.TimeoutMiddleware(contextTimeout),
+			handler.TimeoutMiddleware(contextTimeout, handler.BulkExportPath),
+			handler.ReadYourWritesMiddleware(),
 			handler.ErrorMiddleware(),
+			handler.TenantMiddleware(tenantHostSuffix),
 			middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
-				Validator: handler.AuthenticationMiddleware(secretKey),
+				Validator: handler.AuthenticationMiddleware(secretKey, userService),
 				Skipper: func(c echo.Context) bool {
-					if c.Path() == `/user` || c.Path() == `/user/login` {
+					switch c.Path() {
+					case `/user`, `/user/login`, `/user/email/confirm`, `/user/email/revert`:
 						return true
 					}
 					return false
 				},
 			}),
 		)
-		handler.AddUserHandler(e, userService, secretKey, expiresTime)
+		handler.AddUserHandler(e, userService, secretKey, expiresTime, adminKey)
+		handler.AddExportHandler(e, exportService, adminKey)
+		handler.AddEmailChangeHandler(e, emailChangeService, adminKey)
+		handler.AddAvatarHandler(e, avatarService, avatarMaxSize, adminKey)
+		handler.AddPreferencesHandler(e, preferencesService, adminKey)
+
+		admin := handler.AdminGroup(e, adminKey)
+		handler.AddAdminHandler(admin, userService)
+		handler.AddSearchHandler(admin, searchService)
+		handler.AddImportHandler(admin, importService)
+		handler.AddBulkExportHandler(admin, bulkExportService)
+		handler.AddBatchHandler(admin, batchService)
+		handler.AddStatsHandler(admin, statsService)
+
+		if purgeRetention > 0 {
+			go runPurge(userService, purgeRetention, purgeInterval)
+		}
 
 		e.GET("ping", func(c echo.Context) error {
 			return c.String(http.StatusOK, "pong")
@@ -49,5 +87,7 @@ var serverCmd = &cobra.Command{
 }
 
 func init() {
+	serverCmd.Flags().StringVar(&storage, "storage", "", "storage of the data instead of the database, memory to keep it in memory for demos")
+	serverCmd.Flags().BoolVar(&migrateOnStart, "auto-migrate", false, "apply the migrations not applied yet before starting, one server at a time")
 	rootCmd.AddCommand(serverCmd)
 }
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/arnaz06/users"
+)
+
+var (
+	importFormat    string
+	importDryRun    bool
+	importBatchSize int
+	importTenant    string
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file|->",
+	Short: "Import users from a CSV or NDJSON file",
+	Long: `Import users from a CSV or NDJSON file, or from the standard input with -.
+The format is inferred from the extension of the file unless given with --format.
+The report of the import is printed as JSON.
+When SEARCH_INDEX_DIR is set the imported users are indexed, so the HTTP server must be stopped first.`,
+	Args: cobra.ExactArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initApp()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if searchIndex != nil {
+			defer searchIndex.Close()
+		}
+
+		var r io.Reader = os.Stdin
+		if args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				log.Fatalf("Can't open %s: %+v", args[0], err)
+			}
+			defer f.Close()
+			r = f
+		}
+
+		format := users.ImportFormat(importFormat)
+		if format == "" {
+			format = users.ImportFormat(strings.TrimPrefix(strings.ToLower(filepath.Ext(args[0])), "."))
+			if format == "jsonl" {
+				format = users.ImportNDJSON
+			}
+		}
+
+		ctx := users.WithTenant(context.Background(), importTenant)
+		report, err := importService.Import(ctx, r, format, users.ImportOptions{
+			DryRun:    importDryRun,
+			BatchSize: importBatchSize,
+		})
+		if report.Results != nil {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(report); err != nil {
+				log.Fatalf("Failed to print the import report: %+v", err)
+			}
+		}
+		if err != nil {
+			log.Fatalf("Failed to import users: %+v", err)
+		}
+		log.Infof("Imported %d of %d users, %d failed", report.Imported, report.Total, report.Failed)
+	},
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importFormat, "format", "", "format of the file, csv or ndjson")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "validate the users without importing them")
+	importCmd.Flags().IntVar(&importBatchSize, "batch-size", 100, "number of users inserted per transaction")
+	importCmd.Flags().StringVar(&importTenant, "tenant", users.DefaultTenant, "tenant to import the users into")
+	rootCmd.AddCommand(importCmd)
+}
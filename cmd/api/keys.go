@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	mysqlRepo "github.com/arnaz06/users/internal/mysql"
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage the keys encrypting the fields of the users at rest",
+	// Only the database settings and the keyring are needed, not the settings of the services.
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		initDriver()
+	},
+}
+
+var (
+	rotateBatchSize int
+	rotatePause     time.Duration
+)
+
+var keysRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Encrypt the fields of the users again with the latest key of KEYRING_DIR",
+	Long: `Encrypt the fields of the users again with the latest key of KEYRING_DIR, in batches,
+along with the HTTP server which keeps running. The fields stored before their encryption was enabled are encrypted.
+The former keys must be kept until the rotation is done. A stopped rotation is resumed by running it again.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if dbDriver != "mysql" {
+			log.Fatalf("keys rotate only supports the mysql DB_DRIVER, not %s", dbDriver)
+		}
+		encryption = loadEncryption()
+		if encryption == nil {
+			log.Fatal("KEYRING_DIR not set")
+		}
+		if rotateBatchSize < 1 {
+			log.Fatalf("invalid --batch-size: %d", rotateBatchSize)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-quit
+			log.Info("Stopping the rotation, run it again to resume it")
+			cancel()
+		}()
+
+		db := openDB("mysql", "MYSQL")
+		defer db.Close()
+
+		rotated, err := mysqlRepo.NewKeyRotator(db, encryption, rotateBatchSize, rotatePause).Rotate(ctx)
+		if err != nil {
+			log.Fatalf("Failed to rotate the keys after %d rows: %+v", rotated, err)
+		}
+		log.Infof("Rotated the keys of %d rows", rotated)
+	},
+}
+
+func init() {
+	keysRotateCmd.Flags().IntVar(&rotateBatchSize, "batch-size", 500, "number of rows read at once")
+	keysRotateCmd.Flags().DurationVar(&rotatePause, "pause", 100*time.Millisecond, "wait between the batches, to spare the database")
+	keysCmd.AddCommand(keysRotateCmd)
+	rootCmd.AddCommand(keysCmd)
+}
@@ -3,6 +3,8 @@ package main
 import (
 	// Imported for connecting to Mysql DB
 	_ "github.com/go-sql-driver/mysql"
+	// Imported for connecting to PostgreSQL DB
+	_ "github.com/lib/pq"
 )
 
 func main() {
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"strconv"
+
+	"github.com/golang-migrate/migrate"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	mysqlRepo "github.com/arnaz06/users/internal/mysql"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Manage the schema of the MySQL database",
+	Long: `Manage the schema of the MySQL database with the migrations built in the binary.
+The SQLite database is migrated on opening, and the Postgres one with make migrate-postgres-up.`,
+	// Only the database settings are needed, not the ones of the services.
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		initDriver()
+	},
+}
+
+var migrateDownAll bool
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up [N]",
+	Short: "Apply all the migrations not applied yet, or the next N",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runMigration(func(m *migrate.Migrate) error {
+			if len(args) == 0 {
+				return m.Up()
+			}
+			return m.Steps(parseSteps(args[0]))
+		})
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down [N]",
+	Short: "Roll back the last migration applied, or the last N",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runMigration(func(m *migrate.Migrate) error {
+			if migrateDownAll {
+				return m.Down()
+			}
+			steps := 1
+			if len(args) == 1 {
+				steps = parseSteps(args[0])
+			}
+			return m.Steps(-steps)
+		})
+	},
+}
+
+var migrateGotoCmd = &cobra.Command{
+	Use:   "goto VERSION",
+	Short: "Apply or roll back the migrations up to the version",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		version, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			log.Fatalf("invalid version: %s", args[0])
+		}
+		runMigration(func(m *migrate.Migrate) error {
+			return m.Migrate(uint(version))
+		})
+	},
+}
+
+var migrateForceCmd = &cobra.Command{
+	Use:   "force VERSION",
+	Short: "Set the version of the schema without applying any migration, once a failed one is fixed by hand",
+	Long: `Set the version of the schema without applying any migration, clearing its dirty flag.
+A failed migration leaves the schema dirty, which must be fixed by hand before forcing its version.
+The version -1 marks the schema as having no migration applied.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		version, err := strconv.Atoi(args[0])
+		if err != nil || version < -1 {
+			log.Fatalf("invalid version: %s", args[0])
+		}
+		runMigration(func(m *migrate.Migrate) error {
+			return m.Force(version)
+		})
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the version of the schema and the migrations not applied yet",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runMigration(func(m *migrate.Migrate) error {
+			version, dirty, err := m.Version()
+			if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+				return err
+			}
+			if errors.Is(err, migrate.ErrNilVersion) {
+				log.Info("No migration applied")
+			} else if dirty {
+				log.Warnf("Version %d, dirty: fix its failed migration by hand, then run migrate force %d", version, version)
+			} else {
+				log.Infof("Version %d", version)
+			}
+
+			pending, err := pendingMigrations(version)
+			if err != nil {
+				return err
+			}
+			for _, v := range pending {
+				log.Infof("Pending migration %d", v)
+			}
+			return nil
+		})
+	},
+}
+
+// pendingMigrations returns the versions of the migrations built in the binary which are newer than the version.
+func pendingMigrations(version uint) ([]uint, error) {
+	src, err := mysqlRepo.MigrationSource()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []uint{}
+	v, err := src.First()
+	for ; err == nil; v, err = src.Next(v) {
+		if v > version {
+			pending = append(pending, v)
+		}
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return pending, nil
+}
+
+func parseSteps(arg string) int {
+	steps, err := strconv.Atoi(arg)
+	if err != nil || steps < 1 {
+		log.Fatalf("invalid number of migrations: %s", arg)
+	}
+	return steps
+}
+
+// runMigration runs fn with the migrator of the MySQL database, over a connection pool of its own.
+func runMigration(fn func(m *migrate.Migrate) error) {
+	if dbDriver != "mysql" {
+		log.Fatalf("migrate only supports the mysql DB_DRIVER, not %s", dbDriver)
+	}
+
+	m, err := mysqlRepo.NewMigrator(openMigrationDB())
+	if err != nil {
+		log.Fatalf("Failed to load the migrations: %+v", err)
+	}
+	defer m.Close()
+
+	err = fn(m)
+	if errors.Is(err, migrate.ErrNoChange) {
+		log.Info("No change")
+		return
+	}
+	if err != nil {
+		log.Fatalf("Failed to migrate: %+v", err)
+	}
+
+	version, dirty, err := m.Version()
+	if err == nil {
+		log.Infof("Version %d, dirty: %t", version, dirty)
+	}
+}
+
+// openMigrationDB opens the MySQL database for the migrations, over a connection pool of its own.
+func openMigrationDB() *sql.DB {
+	uri := os.Getenv("MYSQL_URI")
+	if uri == "" {
+		log.Fatal("MYSQL_URI not set")
+	}
+	dsn, err := mysqlRepo.MigrationDSN(uri)
+	if err != nil {
+		log.Fatalf("invalid MYSQL_URI: %v", err)
+	}
+
+	db := openPool("mysql", "MYSQL", dsn)
+	err = db.Ping()
+	if err != nil {
+		log.Fatalf("Can't connect to MYSQL DB got Error: %+v", err)
+	}
+	return db
+}
+
+// autoMigrate applies the migrations not applied yet to the database before the server starts.
+func autoMigrate(ctx context.Context) {
+	switch dbDriver {
+	case "mysql":
+		log.Info("Applying the MySQL migrations")
+		err := mysqlRepo.MigrateUp(ctx, openMigrationDB())
+		if err != nil {
+			log.Fatalf("Failed to apply the MySQL migrations: %+v", err)
+		}
+	case "sqlite", "memory":
+		// Already migrated on opening.
+	default:
+		log.Fatalf("--auto-migrate only supports the mysql DB_DRIVER, not %s", dbDriver)
+	}
+}
+
+func init() {
+	migrateDownCmd.Flags().BoolVar(&migrateDownAll, "all", false, "roll back all the migrations applied")
+	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateGotoCmd, migrateForceCmd, migrateStatusCmd)
+	rootCmd.AddCommand(migrateCmd)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/arnaz06/users"
+)
+
+// runPurge permanently removes the users soft-deleted longer than the retention, every interval.
+func runPurge(service users.UserService, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := service.PurgeDeleted(context.Background(), retention)
+		if err != nil {
+			log.Errorf("Failed to purge deleted users: %+v", err)
+		} else if purged > 0 {
+			log.Infof("Purged %d deleted users", purged)
+		}
+		<-ticker.C
+	}
+}
@@ -1,26 +1,69 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/go-redis/redis/v8"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/arnaz06/users"
 	"github.com/arnaz06/users/cmd/logger"
+	"github.com/arnaz06/users/export"
+	"github.com/arnaz06/users/internal"
+	"github.com/arnaz06/users/internal/blob"
+	"github.com/arnaz06/users/internal/cache"
+	"github.com/arnaz06/users/internal/keyring"
+	"github.com/arnaz06/users/internal/mail"
+	"github.com/arnaz06/users/internal/memory"
 	mysqlRepo "github.com/arnaz06/users/internal/mysql"
+	postgresRepo "github.com/arnaz06/users/internal/postgres"
+	"github.com/arnaz06/users/internal/search"
+	sqliteRepo "github.com/arnaz06/users/internal/sqlite"
 	service "github.com/arnaz06/users/user"
 )
 
 var (
-	contextTimeout time.Duration
-	userRepository users.UserRepository
-	userService    users.UserService
-	secretKey      string
-	expiresTime    time.Duration
+	dbURL              string
+	dbDriver           string
+	encryption         *mysqlRepo.Encryption
+	storage            string
+	contextTimeout     time.Duration
+	userRepository     users.UserRepository
+	userService        users.UserService
+	exportService      users.ExportService
+	importService      users.ImportService
+	bulkExportService  users.BulkExportService
+	batchService       users.BatchService
+	statsService       users.StatsService
+	emailChangeService users.EmailChangeService
+	avatarService      users.AvatarService
+	preferencesService users.PreferencesService
+	searchService      users.SearchService
+	searchIndex        users.SearchIndex
+	searchIndexer      users.SearchIndexer
+	validator          users.Validator
+	avatarMaxSize      int64
+	secretKey          string
+	adminKey           string
+	tenantHostSuffix   string
+	expiresTime        time.Duration
+	purgeRetention     time.Duration
+	purgeInterval      time.Duration
+)
+
+const (
+	emailChangeConfirmTTL = 24 * time.Hour
+	emailChangeRevertTTL  = 7 * 24 * time.Hour
+	defaultAvatarMaxSize  = 5 << 20
 )
 
 var rootCmd = &cobra.Command{
@@ -30,7 +73,7 @@ var rootCmd = &cobra.Command{
 
 func init() {
 	logger.SetupLogs()
-	cobra.OnInitialize(initApp)
+	rootCmd.PersistentFlags().StringVar(&dbURL, "db", "", "database to use instead of the DB_DRIVER one, sqlite://<file> for a SQLite file")
 }
 
 // Execute the main function
@@ -41,6 +84,32 @@ func Execute() {
 	}
 }
 
+// initDriver sets the database driver from the --db and --storage flags and DB_DRIVER, and returns it along with
+// the SQLite file, if any. It is all the commands managing the database need, such as migrate.
+func initDriver() (driver, sqliteFile string) {
+	driver, sqliteFile = os.Getenv("DB_DRIVER"), os.Getenv("SQLITE_FILE")
+	if dbURL != "" {
+		if !strings.HasPrefix(dbURL, "sqlite://") {
+			log.Fatalf("invalid --db: %s, only sqlite://<file> is supported", dbURL)
+		}
+		driver, sqliteFile = "sqlite", strings.TrimPrefix(dbURL, "sqlite://")
+	}
+	switch storage {
+	case "":
+	case "memory":
+		driver = "memory"
+	default:
+		log.Fatalf("invalid --storage: %s, only memory is supported", storage)
+	}
+	if driver == "" {
+		driver = "mysql"
+	}
+	dbDriver = driver
+	return driver, sqliteFile
+}
+
+// initApp wires the repositories and the services from the environment, for the commands serving the users,
+// such as http. It is run before them, so the other commands do not need the settings of the services.
 func initApp() {
 	/*==== Key ======*/
 	secretKey = os.Getenv("SECRET_KEY")
@@ -48,6 +117,14 @@ func initApp() {
 		log.Fatal("SECRET_KEY not set")
 	}
 
+	adminKey = os.Getenv("ADMIN_KEY")
+	if adminKey == "" {
+		log.Warn("ADMIN_KEY not set, admin endpoints are disabled")
+	}
+
+	/*==== TENANT ======*/
+	tenantHostSuffix = os.Getenv("TENANT_HOST_SUFFIX")
+
 	expiry, err := strconv.ParseInt(os.Getenv("TOKEN_EXPIRY_DATE"), 10, 16)
 	if err != nil {
 		log.Fatalf("TOKEN_EXPIRY_DATE not set %+v", err)
@@ -61,56 +138,312 @@ func initApp() {
 	}
 	contextTimeout = time.Duration(t) * time.Millisecond
 
-	/*====MYSQL CONF======*/
+	/*==== PURGE ======*/
+	if retention := os.Getenv("PURGE_RETENTION_DAYS"); retention != "" {
+		days, err := strconv.Atoi(retention)
+		if err != nil {
+			log.Fatal("invalid PURGE_RETENTION_DAYS")
+		}
+		purgeRetention = time.Duration(days) * 24 * time.Hour
+	}
 
-	dsnMysql := os.Getenv("MYSQL_URI")
-	if dsnMysql == "" {
-		log.Fatal("MYSQL_URI not set")
+	purgeInterval = time.Hour
+	if interval := os.Getenv("PURGE_INTERVAL_M"); interval != "" {
+		minutes, err := strconv.Atoi(interval)
+		if err != nil {
+			log.Fatal("invalid PURGE_INTERVAL_M")
+		}
+		purgeInterval = time.Duration(minutes) * time.Minute
 	}
 
-	db, err := sql.Open("mysql", dsnMysql)
+	/*==== DATABASE ======*/
+	var (
+		emailChangeRepository users.EmailChangeRepository
+		preferencesRepository users.PreferencesRepository
+		statsRepository       users.StatsRepository
+		unitOfWork            users.UnitOfWork
+		dbSearcher            users.Searcher
+	)
+	driver, sqliteFile := initDriver()
+	switch driver {
+	case "mysql":
+		db := openDB("mysql", "MYSQL")
+		var replicas *mysqlRepo.ReplicaSet
+		if uris := os.Getenv("MYSQL_REPLICA_URIS"); uris != "" {
+			// The replicas down on start are only left out of the reads until they pass a health check.
+			replicaDBs := []*sql.DB{}
+			for _, dsn := range strings.Split(uris, ",") {
+				replicaDBs = append(replicaDBs, openPool("mysql", "MYSQL", strings.TrimSpace(dsn)))
+			}
+			replicas = mysqlRepo.NewReplicaSet(replicaDBs...)
+			replicas.Check(context.Background())
+			go replicas.Watch(context.Background(), durationSeconds("MYSQL_REPLICA_CHECK_INTERVAL_S", 5*time.Second))
+		}
+		encryption = loadEncryption()
+		userRepository = mysqlRepo.NewEncryptedUserRepository(db, replicas, encryption)
+		emailChangeRepository = mysqlRepo.NewEncryptedEmailChangeRepository(db, encryption)
+		preferencesRepository = mysqlRepo.NewPreferencesRepository(db)
+		statsRepository = mysqlRepo.NewStatsRepository(db)
+		unitOfWork = mysqlRepo.NewEncryptedUnitOfWork(db, encryption)
+		dbSearcher = mysqlRepo.NewEncryptedSearcher(db, encryption)
+	case "postgres":
+		db := openDB("postgres", "POSTGRES")
+		userRepository = postgresRepo.NewUserRepository(db)
+		emailChangeRepository = postgresRepo.NewEmailChangeRepository(db)
+		preferencesRepository = postgresRepo.NewPreferencesRepository(db)
+		statsRepository = postgresRepo.NewStatsRepository(db)
+		unitOfWork = postgresRepo.NewUnitOfWork(db)
+		dbSearcher = postgresRepo.NewSearcher(db)
+	case "sqlite":
+		if sqliteFile == "" {
+			log.Fatal("SQLITE_FILE not set")
+		}
+		// The migrations are applied on opening, as there is no database server to run them against beforehand.
+		db, err := sqliteRepo.Open(sqliteFile)
+		if err != nil {
+			log.Fatalf("Can't open SQLITE DB %s got Error: %+v", sqliteFile, err)
+		}
+		userRepository = sqliteRepo.NewUserRepository(db)
+		emailChangeRepository = sqliteRepo.NewEmailChangeRepository(db)
+		preferencesRepository = sqliteRepo.NewPreferencesRepository(db)
+		statsRepository = sqliteRepo.NewStatsRepository(db)
+		unitOfWork = sqliteRepo.NewUnitOfWork(db)
+		dbSearcher = sqliteRepo.NewSearcher(db)
+	case "memory":
+		// Nothing is kept once the process ends, which is only fit for demos.
+		log.Warn("Storing the data in memory, it is lost when the server stops")
+		store := memory.NewStore()
+		userRepository = memory.NewUserRepository(store)
+		emailChangeRepository = memory.NewEmailChangeRepository(store)
+		preferencesRepository = memory.NewPreferencesRepository(store)
+		statsRepository = memory.NewStatsRepository(store)
+		unitOfWork = memory.NewUnitOfWork(store)
+		dbSearcher = memory.NewSearcher(store)
+	default:
+		log.Fatalf("invalid DB_DRIVER: %s", driver)
+	}
+	if encryption == nil && os.Getenv("KEYRING_DIR") != "" {
+		log.Fatalf("KEYRING_DIR is only supported with the mysql DB_DRIVER, got %s", driver)
+	}
+	err = checkEncryptionAtRest(encryption != nil, os.Getenv("USER_CACHE"), os.Getenv("SEARCH_INDEX_DIR"))
 	if err != nil {
-		log.Fatalf("Can't open MYSQL connection to: %s, got err: %v", dsnMysql, err)
+		log.Fatal(err)
+	}
+
+	/*==== CACHE ======*/
+	// The users are read by ID on every authenticated request, and by email or username on every login.
+	var cachingUserRepository users.CachingUserRepository
+	if backend := os.Getenv("USER_CACHE"); backend != "" {
+		var userCache users.Cache
+		switch backend {
+		case "lru":
+			size := 10000
+			if s := os.Getenv("USER_CACHE_SIZE"); s != "" {
+				size, err = strconv.Atoi(s)
+				if err != nil || size < 1 {
+					log.Fatal("invalid USER_CACHE_SIZE")
+				}
+			}
+			userCache = cache.NewLRUCache(size)
+		case "redis":
+			options, err := redis.ParseURL(os.Getenv("REDIS_URL"))
+			if err != nil {
+				log.Fatalf("invalid REDIS_URL: %+v", err)
+			}
+			userCache = cache.NewRedisCache(redis.NewClient(options), "users:")
+		default:
+			log.Fatalf("invalid USER_CACHE: %s", backend)
+		}
+
+		ttl, negativeTTL := durationSeconds("USER_CACHE_TTL_S", time.Minute), durationSeconds("USER_CACHE_NEGATIVE_TTL_S", 5*time.Second)
+		cachingUserRepository = service.NewCachingUserRepository(userRepository, userCache, ttl, negativeTTL, contextTimeout)
+		userRepository = cachingUserRepository
+	}
+
+	/*==== MAIL ======*/
+	mailer := mail.NewLogMailer()
+	if smtpAddress := os.Getenv("SMTP_ADDRESS"); smtpAddress != "" {
+		mailer = mail.NewSMTPMailer(smtpAddress, os.Getenv("SMTP_FROM"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"))
+	}
+
+	emailChangeURL := os.Getenv("EMAIL_CHANGE_URL")
+	if emailChangeURL == "" {
+		log.Fatal("EMAIL_CHANGE_URL not set")
+	}
+
+	/*==== AVATAR ======*/
+	avatarMaxSize = defaultAvatarMaxSize
+	if maxSize := os.Getenv("AVATAR_MAX_SIZE_KB"); maxSize != "" {
+		kb, err := strconv.ParseInt(maxSize, 10, 64)
+		if err != nil {
+			log.Fatal("invalid AVATAR_MAX_SIZE_KB")
+		}
+		avatarMaxSize = kb << 10
+	}
+
+	var blobStore users.BlobStore
+	switch storage := os.Getenv("AVATAR_STORAGE"); storage {
+	case "", "file":
+		dir := os.Getenv("AVATAR_DIR")
+		if dir == "" {
+			log.Fatal("AVATAR_DIR not set")
+		}
+		blobStore = blob.NewFileStore(dir)
+	case "s3":
+		client, err := minio.New(os.Getenv("S3_ENDPOINT"), &minio.Options{
+			Creds:  credentials.NewStaticV4(os.Getenv("S3_ACCESS_KEY"), os.Getenv("S3_SECRET_KEY"), ""),
+			Secure: os.Getenv("S3_USE_SSL") == "true",
+			Region: os.Getenv("S3_REGION"),
+		})
+		if err != nil {
+			log.Fatalf("Can't create S3 client: %+v", err)
+		}
+
+		bucket := os.Getenv("S3_BUCKET")
+		if bucket == "" {
+			log.Fatal("S3_BUCKET not set")
+		}
+		blobStore = blob.NewS3Store(client, bucket)
+	default:
+		log.Fatalf("invalid AVATAR_STORAGE: %s", storage)
 	}
 
-	err = db.Ping()
+	/*==== EVENTS ======*/
+	// The logins are recorded for the statistics.
+	// The avatars of the permanently deleted users are deleted along with them.
+	eventHandlers := []users.UserEventHandler{service.NewLoginRecorder(statsRepository), service.NewAvatarRemover(blobStore)}
+	if cachingUserRepository != nil {
+		// The cached users are dropped first, so the handlers after it read them afresh.
+		eventHandlers = append([]users.UserEventHandler{cachingUserRepository}, eventHandlers...)
+	}
+
+	/*==== SEARCH ======*/
+	// The users are searched over the text index of the database, unless a search index is set up.
+	searcher := dbSearcher
+	if dir := os.Getenv("SEARCH_INDEX_DIR"); dir != "" {
+		searchIndex, err = search.NewBleveIndex(dir)
+		if err != nil {
+			log.Fatalf("Can't open search index in %s: %+v", dir, err)
+		}
+		searcher = searchIndex
+		searchIndexer = service.NewSearchIndexer(userRepository, searchIndex)
+		eventHandlers = append(eventHandlers, searchIndexer)
+	}
+
+	validator = internal.NewValidator()
+	userService = service.NewUserService(userRepository, eventHandlers...)
+	importService = service.NewImportService(userRepository, validator, eventHandlers...)
+	bulkExportService = service.NewBulkExportService(userRepository)
+	batchService = service.NewBatchService(userRepository, unitOfWork, eventHandlers...)
+	statsService = service.NewStatsService(statsRepository)
+	searchService = service.NewSearchService(userRepository, searcher)
+	avatarService = service.NewAvatarService(userRepository, blobStore, avatarMaxSize)
+	preferencesService = service.NewPreferencesService(userRepository, preferencesRepository)
+	emailChangeService = service.NewEmailChangeService(userRepository, emailChangeRepository, mailer, emailChangeURL, emailChangeConfirmTTL, emailChangeRevertTTL, eventHandlers...)
+	exportService = export.NewExportService(
+		service.NewProfileExporter(userRepository),
+		service.NewStatusHistoryExporter(userRepository),
+		service.NewHistoryExporter(userRepository),
+		service.NewLoginExporter(statsRepository),
+		service.NewEmailChangeExporter(emailChangeRepository),
+		service.NewPreferencesExporter(preferencesRepository),
+	)
+}
+
+// durationSeconds returns the duration of the environment variable in seconds, or def if it is not set.
+func durationSeconds(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		log.Fatalf("invalid %s", name)
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// checkEncryptionAtRest refuses the user cache and the search index along with the encryption at rest,
+// as they would hold the encrypted fields decrypted, out of the database.
+func checkEncryptionAtRest(encrypted bool, userCache, searchIndexDir string) error {
+	if !encrypted {
+		return nil
+	}
+	if userCache != "" {
+		return errors.New("USER_CACHE is not supported along with KEYRING_DIR, as the cached users would be decrypted")
+	}
+	if searchIndexDir != "" {
+		return errors.New("SEARCH_INDEX_DIR is not supported along with KEYRING_DIR, as the indexed users would be decrypted")
+	}
+	return nil
+}
+
+// loadEncryption loads the encryption at rest of the fields listed in ENCRYPTED_FIELDS, with the keyring of KEYRING_DIR.
+// The fields are stored as they are when KEYRING_DIR is not set.
+func loadEncryption() *mysqlRepo.Encryption {
+	dir := os.Getenv("KEYRING_DIR")
+	if dir == "" {
+		return nil
+	}
+
+	keys, err := keyring.Load(dir)
+	if err != nil {
+		log.Fatalf("Can't load the keyring of %s, got err: %+v", dir, err)
+	}
+	fields := mysqlRepo.EncryptableFields
+	if value := os.Getenv("ENCRYPTED_FIELDS"); value != "" {
+		fields = strings.Split(value, ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+	}
+	enc, err := mysqlRepo.NewEncryption(keys, fields...)
 	if err != nil {
-		log.Fatalf("Can't connect to MYSQL DB got Error: %+v", err)
+		log.Fatalf("invalid ENCRYPTED_FIELDS: %v", err)
 	}
+	return enc
+}
 
-	maxIdleConn := os.Getenv("MYSQL_MAX_IDLE_CONNECTION")
-	if maxIdleConn == "" {
-		log.Fatal("MYSQL_MAX_IDLE_CONNECTION not set")
+// openDB opens the connection pool of the database, configured by the environment variables starting with prefix.
+func openDB(driver, prefix string) *sql.DB {
+	dsn := os.Getenv(prefix + "_URI")
+	if dsn == "" {
+		log.Fatalf("%s_URI not set", prefix)
 	}
 
-	mysqlMaxIdleCon, err := strconv.Atoi(maxIdleConn)
+	db := openPool(driver, prefix, dsn)
+	err := db.Ping()
 	if err != nil {
-		log.Fatal("invalid MYSQL_MAX_IDLE_CONNECTION")
+		log.Fatalf("Can't connect to %s DB got Error: %+v", prefix, err)
 	}
-	db.SetMaxIdleConns(mysqlMaxIdleCon)
+	return db
+}
 
-	maxOpenConn := os.Getenv("MYSQL_MAX_OPEN_CONNECTION")
-	if maxIdleConn == "" {
-		log.Fatal("MYSQL_MAX_OPEN_CONNECTION not set")
+// openPool opens the connection pool to the dsn, configured by the environment variables starting with prefix.
+func openPool(driver, prefix, dsn string) *sql.DB {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		log.Fatalf("Can't open %s connection to: %s, got err: %v", prefix, dsn, err)
 	}
 
-	mysqlMaxOpenCon, err := strconv.Atoi(maxOpenConn)
+	maxIdleConn, err := strconv.Atoi(os.Getenv(prefix + "_MAX_IDLE_CONNECTION"))
 	if err != nil {
-		log.Fatal("invalid MYSQL_MAX_OPEN_CONNECTION")
+		log.Fatalf("invalid %s_MAX_IDLE_CONNECTION", prefix)
 	}
-	db.SetMaxOpenConns(mysqlMaxOpenCon)
+	db.SetMaxIdleConns(maxIdleConn)
 
-	connLifeTime := os.Getenv("MYSQL_CONNECTION_LIFETIME_M")
-	if maxIdleConn == "" {
-		log.Fatal("MYSQL_CONNECTION_LIFETIME_M not set")
+	maxOpenConn, err := strconv.Atoi(os.Getenv(prefix + "_MAX_OPEN_CONNECTION"))
+	if err != nil {
+		log.Fatalf("invalid %s_MAX_OPEN_CONNECTION", prefix)
 	}
+	db.SetMaxOpenConns(maxOpenConn)
 
-	mysqlMaxConnLifetime, err := strconv.Atoi(connLifeTime)
+	connLifetime, err := strconv.Atoi(os.Getenv(prefix + "_CONNECTION_LIFETIME_M"))
 	if err != nil {
-		log.Fatal("invalid MYSQL_CONNECTION_LIFETIME_M")
+		log.Fatalf("invalid %s_CONNECTION_LIFETIME_M", prefix)
 	}
-	db.SetConnMaxLifetime(time.Minute * time.Duration(mysqlMaxConnLifetime))
+	db.SetConnMaxLifetime(time.Minute * time.Duration(connLifetime))
 
-	userRepository = mysqlRepo.NewUserRepository(db)
-	userService = service.NewUserService(userRepository)
+	return db
 }
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckEncryptionAtRest(t *testing.T) {
+	tests := []struct {
+		testName       string
+		encrypted      bool
+		userCache      string
+		searchIndexDir string
+		expectedErr    string
+	}{
+		{
+			testName:       "success without encryption",
+			userCache:      "lru",
+			searchIndexDir: "/var/lib/users/search/users.bleve",
+		},
+		{
+			testName:  "success with encryption only",
+			encrypted: true,
+		},
+		{
+			testName:    "with user cache",
+			encrypted:   true,
+			userCache:   "redis",
+			expectedErr: "USER_CACHE is not supported along with KEYRING_DIR, as the cached users would be decrypted",
+		},
+		{
+			testName:       "with search index",
+			encrypted:      true,
+			searchIndexDir: "/var/lib/users/search/users.bleve",
+			expectedErr:    "SEARCH_INDEX_DIR is not supported along with KEYRING_DIR, as the indexed users would be decrypted",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			err := checkEncryptionAtRest(test.encrypted, test.userCache, test.searchIndexDir)
+			if test.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, test.expectedErr)
+		})
+	}
+}
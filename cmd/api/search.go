@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/arnaz06/users"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "Manage the search index of the users",
+}
+
+var rebuildTenants []string
+
+var searchRebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "Rebuild the search index from the users in the database",
+	Long: `Rebuild the search index from the users in the database, for each given tenant.
+The index can only be opened by one process, so the HTTP server must be stopped first.`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initApp()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if searchIndexer == nil {
+			log.Fatal("SEARCH_INDEX_DIR not set")
+		}
+		defer searchIndex.Close()
+
+		for _, tenant := range rebuildTenants {
+			indexed, err := searchIndexer.Rebuild(users.WithTenant(context.Background(), tenant))
+			if err != nil {
+				log.Fatalf("Failed to rebuild the search index of tenant %s: %+v", tenant, err)
+			}
+			log.Infof("Indexed %d users of tenant %s", indexed, tenant)
+		}
+	},
+}
+
+func init() {
+	searchRebuildCmd.Flags().StringSliceVar(&rebuildTenants, "tenant", []string{users.DefaultTenant}, "tenants to rebuild the index of")
+	searchCmd.AddCommand(searchRebuildCmd)
+	rootCmd.AddCommand(searchCmd)
+}
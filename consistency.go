@@ -0,0 +1,33 @@
+package users
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type writesContextKey struct{}
+
+// WithReadYourWrites returns a copy of ctx recording the writes made with it, so that the reads made with it
+// after a write see the write, even where the reads are otherwise made from replicas lagging behind.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, writesContextKey{}, new(int32))
+}
+
+// MarkWritten records a write made with ctx, if ctx records them.
+func MarkWritten(ctx context.Context) {
+	if written, ok := ctx.Value(writesContextKey{}).(*int32); ok {
+		atomic.StoreInt32(written, 1)
+	}
+}
+
+// HasWritten tells whether a write was made with ctx, in which case its reads must see the latest writes.
+func HasWritten(ctx context.Context) bool {
+	written, ok := ctx.Value(writesContextKey{}).(*int32)
+	return ok && atomic.LoadInt32(written) == 1
+}
+
+// WithLatestReads returns a copy of ctx whose reads see the latest writes, as if a write was made with it.
+func WithLatestReads(ctx context.Context) context.Context {
+	written := int32(1)
+	return context.WithValue(ctx, writesContextKey{}, &written)
+}
@@ -0,0 +1,42 @@
+package users
+
+import (
+	"context"
+	"time"
+)
+
+// EmailChange is the struct represent a request to change the user's email.
+// The change is only applied once confirmed from the new email, and can be
+// reverted from the old email until RevertExpiresTime.
+type EmailChange struct {
+	ID                 string     `json:"id"`
+	UserID             string     `json:"user_id"`
+	OldEmail           string     `json:"old_email"`
+	NewEmail           string     `json:"new_email"`
+	ConfirmTokenHash   string     `json:"-"`
+	RevertTokenHash    string     `json:"-"`
+	ConfirmExpiresTime time.Time  `json:"confirm_expires_time"`
+	RevertExpiresTime  time.Time  `json:"revert_expires_time"`
+	ConfirmedTime      *time.Time `json:"confirmed_time,omitempty"`
+	RevertedTime       *time.Time `json:"reverted_time,omitempty"`
+	CreatedTime        time.Time  `json:"created_time"`
+}
+
+// EmailChangeRepository is interface of email change repository.
+// Confirm applies the new email to the user and Revert restores the old one,
+// each only if the user's email is still the one the change expects.
+type EmailChangeRepository interface {
+	Create(ctx context.Context, change EmailChange) (EmailChange, error)
+	GetByConfirmToken(ctx context.Context, tokenHash string) (EmailChange, error)
+	GetByRevertToken(ctx context.Context, tokenHash string) (EmailChange, error)
+	ListByUser(ctx context.Context, userID string) ([]EmailChange, error)
+	Confirm(ctx context.Context, id string) error
+	Revert(ctx context.Context, id string) error
+}
+
+// EmailChangeService is interface of email change service.
+type EmailChangeService interface {
+	Request(ctx context.Context, userID, newEmail string) error
+	Confirm(ctx context.Context, token string) error
+	Revert(ctx context.Context, token string) error
+}
@@ -15,3 +15,12 @@ func EncodeString(password string) (string, error) {
 func CompareHash(hashed, input string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashed), []byte(input))
 }
+
+// IsHash reports whether the string is a bcrypt hashed password, as made by EncodeString.
+func IsHash(hashed string) bool {
+	if len(hashed) != 60 {
+		return false
+	}
+	_, err := bcrypt.Cost([]byte(hashed))
+	return err == nil
+}
@@ -9,6 +9,24 @@ var (
 
 	// ErrNotFound is thrown if any requested object is doesn't exists.
 	ErrNotFound = errors.New("Your requested object does not exists")
+
+	// ErrPreconditionFailed is thrown if the object has been modified since the given version.
+	ErrPreconditionFailed = errors.New("Your requested object has been modified")
+
+	// ErrForbidden is thrown if the request is neither made by the user it is about nor by an admin.
+	ErrForbidden = errors.New("You are not allowed to access the requested object")
+
+	// ErrUserPending is thrown if the user's account has not been activated yet.
+	ErrUserPending = errors.New("Your account is pending activation")
+
+	// ErrUserSuspended is thrown if the user's account has been suspended.
+	ErrUserSuspended = errors.New("Your account has been suspended")
+
+	// ErrUserLocked is thrown if the user's account has been locked.
+	ErrUserLocked = errors.New("Your account has been locked")
+
+	// ErrUserDeactivated is thrown if the user's account has been deactivated.
+	ErrUserDeactivated = errors.New("Your account has been deactivated")
 )
 
 // ConstraintError represents a custom error for a contstraint things.
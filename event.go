@@ -0,0 +1,34 @@
+package users
+
+import "context"
+
+// UserEventType is the kind of change a UserEvent reports.
+type UserEventType string
+
+const (
+	// UserCreated is the event of a user signing up.
+	UserCreated UserEventType = "created"
+	// UserUpdated is the event of a change of the fields or the status of a user.
+	UserUpdated UserEventType = "updated"
+	// UserDeleted is the event of a user being soft-deleted.
+	UserDeleted UserEventType = "deleted"
+	// UserPurged is the event of a soft-deleted user being permanently deleted, with everything recorded about them.
+	UserPurged UserEventType = "purged"
+	// UserRestored is the event of a soft-deleted user being restored.
+	UserRestored UserEventType = "restored"
+	// UserLoggedIn is the event of a user logging in, which changes nothing of the user.
+	UserLoggedIn UserEventType = "logged_in"
+)
+
+// UserEvent is the struct represent a change made to a user, or a login, published once committed.
+// The tenant of the user is carried by the context the event is handled with.
+type UserEvent struct {
+	Type   UserEventType
+	UserID string
+}
+
+// UserEventHandler is interface of the handlers of the user events.
+// Events are handled synchronously, so a failing handler must not fail the change already made.
+type UserEventHandler interface {
+	HandleUserEvent(ctx context.Context, event UserEvent)
+}
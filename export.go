@@ -0,0 +1,27 @@
+package users
+
+import (
+	"context"
+	"time"
+)
+
+// Export is the struct represent all the data held about a user.
+type Export struct {
+	UserID        string                 `json:"user_id"`
+	GeneratedTime time.Time              `json:"generated_time"`
+	Data          map[string]interface{} `json:"data"`
+}
+
+// ExportContributor is interface of a subsystem holding data about a user.
+// Each contributor fills its own section, keyed by its name, of the user's export.
+// Secrets such as password hashes must never be contributed.
+type ExportContributor interface {
+	Name() string
+	Export(ctx context.Context, userID string) (interface{}, error)
+}
+
+// ExportService is interface of user data export service.
+type ExportService interface {
+	Register(contributor ExportContributor)
+	Export(ctx context.Context, userID string) (Export, error)
+}
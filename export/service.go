@@ -0,0 +1,41 @@
+package export
+
+import (
+	"context"
+	"time"
+
+	"github.com/arnaz06/users"
+)
+
+type exportService struct {
+	contributors []users.ExportContributor
+}
+
+// NewExportService creates a new export service
+func NewExportService(contributors ...users.ExportContributor) users.ExportService {
+	return &exportService{
+		contributors: contributors,
+	}
+}
+
+func (s *exportService) Register(contributor users.ExportContributor) {
+	s.contributors = append(s.contributors, contributor)
+}
+
+func (s *exportService) Export(ctx context.Context, userID string) (users.Export, error) {
+	res := users.Export{
+		UserID:        userID,
+		GeneratedTime: time.Now(),
+		Data:          make(map[string]interface{}, len(s.contributors)),
+	}
+
+	for _, contributor := range s.contributors {
+		data, err := contributor.Export(ctx, userID)
+		if err != nil {
+			return users.Export{}, err
+		}
+		res.Data[contributor.Name()] = data
+	}
+
+	return res, nil
+}
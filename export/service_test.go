@@ -0,0 +1,105 @@
+package export_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/export"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
+)
+
+func TestExportService(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	tests := []struct {
+		testName       string
+		profile        testdata.FuncCall
+		sessions       testdata.FuncCall
+		expectedResult map[string]interface{}
+		expectedError  error
+	}{
+		{
+			testName: "success",
+			profile: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{"profile data", nil},
+			},
+			sessions: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{[]string{"session data"}, nil},
+			},
+			expectedResult: map[string]interface{}{
+				"profile":  "profile data",
+				"sessions": []string{"session data"},
+			},
+		},
+		{
+			testName: "error from contributor",
+			profile: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{nil, users.ErrNotFound},
+			},
+			sessions: testdata.FuncCall{
+				Called: false,
+			},
+			expectedError: users.ErrNotFound,
+		},
+		{
+			testName: "error from registered contributor",
+			profile: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{"profile data", nil},
+			},
+			sessions: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{nil, errors.New("unexpected error")},
+			},
+			expectedError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			profile := new(mocks.ExportContributor)
+			profile.On("Name").Return("profile").Maybe()
+			if test.profile.Called {
+				profile.On("Export", test.profile.Input...).
+					Return(test.profile.Output...).Once()
+			}
+
+			sessions := new(mocks.ExportContributor)
+			sessions.On("Name").Return("sessions").Maybe()
+			if test.sessions.Called {
+				sessions.On("Export", test.sessions.Input...).
+					Return(test.sessions.Output...).Once()
+			}
+
+			service := export.NewExportService(profile)
+			service.Register(sessions)
+			res, err := service.Export(context.Background(), mockUser.ID)
+			profile.AssertExpectations(t)
+			sessions.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, mockUser.ID, res.UserID)
+			require.Equal(t, test.expectedResult, res.Data)
+		})
+	}
+}
@@ -3,16 +3,29 @@ module github.com/arnaz06/users
 go 1.13
 
 require (
+	github.com/alicebob/miniredis/v2 v2.14.1
+	github.com/blevesearch/bleve v1.0.14
+	github.com/boltdb/bolt v1.3.1 // indirect
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/go-playground/universal-translator v0.17.0 // indirect
+	github.com/go-redis/redis/v8 v8.4.4
 	github.com/go-sql-driver/mysql v1.5.0
 	github.com/golang-migrate/migrate v3.5.4+incompatible
 	github.com/google/uuid v1.1.2
+	github.com/johannesboyne/gofakes3 v0.0.0-20220627085814-c3ac35da23b2
 	github.com/labstack/echo/v4 v4.1.17
 	github.com/leodido/go-urn v1.2.1 // indirect
+	github.com/lib/pq v1.9.0
+	github.com/minio/minio-go/v7 v7.0.6
 	github.com/sirupsen/logrus v1.7.0
 	github.com/spf13/cobra v1.1.1
 	github.com/stretchr/testify v1.6.1
+	github.com/xitongsys/parquet-go v1.5.1
+	github.com/xitongsys/parquet-go-source v0.0.0-20190524061010-2b72cbee77d5
 	golang.org/x/crypto v0.0.0-20201221181555-eec23a3978ad
+	golang.org/x/image v0.0.0-20201208152932-35266b937fa6
+	golang.org/x/sync v0.0.0-20201020160332-67f06af15bc9
+	golang.org/x/text v0.3.3
 	gopkg.in/go-playground/validator.v9 v9.31.0
+	modernc.org/sqlite v1.10.6
 )
@@ -0,0 +1,67 @@
+package users
+
+import "time"
+
+// RedactedValue replaces the values of the secret fields in the history of a user.
+const RedactedValue = "[REDACTED]"
+
+// HistoryAction is the kind of change recorded in the history of a user.
+type HistoryAction string
+
+const (
+	// HistoryCreate is the action of creating the user.
+	HistoryCreate HistoryAction = "create"
+	// HistoryUpdate is the action of changing the fields of the user.
+	HistoryUpdate HistoryAction = "update"
+	// HistoryDelete is the action of soft-deleting the user.
+	HistoryDelete HistoryAction = "delete"
+	// HistoryRestore is the action of restoring a soft-deleted user.
+	HistoryRestore HistoryAction = "restore"
+	// HistoryStatusChange is the action of changing the status of the user.
+	HistoryStatusChange HistoryAction = "status_change"
+)
+
+// FieldChange is the struct represent the value of a field before and after a change.
+type FieldChange struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// HistoryEntry is the struct represent a change made to the user's data.
+// Actor is empty for the changes made without an authenticated user, such as sign-ups.
+type HistoryEntry struct {
+	ID          int64                  `json:"id"`
+	UserID      string                 `json:"user_id"`
+	Action      HistoryAction          `json:"action"`
+	Actor       string                 `json:"actor"`
+	Changes     map[string]FieldChange `json:"changes"`
+	CreatedTime time.Time              `json:"created_time"`
+}
+
+// HistoryPage is the struct represent a page of the history of a user, newest first.
+// NextCursor is empty on the last page.
+type HistoryPage struct {
+	Entries    []HistoryEntry `json:"entries"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// DiffUsers returns the fields changed from before to after, keyed by their JSON name.
+// The values of secret fields are redacted, only recording that they changed.
+func DiffUsers(before, after User) map[string]FieldChange {
+	changes := map[string]FieldChange{}
+	diff := func(field, before, after string) {
+		if before != after {
+			changes[field] = FieldChange{Before: before, After: after}
+		}
+	}
+
+	diff("email", before.Email, after.Email)
+	diff("username", before.Username, after.Username)
+	diff("address", before.Address, after.Address)
+	diff("status", string(before.Status), string(after.Status))
+	if before.Password != after.Password {
+		changes["password"] = FieldChange{Before: RedactedValue, After: RedactedValue}
+	}
+
+	return changes
+}
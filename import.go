@@ -0,0 +1,61 @@
+package users
+
+import (
+	"context"
+	"io"
+)
+
+// ImportFormat is the format of the files users are imported from.
+type ImportFormat string
+
+const (
+	// ImportCSV is the format of CSV files with a header row naming the columns.
+	ImportCSV ImportFormat = "csv"
+	// ImportNDJSON is the format of files with one JSON object per line.
+	ImportNDJSON ImportFormat = "ndjson"
+)
+
+// ImportRow is the struct represent a user to import, as read from a row of the file.
+// Either Password, in plaintext, or PasswordHash, hashed with bcrypt, must be set.
+type ImportRow struct {
+	Email        string `json:"email"`
+	Username     string `json:"username"`
+	Address      string `json:"address"`
+	Password     string `json:"password"`
+	PasswordHash string `json:"password_hash"`
+}
+
+// ImportOptions is the struct represent the options of an import.
+// With DryRun, every row is validated and inserted, but the inserts are rolled back.
+// With Pending, the users are created pending activation, and can not log in until an admin reactivates them.
+type ImportOptions struct {
+	DryRun    bool
+	BatchSize int
+	Pending   bool
+}
+
+// ImportResult is the struct represent the outcome of importing one row, numbered from 1 after the header
+// of CSV files, or skipping the blank lines of NDJSON files.
+// ID is the ID given to the imported user, and Error why the row was not imported.
+type ImportResult struct {
+	Row   int    `json:"row"`
+	Email string `json:"email,omitempty"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ImportReport is the struct represent the outcome of an import.
+type ImportReport struct {
+	DryRun   bool           `json:"dry_run"`
+	Total    int            `json:"total"`
+	Imported int            `json:"imported"`
+	Failed   int            `json:"failed"`
+	Results  []ImportResult `json:"results"`
+}
+
+// ImportService is interface of the import service.
+// The file is read as a stream, and only fails as a whole when it can not be read or a batch can not be inserted,
+// with the report of the rows handled so far.
+type ImportService interface {
+	Import(ctx context.Context, r io.Reader, format ImportFormat, options ImportOptions) (ImportReport, error)
+}
@@ -0,0 +1,116 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/arnaz06/users"
+)
+
+type fileStore struct {
+	dir string
+}
+
+// NewFileStore is constructor for a blob store keeping every object as a file under dir.
+// The content type of an object is derived from the extension of its key.
+func NewFileStore(dir string) users.BlobStore {
+	return fileStore{
+		dir: dir,
+	}
+}
+
+func (s fileStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (err error) {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(name), 0755)
+	if err != nil {
+		return err
+	}
+
+	// The object is written to a temporary file first, so readers never see a partial object.
+	tmp, err := ioutil.TempFile(filepath.Dir(name), ".tmp-"+filepath.Base(name))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	_, err = io.Copy(tmp, r)
+	if err != nil {
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
+
+func (s fileStore) Get(ctx context.Context, key string) (users.Blob, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return users.Blob{}, err
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return users.Blob{}, users.ErrNotFound
+		}
+		return users.Blob{}, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return users.Blob{}, err
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return users.Blob{
+		Body:        file,
+		ContentType: contentType,
+		Size:        info.Size(),
+	}, nil
+}
+
+func (s fileStore) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(name)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// path returns the file of the object, refusing keys that would escape the directory of the store.
+func (s fileStore) path(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" || strings.HasSuffix(key, "/") {
+		return "", users.ConstraintErrorf("invalid blob key: %s", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(cleaned)), nil
+}
@@ -0,0 +1,61 @@
+package blob
+
+import (
+	"context"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+
+	"github.com/arnaz06/users"
+)
+
+type s3Store struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Store is constructor for a blob store keeping every object in a bucket of an S3-compatible storage.
+func NewS3Store(client *minio.Client, bucket string) users.BlobStore {
+	return s3Store{
+		client: client,
+		bucket: bucket,
+	}
+}
+
+func (s s3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return err
+}
+
+func (s s3Store) Get(ctx context.Context, key string) (users.Blob, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return users.Blob{}, mapS3Error(err)
+	}
+
+	// GetObject is lazy, the object is only requested by Stat.
+	info, err := object.Stat()
+	if err != nil {
+		_ = object.Close()
+		return users.Blob{}, mapS3Error(err)
+	}
+
+	return users.Blob{
+		Body:        object,
+		ContentType: info.ContentType,
+		Size:        info.Size,
+	}, nil
+}
+
+func (s s3Store) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func mapS3Error(err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return users.ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,95 @@
+package blob_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/blob"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blob")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	testStore(t, blob.NewFileStore(dir))
+
+	t.Run("with key escaping the directory", func(t *testing.T) {
+		store := blob.NewFileStore(dir + "/store")
+		err := store.Put(context.Background(), "../outside.png", strings.NewReader("data"), 4, "image/png")
+		require.NoError(t, err)
+
+		_, err = os.Stat(dir + "/outside.png")
+		require.True(t, os.IsNotExist(err))
+		_, err = os.Stat(dir + "/store/outside.png")
+		require.NoError(t, err)
+	})
+}
+
+func TestS3Store(t *testing.T) {
+	server := httptest.NewServer(gofakes3.New(s3mem.New()).Server())
+	defer server.Close()
+
+	endpoint, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds: credentials.NewStaticV4("access-key", "secret-key", ""),
+	})
+	require.NoError(t, err)
+
+	err = client.MakeBucket(context.Background(), "avatars", minio.MakeBucketOptions{})
+	require.NoError(t, err)
+
+	testStore(t, blob.NewS3Store(client, "avatars"))
+}
+
+func testStore(t *testing.T, store users.BlobStore) {
+	ctx := context.Background()
+	key := "avatars/default/123/small.png"
+
+	t.Run("get missing object", func(t *testing.T) {
+		_, err := store.Get(ctx, key)
+		require.Equal(t, users.ErrNotFound, err)
+	})
+
+	t.Run("put and get", func(t *testing.T) {
+		err := store.Put(ctx, key, strings.NewReader("first"), 5, "image/png")
+		require.NoError(t, err)
+		err = store.Put(ctx, key, strings.NewReader("second"), 6, "image/png")
+		require.NoError(t, err)
+
+		res, err := store.Get(ctx, key)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		data, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Equal(t, "second", string(data))
+		require.Equal(t, "image/png", res.ContentType)
+		require.Equal(t, int64(6), res.Size)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		err := store.Delete(ctx, key)
+		require.NoError(t, err)
+
+		_, err = store.Get(ctx, key)
+		require.Equal(t, users.ErrNotFound, err)
+
+		err = store.Delete(ctx, key)
+		require.NoError(t, err)
+	})
+}
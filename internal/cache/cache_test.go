@@ -0,0 +1,83 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/cache"
+)
+
+func TestLRUCache(t *testing.T) {
+	testCache(t, cache.NewLRUCache(10), func(d time.Duration) { time.Sleep(d) })
+
+	t.Run("with least recently used evicted", func(t *testing.T) {
+		ctx := context.Background()
+		c := cache.NewLRUCache(2)
+		require.NoError(t, c.Set(ctx, "a", []byte("1"), 0))
+		require.NoError(t, c.Set(ctx, "b", []byte("2"), 0))
+		_, err := c.Get(ctx, "a")
+		require.NoError(t, err)
+
+		require.NoError(t, c.Set(ctx, "c", []byte("3"), 0))
+		_, err = c.Get(ctx, "b")
+		require.Equal(t, users.ErrNotFound, err)
+		_, err = c.Get(ctx, "a")
+		require.NoError(t, err)
+		_, err = c.Get(ctx, "c")
+		require.NoError(t, err)
+	})
+}
+
+func TestRedisCache(t *testing.T) {
+	server, err := miniredis.Run()
+	require.NoError(t, err)
+	defer server.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	testCache(t, cache.NewRedisCache(client, "users:"), server.FastForward)
+
+	t.Run("with prefixed keys", func(t *testing.T) {
+		require.True(t, server.Exists("users:key"))
+		require.False(t, server.Exists("key"))
+	})
+}
+
+// testCache checks the cache, letting the time to live of its values run with wait.
+func testCache(t *testing.T, c users.Cache, wait func(d time.Duration)) {
+	ctx := context.Background()
+
+	t.Run("with missing key", func(t *testing.T) {
+		_, err := c.Get(ctx, "missing")
+		require.Equal(t, users.ErrNotFound, err)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		require.NoError(t, c.Set(ctx, "key", []byte("value"), 0))
+		res, err := c.Get(ctx, "key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value"), res)
+
+		require.NoError(t, c.Set(ctx, "deleted", []byte("value"), 0))
+		require.NoError(t, c.Delete(ctx, "deleted", "missing"))
+		_, err = c.Get(ctx, "deleted")
+		require.Equal(t, users.ErrNotFound, err)
+	})
+
+	t.Run("with expired value", func(t *testing.T) {
+		require.NoError(t, c.Set(ctx, "expiring", []byte("value"), 50*time.Millisecond))
+		_, err := c.Get(ctx, "expiring")
+		require.NoError(t, err)
+
+		wait(60 * time.Millisecond)
+		_, err = c.Get(ctx, "expiring")
+		require.Equal(t, users.ErrNotFound, err)
+	})
+}
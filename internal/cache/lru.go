@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/arnaz06/users"
+)
+
+type lruItem struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+type lruCache struct {
+	mu    sync.Mutex
+	size  int
+	items map[string]*list.Element
+	// order holds the items from the most to the least recently used.
+	order *list.List
+}
+
+// NewLRUCache is constructor for a cache kept in the memory of the process, holding at most size values.
+// The least recently used value is evicted to make room for a new one.
+func NewLRUCache(size int) users.Cache {
+	return &lruCache{
+		size:  size,
+		items: map[string]*list.Element{},
+		order: list.New(),
+	}
+}
+
+func (c *lruCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, users.ErrNotFound
+	}
+	item := el.Value.(*lruItem)
+	if !item.expires.IsZero() && !time.Now().Before(item.expires) {
+		c.remove(el)
+		return nil, users.ErrNotFound
+	}
+
+	c.order.MoveToFront(el)
+	return append([]byte(nil), item.value...), nil
+}
+
+func (c *lruCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item := &lruItem{
+		key:   key,
+		value: append([]byte(nil), value...),
+	}
+	if ttl > 0 {
+		item.expires = time.Now().Add(ttl)
+	}
+
+	if el, ok := c.items[key]; ok {
+		el.Value = item
+		c.order.MoveToFront(el)
+		return nil
+	}
+
+	c.items[key] = c.order.PushFront(item)
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+func (c *lruCache) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := c.items[key]; ok {
+			c.remove(el)
+		}
+	}
+	return nil
+}
+
+func (c *lruCache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*lruItem).key)
+}
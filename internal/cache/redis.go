@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/arnaz06/users"
+)
+
+type redisCache struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisCache is constructor for a cache kept in Redis, shared by the instances of the service.
+// The keys are prefixed with prefix, so that the database can be shared with other services.
+func NewRedisCache(client redis.UniversalClient, prefix string) users.Cache {
+	return redisCache{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (c redisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	res, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, users.ErrNotFound
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c redisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, c.prefix+key, value, ttl).Err()
+}
+
+func (c redisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	prefixed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixed = append(prefixed, c.prefix+key)
+	}
+	return c.client.Del(ctx, prefixed...).Err()
+}
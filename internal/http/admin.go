@@ -0,0 +1,143 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/arnaz06/users"
+)
+
+type adminHandler struct {
+	service users.UserService
+}
+
+// AdminGroup returns the group of the admin routes, served under /users and restricted to the requests carrying
+// the admin key. It is built once and given to the handlers adding admin routes.
+func AdminGroup(e *echo.Echo, adminKey string) *echo.Group {
+	return e.Group("/users", AdminMiddleware(adminKey))
+}
+
+// AddAdminHandler adds the admin handler to the admin group.
+func AddAdminHandler(g *echo.Group, service users.UserService) {
+	if service == nil {
+		panic("http: nil users service")
+	}
+
+	handler := &adminHandler{
+		service: service,
+	}
+
+	g.GET("", handler.list)
+	g.GET("/deleted", handler.listDeleted)
+	g.POST("/deleted/:userId/restore", handler.restore)
+	g.DELETE("/deleted/:userId", handler.hardDelete)
+	g.POST("/:userId/suspend", handler.changeStatus(users.StatusSuspended))
+	g.POST("/:userId/reactivate", handler.changeStatus(users.StatusActive))
+	g.POST("/:userId/lock", handler.changeStatus(users.StatusLocked))
+	g.POST("/:userId/deactivate", handler.changeStatus(users.StatusDeactivated))
+}
+
+type statusChangeRequest struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+func (h adminHandler) list(c echo.Context) error {
+	filter, err := userFilterFromQuery(c)
+	if err != nil {
+		return err
+	}
+	if param := c.QueryParam("limit"); param != "" {
+		filter.Limit, err = strconv.Atoi(param)
+		if err != nil {
+			return users.ConstraintErrorf("invalid limit: %s", param)
+		}
+	}
+	if param := c.QueryParam("total"); param != "" {
+		filter.WithTotal, err = strconv.ParseBool(param)
+		if err != nil {
+			return users.ConstraintErrorf("invalid total: %s", param)
+		}
+	}
+
+	res, err := h.service.List(c.Request().Context(), filter, c.QueryParam("cursor"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newUserPageResponse(res))
+}
+
+// userFilterFromQuery reads the filters and the sort of a listing from the query params.
+func userFilterFromQuery(c echo.Context) (users.UserFilter, error) {
+	filter := users.UserFilter{
+		EmailPrefix: c.QueryParam("email"),
+		Status:      users.Status(c.QueryParam("status")),
+		Deleted:     users.DeletedFilter(c.QueryParam("deleted")),
+		Sort:        users.UserSort(strings.TrimPrefix(c.QueryParam("sort"), "-")),
+		Desc:        strings.HasPrefix(c.QueryParam("sort"), "-"),
+	}
+
+	var err error
+	if param := c.QueryParam("created_from"); param != "" {
+		filter.CreatedFrom, err = time.Parse(time.RFC3339, param)
+		if err != nil {
+			return users.UserFilter{}, users.ConstraintErrorf("invalid created_from: %s", param)
+		}
+	}
+	if param := c.QueryParam("created_to"); param != "" {
+		filter.CreatedTo, err = time.Parse(time.RFC3339, param)
+		if err != nil {
+			return users.UserFilter{}, users.ConstraintErrorf("invalid created_to: %s", param)
+		}
+	}
+	return filter, nil
+}
+
+func (h adminHandler) listDeleted(c echo.Context) error {
+	res, err := h.service.ListDeleted(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newUserResponses(res))
+}
+
+func (h adminHandler) restore(c echo.Context) error {
+	err := h.service.Restore(c.Request().Context(), c.Param("userId"))
+	if err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h adminHandler) hardDelete(c echo.Context) error {
+	err := h.service.HardDelete(c.Request().Context(), c.Param("userId"))
+	if err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h adminHandler) changeStatus(to users.Status) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var input statusChangeRequest
+		if err := c.Bind(&input); err != nil {
+			return users.ConstraintErrorf("%s", err)
+		}
+
+		if err := c.Validate(input); err != nil {
+			return users.ConstraintErrorf("error validating status change: %+v", err)
+		}
+
+		actor, _ := c.Get(userIDKey).(string)
+		err := h.service.ChangeStatus(c.Request().Context(), c.Param("userId"), to, input.Reason, actor)
+		if err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+}
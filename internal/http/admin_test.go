@@ -0,0 +1,393 @@
+package http_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	handler "github.com/arnaz06/users/internal/http"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
+)
+
+const adminKey = "admin-secret"
+
+func TestListDeletedUserHandler(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	tests := []struct {
+		testName       string
+		adminKey       string
+		service        testdata.FuncCall
+		expectedStatus int
+	}{
+		{
+			testName: "success",
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything},
+				Output: []interface{}{[]users.User{mockUser}, nil},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName: "with invalid admin key",
+			adminKey: "invalid",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			testName: "with unexpected error from service",
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything},
+				Output: []interface{}{nil, errors.New("unexpected error")},
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			e := getEchoServer()
+			mockService := new(mocks.UserService)
+			if test.service.Called {
+				mockService.On("ListDeleted", test.service.Input...).
+					Return(test.service.Output...).Once()
+			}
+
+			req := httptest.NewRequest(echo.GET, "/users/deleted", nil)
+			req.Header.Set("X-Admin-Key", test.adminKey)
+			rec := httptest.NewRecorder()
+
+			handler.AddAdminHandler(handler.AdminGroup(e, adminKey), mockService)
+			e.ServeHTTP(rec, req)
+
+			mockService.AssertExpectations(t)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+			require.NotContains(t, rec.Body.String(), "password")
+		})
+	}
+}
+
+func TestRestoreUserHandler(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	tests := []struct {
+		testName       string
+		service        testdata.FuncCall
+		expectedStatus int
+	}{
+		{
+			testName: "success",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{nil},
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			testName: "with email already taken",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.ConstraintErrorf("email is already used")},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with deleted user not found",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.ErrNotFound},
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			e := getEchoServer()
+			mockService := new(mocks.UserService)
+			if test.service.Called {
+				mockService.On("Restore", test.service.Input...).
+					Return(test.service.Output...).Once()
+			}
+
+			req := httptest.NewRequest(echo.POST, "/users/deleted/"+mockUser.ID+"/restore", nil)
+			req.Header.Set("X-Admin-Key", adminKey)
+			rec := httptest.NewRecorder()
+
+			handler.AddAdminHandler(handler.AdminGroup(e, adminKey), mockService)
+			e.ServeHTTP(rec, req)
+
+			mockService.AssertExpectations(t)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestHardDeleteUserHandler(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	tests := []struct {
+		testName       string
+		service        testdata.FuncCall
+		expectedStatus int
+	}{
+		{
+			testName: "success",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{nil},
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			testName: "with unexpected error from service",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{errors.New("unexpected error")},
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			e := getEchoServer()
+			mockService := new(mocks.UserService)
+			if test.service.Called {
+				mockService.On("HardDelete", test.service.Input...).
+					Return(test.service.Output...).Once()
+			}
+
+			req := httptest.NewRequest(echo.DELETE, "/users/deleted/"+mockUser.ID, nil)
+			req.Header.Set("X-Admin-Key", adminKey)
+			rec := httptest.NewRecorder()
+
+			handler.AddAdminHandler(handler.AdminGroup(e, adminKey), mockService)
+			e.ServeHTTP(rec, req)
+
+			mockService.AssertExpectations(t)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestChangeStatusUserHandler(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	tests := []struct {
+		testName       string
+		transition     string
+		input          string
+		service        testdata.FuncCall
+		expectedStatus int
+	}{
+		{
+			testName:   "success suspend",
+			transition: "suspend",
+			input:      `{"reason": "spam"}`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID, users.StatusSuspended, "spam", ""},
+				Output: []interface{}{nil},
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			testName:   "success reactivate",
+			transition: "reactivate",
+			input:      `{"reason": "appeal accepted"}`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID, users.StatusActive, "appeal accepted", ""},
+				Output: []interface{}{nil},
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			testName:   "with missing reason",
+			transition: "lock",
+			input:      `{}`,
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:   "with invalid transition",
+			transition: "deactivate",
+			input:      `{"reason": "closed"}`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID, users.StatusDeactivated, "closed", ""},
+				Output: []interface{}{users.ConstraintErrorf("invalid transition")},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			e := getEchoServer()
+			mockService := new(mocks.UserService)
+			if test.service.Called {
+				mockService.On("ChangeStatus", test.service.Input...).
+					Return(test.service.Output...).Once()
+			}
+
+			req := httptest.NewRequest(echo.POST, "/users/"+mockUser.ID+"/"+test.transition, strings.NewReader(test.input))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set("X-Admin-Key", adminKey)
+			rec := httptest.NewRecorder()
+
+			handler.AddAdminHandler(handler.AdminGroup(e, adminKey), mockService)
+			e.ServeHTTP(rec, req)
+
+			mockService.AssertExpectations(t)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestListUserHandler(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+	total := int64(1)
+
+	tests := []struct {
+		testName       string
+		query          string
+		cursor         string
+		service        testdata.FuncCall
+		expectedStatus int
+	}{
+		{
+			testName: "success",
+			query:    "?email=jhon&status=active&deleted=include&sort=-email&limit=10&total=true&created_from=2021-01-01T00:00:00Z&created_to=2021-02-01T00:00:00Z&cursor=abc",
+			service: testdata.FuncCall{
+				Called: true,
+				Input: []interface{}{mock.Anything, users.UserFilter{
+					EmailPrefix: "jhon",
+					CreatedFrom: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+					CreatedTo:   time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+					Status:      users.StatusActive,
+					Deleted:     users.DeletedInclude,
+					Sort:        users.SortEmail,
+					Desc:        true,
+					Limit:       10,
+					WithTotal:   true,
+				}, "abc"},
+				Output: []interface{}{users.UserPage{Users: []users.User{mockUser}, NextCursor: "def", Total: &total}, nil},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName: "with invalid created_from",
+			query:    "?created_from=yesterday",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with invalid limit",
+			query:    "?limit=abc",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with invalid total",
+			query:    "?total=maybe",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with invalid filter",
+			query:    "?sort=password",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, users.UserFilter{Sort: "password"}, ""},
+				Output: []interface{}{users.UserPage{}, users.ConstraintErrorf("invalid sort: password")},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with unexpected error from service",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, users.UserFilter{}, ""},
+				Output: []interface{}{users.UserPage{}, errors.New("unexpected error")},
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			e := getEchoServer()
+			mockService := new(mocks.UserService)
+			if test.service.Called {
+				mockService.On("List", test.service.Input...).
+					Return(test.service.Output...).Once()
+			}
+
+			req := httptest.NewRequest(echo.GET, "/users"+test.query, nil)
+			req.Header.Set("X-Admin-Key", adminKey)
+			rec := httptest.NewRecorder()
+
+			handler.AddAdminHandler(handler.AdminGroup(e, adminKey), mockService)
+			e.ServeHTTP(rec, req)
+
+			mockService.AssertExpectations(t)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+			if test.expectedStatus == http.StatusOK {
+				require.NotContains(t, rec.Body.String(), "password")
+				require.JSONEq(t, `{
+					"users": [{
+						"id": "123",
+						"email": "jhon@doe.com",
+						"address": "lorem ipsum lorem ipsum",
+						"status": "active",
+						"version": 1,
+						"created_time": "2020-08-29T09:32:25+07:00",
+						"updated_time": "2020-08-29T09:32:25+07:00"
+					}],
+					"next_cursor": "def",
+					"total": 1
+				}`, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/arnaz06/users"
+)
+
+// multipartOverhead is the room left in an upload request for the multipart headers around the avatar.
+const multipartOverhead = 64 << 10
+
+// errBodyTooLarge is the message of the error of the request bodies read past their limit.
+const errBodyTooLarge = "http: request body too large"
+
+type avatarHandler struct {
+	service users.AvatarService
+	maxSize int64
+}
+
+// AddAvatarHandler adds the avatar handler, restricted to the user and the admins.
+// Upload requests with an avatar larger than maxSize bytes, or a body larger than that plus the multipart overhead,
+// are rejected as too large.
+func AddAvatarHandler(e *echo.Echo, service users.AvatarService, maxSize int64, adminKey string) {
+	if service == nil {
+		panic("http: nil avatar service")
+	}
+
+	handler := &avatarHandler{
+		service: service,
+		maxSize: maxSize,
+	}
+
+	e.PUT("/user/:userId/avatar", handler.upload, OwnerOrAdminMiddleware(adminKey))
+	e.GET("/user/:userId/avatar", handler.get, OwnerOrAdminMiddleware(adminKey))
+}
+
+func (h avatarHandler) upload(c echo.Context) error {
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, h.maxSize+multipartOverhead)
+
+	header, err := c.FormFile("avatar")
+	if err != nil && strings.Contains(err.Error(), errBodyTooLarge) {
+		return h.tooLarge()
+	}
+	if err != nil {
+		return users.ConstraintErrorf("error reading avatar: %s", err)
+	}
+	if header.Size > h.maxSize {
+		return h.tooLarge()
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = h.service.Upload(req.Context(), c.Param("userId"), file)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h avatarHandler) tooLarge() error {
+	return echo.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("avatar must not be larger than %d bytes", h.maxSize))
+}
+
+func (h avatarHandler) get(c echo.Context) error {
+	size := users.AvatarMedium
+	if param := c.QueryParam("size"); param != "" {
+		size = users.AvatarSize(param)
+	}
+
+	res, err := h.service.Get(c.Request().Context(), c.Param("userId"), size)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	c.Response().Header().Set("Cache-Control", "private, no-cache")
+	return c.Stream(http.StatusOK, res.ContentType, res.Body)
+}
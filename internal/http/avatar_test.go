@@ -0,0 +1,254 @@
+package http_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	handler "github.com/arnaz06/users/internal/http"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
+)
+
+const avatarMaxSize = 1 << 20
+
+func multipartAvatar(t *testing.T, field string, data []byte) (*bytes.Buffer, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(field, "avatar.png")
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return &body, writer.FormDataContentType()
+}
+
+func TestUploadAvatarHandler(t *testing.T) {
+	userID := "123"
+	avatar := []byte("\x89PNG\r\n\x1a\n")
+
+	tests := []struct {
+		testName            string
+		field               string
+		data                []byte
+		authenticatedUserID string
+		adminKey            string
+		service             testdata.FuncCall
+		expectedStatus      int
+	}{
+		{
+			testName: "success",
+			field:    "avatar",
+			data:     avatar,
+			service: testdata.FuncCall{
+				Called: true,
+				Input: []interface{}{mock.Anything, userID, mock.MatchedBy(func(r io.Reader) bool {
+					data, err := ioutil.ReadAll(r)
+					return err == nil && bytes.Equal(avatar, data)
+				})},
+				Output: []interface{}{nil},
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			testName:            "success with admin key",
+			field:               "avatar",
+			data:                avatar,
+			authenticatedUserID: "other-user",
+			adminKey:            adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, userID, mock.Anything},
+				Output: []interface{}{nil},
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			testName:            "with other user",
+			field:               "avatar",
+			data:                avatar,
+			authenticatedUserID: "other-user",
+			expectedStatus:      http.StatusForbidden,
+		},
+		{
+			testName:       "with missing avatar field",
+			field:          "picture",
+			data:           avatar,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "with body too large",
+			field:          "avatar",
+			data:           make([]byte, 2*avatarMaxSize),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			testName:       "with avatar too large",
+			field:          "avatar",
+			data:           make([]byte, avatarMaxSize+1),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			testName: "with invalid image",
+			field:    "avatar",
+			data:     []byte("not an image"),
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, userID, mock.Anything},
+				Output: []interface{}{users.ConstraintErrorf("avatar must be a GIF, JPEG or PNG image, got text/plain; charset=utf-8")},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with user not found",
+			field:    "avatar",
+			data:     avatar,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, userID, mock.Anything},
+				Output: []interface{}{users.ErrNotFound},
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			authenticatedUserID := userID
+			if test.authenticatedUserID != "" {
+				authenticatedUserID = test.authenticatedUserID
+			}
+			e := getAuthenticatedEchoServer(authenticatedUserID)
+			mockService := new(mocks.AvatarService)
+			if test.service.Called {
+				mockService.On("Upload", test.service.Input...).
+					Return(test.service.Output...).Once()
+			}
+
+			body, contentType := multipartAvatar(t, test.field, test.data)
+			req := httptest.NewRequest(echo.PUT, "/user/"+userID+"/avatar", body)
+			req.Header.Set(echo.HeaderContentType, contentType)
+			req.Header.Set("X-Admin-Key", test.adminKey)
+			rec := httptest.NewRecorder()
+
+			handler.AddAvatarHandler(e, mockService, avatarMaxSize, adminKey)
+			e.ServeHTTP(rec, req)
+
+			mockService.AssertExpectations(t)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestGetAvatarHandler(t *testing.T) {
+	userID := "123"
+
+	tests := []struct {
+		testName            string
+		query               string
+		authenticatedUserID string
+		adminKey            string
+		service             testdata.FuncCall
+		expectedStatus      int
+		expectedBody        string
+	}{
+		{
+			testName: "success with default size",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, userID, users.AvatarMedium},
+				Output: []interface{}{users.Blob{Body: ioutil.NopCloser(strings.NewReader("medium")), ContentType: "image/png", Size: 6}, nil},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "medium",
+		},
+		{
+			testName: "success with size",
+			query:    "?size=small",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, userID, users.AvatarSmall},
+				Output: []interface{}{users.Blob{Body: ioutil.NopCloser(strings.NewReader("small")), ContentType: "image/png", Size: 5}, nil},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "small",
+		},
+		{
+			testName:            "success with admin key",
+			authenticatedUserID: "other-user",
+			adminKey:            adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, userID, users.AvatarMedium},
+				Output: []interface{}{users.Blob{Body: ioutil.NopCloser(strings.NewReader("medium")), ContentType: "image/png", Size: 6}, nil},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "medium",
+		},
+		{
+			testName:            "with other user",
+			authenticatedUserID: "other-user",
+			expectedStatus:      http.StatusForbidden,
+		},
+		{
+			testName: "with invalid size",
+			query:    "?size=huge",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, userID, users.AvatarSize("huge")},
+				Output: []interface{}{users.Blob{}, users.ConstraintErrorf("invalid avatar size: huge")},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with avatar not uploaded",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, userID, users.AvatarMedium},
+				Output: []interface{}{users.Blob{}, users.ErrNotFound},
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			authenticatedUserID := userID
+			if test.authenticatedUserID != "" {
+				authenticatedUserID = test.authenticatedUserID
+			}
+			e := getAuthenticatedEchoServer(authenticatedUserID)
+			mockService := new(mocks.AvatarService)
+			if test.service.Called {
+				mockService.On("Get", test.service.Input...).
+					Return(test.service.Output...).Once()
+			}
+
+			req := httptest.NewRequest(echo.GET, "/user/"+userID+"/avatar"+test.query, nil)
+			req.Header.Set("X-Admin-Key", test.adminKey)
+			rec := httptest.NewRecorder()
+
+			handler.AddAvatarHandler(e, mockService, avatarMaxSize, adminKey)
+			e.ServeHTTP(rec, req)
+
+			mockService.AssertExpectations(t)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+			if test.expectedStatus == http.StatusOK {
+				require.Equal(t, "image/png", rec.Header().Get(echo.HeaderContentType))
+				require.Equal(t, test.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/arnaz06/users"
+)
+
+type batchHandler struct {
+	service users.BatchService
+}
+
+// AddBatchHandler adds the batch handler to the admin group.
+func AddBatchHandler(g *echo.Group, service users.BatchService) {
+	if service == nil {
+		panic("http: nil batch service")
+	}
+
+	handler := &batchHandler{
+		service: service,
+	}
+
+	g.POST("/batch", handler.execute)
+}
+
+func (h batchHandler) execute(c echo.Context) error {
+	var input batchRequest
+	if err := c.Bind(&input); err != nil {
+		return users.ConstraintErrorf("%s", err)
+	}
+	if input.Mode == "" {
+		input.Mode = users.BatchAbortOnError
+	}
+
+	res, err := h.service.Execute(c.Request().Context(), input.toOperations(), input.Mode)
+	if err != nil {
+		return err
+	}
+
+	// A batch rolled back as a whole is reported with the failed operation, but is not a success.
+	if !res.Committed {
+		return c.JSON(http.StatusUnprocessableEntity, res)
+	}
+	return c.JSON(http.StatusOK, res)
+}
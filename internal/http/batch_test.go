@@ -0,0 +1,144 @@
+package http_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	handler "github.com/arnaz06/users/internal/http"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
+)
+
+func TestBatchHandler(t *testing.T) {
+	body := `{"operations":[
+		{"op":"create","email":"jane@doe.com","password":"secret-123"},
+		{"op":"delete","id":"123","version":2}
+	]}`
+	operations := []users.BatchOperation{
+		{Type: users.BatchCreate, User: users.User{Email: "jane@doe.com", Password: "secret-123"}},
+		{Type: users.BatchDelete, User: users.User{ID: "123", Version: 2}},
+	}
+	committed := users.BatchReport{
+		Mode:      users.BatchAbortOnError,
+		Committed: true,
+		Applied:   2,
+		Results:   []users.BatchResult{{Index: 0, ID: "456", Applied: true}, {Index: 1, ID: "123", Applied: true}},
+	}
+
+	tests := []struct {
+		testName       string
+		body           string
+		adminKey       string
+		service        testdata.FuncCall
+		expectedStatus int
+	}{
+		{
+			testName: "success",
+			body:     body,
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, operations, users.BatchAbortOnError},
+				Output: []interface{}{committed, nil},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName: "success with continue mode",
+			body:     `{"mode":"continue","operations":[{"op":"delete","id":"123","version":2}]}`,
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, operations[1:], users.BatchContinueOnError},
+				Output: []interface{}{users.BatchReport{Mode: users.BatchContinueOnError, Committed: true, Failed: 1}, nil},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName: "with rolled back batch",
+			body:     body,
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, operations, users.BatchAbortOnError},
+				Output: []interface{}{users.BatchReport{Mode: users.BatchAbortOnError, Failed: 1}, nil},
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			testName: "with invalid admin key",
+			body:     body,
+			adminKey: "invalid",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			testName: "with invalid body",
+			body:     `{"operations":`,
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with invalid batch",
+			body:     `{"operations":[]}`,
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, []users.BatchOperation{}, users.BatchAbortOnError},
+				Output: []interface{}{users.BatchReport{}, users.ConstraintErrorf("batch has no operations")},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with unexpected error from service",
+			body:     body,
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, operations, users.BatchAbortOnError},
+				Output: []interface{}{users.BatchReport{}, errors.New("unexpected error")},
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			e := getEchoServer()
+			mockService := new(mocks.BatchService)
+			if test.service.Called {
+				mockService.On("Execute", test.service.Input...).
+					Return(test.service.Output...).Once()
+			}
+
+			req := httptest.NewRequest(echo.POST, "/users/batch", strings.NewReader(test.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set("X-Admin-Key", test.adminKey)
+			rec := httptest.NewRecorder()
+
+			handler.AddBatchHandler(handler.AdminGroup(e, adminKey), mockService)
+			e.ServeHTTP(rec, req)
+
+			mockService.AssertExpectations(t)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+			if test.expectedStatus == http.StatusOK || test.expectedStatus == http.StatusUnprocessableEntity {
+				require.Contains(t, rec.Body.String(), `"results"`)
+				require.NotContains(t, rec.Body.String(), "password")
+			}
+		})
+	}
+}
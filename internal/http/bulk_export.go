@@ -0,0 +1,91 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/arnaz06/users"
+)
+
+// BulkExportPath is the path of the bulk export in the admin group, which streams its response past the context timeout.
+const BulkExportPath = "/users/export"
+
+var bulkExportContentTypes = map[users.BulkExportFormat]string{
+	users.BulkExportCSV:     "text/csv; charset=utf-8",
+	users.BulkExportNDJSON:  "application/x-ndjson",
+	users.BulkExportParquet: "application/vnd.apache.parquet",
+}
+
+type bulkExportHandler struct {
+	service users.BulkExportService
+}
+
+// AddBulkExportHandler adds the bulk export handler to the admin group.
+func AddBulkExportHandler(g *echo.Group, service users.BulkExportService) {
+	if service == nil {
+		panic("http: nil bulk export service")
+	}
+
+	handler := &bulkExportHandler{
+		service: service,
+	}
+
+	g.GET("/export", handler.export)
+}
+
+// streamWriter sends the headers of the response on the first write, so the errors returned
+// before anything is written are still sent with their status.
+type streamWriter struct {
+	c           echo.Context
+	contentType string
+	filename    string
+}
+
+func (w streamWriter) Write(b []byte) (int, error) {
+	res := w.c.Response()
+	if !res.Committed {
+		res.Header().Set(echo.HeaderContentType, w.contentType)
+		res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, w.filename))
+		res.WriteHeader(http.StatusOK)
+	}
+	return res.Write(b)
+}
+
+func (h bulkExportHandler) export(c echo.Context) error {
+	filter, err := userFilterFromQuery(c)
+	if err != nil {
+		return err
+	}
+
+	options := users.BulkExportOptions{
+		Format: users.BulkExportFormat(c.QueryParam("format")),
+		Filter: filter,
+	}
+	if options.Format == "" {
+		options.Format = users.BulkExportCSV
+	}
+	if param := c.QueryParam("columns"); param != "" {
+		options.Columns = strings.Split(param, ",")
+	}
+
+	w := streamWriter{
+		c:           c,
+		contentType: bulkExportContentTypes[options.Format],
+		filename:    fmt.Sprintf("users-%s.%s", time.Now().UTC().Format("20060102T150405Z"), options.Format),
+	}
+	_, err = h.service.Export(c.Request().Context(), w, options)
+	if err != nil {
+		return err
+	}
+
+	// An export without any user, in a format without a header, has nothing written yet.
+	if !c.Response().Committed {
+		c.Response().Header().Set(echo.HeaderContentType, w.contentType)
+		c.Response().WriteHeader(http.StatusOK)
+	}
+	return nil
+}
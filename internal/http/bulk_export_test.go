@@ -0,0 +1,171 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	handler "github.com/arnaz06/users/internal/http"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
+)
+
+func TestBulkExportHandler(t *testing.T) {
+	tests := []struct {
+		testName            string
+		query               string
+		adminKey            string
+		service             testdata.FuncCall
+		written             string
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			testName: "success with default format",
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mock.Anything, users.BulkExportOptions{Format: users.BulkExportCSV}},
+				Output: []interface{}{int64(1), nil},
+			},
+			written:             "id,email\n123,jhon@doe.com\n",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "id,email\n123,jhon@doe.com\n",
+		},
+		{
+			testName: "success with format, columns and filters",
+			query:    "?format=ndjson&columns=id,email&status=active&sort=-email&created_from=2021-01-01T00:00:00Z",
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input: []interface{}{mock.Anything, mock.Anything, users.BulkExportOptions{
+					Format:  users.BulkExportNDJSON,
+					Columns: []string{"id", "email"},
+					Filter: users.UserFilter{
+						Status:      users.StatusActive,
+						Sort:        users.SortEmail,
+						Desc:        true,
+						CreatedFrom: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+					},
+				}},
+				Output: []interface{}{int64(1), nil},
+			},
+			written:             `{"email":"jhon@doe.com","id":"123"}` + "\n",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+			expectedBody:        `{"email":"jhon@doe.com","id":"123"}` + "\n",
+		},
+		{
+			testName: "success without users",
+			query:    "?format=ndjson",
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mock.Anything, users.BulkExportOptions{Format: users.BulkExportNDJSON}},
+				Output: []interface{}{int64(0), nil},
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+		},
+		{
+			testName: "with invalid admin key",
+			adminKey: "invalid",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			testName: "with invalid created from",
+			query:    "?created_from=yesterday",
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with invalid columns",
+			query:    "?columns=email,password",
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mock.Anything, users.BulkExportOptions{Format: users.BulkExportCSV, Columns: []string{"email", "password"}}},
+				Output: []interface{}{int64(0), users.ConstraintErrorf("unknown column: password")},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with unexpected error from service",
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mock.Anything, users.BulkExportOptions{Format: users.BulkExportCSV}},
+				Output: []interface{}{int64(0), errors.New("unexpected error")},
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			e := getEchoServer()
+			mockService := new(mocks.BulkExportService)
+			if test.service.Called {
+				mockService.On("Export", test.service.Input...).
+					Run(func(args mock.Arguments) {
+						if test.written != "" {
+							_, _ = io.WriteString(args.Get(1).(io.Writer), test.written)
+						}
+					}).
+					Return(test.service.Output...).Once()
+			}
+
+			req := httptest.NewRequest(echo.GET, handler.BulkExportPath+test.query, nil)
+			req.Header.Set("X-Admin-Key", test.adminKey)
+			rec := httptest.NewRecorder()
+
+			handler.AddBulkExportHandler(handler.AdminGroup(e, adminKey), mockService)
+			e.ServeHTTP(rec, req)
+
+			mockService.AssertExpectations(t)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+			if test.expectedStatus == http.StatusOK {
+				require.Equal(t, test.expectedContentType, rec.Header().Get(echo.HeaderContentType))
+				require.Equal(t, test.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestTimeoutMiddlewareSkippedPaths(t *testing.T) {
+	e := echo.New()
+	e.Use(handler.TimeoutMiddleware(time.Millisecond, "/stream"))
+	hasDeadline := func(c echo.Context) error {
+		_, ok := c.Request().Context().Deadline()
+		if ok {
+			return c.NoContent(http.StatusOK)
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+	e.GET("/stream", hasDeadline)
+	e.GET("/user", hasDeadline)
+
+	for path, expectedStatus := range map[string]int{"/stream": http.StatusNoContent, "/user": http.StatusOK} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(echo.GET, path, nil).WithContext(context.Background()))
+		require.Equal(t, expectedStatus, rec.Code, path)
+	}
+}
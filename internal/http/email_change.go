@@ -0,0 +1,91 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/arnaz06/users"
+)
+
+type emailChangeHandler struct {
+	service users.EmailChangeService
+}
+
+type emailChangeRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type emailChangeTokenRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// AddEmailChangeHandler adds the email change handler. The changes are only requested by the user or the admins,
+// and confirmed or reverted by the holders of the tokens.
+func AddEmailChangeHandler(e *echo.Echo, service users.EmailChangeService, adminKey string) {
+	if service == nil {
+		panic("http: nil email change service")
+	}
+
+	handler := &emailChangeHandler{
+		service: service,
+	}
+
+	e.POST("/user/:userId/email", handler.request, OwnerOrAdminMiddleware(adminKey))
+	e.POST("/user/email/confirm", handler.confirm)
+	e.POST("/user/email/revert", handler.revert)
+}
+
+func (h emailChangeHandler) request(c echo.Context) error {
+	var input emailChangeRequest
+	if err := c.Bind(&input); err != nil {
+		return users.ConstraintErrorf("%s", err)
+	}
+
+	if err := c.Validate(input); err != nil {
+		return users.ConstraintErrorf("error validating email change: %+v", err)
+	}
+
+	err := h.service.Request(c.Request().Context(), c.Param("userId"), input.Email)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusAccepted)
+}
+
+func (h emailChangeHandler) confirm(c echo.Context) error {
+	var input emailChangeTokenRequest
+	if err := c.Bind(&input); err != nil {
+		return users.ConstraintErrorf("%s", err)
+	}
+
+	if err := c.Validate(input); err != nil {
+		return users.ConstraintErrorf("error validating email change confirmation: %+v", err)
+	}
+
+	err := h.service.Confirm(c.Request().Context(), input.Token)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h emailChangeHandler) revert(c echo.Context) error {
+	var input emailChangeTokenRequest
+	if err := c.Bind(&input); err != nil {
+		return users.ConstraintErrorf("%s", err)
+	}
+
+	if err := c.Validate(input); err != nil {
+		return users.ConstraintErrorf("error validating email change revert: %+v", err)
+	}
+
+	err := h.service.Revert(c.Request().Context(), input.Token)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,213 @@
+package http_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	handler "github.com/arnaz06/users/internal/http"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
+)
+
+func TestRequestEmailChangeHandler(t *testing.T) {
+	userID := "df6a5a4f-7fcf-4c4f-9b0b-3ed0e9c20e63"
+
+	tests := []struct {
+		testName            string
+		body                string
+		authenticatedUserID string
+		adminKey            string
+		service             testdata.FuncCall
+		expectedStatus      int
+	}{
+		{
+			testName: "success",
+			body:     `{"email":"new@doe.com"}`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, userID, "new@doe.com"},
+				Output: []interface{}{nil},
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			testName:            "success with admin key",
+			body:                `{"email":"new@doe.com"}`,
+			authenticatedUserID: "other-user",
+			adminKey:            adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, userID, "new@doe.com"},
+				Output: []interface{}{nil},
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			testName:            "with other user",
+			body:                `{"email":"new@doe.com"}`,
+			authenticatedUserID: "other-user",
+			expectedStatus:      http.StatusForbidden,
+		},
+		{
+			testName:       "with invalid email",
+			body:           `{"email":"not-an-email"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with email already used",
+			body:     `{"email":"new@doe.com"}`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, userID, "new@doe.com"},
+				Output: []interface{}{users.ConstraintErrorf("email new@doe.com is already used by another user")},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with user not found",
+			body:     `{"email":"new@doe.com"}`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, userID, "new@doe.com"},
+				Output: []interface{}{users.ErrNotFound},
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			authenticatedUserID := userID
+			if test.authenticatedUserID != "" {
+				authenticatedUserID = test.authenticatedUserID
+			}
+			e := getAuthenticatedEchoServer(authenticatedUserID)
+			mockService := new(mocks.EmailChangeService)
+			if test.service.Called {
+				mockService.On("Request", test.service.Input...).
+					Return(test.service.Output...).Once()
+			}
+
+			req := httptest.NewRequest(echo.POST, "/user/"+userID+"/email", strings.NewReader(test.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set("X-Admin-Key", test.adminKey)
+			rec := httptest.NewRecorder()
+
+			handler.AddEmailChangeHandler(e, mockService, adminKey)
+			e.ServeHTTP(rec, req)
+
+			mockService.AssertExpectations(t)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestConfirmAndRevertEmailChangeHandler(t *testing.T) {
+	tests := []struct {
+		testName       string
+		method         string
+		path           string
+		body           string
+		service        testdata.FuncCall
+		expectedStatus int
+	}{
+		{
+			testName: "confirm success",
+			method:   "Confirm",
+			path:     "/user/email/confirm",
+			body:     `{"token":"abc"}`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "abc"},
+				Output: []interface{}{nil},
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			testName:       "confirm without token",
+			method:         "Confirm",
+			path:           "/user/email/confirm",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "confirm with expired token",
+			method:   "Confirm",
+			path:     "/user/email/confirm",
+			body:     `{"token":"abc"}`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "abc"},
+				Output: []interface{}{users.ConstraintErrorf("email change confirmation has expired")},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "revert success",
+			method:   "Revert",
+			path:     "/user/email/revert",
+			body:     `{"token":"abc"}`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "abc"},
+				Output: []interface{}{nil},
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			testName: "revert with unknown token",
+			method:   "Revert",
+			path:     "/user/email/revert",
+			body:     `{"token":"abc"}`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "abc"},
+				Output: []interface{}{users.ErrNotFound},
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			testName: "revert with unexpected error",
+			method:   "Revert",
+			path:     "/user/email/revert",
+			body:     `{"token":"abc"}`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "abc"},
+				Output: []interface{}{errors.New("unexpected error")},
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			e := getEchoServer()
+			mockService := new(mocks.EmailChangeService)
+			if test.service.Called {
+				mockService.On(test.method, test.service.Input...).
+					Return(test.service.Output...).Once()
+			}
+
+			req := httptest.NewRequest(echo.POST, test.path, strings.NewReader(test.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+
+			handler.AddEmailChangeHandler(e, mockService, adminKey)
+			e.ServeHTTP(rec, req)
+
+			mockService.AssertExpectations(t)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+		})
+	}
+}
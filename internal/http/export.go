@@ -0,0 +1,101 @@
+package http
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/arnaz06/users"
+)
+
+type exportHandler struct {
+	service users.ExportService
+}
+
+// AddExportHandler adds the user data export handler, restricted to the user and the admins.
+func AddExportHandler(e *echo.Echo, service users.ExportService, adminKey string) {
+	if service == nil {
+		panic("http: nil export service")
+	}
+
+	handler := &exportHandler{
+		service: service,
+	}
+
+	e.GET("/user/:userId/export", handler.export, OwnerOrAdminMiddleware(adminKey))
+}
+
+func (h exportHandler) export(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format != "" && format != "json" && format != "zip" {
+		return users.ConstraintErrorf("unsupported export format: %s", format)
+	}
+
+	res, err := h.service.Export(c.Request().Context(), c.Param("userId"))
+	if err != nil {
+		return err
+	}
+
+	filename := fmt.Sprintf("user-%s-export", res.UserID)
+	if format != "zip" {
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+		return c.JSON(http.StatusOK, res)
+	}
+
+	// The archive is built before anything is sent, so that failing to build it is answered with an error.
+	var archive bytes.Buffer
+	err = writeExportArchive(&archive, res)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.zip"`, filename))
+	return c.Blob(http.StatusOK, "application/zip", archive.Bytes())
+}
+
+// writeExportArchive writes the export as a zip archive holding a manifest and one JSON file per section.
+func writeExportArchive(w io.Writer, export users.Export) error {
+	archive := zip.NewWriter(w)
+
+	manifest := struct {
+		UserID        string    `json:"user_id"`
+		GeneratedTime time.Time `json:"generated_time"`
+		Sections      []string  `json:"sections"`
+	}{
+		UserID:        export.UserID,
+		GeneratedTime: export.GeneratedTime,
+	}
+	for name := range export.Data {
+		manifest.Sections = append(manifest.Sections, name)
+	}
+	sort.Strings(manifest.Sections)
+
+	if err := writeArchiveJSON(archive, "manifest.json", manifest); err != nil {
+		return err
+	}
+	for _, name := range manifest.Sections {
+		if err := writeArchiveJSON(archive, name+".json", export.Data[name]); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+func writeArchiveJSON(archive *zip.Writer, name string, data interface{}) error {
+	f, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
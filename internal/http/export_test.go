@@ -0,0 +1,172 @@
+package http_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	handler "github.com/arnaz06/users/internal/http"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
+)
+
+func TestExportUserHandler(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	mockExport := users.Export{
+		UserID:        mockUser.ID,
+		GeneratedTime: time.Now(),
+		Data: map[string]interface{}{
+			"profile": map[string]string{"email": mockUser.Email},
+		},
+	}
+
+	tests := []struct {
+		testName            string
+		format              string
+		authenticatedUserID string
+		adminKey            string
+		service             testdata.FuncCall
+		expectedStatus      int
+		expectedContentType string
+	}{
+		{
+			testName: "success",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockExport, nil},
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: echo.MIMEApplicationJSONCharsetUTF8,
+		},
+		{
+			testName:            "success with admin key",
+			authenticatedUserID: "other-user",
+			adminKey:            adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockExport, nil},
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: echo.MIMEApplicationJSONCharsetUTF8,
+		},
+		{
+			testName:            "with other user",
+			authenticatedUserID: "other-user",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			testName: "success with zip archive",
+			format:   "zip",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockExport, nil},
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/zip",
+		},
+		{
+			testName: "with section failing to be archived",
+			format:   "zip",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.Export{
+					UserID: mockUser.ID,
+					Data:   map[string]interface{}{"profile": math.Inf(1)},
+				}, nil},
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			testName: "with unsupported format",
+			format:   "xml",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with user not found",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.Export{}, users.ErrNotFound},
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			testName: "with unexpected error from service",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.Export{}, errors.New("unexpected error")},
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			authenticatedUserID := mockUser.ID
+			if test.authenticatedUserID != "" {
+				authenticatedUserID = test.authenticatedUserID
+			}
+			e := getAuthenticatedEchoServer(authenticatedUserID)
+			mockService := new(mocks.ExportService)
+			if test.service.Called {
+				mockService.On("Export", test.service.Input...).
+					Return(test.service.Output...).Once()
+			}
+
+			req := httptest.NewRequest(echo.GET, "/user/"+mockUser.ID+"/export?format="+test.format, nil)
+			req.Header.Set("X-Admin-Key", test.adminKey)
+			rec := httptest.NewRecorder()
+
+			handler.AddExportHandler(e, mockService, adminKey)
+			e.ServeHTTP(rec, req)
+
+			mockService.AssertExpectations(t)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+			if test.expectedContentType == "" {
+				require.Empty(t, rec.Header().Get(echo.HeaderContentDisposition))
+				return
+			}
+			require.Equal(t, test.expectedContentType, rec.Header().Get(echo.HeaderContentType))
+
+			if test.format != "zip" {
+				var res users.Export
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+				require.Equal(t, mockUser.ID, res.UserID)
+				return
+			}
+
+			archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+			require.NoError(t, err)
+			files := []string{}
+			for _, f := range archive.File {
+				files = append(files, f.Name)
+			}
+			require.Equal(t, []string{"manifest.json", "profile.json"}, files)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/arnaz06/users"
+)
+
+type importHandler struct {
+	service users.ImportService
+}
+
+// AddImportHandler adds the import handler to the admin group.
+func AddImportHandler(g *echo.Group, service users.ImportService) {
+	if service == nil {
+		panic("http: nil import service")
+	}
+
+	handler := &importHandler{
+		service: service,
+	}
+
+	g.POST("/import", handler.importUsers)
+}
+
+// importFormat returns the format of the import, given by the format param or else by the content type of the body.
+func importFormat(c echo.Context) users.ImportFormat {
+	if format := c.QueryParam("format"); format != "" {
+		return users.ImportFormat(format)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	switch mediaType {
+	case "text/csv":
+		return users.ImportCSV
+	case "application/x-ndjson":
+		return users.ImportNDJSON
+	}
+	return users.ImportFormat(mediaType)
+}
+
+func (h importHandler) importUsers(c echo.Context) error {
+	var options users.ImportOptions
+	if param := c.QueryParam("dry_run"); param != "" {
+		dryRun, err := strconv.ParseBool(param)
+		if err != nil {
+			return users.ConstraintErrorf("invalid dry_run: %s", param)
+		}
+		options.DryRun = dryRun
+	}
+	if param := c.QueryParam("batch_size"); param != "" {
+		batchSize, err := strconv.Atoi(param)
+		if err != nil {
+			return users.ConstraintErrorf("invalid batch_size: %s", param)
+		}
+		options.BatchSize = batchSize
+	}
+	if param := c.QueryParam("pending"); param != "" {
+		pending, err := strconv.ParseBool(param)
+		if err != nil {
+			return users.ConstraintErrorf("invalid pending: %s", param)
+		}
+		options.Pending = pending
+	}
+
+	res, err := h.service.Import(c.Request().Context(), c.Request().Body, importFormat(c), options)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
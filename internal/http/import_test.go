@@ -0,0 +1,158 @@
+package http_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	handler "github.com/arnaz06/users/internal/http"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
+)
+
+func TestImportHandler(t *testing.T) {
+	report := users.ImportReport{
+		Total:    1,
+		Imported: 1,
+		Results:  []users.ImportResult{{Row: 1, Email: "jhon@doe.com", ID: "123"}},
+	}
+
+	tests := []struct {
+		testName       string
+		query          string
+		contentType    string
+		adminKey       string
+		service        testdata.FuncCall
+		expectedStatus int
+	}{
+		{
+			testName:    "success with csv content type",
+			contentType: "text/csv; charset=utf-8",
+			adminKey:    adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mock.Anything, users.ImportCSV, users.ImportOptions{}},
+				Output: []interface{}{report, nil},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:    "success with format param as a dry run",
+			query:       "?format=ndjson&dry_run=true&batch_size=50",
+			contentType: echo.MIMEOctetStream,
+			adminKey:    adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mock.Anything, users.ImportNDJSON, users.ImportOptions{DryRun: true, BatchSize: 50}},
+				Output: []interface{}{report, nil},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:    "success with pending users",
+			query:       "?pending=true",
+			contentType: "text/csv",
+			adminKey:    adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mock.Anything, users.ImportCSV, users.ImportOptions{Pending: true}},
+				Output: []interface{}{report, nil},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:    "with invalid admin key",
+			contentType: "text/csv",
+			adminKey:    "invalid",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			testName:    "with invalid dry run",
+			query:       "?dry_run=maybe",
+			contentType: "text/csv",
+			adminKey:    adminKey,
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:    "with invalid pending",
+			query:       "?pending=maybe",
+			contentType: "text/csv",
+			adminKey:    adminKey,
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:    "with invalid batch size",
+			query:       "?batch_size=abc",
+			contentType: "text/csv",
+			adminKey:    adminKey,
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:    "with invalid file",
+			contentType: "text/csv",
+			adminKey:    adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mock.Anything, users.ImportCSV, users.ImportOptions{}},
+				Output: []interface{}{users.ImportReport{}, users.ConstraintErrorf("csv header is missing")},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:    "with unexpected error from service",
+			contentType: "text/csv",
+			adminKey:    adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mock.Anything, users.ImportCSV, users.ImportOptions{}},
+				Output: []interface{}{users.ImportReport{}, errors.New("unexpected error")},
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			e := getEchoServer()
+			mockService := new(mocks.ImportService)
+			if test.service.Called {
+				mockService.On("Import", test.service.Input...).
+					Return(test.service.Output...).Once()
+			}
+
+			req := httptest.NewRequest(echo.POST, "/users/import"+test.query, strings.NewReader("email,password\njhon@doe.com,secret-123\n"))
+			req.Header.Set(echo.HeaderContentType, test.contentType)
+			req.Header.Set("X-Admin-Key", test.adminKey)
+			rec := httptest.NewRecorder()
+
+			handler.AddImportHandler(handler.AdminGroup(e, adminKey), mockService)
+			e.ServeHTTP(rec, req)
+
+			mockService.AssertExpectations(t)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+			if test.expectedStatus == http.StatusOK {
+				require.Contains(t, rec.Body.String(), `"imported":1`)
+			}
+		})
+	}
+}
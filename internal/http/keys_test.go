@@ -0,0 +1,4 @@
+package http
+
+// UserIDKey exposes the key of the authenticated user's ID to the tests of the handlers.
+const UserIDKey = userIDKey
@@ -2,8 +2,11 @@ package http
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
+	"net"
 	"net/http"
+	"regexp"
 	"strings"
 	"time"
 
@@ -15,15 +18,32 @@ import (
 	"github.com/arnaz06/users"
 )
 
+const (
+	// userIDKey is the key of the authenticated user's ID in the echo context.
+	userIDKey = "userID"
+	// tenantKey is the key of the tenant resolved from the request's header or host in the echo context.
+	tenantKey = "tenant"
+)
+
+var tenantPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,50}$`)
+
 type myCustomClaims struct {
-    Email string `json:"email"`
-    jwt.StandardClaims
+	Email  string `json:"email"`
+	Tenant string `json:"tenant,omitempty"`
+	jwt.StandardClaims
 }
 
 // TimeoutMiddleware is used to add timeout for context cancellation.
-func TimeoutMiddleware(timeout time.Duration) echo.MiddlewareFunc {
+// The requests to the skipped paths, such as the ones streaming their responses, are only cancelled when the client goes away.
+func TimeoutMiddleware(timeout time.Duration, skippedPaths ...string) echo.MiddlewareFunc {
 	return func(handlerFunc echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			for _, path := range skippedPaths {
+				if c.Path() == path {
+					return handlerFunc(c)
+				}
+			}
+
 			ctxWithTimeout, cancel := context.WithTimeout(c.Request().Context(), timeout)
 			defer cancel()
 			req := c.Request().WithContext(ctxWithTimeout)
@@ -33,8 +53,61 @@ func TimeoutMiddleware(timeout time.Duration) echo.MiddlewareFunc {
 	}
 }
 
+// ReadYourWritesMiddleware is used to record the writes made while handling the request, so that the reads
+// made after a write in the same request see it, even where the reads are otherwise made from replicas.
+func ReadYourWritesMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.SetRequest(c.Request().WithContext(users.WithReadYourWrites(c.Request().Context())))
+			return next(c)
+		}
+	}
+}
+
+// TenantMiddleware is used to resolve the tenant of the request from the X-Tenant-ID header, or else from the host.
+// A host resolves to its first label when the rest of it is the given suffix, e.g. "acme" for "acme.users.example.com".
+func TenantMiddleware(hostSuffix string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			tenantID := c.Request().Header.Get("X-Tenant-ID")
+			if tenantID == "" {
+				tenantID = tenantFromHost(c.Request().Host, hostSuffix)
+			}
+			if tenantID == "" {
+				return next(c)
+			}
+
+			if !tenantPattern.MatchString(tenantID) {
+				return users.ConstraintErrorf("invalid tenant: %s", tenantID)
+			}
+
+			c.Set(tenantKey, tenantID)
+			c.SetRequest(c.Request().WithContext(users.WithTenant(c.Request().Context(), tenantID)))
+			return next(c)
+		}
+	}
+}
+
+func tenantFromHost(host, hostSuffix string) string {
+	if hostSuffix == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	label := strings.TrimSuffix(host, "."+hostSuffix)
+	if label == host || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
 // AuthenticationMiddleware is a function to check a user based on key authentication.
-func AuthenticationMiddleware(secretKey string) middleware.KeyAuthValidator {
+// Only the tokens of active users are accepted. The tenant of the token must be the one
+// resolved from the request, if any, and becomes the tenant of the request otherwise.
+// The tokens issued before they carried the user's ID as subject are accepted by their email claim until they expire.
+func AuthenticationMiddleware(secretKey string, service users.UserService) middleware.KeyAuthValidator {
 	return func(key string, c echo.Context) (bool, error) {
 		tokenString := c.Request().Header.Get("Authorization")
 
@@ -44,17 +117,79 @@ func AuthenticationMiddleware(secretKey string) middleware.KeyAuthValidator {
 			return false, users.UnauthorizedErrorf("invalid token format")
 		}
 
-		_, err := jwt.ParseWithClaims(splitedString[1], &myCustomClaims{}, func(token *jwt.Token) (interface{}, error) {
+		claims := &myCustomClaims{}
+		_, err := jwt.ParseWithClaims(splitedString[1], claims, func(token *jwt.Token) (interface{}, error) {
 			return []byte(secretKey), nil
 		})
 		if err != nil {
 			return false, users.UnauthorizedErrorf("invalid token: %+v", err)
 		}
 
+		ctx := users.WithTenant(c.Request().Context(), claims.Tenant)
+		if tenantID, ok := c.Get(tenantKey).(string); ok && tenantID != users.TenantFromContext(ctx) {
+			return false, users.UnauthorizedErrorf("invalid token: not issued for tenant %s", tenantID)
+		}
+		c.SetRequest(c.Request().WithContext(ctx))
+
+		var user users.User
+		switch {
+		case claims.Subject != "":
+			user, err = service.Get(c.Request().Context(), claims.Subject)
+		case claims.Email != "":
+			user, err = service.GetByEmail(c.Request().Context(), claims.Email)
+		default:
+			err = users.ErrNotFound
+		}
+		if err != nil {
+			if err == users.ErrNotFound {
+				return false, users.UnauthorizedErrorf("invalid token: unknown user")
+			}
+			return false, err
+		}
+
+		if err := user.Status.Err(); err != nil {
+			return false, err
+		}
+
+		c.Set(userIDKey, user.ID)
+		c.SetRequest(c.Request().WithContext(users.WithActor(c.Request().Context(), user.ID)))
 		return true, nil
 	}
 }
 
+// AdminMiddleware is used to restrict access to the requests carrying the admin key.
+func AdminMiddleware(adminKey string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !isAdmin(c, adminKey) {
+				return users.UnauthorizedErrorf("invalid admin key")
+			}
+			return next(c)
+		}
+	}
+}
+
+// OwnerOrAdminMiddleware is used to restrict access to the data of the user identified by the userId path parameter
+// to the requests authenticated as this user, or carrying the admin key.
+func OwnerOrAdminMiddleware(adminKey string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if userID, ok := c.Get(userIDKey).(string); ok && userID != "" && userID == c.Param("userId") {
+				return next(c)
+			}
+			if !isAdmin(c, adminKey) {
+				return users.ErrForbidden
+			}
+			return next(c)
+		}
+	}
+}
+
+func isAdmin(c echo.Context, adminKey string) bool {
+	key := c.Request().Header.Get("X-Admin-Key")
+	return adminKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) == 1
+}
+
 // ErrorMiddleware is a function to generate http status code.
 func ErrorMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -74,6 +209,11 @@ func ErrorMiddleware() echo.MiddlewareFunc {
 				"uri":     c.Request().RequestURI,
 			})
 
+			// Errors wrapped by echo's middlewares, such as the key authentication, are mapped by their cause.
+			if e, ok := err.(*echo.HTTPError); ok && e.Internal != nil {
+				err = e.Internal
+			}
+
 			if e, ok := err.(*echo.HTTPError); ok {
 				if e.Code >= http.StatusInternalServerError {
 					lg.Errorln(e.Message)
@@ -97,6 +237,12 @@ func ErrorMiddleware() echo.MiddlewareFunc {
 
 			case users.ErrNotFound:
 				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+
+			case users.ErrPreconditionFailed:
+				return echo.NewHTTPError(http.StatusPreconditionFailed, err.Error())
+
+			case users.ErrForbidden, users.ErrUserPending, users.ErrUserSuspended, users.ErrUserLocked, users.ErrUserDeactivated:
+				return echo.NewHTTPError(http.StatusForbidden, err.Error())
 			}
 
 			lg.Errorln(err.Error())
@@ -2,17 +2,23 @@ package http_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo/v4"
 	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/arnaz06/users"
 	handler "github.com/arnaz06/users/internal/http"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
 )
 
 func TestErrorMiddleware(t *testing.T) {
@@ -37,6 +43,50 @@ func TestErrorMiddleware(t *testing.T) {
 		require.Equal(t, http.StatusNotFound, err.Code)
 	})
 
+	t.Run("with outdated version", func(t *testing.T) {
+		h := func(c echo.Context) error {
+			return users.ErrPreconditionFailed
+		}
+		err := mw(h)(c).(*echo.HTTPError)
+
+		require.Error(t, err)
+		require.Equal(t, http.StatusPreconditionFailed, err.Code)
+	})
+
+	t.Run("with suspended user", func(t *testing.T) {
+		h := func(c echo.Context) error {
+			return users.ErrUserSuspended
+		}
+		err := mw(h)(c).(*echo.HTTPError)
+
+		require.Error(t, err)
+		require.Equal(t, http.StatusForbidden, err.Code)
+	})
+
+	t.Run("with forbidden access", func(t *testing.T) {
+		h := func(c echo.Context) error {
+			return users.ErrForbidden
+		}
+		err := mw(h)(c).(*echo.HTTPError)
+
+		require.Error(t, err)
+		require.Equal(t, http.StatusForbidden, err.Code)
+	})
+
+	t.Run("with error wrapped by echo", func(t *testing.T) {
+		h := func(c echo.Context) error {
+			return &echo.HTTPError{
+				Code:     http.StatusUnauthorized,
+				Message:  "invalid key",
+				Internal: users.ErrUserLocked,
+			}
+		}
+		err := mw(h)(c).(*echo.HTTPError)
+
+		require.Error(t, err)
+		require.Equal(t, http.StatusForbidden, err.Code)
+	})
+
 	t.Run("with constraint error", func(t *testing.T) {
 		h := func(c echo.Context) error {
 			return users.ConstraintErrorf("this is a constraint error")
@@ -78,3 +128,398 @@ func TestErrorMiddleware(t *testing.T) {
 	})
 
 }
+
+func TestAdminMiddleware(t *testing.T) {
+	h := func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	tests := []struct {
+		testName      string
+		adminKey      string
+		requestKey    string
+		expectedError error
+	}{
+		{
+			testName:   "success",
+			adminKey:   "admin-secret",
+			requestKey: "admin-secret",
+		},
+		{
+			testName:      "with invalid admin key",
+			adminKey:      "admin-secret",
+			requestKey:    "invalid",
+			expectedError: users.UnauthorizedErrorf("invalid admin key"),
+		},
+		{
+			testName:      "with admin key not configured",
+			adminKey:      "",
+			requestKey:    "",
+			expectedError: users.UnauthorizedErrorf("invalid admin key"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Admin-Key", test.requestKey)
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			err := handler.AdminMiddleware(test.adminKey)(h)(c)
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestOwnerOrAdminMiddleware(t *testing.T) {
+	h := func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	tests := []struct {
+		testName      string
+		userID        string
+		requestKey    string
+		expectedError error
+	}{
+		{
+			testName: "success with owner",
+			userID:   "user-1",
+		},
+		{
+			testName:   "success with admin key",
+			userID:     "user-2",
+			requestKey: "admin-secret",
+		},
+		{
+			testName:      "with other user",
+			userID:        "user-2",
+			expectedError: users.ErrForbidden,
+		},
+		{
+			testName:      "with other user and invalid admin key",
+			userID:        "user-2",
+			requestKey:    "invalid",
+			expectedError: users.ErrForbidden,
+		},
+		{
+			testName:      "without authenticated user",
+			expectedError: users.ErrForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/user/user-1/export", nil)
+			req.Header.Set("X-Admin-Key", test.requestKey)
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+			c.SetParamNames("userId")
+			c.SetParamValues("user-1")
+			if test.userID != "" {
+				c.Set(handler.UserIDKey, test.userID)
+			}
+
+			err := handler.OwnerOrAdminMiddleware("admin-secret")(h)(c)
+			require.Equal(t, test.expectedError, err)
+		})
+	}
+}
+
+func TestAuthenticationMiddleware(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	suspendedUser := users.User(mockUser)
+	suspendedUser.Status = users.StatusSuspended
+
+	signToken := func(secret, subject string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(time.Hour).Unix(),
+			Subject:   subject,
+		})
+		signed, err := token.SignedString([]byte(secret))
+		require.NoError(t, err)
+		return "Bearer " + signed
+	}
+
+	signTenantToken := func(tenantID string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"exp":    time.Now().Add(time.Hour).Unix(),
+			"sub":    mockUser.ID,
+			"tenant": tenantID,
+		})
+		signed, err := token.SignedString([]byte("secret"))
+		require.NoError(t, err)
+		return "Bearer " + signed
+	}
+
+	signEmailToken := func(email string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"email": email,
+		})
+		signed, err := token.SignedString([]byte("secret"))
+		require.NoError(t, err)
+		return "Bearer " + signed
+	}
+
+	tests := []struct {
+		testName       string
+		token          string
+		tenant         string
+		method         string
+		service        testdata.FuncCall
+		expectedTenant string
+		expectedError  error
+	}{
+		{
+			testName: "success",
+			token:    signToken("secret", mockUser.ID),
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+		},
+		{
+			testName: "success with tenant of the token",
+			token:    signTenantToken("acme"),
+			service: testdata.FuncCall{
+				Called: true,
+				Input: []interface{}{mock.MatchedBy(func(ctx context.Context) bool {
+					return users.TenantFromContext(ctx) == "acme"
+				}), mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			expectedTenant: "acme",
+		},
+		{
+			testName: "success with tenant of the request",
+			token:    signTenantToken("acme"),
+			tenant:   "acme",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			expectedTenant: "acme",
+		},
+		{
+			testName: "with token of another tenant",
+			token:    signTenantToken("acme"),
+			tenant:   "umbrella",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedError: users.UnauthorizedErrorf("invalid token: not issued for tenant umbrella"),
+		},
+		{
+			testName: "with token of default tenant on another tenant",
+			token:    signToken("secret", mockUser.ID),
+			tenant:   "umbrella",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedError: users.UnauthorizedErrorf("invalid token: not issued for tenant umbrella"),
+		},
+		{
+			testName: "with invalid token format",
+			token:    "invalid",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedError: users.UnauthorizedErrorf("invalid token format"),
+		},
+		{
+			testName: "with token signed by another secret",
+			token:    signToken("another-secret", mockUser.ID),
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedError: users.UnauthorizedErrorf("invalid token: signature is invalid"),
+		},
+		{
+			testName: "success with email of a token without subject",
+			token:    signEmailToken(mockUser.Email),
+			method:   "GetByEmail",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.Email},
+				Output: []interface{}{mockUser, nil},
+			},
+		},
+		{
+			testName: "with unknown email of a token without subject",
+			token:    signEmailToken("unknown@doe.com"),
+			method:   "GetByEmail",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "unknown@doe.com"},
+				Output: []interface{}{users.User{}, users.ErrNotFound},
+			},
+			expectedError: users.UnauthorizedErrorf("invalid token: unknown user"),
+		},
+		{
+			testName: "with token without subject nor email",
+			token:    signToken("secret", ""),
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedError: users.UnauthorizedErrorf("invalid token: unknown user"),
+		},
+		{
+			testName: "with unknown user",
+			token:    signToken("secret", mockUser.ID),
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.User{}, users.ErrNotFound},
+			},
+			expectedError: users.UnauthorizedErrorf("invalid token: unknown user"),
+		},
+		{
+			testName: "with suspended user",
+			token:    signToken("secret", mockUser.ID),
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{suspendedUser, nil},
+			},
+			expectedError: users.ErrUserSuspended,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockService := new(mocks.UserService)
+			if test.service.Called {
+				method := test.method
+				if method == "" {
+					method = "Get"
+				}
+				mockService.On(method, test.service.Input...).
+					Return(test.service.Output...).Once()
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", test.token)
+			if test.tenant != "" {
+				req.Header.Set("X-Tenant-ID", test.tenant)
+			}
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			var valid bool
+			authenticate := func(c echo.Context) (err error) {
+				valid, err = handler.AuthenticationMiddleware("secret", mockService)(test.token, c)
+				return err
+			}
+			err := handler.TenantMiddleware("")(authenticate)(c)
+			mockService.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				require.False(t, valid)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, valid)
+
+			expectedTenant := test.expectedTenant
+			if expectedTenant == "" {
+				expectedTenant = users.DefaultTenant
+			}
+			require.Equal(t, expectedTenant, users.TenantFromContext(c.Request().Context()))
+		})
+	}
+}
+
+func TestTenantMiddleware(t *testing.T) {
+	tests := []struct {
+		testName       string
+		host           string
+		header         string
+		expectedTenant string
+		expectedError  error
+	}{
+		{
+			testName:       "with tenant header",
+			host:           "umbrella.users.example.com",
+			header:         "acme",
+			expectedTenant: "acme",
+		},
+		{
+			testName:       "with tenant host",
+			host:           "umbrella.users.example.com:7723",
+			expectedTenant: "umbrella",
+		},
+		{
+			testName:       "with host of another domain",
+			host:           "umbrella.example.com",
+			expectedTenant: users.DefaultTenant,
+		},
+		{
+			testName:       "with nested host",
+			host:           "a.umbrella.users.example.com",
+			expectedTenant: users.DefaultTenant,
+		},
+		{
+			testName:      "with invalid tenant",
+			header:        "acme corp",
+			expectedError: users.ConstraintErrorf("invalid tenant: acme corp"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Host = test.host
+			if test.header != "" {
+				req.Header.Set("X-Tenant-ID", test.header)
+			}
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			var tenantID string
+			h := func(c echo.Context) error {
+				tenantID = users.TenantFromContext(c.Request().Context())
+				return nil
+			}
+
+			err := handler.TenantMiddleware("users.example.com")(h)(c)
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expectedTenant, tenantID)
+		})
+	}
+}
+
+func TestReadYourWritesMiddleware(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	var before, after bool
+	h := func(c echo.Context) error {
+		ctx := c.Request().Context()
+		before = users.HasWritten(ctx)
+		users.MarkWritten(ctx)
+		after = users.HasWritten(ctx)
+		return nil
+	}
+
+	err := handler.ReadYourWritesMiddleware()(h)(c)
+	require.NoError(t, err)
+	require.False(t, before)
+	require.True(t, after)
+	require.False(t, users.HasWritten(req.Context()))
+}
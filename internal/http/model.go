@@ -0,0 +1,129 @@
+package http
+
+import (
+	"time"
+
+	"github.com/arnaz06/users"
+)
+
+// createUserRequest is the body of a sign-up. The fields managed by the server, such as the ID
+// and the status, can not be set by the client.
+type createUserRequest users.SignUp
+
+func (r createUserRequest) toUser() users.User {
+	return users.User{
+		Email:    r.Email,
+		Username: r.Username,
+		Address:  r.Address,
+		Password: r.Password,
+		Status:   users.StatusActive,
+	}
+}
+
+// updateUserRequest is the body of an update, changing only the fields it holds and leaving the omitted ones unchanged.
+// The fields given are held to the rules of a sign-up, but for the empty username removing it.
+type updateUserRequest struct {
+	Email    *string `json:"email" validate:"omitempty,email,max=255"`
+	Username *string `json:"username" validate:"omitempty,max=30,eq=|min=3"`
+	Address  *string `json:"address" validate:"omitempty,max=255"`
+	Password *string `json:"password" validate:"omitempty,min=8,max=72"`
+}
+
+func (r updateUserRequest) toPatch() users.UserPatch {
+	return users.UserPatch{
+		Email:    r.Email,
+		Username: r.Username,
+		Address:  r.Address,
+		Password: r.Password,
+	}
+}
+
+type loginRequest struct {
+	Identifier string `json:"identifier" validate:"required"`
+	Password   string `json:"password" validate:"required"`
+}
+
+// userResponse is the public view of a user. It has no password, so the hash is never sent to clients.
+type userResponse struct {
+	ID          string       `json:"id"`
+	Email       string       `json:"email"`
+	Username    string       `json:"username,omitempty"`
+	Address     string       `json:"address"`
+	Status      users.Status `json:"status"`
+	Version     int64        `json:"version"`
+	CreatedTime time.Time    `json:"created_time"`
+	UpdatedTime time.Time    `json:"updated_time"`
+	DeletedTime *time.Time   `json:"deleted_time,omitempty"`
+}
+
+func newUserResponse(user users.User) userResponse {
+	return userResponse{
+		ID:          user.ID,
+		Email:       user.Email,
+		Username:    user.Username,
+		Address:     user.Address,
+		Status:      user.Status,
+		Version:     user.Version,
+		CreatedTime: user.CreatedTime,
+		UpdatedTime: user.UpdatedTime,
+		DeletedTime: user.DeletedTime,
+	}
+}
+
+func newUserResponses(list []users.User) []userResponse {
+	res := make([]userResponse, 0, len(list))
+	for _, user := range list {
+		res = append(res, newUserResponse(user))
+	}
+	return res
+}
+
+type userPageResponse struct {
+	Users      []userResponse `json:"users"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	Total      *int64         `json:"total,omitempty"`
+}
+
+func newUserPageResponse(page users.UserPage) userPageResponse {
+	return userPageResponse{
+		Users:      newUserResponses(page.Users),
+		NextCursor: page.NextCursor,
+		Total:      page.Total,
+	}
+}
+
+// batchRequest is the body of a batch, aborting on the first error unless the mode is continue.
+type batchRequest struct {
+	Mode       users.BatchMode         `json:"mode"`
+	Operations []batchOperationRequest `json:"operations"`
+}
+
+// batchOperationRequest is an operation of a batch. The ID and version are the ones of the user
+// to update or delete, and the other fields the ones of the user to create or update.
+type batchOperationRequest struct {
+	Op       users.BatchOperationType `json:"op"`
+	ID       string                   `json:"id"`
+	Version  int64                    `json:"version"`
+	Email    string                   `json:"email"`
+	Username string                   `json:"username"`
+	Address  string                   `json:"address"`
+	Password string                   `json:"password"`
+}
+
+func (r batchRequest) toOperations() []users.BatchOperation {
+	res := make([]users.BatchOperation, 0, len(r.Operations))
+	for _, op := range r.Operations {
+		res = append(res, users.BatchOperation{
+			Type: op.Op,
+			User: users.User{
+				ID:       op.ID,
+				Email:    op.Email,
+				Username: op.Username,
+				Address:  op.Address,
+				Password: op.Password,
+				Version:  op.Version,
+			},
+		})
+	}
+	return res
+}
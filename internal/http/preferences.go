@@ -0,0 +1,55 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/arnaz06/users"
+)
+
+type preferencesHandler struct {
+	service users.PreferencesService
+}
+
+// AddPreferencesHandler adds the preferences handler, restricted to the user and the admins.
+func AddPreferencesHandler(e *echo.Echo, service users.PreferencesService, adminKey string) {
+	if service == nil {
+		panic("http: nil preferences service")
+	}
+
+	handler := &preferencesHandler{
+		service: service,
+	}
+
+	e.GET("/user/:userId/preferences", handler.get, OwnerOrAdminMiddleware(adminKey))
+	e.PUT("/user/:userId/preferences", handler.update, OwnerOrAdminMiddleware(adminKey))
+}
+
+func (h preferencesHandler) get(c echo.Context) error {
+	res, err := h.service.Get(c.Request().Context(), c.Param("userId"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h preferencesHandler) update(c echo.Context) error {
+	// The preferences left out of the request are reset to their default,
+	// and unknown keys are rejected rather than silently dropped.
+	input := users.DefaultPreferences()
+	decoder := json.NewDecoder(c.Request().Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&input); err != nil {
+		return users.ConstraintErrorf("error reading preferences: %s", err)
+	}
+
+	err := h.service.Update(c.Request().Context(), c.Param("userId"), input)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
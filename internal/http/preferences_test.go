@@ -0,0 +1,189 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	handler "github.com/arnaz06/users/internal/http"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
+)
+
+func TestGetPreferencesHandler(t *testing.T) {
+	userID := "123"
+
+	tests := []struct {
+		testName            string
+		authenticatedUserID string
+		adminKey            string
+		service             testdata.FuncCall
+		expectedStatus      int
+	}{
+		{
+			testName: "success",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, userID},
+				Output: []interface{}{users.DefaultPreferences(), nil},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:            "success with admin key",
+			authenticatedUserID: "other-user",
+			adminKey:            adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, userID},
+				Output: []interface{}{users.DefaultPreferences(), nil},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:            "with other user",
+			authenticatedUserID: "other-user",
+			expectedStatus:      http.StatusForbidden,
+		},
+		{
+			testName: "with user not found",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, userID},
+				Output: []interface{}{users.Preferences{}, users.ErrNotFound},
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			authenticatedUserID := userID
+			if test.authenticatedUserID != "" {
+				authenticatedUserID = test.authenticatedUserID
+			}
+			e := getAuthenticatedEchoServer(authenticatedUserID)
+			mockService := new(mocks.PreferencesService)
+			if test.service.Called {
+				mockService.On("Get", test.service.Input...).
+					Return(test.service.Output...).Once()
+			}
+
+			req := httptest.NewRequest(echo.GET, "/user/"+userID+"/preferences", nil)
+			req.Header.Set("X-Admin-Key", test.adminKey)
+			rec := httptest.NewRecorder()
+
+			handler.AddPreferencesHandler(e, mockService, adminKey)
+			e.ServeHTTP(rec, req)
+
+			mockService.AssertExpectations(t)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+			if test.expectedStatus == http.StatusOK {
+				var res users.Preferences
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+				require.Equal(t, users.DefaultPreferences(), res)
+			}
+		})
+	}
+}
+
+func TestUpdatePreferencesHandler(t *testing.T) {
+	userID := "123"
+
+	partial := users.DefaultPreferences()
+	partial.Theme = users.ThemeDark
+	partial.Notifications.Newsletter = true
+
+	tests := []struct {
+		testName            string
+		body                string
+		authenticatedUserID string
+		adminKey            string
+		service             testdata.FuncCall
+		expectedStatus      int
+	}{
+		{
+			testName: "success with defaults for missing keys",
+			body:     `{"theme":"dark","notifications":{"newsletter":true}}`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, userID, partial},
+				Output: []interface{}{nil},
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			testName:            "success with admin key",
+			body:                `{"theme":"dark","notifications":{"newsletter":true}}`,
+			authenticatedUserID: "other-user",
+			adminKey:            adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, userID, partial},
+				Output: []interface{}{nil},
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			testName:            "with other user",
+			body:                `{"theme":"dark"}`,
+			authenticatedUserID: "other-user",
+			expectedStatus:      http.StatusForbidden,
+		},
+		{
+			testName:       "with unknown key",
+			body:           `{"theme":"dark","font_size":12}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:       "with invalid type",
+			body:           `{"notifications":{"email":"yes"}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with invalid value",
+			body:     `{"theme":"neon"}`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, userID, mock.Anything},
+				Output: []interface{}{users.ConstraintErrorf("invalid theme: neon")},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			authenticatedUserID := userID
+			if test.authenticatedUserID != "" {
+				authenticatedUserID = test.authenticatedUserID
+			}
+			e := getAuthenticatedEchoServer(authenticatedUserID)
+			mockService := new(mocks.PreferencesService)
+			if test.service.Called {
+				mockService.On("Update", test.service.Input...).
+					Return(test.service.Output...).Once()
+			}
+
+			req := httptest.NewRequest(echo.PUT, "/user/"+userID+"/preferences", strings.NewReader(test.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set("X-Admin-Key", test.adminKey)
+			rec := httptest.NewRecorder()
+
+			handler.AddPreferencesHandler(e, mockService, adminKey)
+			e.ServeHTTP(rec, req)
+
+			mockService.AssertExpectations(t)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/arnaz06/users"
+)
+
+type searchHandler struct {
+	service users.SearchService
+}
+
+// AddSearchHandler adds the search handler to the admin group.
+func AddSearchHandler(g *echo.Group, service users.SearchService) {
+	if service == nil {
+		panic("http: nil search service")
+	}
+
+	handler := &searchHandler{
+		service: service,
+	}
+
+	g.GET("/search", handler.search)
+}
+
+func (h searchHandler) search(c echo.Context) error {
+	var limit int
+	if param := c.QueryParam("limit"); param != "" {
+		var err error
+		limit, err = strconv.Atoi(param)
+		if err != nil {
+			return users.ConstraintErrorf("invalid limit: %s", param)
+		}
+	}
+
+	res, err := h.service.Search(c.Request().Context(), c.QueryParam("q"), limit)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newUserResponses(res))
+}
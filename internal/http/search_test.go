@@ -0,0 +1,107 @@
+package http_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	handler "github.com/arnaz06/users/internal/http"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
+)
+
+func TestSearchHandler(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	tests := []struct {
+		testName       string
+		query          string
+		adminKey       string
+		service        testdata.FuncCall
+		expectedStatus int
+	}{
+		{
+			testName: "success",
+			query:    "?q=jhon+doe&limit=5",
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "jhon doe", 5},
+				Output: []interface{}{[]users.User{mockUser}, nil},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName: "with invalid admin key",
+			query:    "?q=jhon",
+			adminKey: "invalid",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			testName: "with invalid limit",
+			query:    "?q=jhon&limit=abc",
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with missing query",
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "", 0},
+				Output: []interface{}{nil, users.ConstraintErrorf("search query is required")},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with unexpected error from service",
+			query:    "?q=jhon",
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "jhon", 0},
+				Output: []interface{}{nil, errors.New("unexpected error")},
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			e := getEchoServer()
+			mockService := new(mocks.SearchService)
+			if test.service.Called {
+				mockService.On("Search", test.service.Input...).
+					Return(test.service.Output...).Once()
+			}
+
+			req := httptest.NewRequest(echo.GET, "/users/search"+test.query, nil)
+			req.Header.Set("X-Admin-Key", test.adminKey)
+			rec := httptest.NewRecorder()
+
+			handler.AddSearchHandler(handler.AdminGroup(e, adminKey), mockService)
+			e.ServeHTTP(rec, req)
+
+			mockService.AssertExpectations(t)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+			if test.expectedStatus == http.StatusOK {
+				require.Contains(t, rec.Body.String(), mockUser.ID)
+				require.NotContains(t, rec.Body.String(), "password")
+			}
+		})
+	}
+}
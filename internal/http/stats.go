@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/arnaz06/users"
+)
+
+type statsHandler struct {
+	service users.StatsService
+}
+
+// AddStatsHandler adds the statistics handler to the admin group.
+func AddStatsHandler(g *echo.Group, service users.StatsService) {
+	if service == nil {
+		panic("http: nil stats service")
+	}
+
+	handler := &statsHandler{
+		service: service,
+	}
+
+	g.GET("/stats", handler.stats)
+}
+
+func (h statsHandler) stats(c echo.Context) error {
+	filter := users.StatsFilter{
+		Interval: users.StatsInterval(c.QueryParam("interval")),
+	}
+
+	var err error
+	if param := c.QueryParam("from"); param != "" {
+		filter.From, err = time.Parse(time.RFC3339, param)
+		if err != nil {
+			return users.ConstraintErrorf("invalid from: %s", param)
+		}
+	}
+	if param := c.QueryParam("to"); param != "" {
+		filter.To, err = time.Parse(time.RFC3339, param)
+		if err != nil {
+			return users.ConstraintErrorf("invalid to: %s", param)
+		}
+	}
+
+	res, err := h.service.Stats(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,136 @@
+package http_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	handler "github.com/arnaz06/users/internal/http"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
+)
+
+func TestStatsHandler(t *testing.T) {
+	from := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2021, 3, 2, 0, 0, 0, 0, time.UTC)
+	stats := users.UserStats{
+		From:      from,
+		To:        to,
+		Interval:  users.IntervalDay,
+		Total:     5,
+		ByStatus:  map[users.Status]int64{users.StatusActive: 5},
+		Signups:   []users.StatsBucket{{Start: from, Count: 2}},
+		Deletions: []users.StatsBucket{{Start: from, Count: 0}},
+		Logins:    []users.StatsBucket{{Start: from, Count: 7}},
+	}
+
+	tests := []struct {
+		testName       string
+		query          string
+		adminKey       string
+		service        testdata.FuncCall
+		expectedStatus int
+	}{
+		{
+			testName: "success",
+			query:    "?from=2021-03-01T00:00:00Z&to=2021-03-02T00:00:00Z&interval=day",
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, users.StatsFilter{From: from, To: to, Interval: users.IntervalDay}},
+				Output: []interface{}{stats, nil},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName: "success with default range",
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, users.StatsFilter{}},
+				Output: []interface{}{stats, nil},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName: "with invalid admin key",
+			adminKey: "invalid",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			testName: "with invalid from",
+			query:    "?from=yesterday",
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with invalid to",
+			query:    "?to=today",
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with invalid interval",
+			query:    "?interval=year",
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, users.StatsFilter{Interval: "year"}},
+				Output: []interface{}{users.UserStats{}, users.ConstraintErrorf("invalid interval: year")},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with unexpected error from service",
+			adminKey: adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, users.StatsFilter{}},
+				Output: []interface{}{users.UserStats{}, errors.New("unexpected error")},
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			e := getEchoServer()
+			mockService := new(mocks.StatsService)
+			if test.service.Called {
+				mockService.On("Stats", test.service.Input...).
+					Return(test.service.Output...).Once()
+			}
+
+			req := httptest.NewRequest(echo.GET, "/users/stats"+test.query, nil)
+			req.Header.Set("X-Admin-Key", test.adminKey)
+			rec := httptest.NewRecorder()
+
+			handler.AddStatsHandler(handler.AdminGroup(e, adminKey), mockService)
+			e.ServeHTTP(rec, req)
+
+			mockService.AssertExpectations(t)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+			if test.expectedStatus == http.StatusOK {
+				require.Contains(t, rec.Body.String(), `"by_status":{"active":5}`)
+				require.Contains(t, rec.Body.String(), `"logins":[{"start":"2021-03-01T00:00:00Z","count":7}]`)
+			}
+		})
+	}
+}
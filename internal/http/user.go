@@ -3,6 +3,8 @@ package http
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/arnaz06/users"
@@ -16,8 +18,8 @@ type userHandler struct {
 	expiresTime time.Duration
 }
 
-// AddUserHandler adds the user handler.
-func AddUserHandler(e *echo.Echo, service users.UserService, secretKey string, expiresTime time.Duration) {
+// AddUserHandler adds the user handler. A user, and its history, are restricted to the user and the admins.
+func AddUserHandler(e *echo.Echo, service users.UserService, secretKey string, expiresTime time.Duration, adminKey string) {
 	if service == nil {
 		panic("http: nil users service")
 	}
@@ -29,14 +31,16 @@ func AddUserHandler(e *echo.Echo, service users.UserService, secretKey string, e
 	}
 
 	e.POST("/user", handler.create)
-	e.GET("/user/:userId", handler.get)
+	e.GET("/user/:userId", handler.get, OwnerOrAdminMiddleware(adminKey))
 	e.POST("/user/login", handler.login)
-	e.PUT("/user/:userId", handler.update)
-	e.DELETE("/user/:userId", handler.delete)
+	e.PUT("/user/:userId", handler.update, OwnerOrAdminMiddleware(adminKey))
+	e.PATCH("/user/:userId", handler.update, OwnerOrAdminMiddleware(adminKey))
+	e.DELETE("/user/:userId", handler.delete, OwnerOrAdminMiddleware(adminKey))
+	e.GET("/user/:userId/history", handler.history, OwnerOrAdminMiddleware(adminKey))
 }
 
 func (h userHandler) create(c echo.Context) error {
-	var input users.User
+	var input createUserRequest
 	if err := c.Bind(&input); err != nil {
 		return users.ConstraintErrorf("%s", err)
 	}
@@ -45,18 +49,12 @@ func (h userHandler) create(c echo.Context) error {
 		return users.ConstraintErrorf("error validating user: %+v", err)
 	}
 
-	hashedPassword, err := users.EncodeString(input.Password)
+	res, err := h.service.Create(c.Request().Context(), input.toUser())
 	if err != nil {
 		return err
 	}
-	input.Password = hashedPassword
 
-	res, err := h.service.Create(c.Request().Context(), input)
-	if err != nil {
-		return err
-	}
-
-	return c.JSON(http.StatusCreated, res)
+	return c.JSON(http.StatusCreated, newUserResponse(res))
 }
 
 func (h userHandler) get(c echo.Context) error {
@@ -65,23 +63,32 @@ func (h userHandler) get(c echo.Context) error {
 		return err
 	}
 
-	return c.JSON(http.StatusOK, res)
+	c.Response().Header().Set("ETag", formatETag(res.Version))
+	return c.JSON(http.StatusOK, newUserResponse(res))
 }
 
 func (h userHandler) login(c echo.Context) error {
-	var input users.User
+	var input loginRequest
 	if err := c.Bind(&input); err != nil {
 		return users.ConstraintErrorf("%s", err)
 	}
 
-	err := h.service.Login(c.Request().Context(), input.Email, input.Password)
+	if err := c.Validate(input); err != nil {
+		return users.ConstraintErrorf("error validating login: %+v", err)
+	}
+
+	user, err := h.service.Login(c.Request().Context(), input.Identifier, input.Password)
 	if err != nil {
 		return err
 	}
 
-	token := jwt.NewWithClaims(jwt.GetSigningMethod("HS256"), jwt.StandardClaims{
-		ExpiresAt: time.Now().Add(h.expiresTime).Unix(),
-		Audience:  c.Param("email"),
+	token := jwt.NewWithClaims(jwt.GetSigningMethod("HS256"), myCustomClaims{
+		Email:  user.Email,
+		Tenant: users.TenantFromContext(c.Request().Context()),
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(h.expiresTime).Unix(),
+			Subject:   user.ID,
+		},
 	})
 
 	tokenString, err := token.SignedString([]byte(h.secretKey))
@@ -92,8 +99,9 @@ func (h userHandler) login(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]string{"token": fmt.Sprintf("Bearer %s", tokenString)})
 }
 
+// update changes the fields given in the body, for both PUT and PATCH, so the omitted fields are never cleared.
 func (h userHandler) update(c echo.Context) error {
-	var input users.User
+	var input updateUserRequest
 	if err := c.Bind(&input); err != nil {
 		return users.ConstraintErrorf("%s", err)
 	}
@@ -102,15 +110,12 @@ func (h userHandler) update(c echo.Context) error {
 		return users.ConstraintErrorf("error validating user: %+v", err)
 	}
 
-	hashedPassword, err := users.EncodeString(input.Password)
+	version, err := parseIfMatch(c.Request().Header.Get("If-Match"))
 	if err != nil {
 		return err
 	}
 
-	input.Password = hashedPassword
-	input.ID = c.Param("userId")
-
-	err = h.service.Update(c.Request().Context(), input)
+	err = h.service.Patch(c.Request().Context(), c.Param("userId"), input.toPatch(), version)
 	if err != nil {
 		return err
 	}
@@ -119,9 +124,51 @@ func (h userHandler) update(c echo.Context) error {
 }
 
 func (h userHandler) delete(c echo.Context) error {
-	err := h.service.Delete(c.Request().Context(), c.Param("userId"))
+	version, err := parseIfMatch(c.Request().Header.Get("If-Match"))
+	if err != nil {
+		return err
+	}
+
+	err = h.service.Delete(c.Request().Context(), c.Param("userId"), version)
 	if err != nil {
 		return err
 	}
 	return c.NoContent(http.StatusNoContent)
 }
+
+func (h userHandler) history(c echo.Context) error {
+	var limit int
+	if param := c.QueryParam("limit"); param != "" {
+		var err error
+		limit, err = strconv.Atoi(param)
+		if err != nil {
+			return users.ConstraintErrorf("invalid limit: %s", param)
+		}
+	}
+
+	res, err := h.service.History(c.Request().Context(), c.Param("userId"), c.QueryParam("cursor"), limit)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func formatETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// parseIfMatch returns the version expected by the If-Match header, or zero if any version is accepted.
+// A header which is not the ETag of a version is rejected as a bad request.
+func parseIfMatch(header string) (int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(header, "W/"), `"`), 10, 64)
+	if err != nil || version <= 0 {
+		return 0, users.ConstraintErrorf("invalid If-Match header: %s", header)
+	}
+	return version, nil
+}
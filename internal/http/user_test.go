@@ -26,6 +26,18 @@ func getEchoServer() *echo.Echo {
 	return e
 }
 
+// getAuthenticatedEchoServer returns a server handling the requests as authenticated by the given user.
+func getAuthenticatedEchoServer(userID string) *echo.Echo {
+	e := getEchoServer()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(handler.UserIDKey, userID)
+			return next(c)
+		}
+	})
+	return e
+}
+
 func TestCreateUserHandler(t *testing.T) {
 	userJSON := testdata.GetGolden(t, "user")
 	var mockUser users.User
@@ -47,7 +59,10 @@ func TestCreateUserHandler(t *testing.T) {
 			input:    userJSON,
 			service: testdata.FuncCall{
 				Called: true,
-				Input:  []interface{}{mock.Anything, mock.AnythingOfType("users.User")},
+				Input: []interface{}{mock.Anything, mock.MatchedBy(func(user users.User) bool {
+					return user.ID == "" && user.Version == 0 && user.CreatedTime.IsZero() &&
+						user.Status == users.StatusActive && user.Password == mockUser.Password
+				})},
 				Output: []interface{}{mockUser, nil},
 			},
 			expectedStatus: http.StatusCreated,
@@ -68,6 +83,14 @@ func TestCreateUserHandler(t *testing.T) {
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			testName: "error validator with short password",
+			input:    []byte(`{"email":"jhon@doe.com","password":"secret"}`),
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			testName: "with unexpected error from service",
 			input:    userJSON,
@@ -93,12 +116,13 @@ func TestCreateUserHandler(t *testing.T) {
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			rec := httptest.NewRecorder()
 
-			handler.AddUserHandler(e, mockService, "secret", time.Duration(3600))
+			handler.AddUserHandler(e, mockService, "secret", time.Duration(3600), adminKey)
 			e.ServeHTTP(rec, req)
 
 			mockService.AssertExpectations(t)
 
 			require.Equal(t, test.expectedStatus, rec.Code)
+			require.NotContains(t, rec.Body.String(), "password")
 		})
 	}
 }
@@ -108,38 +132,74 @@ func TestUpdateUserHandler(t *testing.T) {
 	var mockUser users.User
 	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
 
-	missingEmail := users.User(mockUser)
-	missingEmail.Email = ""
-	missingEMailJSON, err := json.Marshal(missingEmail)
-	require.NoError(t, err)
+	username := "jhon.doe"
 
 	tests := []struct {
-		testName       string
-		input          []byte
-		service        testdata.FuncCall
-		expectedStatus int
+		testName            string
+		input               []byte
+		ifMatch             string
+		service             testdata.FuncCall
+		authenticatedUserID string
+		expectedStatus      int
 	}{
 		{
 			testName: "success",
 			input:    userJSON,
 			service: testdata.FuncCall{
 				Called: true,
-				Input:  []interface{}{mock.Anything, mock.AnythingOfType("users.User")},
+				Input: []interface{}{mock.Anything, "456", users.UserPatch{
+					Email:    &mockUser.Email,
+					Address:  &mockUser.Address,
+					Password: &mockUser.Password,
+				}, int64(0)},
 				Output: []interface{}{nil},
 			},
 			expectedStatus: http.StatusNoContent,
 		},
 		{
-			testName: "with invalid request body",
-			input:    []byte(`invalid body`),
+			testName: "success without password",
+			input:    []byte(`{"username":"jhon.doe"}`),
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "456", users.UserPatch{Username: &username}, int64(0)},
+				Output: []interface{}{nil},
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			testName: "success with matching version",
+			input:    userJSON,
+			ifMatch:  `"1"`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "456", mock.AnythingOfType("users.UserPatch"), int64(1)},
+				Output: []interface{}{nil},
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			testName: "with outdated version",
+			input:    userJSON,
+			ifMatch:  `"1"`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "456", mock.AnythingOfType("users.UserPatch"), int64(1)},
+				Output: []interface{}{users.ErrPreconditionFailed},
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			testName: "with invalid If-Match header",
+			input:    userJSON,
+			ifMatch:  `"abc"`,
 			service: testdata.FuncCall{
 				Called: false,
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
-			testName: "error validator",
-			input:    missingEMailJSON,
+			testName: "with invalid request body",
+			input:    []byte(`invalid body`),
 			service: testdata.FuncCall{
 				Called: false,
 			},
@@ -150,27 +210,179 @@ func TestUpdateUserHandler(t *testing.T) {
 			input:    userJSON,
 			service: testdata.FuncCall{
 				Called: true,
-				Input:  []interface{}{mock.Anything, mock.AnythingOfType("users.User")},
+				Input:  []interface{}{mock.Anything, "456", mock.AnythingOfType("users.UserPatch"), int64(0)},
 				Output: []interface{}{errors.New("unexpected error")},
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
+		{
+			testName:            "with other user",
+			input:               userJSON,
+			authenticatedUserID: "other-user",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			authenticatedUserID := "456"
+			if test.authenticatedUserID != "" {
+				authenticatedUserID = test.authenticatedUserID
+			}
+			e := getAuthenticatedEchoServer(authenticatedUserID)
+			mockService := new(mocks.UserService)
+			if test.service.Called {
+				mockService.On("Patch", test.service.Input...).
+					Return(test.service.Output...).Once()
+			}
+
+			req := httptest.NewRequest(echo.PUT, "/user/456", strings.NewReader(string(test.input)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if test.ifMatch != "" {
+				req.Header.Set("If-Match", test.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.AddUserHandler(e, mockService, "secret", time.Duration(3600), adminKey)
+			e.ServeHTTP(rec, req)
+
+			mockService.AssertExpectations(t)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestPatchUserHandler(t *testing.T) {
+	address := "new address"
+	empty := ""
+
+	tests := []struct {
+		testName            string
+		input               string
+		ifMatch             string
+		service             testdata.FuncCall
+		authenticatedUserID string
+		expectedStatus      int
+	}{
+		{
+			testName: "success",
+			input:    `{"address":"new address"}`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "456", users.UserPatch{Address: &address}, int64(0)},
+				Output: []interface{}{nil},
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			testName: "success with matching version",
+			input:    `{"address":"new address"}`,
+			ifMatch:  `"2"`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "456", users.UserPatch{Address: &address}, int64(2)},
+				Output: []interface{}{nil},
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			testName: "with outdated version",
+			input:    `{"address":"new address"}`,
+			ifMatch:  `"1"`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "456", users.UserPatch{Address: &address}, int64(1)},
+				Output: []interface{}{users.ErrPreconditionFailed},
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			testName: "with invalid If-Match header",
+			input:    `{"address":"new address"}`,
+			ifMatch:  `"abc"`,
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with empty password",
+			input:    `{"password":""}`,
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with short password",
+			input:    `{"password":"secret"}`,
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with short username",
+			input:    `{"username":"jd"}`,
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with empty username",
+			input:    `{"username":""}`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "456", users.UserPatch{Username: &empty}, int64(0)},
+				Output: []interface{}{nil},
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			testName: "with invalid request body",
+			input:    `invalid body`,
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName:            "with other user",
+			input:               `{"address":"new address"}`,
+			authenticatedUserID: "other-user",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusForbidden,
+		},
 	}
 
-	e := getEchoServer()
 	for _, test := range tests {
 		t.Run(test.testName, func(t *testing.T) {
+			authenticatedUserID := "456"
+			if test.authenticatedUserID != "" {
+				authenticatedUserID = test.authenticatedUserID
+			}
+			e := getAuthenticatedEchoServer(authenticatedUserID)
 			mockService := new(mocks.UserService)
 			if test.service.Called {
-				mockService.On("Update", test.service.Input...).
+				mockService.On("Patch", test.service.Input...).
 					Return(test.service.Output...).Once()
 			}
 
-			req := httptest.NewRequest(echo.PUT, "/user/123", strings.NewReader(string(test.input)))
+			req := httptest.NewRequest(echo.PATCH, "/user/456", strings.NewReader(test.input))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if test.ifMatch != "" {
+				req.Header.Set("If-Match", test.ifMatch)
+			}
 			rec := httptest.NewRecorder()
 
-			handler.AddUserHandler(e, mockService, "secret", time.Duration(3600))
+			handler.AddUserHandler(e, mockService, "secret", time.Duration(3600), adminKey)
 			e.ServeHTTP(rec, req)
 
 			mockService.AssertExpectations(t)
@@ -185,36 +397,82 @@ func TestDeleteUserHandler(t *testing.T) {
 	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
 
 	tests := []struct {
-		testName       string
-		input          string
-		service        testdata.FuncCall
-		expectedStatus int
+		testName            string
+		input               string
+		ifMatch             string
+		service             testdata.FuncCall
+		authenticatedUserID string
+		expectedStatus      int
 	}{
 		{
 			testName: "success",
 			input:    mockUser.ID,
 			service: testdata.FuncCall{
 				Called: true,
-				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Input:  []interface{}{mock.Anything, mockUser.ID, int64(0)},
 				Output: []interface{}{nil},
 			},
 			expectedStatus: http.StatusNoContent,
 		},
+		{
+			testName: "success with matching version",
+			input:    mockUser.ID,
+			ifMatch:  `W/"1"`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID, int64(1)},
+				Output: []interface{}{nil},
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			testName: "with invalid If-Match header",
+			input:    mockUser.ID,
+			ifMatch:  `"0"`,
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with outdated version",
+			input:    mockUser.ID,
+			ifMatch:  `"1"`,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID, int64(1)},
+				Output: []interface{}{users.ErrPreconditionFailed},
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
 		{
 			testName: "with unexpected error from service",
 			input:    mockUser.ID,
 			service: testdata.FuncCall{
 				Called: true,
-				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Input:  []interface{}{mock.Anything, mockUser.ID, int64(0)},
 				Output: []interface{}{errors.New("unexpected error")},
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
+		{
+			testName:            "with other user",
+			input:               mockUser.ID,
+			authenticatedUserID: "other-user",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusForbidden,
+		},
 	}
 
-	e := getEchoServer()
 	for _, test := range tests {
 		t.Run(test.testName, func(t *testing.T) {
+			authenticatedUserID := "123"
+			if test.authenticatedUserID != "" {
+				authenticatedUserID = test.authenticatedUserID
+			}
+			e := getAuthenticatedEchoServer(authenticatedUserID)
 			mockService := new(mocks.UserService)
 			if test.service.Called {
 				mockService.On("Delete", test.service.Input...).
@@ -223,9 +481,12 @@ func TestDeleteUserHandler(t *testing.T) {
 
 			req := httptest.NewRequest(echo.DELETE, "/user/123", nil)
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if test.ifMatch != "" {
+				req.Header.Set("If-Match", test.ifMatch)
+			}
 			rec := httptest.NewRecorder()
 
-			handler.AddUserHandler(e, mockService, "secret", time.Duration(3600))
+			handler.AddUserHandler(e, mockService, "secret", time.Duration(3600), adminKey)
 			e.ServeHTTP(rec, req)
 
 			mockService.AssertExpectations(t)
@@ -239,10 +500,12 @@ func TestGetUserHandler(t *testing.T) {
 	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
 
 	tests := []struct {
-		testName       string
-		input          string
-		service        testdata.FuncCall
-		expectedStatus int
+		testName            string
+		input               string
+		service             testdata.FuncCall
+		authenticatedUserID string
+		expectedStatus      int
+		expectedETag        string
 	}{
 		{
 			testName: "success",
@@ -253,6 +516,7 @@ func TestGetUserHandler(t *testing.T) {
 				Output: []interface{}{mockUser, nil},
 			},
 			expectedStatus: http.StatusOK,
+			expectedETag:   `"1"`,
 		},
 		{
 			testName: "with unexpected error from service",
@@ -264,11 +528,24 @@ func TestGetUserHandler(t *testing.T) {
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
+		{
+			testName:            "with other user",
+			input:               mockUser.ID,
+			authenticatedUserID: "other-user",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusForbidden,
+		},
 	}
 
-	e := getEchoServer()
 	for _, test := range tests {
 		t.Run(test.testName, func(t *testing.T) {
+			authenticatedUserID := "123"
+			if test.authenticatedUserID != "" {
+				authenticatedUserID = test.authenticatedUserID
+			}
+			e := getAuthenticatedEchoServer(authenticatedUserID)
 			mockService := new(mocks.UserService)
 			if test.service.Called {
 				mockService.On("Get", test.service.Input...).
@@ -279,20 +556,25 @@ func TestGetUserHandler(t *testing.T) {
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			rec := httptest.NewRecorder()
 
-			handler.AddUserHandler(e, mockService, "secret", time.Duration(3600))
+			handler.AddUserHandler(e, mockService, "secret", time.Duration(3600), adminKey)
 			e.ServeHTTP(rec, req)
 
 			mockService.AssertExpectations(t)
 
 			require.Equal(t, test.expectedStatus, rec.Code)
+			require.Equal(t, test.expectedETag, rec.Header().Get("ETag"))
+			require.NotContains(t, rec.Body.String(), "password")
 		})
 	}
 }
 
 func TestLoginUserHandler(t *testing.T) {
-	userJSON := testdata.GetGolden(t, "user")
 	var mockUser users.User
 	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+	mockUser.Username = "jhon"
+
+	emailJSON := []byte(`{"identifier":"` + mockUser.Email + `","password":"` + mockUser.Password + `"}`)
+	usernameJSON := []byte(`{"identifier":"` + mockUser.Username + `","password":"` + mockUser.Password + `"}`)
 
 	tests := []struct {
 		testName       string
@@ -301,12 +583,22 @@ func TestLoginUserHandler(t *testing.T) {
 		expectedStatus int
 	}{
 		{
-			testName: "success",
-			input:    userJSON,
+			testName: "success with email",
+			input:    emailJSON,
 			service: testdata.FuncCall{
 				Called: true,
 				Input:  []interface{}{mock.Anything, mockUser.Email, mockUser.Password},
-				Output: []interface{}{nil},
+				Output: []interface{}{mockUser, nil},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName: "success with username",
+			input:    usernameJSON,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.Username, mockUser.Password},
+				Output: []interface{}{mockUser, nil},
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -318,16 +610,34 @@ func TestLoginUserHandler(t *testing.T) {
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			testName: "with missing identifier",
+			input:    []byte(`{"password":"secret-123"}`),
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			testName: "with unexpected error from service",
-			input:    userJSON,
+			input:    emailJSON,
 			service: testdata.FuncCall{
 				Called: true,
 				Input:  []interface{}{mock.Anything, mockUser.Email, mockUser.Password},
-				Output: []interface{}{errors.New("unexpected error")},
+				Output: []interface{}{users.User{}, errors.New("unexpected error")},
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
+		{
+			testName: "with locked user",
+			input:    emailJSON,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.Email, mockUser.Password},
+				Output: []interface{}{users.User{}, users.ErrUserLocked},
+			},
+			expectedStatus: http.StatusForbidden,
+		},
 	}
 
 	e := getEchoServer()
@@ -343,12 +653,119 @@ func TestLoginUserHandler(t *testing.T) {
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			rec := httptest.NewRecorder()
 
-			handler.AddUserHandler(e, mockService, "secret", time.Duration(3600))
+			handler.AddUserHandler(e, mockService, "secret", time.Duration(3600), adminKey)
+			e.ServeHTTP(rec, req)
+
+			mockService.AssertExpectations(t)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestHistoryUserHandler(t *testing.T) {
+	page := users.HistoryPage{
+		Entries: []users.HistoryEntry{
+			{ID: 2, UserID: "123", Action: users.HistoryUpdate},
+		},
+		NextCursor: "2",
+	}
+
+	tests := []struct {
+		testName            string
+		query               string
+		authenticatedUserID string
+		adminKey            string
+		service             testdata.FuncCall
+		expectedStatus      int
+	}{
+		{
+			testName: "success",
+			query:    "?cursor=5&limit=1",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "123", "5", 1},
+				Output: []interface{}{page, nil},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:            "success with admin key",
+			query:               "?cursor=5&limit=1",
+			authenticatedUserID: "other-user",
+			adminKey:            adminKey,
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "123", "5", 1},
+				Output: []interface{}{page, nil},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			testName:            "with other user",
+			authenticatedUserID: "other-user",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			testName: "with invalid limit",
+			query:    "?limit=abc",
+			service: testdata.FuncCall{
+				Called: false,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with invalid cursor",
+			query:    "?cursor=abc",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "123", "abc", 0},
+				Output: []interface{}{users.HistoryPage{}, users.ConstraintErrorf("invalid history cursor: abc")},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			testName: "with unexpected error from service",
+			service: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "123", "", 0},
+				Output: []interface{}{users.HistoryPage{}, errors.New("unexpected error")},
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			authenticatedUserID := "123"
+			if test.authenticatedUserID != "" {
+				authenticatedUserID = test.authenticatedUserID
+			}
+			e := getAuthenticatedEchoServer(authenticatedUserID)
+			mockService := new(mocks.UserService)
+			if test.service.Called {
+				mockService.On("History", test.service.Input...).
+					Return(test.service.Output...).Once()
+			}
+
+			req := httptest.NewRequest(echo.GET, "/user/123/history"+test.query, nil)
+			req.Header.Set("X-Admin-Key", test.adminKey)
+			rec := httptest.NewRecorder()
+
+			handler.AddUserHandler(e, mockService, "secret", time.Duration(3600), adminKey)
 			e.ServeHTTP(rec, req)
 
 			mockService.AssertExpectations(t)
 
 			require.Equal(t, test.expectedStatus, rec.Code)
+			if test.expectedStatus == http.StatusOK {
+				var res users.HistoryPage
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+				require.Equal(t, page, res)
+			}
 		})
 	}
 }
@@ -0,0 +1,284 @@
+// Package keyring encrypts the values stored at rest with versioned keys, so that the keys can be rotated.
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// prefix marks the encrypted values, the others being read as stored before the encryption was enabled.
+	prefix = "enc:"
+	// keySize is the size of the keys, for AES-256.
+	keySize = 32
+	// indexKeyFile is the file of the key of the blind indexes, which is not versioned.
+	indexKeyFile = "index.key"
+)
+
+// ErrUnknownKey is returned when a value is encrypted with a version of the keys missing from the keyring.
+var ErrUnknownKey = errors.New("value encrypted with an unknown key")
+
+// Keyring encrypts the values with envelope encryption: each value is encrypted with a data key of its own,
+// which is wrapped with the latest key of the keyring. The former keys are kept to decrypt the values
+// not rotated yet. It is safe for concurrent use.
+type Keyring struct {
+	keys     map[uint32]cipher.AEAD
+	current  uint32
+	indexKey []byte
+}
+
+// New creates the keyring of the keys by version, and of the key of the blind indexes.
+func New(keys map[uint32][]byte, indexKey []byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no key in the keyring")
+	}
+	if len(indexKey) < keySize {
+		return nil, fmt.Errorf("index key must be at least %d bytes", keySize)
+	}
+
+	k := &Keyring{
+		keys:     map[uint32]cipher.AEAD{},
+		indexKey: indexKey,
+	}
+	for version, key := range keys {
+		if version == 0 {
+			return nil, errors.New("key versions start at 1")
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("key %d must be %d bytes", version, keySize)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[version] = aead
+		if version > k.current {
+			k.current = version
+		}
+	}
+	return k, nil
+}
+
+// Load loads the keyring from the directory, holding the keys in files named after their version, such as 1.key,
+// and the key of the blind indexes in index.key. Each file holds a base64 encoded key of 32 random bytes.
+// A key is rotated by adding the file of the next version.
+func Load(dir string) (*Keyring, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.key"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[uint32][]byte{}
+	var indexKey []byte
+	for _, file := range files {
+		key, err := readKey(file)
+		if err != nil {
+			return nil, err
+		}
+
+		name := filepath.Base(file)
+		if name == indexKeyFile {
+			indexKey = key
+			continue
+		}
+		version, err := strconv.ParseUint(strings.TrimSuffix(name, ".key"), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("key file %s is not named after its version", name)
+		}
+		keys[uint32(version)] = key
+	}
+	if indexKey == nil {
+		return nil, fmt.Errorf("%s not found in %s", indexKeyFile, dir)
+	}
+	return New(keys, indexKey)
+}
+
+func readKey(file string) ([]byte, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("key file %s is not base64 encoded: %w", filepath.Base(file), err)
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Current returns the version of the key encrypting the values.
+func (k *Keyring) Current() uint32 {
+	return k.current
+}
+
+// IsEncrypted tells whether the value is encrypted, or stored before the encryption was enabled.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// envelope is an encrypted value: its data key wrapped with a key of the keyring, and the value encrypted with it.
+type envelope struct {
+	version    uint32
+	wrapped    []byte
+	ciphertext []byte
+}
+
+// The values are stored as the version of the key, then the wrapped data key and the encrypted value,
+// each with its nonce.
+func (e envelope) String() string {
+	data := make([]byte, 4, 4+len(e.wrapped)+len(e.ciphertext))
+	binary.BigEndian.PutUint32(data, e.version)
+	data = append(data, e.wrapped...)
+	data = append(data, e.ciphertext...)
+	return prefix + base64.RawStdEncoding.EncodeToString(data)
+}
+
+func parseEnvelope(value string, aead cipher.AEAD) (envelope, error) {
+	data, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, prefix))
+	if err != nil {
+		return envelope{}, err
+	}
+
+	wrappedSize := aead.NonceSize() + keySize + aead.Overhead()
+	if len(data) < 4+wrappedSize {
+		return envelope{}, errors.New("encrypted value too short")
+	}
+	return envelope{
+		version:    binary.BigEndian.Uint32(data),
+		wrapped:    data[4 : 4+wrappedSize],
+		ciphertext: data[4+wrappedSize:],
+	}, nil
+}
+
+// seal encrypts the plaintext with a new nonce, put in front of the ciphertext.
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, ciphertext, aad []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("encrypted value too short")
+	}
+	nonce := ciphertext[:aead.NonceSize()]
+	return aead.Open(nil, nonce, ciphertext[aead.NonceSize():], aad)
+}
+
+// Encrypt encrypts the value with a new data key wrapped with the current key. The additional data, such as
+// the column and ID of the row storing the value, must be given again to decrypt it, so that the value can not
+// be moved to another row.
+func (k *Keyring) Encrypt(value, aad string) (string, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := seal(data, []byte(value), []byte(aad))
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := seal(k.keys[k.current], dataKey, []byte(aad))
+	if err != nil {
+		return "", err
+	}
+	return envelope{version: k.current, wrapped: wrapped, ciphertext: ciphertext}.String(), nil
+}
+
+// Decrypt decrypts the value encrypted with the additional data. The values not encrypted are returned as they are.
+func (k *Keyring) Decrypt(value, aad string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	env, dataKey, err := k.unwrap(value, aad)
+	if err != nil {
+		return "", err
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(data, env.ciphertext, []byte(aad))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Rotate returns the value with its data key wrapped with the current key, and whether it changed.
+// Only the data key is encrypted again, and the values not encrypted are encrypted.
+func (k *Keyring) Rotate(value, aad string) (string, bool, error) {
+	if !IsEncrypted(value) {
+		encrypted, err := k.Encrypt(value, aad)
+		return encrypted, err == nil, err
+	}
+
+	env, dataKey, err := k.unwrap(value, aad)
+	if err != nil {
+		return "", false, err
+	}
+	if env.version == k.current {
+		return value, false, nil
+	}
+
+	env.version = k.current
+	env.wrapped, err = seal(k.keys[k.current], dataKey, []byte(aad))
+	if err != nil {
+		return "", false, err
+	}
+	return env.String(), true, nil
+}
+
+// unwrap returns the envelope of the value, and its data key.
+func (k *Keyring) unwrap(value, aad string) (envelope, []byte, error) {
+	// The size of the nonces and tags is the same for every key.
+	env, err := parseEnvelope(value, k.keys[k.current])
+	if err != nil {
+		return envelope{}, nil, err
+	}
+	key, ok := k.keys[env.version]
+	if !ok {
+		return envelope{}, nil, fmt.Errorf("%w %d", ErrUnknownKey, env.version)
+	}
+
+	dataKey, err := open(key, env.wrapped, []byte(aad))
+	if err != nil {
+		return envelope{}, nil, err
+	}
+	return env, dataKey, nil
+}
+
+// BlindIndex returns the keyed hash of the value, looked up in place of the value once it is encrypted.
+// Equal values have the same index, which tells nothing else about them.
+func (k *Keyring) BlindIndex(value string) string {
+	mac := hmac.New(sha256.New, k.indexKey)
+	_, _ = mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,146 @@
+package keyring_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users/internal/keyring"
+)
+
+func key(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func newKeyring(t *testing.T, versions ...uint32) *keyring.Keyring {
+	keys := map[uint32][]byte{}
+	for _, version := range versions {
+		keys[version] = key(byte(version))
+	}
+	k, err := keyring.New(keys, key(0xff))
+	require.NoError(t, err)
+	return k
+}
+
+func TestEncrypt(t *testing.T) {
+	k := newKeyring(t, 1, 2)
+	require.Equal(t, uint32(2), k.Current())
+
+	encrypted, err := k.Encrypt("lorem ipsum", "address/123")
+	require.NoError(t, err)
+	require.True(t, keyring.IsEncrypted(encrypted))
+	require.NotContains(t, encrypted, "lorem")
+
+	again, err := k.Encrypt("lorem ipsum", "address/123")
+	require.NoError(t, err)
+	require.NotEqual(t, encrypted, again)
+
+	t.Run("decrypt", func(t *testing.T) {
+		res, err := k.Decrypt(encrypted, "address/123")
+		require.NoError(t, err)
+		require.Equal(t, "lorem ipsum", res)
+	})
+
+	t.Run("decrypt with other additional data", func(t *testing.T) {
+		_, err := k.Decrypt(encrypted, "address/456")
+		require.Error(t, err)
+	})
+
+	t.Run("decrypt value not encrypted", func(t *testing.T) {
+		res, err := k.Decrypt("lorem ipsum", "address/123")
+		require.NoError(t, err)
+		require.Equal(t, "lorem ipsum", res)
+	})
+
+	t.Run("decrypt with unknown key", func(t *testing.T) {
+		_, err := newKeyring(t, 1).Decrypt(encrypted, "address/123")
+		require.True(t, errors.Is(err, keyring.ErrUnknownKey))
+	})
+
+	t.Run("decrypt tampered value", func(t *testing.T) {
+		tampered := []byte(encrypted)
+		tampered[len(tampered)-2] ^= 1
+		_, err := k.Decrypt(string(tampered), "address/123")
+		require.Error(t, err)
+	})
+}
+
+func TestRotate(t *testing.T) {
+	old := newKeyring(t, 1)
+	encrypted, err := old.Encrypt("lorem ipsum", "address/123")
+	require.NoError(t, err)
+
+	k := newKeyring(t, 1, 2)
+
+	rotated, changed, err := k.Rotate(encrypted, "address/123")
+	require.NoError(t, err)
+	require.True(t, changed)
+	res, err := k.Decrypt(rotated, "address/123")
+	require.NoError(t, err)
+	require.Equal(t, "lorem ipsum", res)
+
+	// The value can only be decrypted with the new key.
+	_, err = old.Decrypt(rotated, "address/123")
+	require.True(t, errors.Is(err, keyring.ErrUnknownKey))
+
+	again, changed, err := k.Rotate(rotated, "address/123")
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Equal(t, rotated, again)
+
+	plaintext, changed, err := k.Rotate("lorem ipsum", "address/123")
+	require.NoError(t, err)
+	require.True(t, changed)
+	res, err = k.Decrypt(plaintext, "address/123")
+	require.NoError(t, err)
+	require.Equal(t, "lorem ipsum", res)
+}
+
+func TestBlindIndex(t *testing.T) {
+	k := newKeyring(t, 1)
+	require.Equal(t, k.BlindIndex("jhon@doe.com"), newKeyring(t, 1, 2).BlindIndex("jhon@doe.com"))
+	require.NotEqual(t, k.BlindIndex("jhon@doe.com"), k.BlindIndex("jane@doe.com"))
+	require.Len(t, k.BlindIndex("jhon@doe.com"), 64)
+}
+
+func writeKey(t *testing.T, dir, name string, key []byte) {
+	err := ioutil.WriteFile(filepath.Join(dir, name), []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600)
+	require.NoError(t, err)
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "users-keyring")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeKey(t, dir, "1.key", key(1))
+	_, err = keyring.Load(dir)
+	require.EqualError(t, err, "index.key not found in "+dir)
+
+	writeKey(t, dir, "index.key", key(0xff))
+	writeKey(t, dir, "2.key", key(2))
+	k, err := keyring.Load(dir)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), k.Current())
+	require.Equal(t, newKeyring(t, 1).BlindIndex("jhon@doe.com"), k.BlindIndex("jhon@doe.com"))
+
+	t.Run("with short key", func(t *testing.T) {
+		writeKey(t, dir, "3.key", key(3)[:16])
+		defer os.Remove(filepath.Join(dir, "3.key"))
+		_, err := keyring.Load(dir)
+		require.EqualError(t, err, "key 3 must be 32 bytes")
+	})
+
+	t.Run("with key not named after its version", func(t *testing.T) {
+		writeKey(t, dir, "latest.key", key(3))
+		defer os.Remove(filepath.Join(dir, "latest.key"))
+		_, err := keyring.Load(dir)
+		require.EqualError(t, err, "key file latest.key is not named after its version")
+	})
+}
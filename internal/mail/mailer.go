@@ -0,0 +1,70 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/arnaz06/users"
+)
+
+type smtpMailer struct {
+	address string
+	from    string
+	auth    smtp.Auth
+}
+
+// NewSMTPMailer creates a mailer sending emails through the SMTP server at address.
+// The server is authenticated against when username is set.
+func NewSMTPMailer(address, from, username, password string) users.Mailer {
+	var auth smtp.Auth
+	if username != "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			host = address
+		}
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return smtpMailer{
+		address: address,
+		from:    from,
+		auth:    auth,
+	}
+}
+
+func (m smtpMailer) Send(ctx context.Context, to, subject, body string) error {
+	return smtp.SendMail(m.address, m.auth, m.from, []string{to}, Message(m.from, to, subject, body))
+}
+
+// Message builds a plain text email message.
+func Message(from, to, subject, body string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+type logMailer struct{}
+
+// NewLogMailer creates a mailer only logging the emails, for the environments without an SMTP server.
+func NewLogMailer() users.Mailer {
+	return logMailer{}
+}
+
+func (m logMailer) Send(ctx context.Context, to, subject, body string) error {
+	log.WithFields(log.Fields{
+		"to":      to,
+		"subject": subject,
+	}).Info(body)
+	return nil
+}
@@ -0,0 +1,34 @@
+package mail_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users/internal/mail"
+)
+
+func TestMessage(t *testing.T) {
+	msg := mail.Message("no-reply@users.com", "jhon@doe.com", "Hello", "first line\nsecond line")
+
+	require.Equal(t, "From: no-reply@users.com\r\n"+
+		"To: jhon@doe.com\r\n"+
+		"Subject: Hello\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=UTF-8\r\n"+
+		"\r\n"+
+		"first line\r\nsecond line", string(msg))
+}
+
+func TestLogMailer(t *testing.T) {
+	buf := new(bytes.Buffer)
+	log.SetOutput(buf)
+
+	err := mail.NewLogMailer().Send(context.Background(), "jhon@doe.com", "Hello", "confirm your email")
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "jhon@doe.com")
+	require.Contains(t, buf.String(), "confirm your email")
+}
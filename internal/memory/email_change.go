@@ -0,0 +1,197 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arnaz06/users"
+)
+
+type emailChangeRepo struct {
+	store *Store
+}
+
+// NewEmailChangeRepository is constructor for the email change repository kept in the store.
+// Every query is scoped by the tenant carried in the context.
+func NewEmailChangeRepository(store *Store) users.EmailChangeRepository {
+	return emailChangeRepo{
+		store: store,
+	}
+}
+
+func (r emailChangeRepo) Create(ctx context.Context, change users.EmailChange) (res users.EmailChange, err error) {
+	if change.ID == "" {
+		change.ID = uuid.New().String()
+	}
+	if change.CreatedTime.IsZero() {
+		change.CreatedTime = time.Now()
+	}
+
+	err = r.store.update(nil, func(d *data) error {
+		if _, ok := d.emailChanges[change.ID]; ok {
+			return fmt.Errorf("duplicate email change id %s", change.ID)
+		}
+		// The token hashes are unique across the tenants, as the unique keys of the SQL repositories.
+		for _, row := range d.emailChanges {
+			if row.change.ConfirmTokenHash == change.ConfirmTokenHash || row.change.RevertTokenHash == change.RevertTokenHash {
+				return fmt.Errorf("duplicate token hash of email change %s", change.ID)
+			}
+		}
+
+		stored := change
+		stored.ConfirmExpiresTime = unix(change.ConfirmExpiresTime)
+		stored.RevertExpiresTime = unix(change.RevertExpiresTime)
+		stored.CreatedTime = unix(change.CreatedTime)
+		stored.ConfirmedTime = nil
+		stored.RevertedTime = nil
+		d.lastChangeSeq++
+		d.emailChanges[change.ID] = emailChangeRow{tenantID: users.TenantFromContext(ctx), change: stored, seq: d.lastChangeSeq}
+		return nil
+	})
+	if err != nil {
+		return users.EmailChange{}, err
+	}
+	return change, nil
+}
+
+func (r emailChangeRepo) GetByConfirmToken(ctx context.Context, tokenHash string) (users.EmailChange, error) {
+	return r.find(ctx, func(change users.EmailChange) bool {
+		return change.ConfirmTokenHash == tokenHash
+	})
+}
+
+func (r emailChangeRepo) GetByRevertToken(ctx context.Context, tokenHash string) (users.EmailChange, error) {
+	return r.find(ctx, func(change users.EmailChange) bool {
+		return change.RevertTokenHash == tokenHash
+	})
+}
+
+func (r emailChangeRepo) find(ctx context.Context, match func(change users.EmailChange) bool) (res users.EmailChange, err error) {
+	err = r.store.view(nil, func(d *data) error {
+		tenantID := users.TenantFromContext(ctx)
+		for _, row := range d.emailChanges {
+			if row.tenantID == tenantID && match(row.change) {
+				res = row.change
+				return nil
+			}
+		}
+		return users.ErrNotFound
+	})
+	return res, err
+}
+
+func (r emailChangeRepo) ListByUser(ctx context.Context, userID string) (res []users.EmailChange, err error) {
+	var rows []emailChangeRow
+	err = r.store.view(nil, func(d *data) error {
+		tenantID := users.TenantFromContext(ctx)
+		for _, row := range d.emailChanges {
+			if row.tenantID == tenantID && row.change.UserID == userID {
+				rows = append(rows, row)
+			}
+		}
+		return nil
+	})
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].change.CreatedTime.Equal(rows[j].change.CreatedTime) {
+			return rows[i].change.CreatedTime.Before(rows[j].change.CreatedTime)
+		}
+		return rows[i].seq < rows[j].seq
+	})
+
+	res = make([]users.EmailChange, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, row.change)
+	}
+	return res, err
+}
+
+func (r emailChangeRepo) Confirm(ctx context.Context, id string) error {
+	return r.store.update(nil, func(d *data) error {
+		tenantID := users.TenantFromContext(ctx)
+		row, ok := d.emailChanges[id]
+		if !ok || row.tenantID != tenantID {
+			return users.ErrNotFound
+		}
+		change := row.change
+		if change.ConfirmedTime != nil || change.RevertedTime != nil {
+			return users.ErrPreconditionFailed
+		}
+
+		now := time.Now()
+		err := d.changeEmail(ctx, change.UserID, change.OldEmail, change.NewEmail, now)
+		if err == errEmailChanged {
+			return users.ConstraintErrorf("email of the user has changed since the change was requested")
+		}
+		if err != nil {
+			return err
+		}
+
+		confirmed := unix(now)
+		change.ConfirmedTime = &confirmed
+		row.change = change
+		d.emailChanges[id] = row
+		return nil
+	})
+}
+
+func (r emailChangeRepo) Revert(ctx context.Context, id string) error {
+	return r.store.update(nil, func(d *data) error {
+		tenantID := users.TenantFromContext(ctx)
+		row, ok := d.emailChanges[id]
+		if !ok || row.tenantID != tenantID {
+			return users.ErrNotFound
+		}
+		change := row.change
+		if change.RevertedTime != nil {
+			return users.ErrPreconditionFailed
+		}
+
+		now := time.Now()
+		if change.ConfirmedTime != nil {
+			err := d.changeEmail(ctx, change.UserID, change.NewEmail, change.OldEmail, now)
+			if err == errEmailChanged {
+				return users.ConstraintErrorf("email of the user has changed since the change was confirmed")
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		reverted := unix(now)
+		change.RevertedTime = &reverted
+		row.change = change
+		d.emailChanges[id] = row
+		return nil
+	})
+}
+
+// errEmailChanged is returned by changeEmail when the user is gone or its email is no longer the expected one.
+var errEmailChanged = errors.New("email of the user has changed")
+
+// changeEmail sets the email of the active user from the email it is expected to have, and records it in the history.
+func (d *data) changeEmail(ctx context.Context, userID, from, to string, now time.Time) error {
+	tenantID := users.TenantFromContext(ctx)
+	user, ok := d.active(tenantID, userID)
+	if !ok || users.NormalizeEmail(user.Email) != users.NormalizeEmail(from) {
+		return errEmailChanged
+	}
+
+	user.Email = to
+	if err := d.checkUnique(tenantID, user); err != nil {
+		return err
+	}
+	user.Version++
+	user.UpdatedTime = unix(now)
+	d.users[userID] = userRow{tenantID: tenantID, user: user}
+
+	changes := map[string]users.FieldChange{
+		"email": {Before: from, After: to},
+	}
+	d.insertHistory(ctx, userID, users.HistoryUpdate, changes, now)
+	return nil
+}
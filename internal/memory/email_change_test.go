@@ -0,0 +1,103 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/memory"
+	"github.com/arnaz06/users/testdata"
+)
+
+func createChange(t *testing.T, repo users.EmailChangeRepository, user users.User, newEmail string) users.EmailChange {
+	now := time.Now()
+	change, err := repo.Create(context.Background(), users.EmailChange{
+		UserID:             user.ID,
+		OldEmail:           user.Email,
+		NewEmail:           newEmail,
+		ConfirmTokenHash:   "confirm-" + newEmail,
+		RevertTokenHash:    "revert-" + newEmail,
+		ConfirmExpiresTime: now.Add(time.Hour),
+		RevertExpiresTime:  now.Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+	return change
+}
+
+func TestEmailChangeGetByToken(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+	store := memory.NewStore()
+	repo := memory.NewEmailChangeRepository(store)
+	change := createChange(t, repo, mockUser, "new@doe.com")
+
+	res, err := repo.GetByConfirmToken(context.Background(), change.ConfirmTokenHash)
+	require.NoError(t, err)
+	require.Equal(t, change.ID, res.ID)
+
+	res, err = repo.GetByRevertToken(context.Background(), change.RevertTokenHash)
+	require.NoError(t, err)
+	require.Equal(t, change.ID, res.ID)
+
+	_, err = repo.GetByConfirmToken(users.WithTenant(context.Background(), "other"), change.ConfirmTokenHash)
+	require.Equal(t, users.ErrNotFound, err)
+
+	_, err = repo.Create(context.Background(), change)
+	require.Error(t, err)
+}
+
+func TestEmailChangeConfirmAndRevert(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+	store := memory.NewStore()
+	userRepo := memory.NewUserRepository(store)
+	mockUser, err := userRepo.Create(context.Background(), mockUser)
+	require.NoError(t, err)
+	_, err = userRepo.Create(context.Background(), users.User{Email: "taken@doe.com"})
+	require.NoError(t, err)
+
+	repo := memory.NewEmailChangeRepository(store)
+	getUser := func() users.User {
+		res, err := userRepo.Get(context.Background(), mockUser.ID)
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("with email taken", func(t *testing.T) {
+		change := createChange(t, repo, mockUser, "taken@doe.com")
+		err := repo.Confirm(context.Background(), change.ID)
+		require.EqualError(t, err, "email taken@doe.com is already used by another user")
+		require.Equal(t, mockUser.Email, getUser().Email)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		change := createChange(t, repo, mockUser, "new@doe.com")
+		require.NoError(t, repo.Confirm(context.Background(), change.ID))
+		require.Equal(t, "new@doe.com", getUser().Email)
+		require.Equal(t, mockUser.Version+1, getUser().Version)
+		require.Equal(t, users.ErrPreconditionFailed, repo.Confirm(context.Background(), change.ID))
+
+		require.NoError(t, repo.Revert(context.Background(), change.ID))
+		require.Equal(t, mockUser.Email, getUser().Email)
+		require.Equal(t, users.ErrPreconditionFailed, repo.Revert(context.Background(), change.ID))
+
+		list, err := repo.ListByUser(context.Background(), mockUser.ID)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		require.NotNil(t, list[1].ConfirmedTime)
+		require.NotNil(t, list[1].RevertedTime)
+
+		history, err := userRepo.ListHistory(context.Background(), mockUser.ID, 0, 10)
+		require.NoError(t, err)
+		require.Equal(t, users.FieldChange{Before: "new@doe.com", After: mockUser.Email}, history[0].Changes["email"])
+	})
+
+	t.Run("with email changed since", func(t *testing.T) {
+		change := createChange(t, repo, users.User{ID: mockUser.ID, Email: "old@doe.com"}, "other@doe.com")
+		err := repo.Confirm(context.Background(), change.ID)
+		require.EqualError(t, err, "email of the user has changed since the change was requested")
+	})
+}
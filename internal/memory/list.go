@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/arnaz06/users"
+)
+
+// matches tells whether the user of the tenant matches the filter, but for its position.
+// The times are compared at the second, as stored by the SQL repositories.
+func matches(tenantID string, row userRow, filter users.UserFilter) bool {
+	if row.tenantID != tenantID {
+		return false
+	}
+
+	user := row.user
+	switch filter.Deleted {
+	case users.DeletedOnly:
+		if user.DeletedTime == nil {
+			return false
+		}
+	case users.DeletedInclude:
+	default:
+		if user.DeletedTime != nil {
+			return false
+		}
+	}
+	if filter.EmailPrefix != "" && !strings.HasPrefix(user.Email, filter.EmailPrefix) {
+		return false
+	}
+	if !filter.CreatedFrom.IsZero() && user.CreatedTime.Unix() < filter.CreatedFrom.Unix() {
+		return false
+	}
+	if !filter.CreatedTo.IsZero() && user.CreatedTime.Unix() >= filter.CreatedTo.Unix() {
+		return false
+	}
+	if filter.Status != "" && user.Status != filter.Status {
+		return false
+	}
+	return true
+}
+
+// less tells whether the key a comes before the key b in the order of the filter.
+func less(filter users.UserFilter, a, b users.UserKey) bool {
+	if filter.Sort == users.SortEmail {
+		if a.Email != b.Email {
+			return a.Email < b.Email != filter.Desc
+		}
+	} else if a.CreatedTime.Unix() != b.CreatedTime.Unix() {
+		return a.CreatedTime.Unix() < b.CreatedTime.Unix() != filter.Desc
+	}
+	return a.ID < b.ID != filter.Desc
+}
+
+func userKey(user users.User) users.UserKey {
+	return users.UserKey{
+		ID:          user.ID,
+		Email:       user.Email,
+		CreatedTime: user.CreatedTime,
+	}
+}
+
+func (r userRepo) List(ctx context.Context, filter users.UserFilter) (res []users.User, err error) {
+	err = r.view(func(d *data) error {
+		tenantID := users.TenantFromContext(ctx)
+		res = []users.User{}
+		for _, row := range d.users {
+			if !matches(tenantID, row, filter) {
+				continue
+			}
+			if filter.After != nil && !less(filter, *filter.After, userKey(row.user)) {
+				continue
+			}
+			res = append(res, row.user)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return less(filter, userKey(res[i]), userKey(res[j]))
+	})
+	if len(res) > filter.Limit {
+		res = res[:filter.Limit]
+	}
+	return res, nil
+}
+
+func (r userRepo) Count(ctx context.Context, filter users.UserFilter) (res int64, err error) {
+	err = r.view(func(d *data) error {
+		tenantID := users.TenantFromContext(ctx)
+		for _, row := range d.users {
+			if matches(tenantID, row, filter) {
+				res++
+			}
+		}
+		return nil
+	})
+	return res, err
+}
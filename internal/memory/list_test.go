@@ -0,0 +1,81 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/memory"
+)
+
+func TestListUsers(t *testing.T) {
+	repo := memory.NewUserRepository(memory.NewStore())
+	ctx := context.Background()
+	for _, email := range []string{"carol@doe.com", "alice@doe.com", "bob@doe.com", "alan@doe.com"} {
+		_, err := repo.Create(ctx, users.User{Email: email, Password: "hash"})
+		require.NoError(t, err)
+	}
+	bob, err := repo.GetByEmail(ctx, "bob@doe.com")
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, bob.ID, 0))
+
+	emails := func(list []users.User) []string {
+		res := []string{}
+		for _, user := range list {
+			res = append(res, user.Email)
+		}
+		return res
+	}
+
+	tests := []struct {
+		testName       string
+		filter         users.UserFilter
+		expectedEmails []string
+		expectedCount  int64
+	}{
+		{
+			testName:       "by email",
+			filter:         users.UserFilter{Sort: users.SortEmail, Limit: 10},
+			expectedEmails: []string{"alan@doe.com", "alice@doe.com", "carol@doe.com"},
+			expectedCount:  3,
+		},
+		{
+			testName:       "by email descending after a position",
+			filter:         users.UserFilter{Sort: users.SortEmail, Desc: true, After: &users.UserKey{Email: "carol@doe.com"}, Limit: 1},
+			expectedEmails: []string{"alice@doe.com"},
+			expectedCount:  3,
+		},
+		{
+			testName:       "with email prefix",
+			filter:         users.UserFilter{Sort: users.SortEmail, EmailPrefix: "al", Limit: 10},
+			expectedEmails: []string{"alan@doe.com", "alice@doe.com"},
+			expectedCount:  2,
+		},
+		{
+			testName:       "deleted only",
+			filter:         users.UserFilter{Deleted: users.DeletedOnly, Limit: 10},
+			expectedEmails: []string{"bob@doe.com"},
+			expectedCount:  1,
+		},
+		{
+			testName:       "with status",
+			filter:         users.UserFilter{Status: users.StatusLocked, Limit: 10},
+			expectedEmails: []string{},
+			expectedCount:  0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			res, err := repo.List(ctx, test.filter)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedEmails, emails(res))
+
+			count, err := repo.Count(ctx, test.filter)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedCount, count)
+		})
+	}
+}
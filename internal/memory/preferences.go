@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/arnaz06/users"
+)
+
+type preferencesRepo struct {
+	store *Store
+}
+
+// NewPreferencesRepository is constructor for the preferences repository kept in the store.
+// Every query is scoped by the tenant carried in the context.
+func NewPreferencesRepository(store *Store) users.PreferencesRepository {
+	return preferencesRepo{
+		store: store,
+	}
+}
+
+func (r preferencesRepo) Get(ctx context.Context, userID string) (res users.Preferences, err error) {
+	err = r.store.view(nil, func(d *data) error {
+		preferences, ok := d.preferences[key{tenantID: users.TenantFromContext(ctx), userID: userID}]
+		if !ok {
+			return users.ErrNotFound
+		}
+		res = preferences
+		return nil
+	})
+	return res, err
+}
+
+func (r preferencesRepo) Save(ctx context.Context, userID string, preferences users.Preferences) error {
+	return r.store.update(nil, func(d *data) error {
+		d.preferences[key{tenantID: users.TenantFromContext(ctx), userID: userID}] = preferences
+		return nil
+	})
+}
@@ -0,0 +1,30 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/memory"
+)
+
+func TestPreferences(t *testing.T) {
+	repo := memory.NewPreferencesRepository(memory.NewStore())
+	ctx := context.Background()
+
+	_, err := repo.Get(ctx, "user-1")
+	require.Equal(t, users.ErrNotFound, err)
+
+	preferences := users.DefaultPreferences()
+	preferences.Theme = users.ThemeDark
+	require.NoError(t, repo.Save(ctx, "user-1", preferences))
+
+	res, err := repo.Get(ctx, "user-1")
+	require.NoError(t, err)
+	require.Equal(t, preferences, res)
+
+	_, err = repo.Get(users.WithTenant(ctx, "acme"), "user-1")
+	require.Equal(t, users.ErrNotFound, err)
+}
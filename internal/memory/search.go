@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/arnaz06/users"
+)
+
+type searcher struct {
+	store *Store
+}
+
+// NewSearcher is constructor for the search of users kept in the store, used when no search index is set up.
+// Like the searchers of the SQL repositories, it only matches the words starting with the words of the query.
+func NewSearcher(store *Store) users.Searcher {
+	return searcher{
+		store: store,
+	}
+}
+
+// words splits the text into its lowercase words of letters and digits.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// score returns the number of words of the user starting with a word of the query, or zero when a word
+// of the query starts none of them.
+func score(user users.User, query []string) int {
+	fields := words(user.Email + " " + user.Username + " " + user.Address)
+	res := 0
+	for _, q := range query {
+		matched := 0
+		for _, field := range fields {
+			if strings.HasPrefix(field, q) {
+				matched++
+			}
+		}
+		if matched == 0 {
+			return 0
+		}
+		res += matched
+	}
+	return res
+}
+
+func (s searcher) Search(ctx context.Context, query string, limit int) ([]string, error) {
+	q := words(query)
+	if len(q) == 0 {
+		return []string{}, nil
+	}
+
+	type match struct {
+		id    string
+		score int
+	}
+	var matches []match
+	err := s.store.view(nil, func(d *data) error {
+		tenantID := users.TenantFromContext(ctx)
+		for id, row := range d.users {
+			if row.tenantID != tenantID || row.user.DeletedTime != nil {
+				continue
+			}
+			if n := score(row.user, q); n > 0 {
+				matches = append(matches, match{id: id, score: n})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].id < matches[j].id
+	})
+
+	res := []string{}
+	for i := 0; i < len(matches) && i < limit; i++ {
+		res = append(res, matches[i].id)
+	}
+	return res, nil
+}
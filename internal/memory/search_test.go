@@ -0,0 +1,50 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/memory"
+)
+
+func TestSearch(t *testing.T) {
+	store := memory.NewStore()
+	repo := memory.NewUserRepository(store)
+	ctx := context.Background()
+
+	jhon, err := repo.Create(ctx, users.User{Email: "jhon@doe.com", Username: "jhon", Address: "Jakarta Selatan", Password: "hash"})
+	require.NoError(t, err)
+	jane, err := repo.Create(ctx, users.User{Email: "jane@doe.com", Address: "Bandung", Password: "hash"})
+	require.NoError(t, err)
+	deleted, err := repo.Create(ctx, users.User{Email: "jakarta@doe.com", Password: "hash"})
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, deleted.ID, 0))
+
+	searcher := memory.NewSearcher(store)
+	tests := []struct {
+		testName       string
+		query          string
+		expectedResult []string
+	}{
+		{testName: "by prefix of the address", query: "jak", expectedResult: []string{jhon.ID}},
+		{testName: "best match first", query: "j", expectedResult: []string{jhon.ID, jane.ID}},
+		{testName: "with every word", query: "doe BANDUNG", expectedResult: []string{jane.ID}},
+		{testName: "by the middle of a word", query: "karta", expectedResult: []string{}},
+		{testName: "with operators only", query: "+*", expectedResult: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			res, err := searcher.Search(ctx, test.query, 10)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedResult, res)
+		})
+	}
+
+	res, err := searcher.Search(users.WithTenant(ctx, "acme"), "jhon", 10)
+	require.NoError(t, err)
+	require.Empty(t, res)
+}
@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/arnaz06/users"
+)
+
+type statsRepo struct {
+	store *Store
+}
+
+// NewStatsRepository is constructor for the statistics repository kept in the store.
+func NewStatsRepository(store *Store) users.StatsRepository {
+	return statsRepo{
+		store: store,
+	}
+}
+
+func (r statsRepo) RecordLogin(ctx context.Context, userID string, loginTime time.Time) error {
+	return r.store.update(nil, func(d *data) error {
+		d.logins = append(d.logins, loginRow{
+			tenantID:    users.TenantFromContext(ctx),
+			userID:      userID,
+			createdTime: unix(loginTime),
+		})
+		return nil
+	})
+}
+
+func (r statsRepo) ListLogins(ctx context.Context, userID string) (res []time.Time, err error) {
+	err = r.store.view(nil, func(d *data) error {
+		tenantID := users.TenantFromContext(ctx)
+		res = []time.Time{}
+		for _, row := range d.logins {
+			if row.tenantID == tenantID && row.userID == userID {
+				res = append(res, row.createdTime)
+			}
+		}
+		return nil
+	})
+	return res, err
+}
+
+func (r statsRepo) CountByStatus(ctx context.Context) (res map[users.Status]int64, err error) {
+	err = r.store.view(nil, func(d *data) error {
+		tenantID := users.TenantFromContext(ctx)
+		res = map[users.Status]int64{}
+		for _, row := range d.users {
+			if row.tenantID == tenantID && row.user.DeletedTime == nil {
+				res[row.user.Status]++
+			}
+		}
+		return nil
+	})
+	return res, err
+}
+
+func (r statsRepo) CountSignups(ctx context.Context, bounds []time.Time) (res []int64, err error) {
+	err = r.store.view(nil, func(d *data) error {
+		tenantID := users.TenantFromContext(ctx)
+		res = countByPeriod(bounds, func(count func(t time.Time)) {
+			for _, row := range d.users {
+				if row.tenantID == tenantID {
+					count(row.user.CreatedTime)
+				}
+			}
+		})
+		return nil
+	})
+	return res, err
+}
+
+func (r statsRepo) CountDeletions(ctx context.Context, bounds []time.Time) (res []int64, err error) {
+	err = r.store.view(nil, func(d *data) error {
+		tenantID := users.TenantFromContext(ctx)
+		res = countByPeriod(bounds, func(count func(t time.Time)) {
+			for _, row := range d.users {
+				if row.tenantID == tenantID && row.user.DeletedTime != nil {
+					count(*row.user.DeletedTime)
+				}
+			}
+		})
+		return nil
+	})
+	return res, err
+}
+
+func (r statsRepo) CountLogins(ctx context.Context, bounds []time.Time) (res []int64, err error) {
+	err = r.store.view(nil, func(d *data) error {
+		tenantID := users.TenantFromContext(ctx)
+		res = countByPeriod(bounds, func(count func(t time.Time)) {
+			for _, row := range d.logins {
+				if row.tenantID == tenantID {
+					count(row.createdTime)
+				}
+			}
+		})
+		return nil
+	})
+	return res, err
+}
+
+// countByPeriod counts the times given to count by the period they fall in, compared at the second as stored
+// by the SQL repositories.
+func countByPeriod(bounds []time.Time, each func(count func(t time.Time))) []int64 {
+	periods := len(bounds) - 1
+	if periods < 1 {
+		return []int64{}
+	}
+
+	res := make([]int64, periods)
+	each(func(t time.Time) {
+		seconds := t.Unix()
+		if seconds < bounds[0].Unix() || seconds >= bounds[periods].Unix() {
+			return
+		}
+		// The period is the last one starting at or before the time.
+		period := sort.Search(periods, func(i int) bool {
+			return bounds[i+1].Unix() > seconds
+		})
+		res[period]++
+	})
+	return res
+}
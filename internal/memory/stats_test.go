@@ -0,0 +1,68 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/memory"
+)
+
+func TestStats(t *testing.T) {
+	store := memory.NewStore()
+	userRepo := memory.NewUserRepository(store)
+	repo := memory.NewStatsRepository(store)
+	ctx := context.Background()
+
+	jhon, err := userRepo.Create(ctx, users.User{Email: "jhon@doe.com", Password: "hash"})
+	require.NoError(t, err)
+	jane, err := userRepo.Create(ctx, users.User{Email: "jane@doe.com", Password: "hash"})
+	require.NoError(t, err)
+	_, err = userRepo.Create(ctx, users.User{Email: "bob@doe.com", Password: "hash"})
+	require.NoError(t, err)
+	require.NoError(t, userRepo.UpdateStatus(ctx, users.StatusChange{UserID: jane.ID, From: users.StatusActive, To: users.StatusLocked}))
+	require.NoError(t, userRepo.Delete(ctx, jhon.ID, 0))
+
+	statuses, err := repo.CountByStatus(ctx)
+	require.NoError(t, err)
+	require.Equal(t, map[users.Status]int64{users.StatusActive: 1, users.StatusLocked: 1}, statuses)
+
+	day := func(d int) time.Time {
+		return time.Date(2021, 3, d, 0, 0, 0, 0, time.UTC)
+	}
+	require.NoError(t, repo.RecordLogin(ctx, jhon.ID, day(1)))
+	require.NoError(t, repo.RecordLogin(ctx, jhon.ID, day(2).Add(-time.Second)))
+	require.NoError(t, repo.RecordLogin(ctx, jhon.ID, day(3).Add(time.Hour)))
+	require.NoError(t, repo.RecordLogin(ctx, jhon.ID, day(4)))
+	require.NoError(t, repo.RecordLogin(users.WithTenant(ctx, "acme"), jhon.ID, day(1)))
+
+	logins, err := repo.CountLogins(ctx, []time.Time{day(1), day(2), day(3), day(4)})
+	require.NoError(t, err)
+	require.Equal(t, []int64{2, 0, 1}, logins)
+
+	times, err := repo.ListLogins(ctx, jhon.ID)
+	require.NoError(t, err)
+	require.Len(t, times, 4)
+	require.True(t, day(1).Equal(times[0]))
+
+	other, err := repo.ListLogins(ctx, jane.ID)
+	require.NoError(t, err)
+	require.Empty(t, other)
+
+	now := time.Now()
+	bounds := []time.Time{now.Add(-time.Hour), now.Add(time.Hour)}
+	signups, err := repo.CountSignups(ctx, bounds)
+	require.NoError(t, err)
+	require.Equal(t, []int64{3}, signups)
+
+	deletions, err := repo.CountDeletions(ctx, bounds)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1}, deletions)
+
+	empty, err := repo.CountLogins(ctx, bounds[:1])
+	require.NoError(t, err)
+	require.Empty(t, empty)
+}
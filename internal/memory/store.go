@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/arnaz06/users"
+)
+
+// key identifies the rows of a user of a tenant.
+type key struct {
+	tenantID string
+	userID   string
+}
+
+// userRow is a user of a tenant. The users are keyed by their ID alone, which is unique across the tenants
+// as the primary key of the users table is.
+type userRow struct {
+	tenantID string
+	user     users.User
+}
+
+type emailChangeRow struct {
+	tenantID string
+	change   users.EmailChange
+	// seq orders the changes created in the same second, as the SQL databases return them in insertion order.
+	seq int64
+}
+
+type historyRow struct {
+	tenantID string
+	entry    users.HistoryEntry
+}
+
+type statusChangeRow struct {
+	tenantID string
+	change   users.StatusChange
+}
+
+type loginRow struct {
+	tenantID    string
+	userID      string
+	createdTime time.Time
+}
+
+// data is the content of a store. Its rows are values, so a shallow copy of the maps and slices is a snapshot.
+type data struct {
+	users         map[string]userRow
+	statusChanges []statusChangeRow
+	history       []historyRow
+	lastHistoryID int64
+	emailChanges  map[string]emailChangeRow
+	lastChangeSeq int64
+	preferences   map[key]users.Preferences
+	logins        []loginRow
+}
+
+func (d *data) clone() *data {
+	res := &data{
+		users:         make(map[string]userRow, len(d.users)),
+		statusChanges: append([]statusChangeRow(nil), d.statusChanges...),
+		history:       append([]historyRow(nil), d.history...),
+		lastHistoryID: d.lastHistoryID,
+		emailChanges:  make(map[string]emailChangeRow, len(d.emailChanges)),
+		lastChangeSeq: d.lastChangeSeq,
+		preferences:   make(map[key]users.Preferences, len(d.preferences)),
+		logins:        append([]loginRow(nil), d.logins...),
+	}
+	for id, row := range d.users {
+		res.users[id] = row
+	}
+	for id, row := range d.emailChanges {
+		res.emailChanges[id] = row
+	}
+	for k, preferences := range d.preferences {
+		res.preferences[k] = preferences
+	}
+	return res
+}
+
+// deleteUser permanently deletes the user, with everything recorded about them.
+func (d *data) deleteUser(tenantID, userID string) {
+	delete(d.users, userID)
+	delete(d.preferences, key{tenantID: tenantID, userID: userID})
+	for id, row := range d.emailChanges {
+		if row.tenantID == tenantID && row.change.UserID == userID {
+			delete(d.emailChanges, id)
+		}
+	}
+
+	statusChanges := make([]statusChangeRow, 0, len(d.statusChanges))
+	for _, row := range d.statusChanges {
+		if row.tenantID != tenantID || row.change.UserID != userID {
+			statusChanges = append(statusChanges, row)
+		}
+	}
+	d.statusChanges = statusChanges
+
+	history := make([]historyRow, 0, len(d.history))
+	for _, row := range d.history {
+		if row.tenantID != tenantID || row.entry.UserID != userID {
+			history = append(history, row)
+		}
+	}
+	d.history = history
+
+	logins := make([]loginRow, 0, len(d.logins))
+	for _, row := range d.logins {
+		if row.tenantID != tenantID || row.userID != userID {
+			logins = append(logins, row)
+		}
+	}
+	d.logins = logins
+}
+
+// Store keeps the data of the repositories in memory, for the demos and the tests needing no database.
+// It is safe for concurrent use, and its content is lost with the process.
+type Store struct {
+	mu   sync.RWMutex
+	data *data
+}
+
+// NewStore creates an empty store, to be shared by the repositories as a database is.
+func NewStore() *Store {
+	return &Store{
+		data: &data{
+			users:        map[string]userRow{},
+			emailChanges: map[string]emailChangeRow{},
+			preferences:  map[key]users.Preferences{},
+		},
+	}
+}
+
+// view runs fn with the data of the transaction if any, or else with the data of the store locked for reading.
+func (s *Store) view(tx *data, fn func(d *data) error) error {
+	if tx != nil {
+		return fn(tx)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fn(s.data)
+}
+
+// update runs fn with the data of the transaction if any, or else with the data of the store locked for writing.
+// The writes check everything before changing the data, so a failed one changes nothing.
+func (s *Store) update(tx *data, fn func(d *data) error) error {
+	if tx != nil {
+		return fn(tx)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(s.data)
+}
+
+// unix truncates the time to the second, the precision the SQL repositories store the times with.
+func unix(t time.Time) time.Time {
+	return time.Unix(t.Unix(), 0)
+}
@@ -0,0 +1,33 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/arnaz06/users"
+)
+
+type unitOfWork struct {
+	store *Store
+}
+
+// NewUnitOfWork is constructor for the unit of work over the user repository kept in the store.
+func NewUnitOfWork(store *Store) users.UnitOfWork {
+	return unitOfWork{
+		store: store,
+	}
+}
+
+// Do runs fn on a copy of the data while the store is locked for writing, and keeps the copy only when fn succeeds.
+// The repositories not given to fn are thus blocked until the unit of work ends, as with a transaction locking the tables.
+func (u unitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repo users.UserRepository) error) error {
+	u.store.mu.Lock()
+	defer u.store.mu.Unlock()
+
+	tx := u.store.data.clone()
+	err := fn(ctx, userRepo{store: u.store, tx: tx})
+	if err != nil {
+		return err
+	}
+	u.store.data = tx
+	return nil
+}
@@ -0,0 +1,70 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/memory"
+	"github.com/arnaz06/users/testdata"
+)
+
+func TestUnitOfWork(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+	store := memory.NewStore()
+	userRepo := memory.NewUserRepository(store)
+	mockUser, err := userRepo.Create(context.Background(), mockUser)
+	require.NoError(t, err)
+	unit := memory.NewUnitOfWork(store)
+
+	t.Run("with rollback", func(t *testing.T) {
+		err := unit.Do(context.Background(), func(ctx context.Context, repo users.UserRepository) error {
+			_, err := repo.Create(ctx, users.User{Email: "jane@doe.com", Password: mockUser.Password})
+			require.NoError(t, err)
+
+			// The writes of the unit of work are seen by its own reads.
+			_, err = repo.GetByEmail(ctx, "jane@doe.com")
+			require.NoError(t, err)
+
+			require.NoError(t, repo.Delete(ctx, mockUser.ID, mockUser.Version))
+			return errors.New("unexpected error")
+		})
+		require.EqualError(t, err, "unexpected error")
+
+		_, err = userRepo.GetByEmail(context.Background(), "jane@doe.com")
+		require.Equal(t, users.ErrNotFound, err)
+		_, err = userRepo.Get(context.Background(), mockUser.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("with dry run batch", func(t *testing.T) {
+		err := unit.Do(context.Background(), func(ctx context.Context, repo users.UserRepository) error {
+			errs, err := repo.CreateBatch(ctx, []users.User{{Email: "bob@doe.com", Password: mockUser.Password}}, true)
+			require.NoError(t, err)
+			require.NoError(t, errs[0])
+
+			_, err = repo.Create(ctx, users.User{Email: "jane@doe.com", Password: mockUser.Password})
+			return err
+		})
+		require.NoError(t, err)
+
+		_, err = userRepo.GetByEmail(context.Background(), "bob@doe.com")
+		require.Equal(t, users.ErrNotFound, err)
+		_, err = userRepo.GetByEmail(context.Background(), "jane@doe.com")
+		require.NoError(t, err)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		err := unit.Do(context.Background(), func(ctx context.Context, repo users.UserRepository) error {
+			return repo.Delete(ctx, mockUser.ID, mockUser.Version)
+		})
+		require.NoError(t, err)
+
+		_, err = userRepo.Get(context.Background(), mockUser.ID)
+		require.Equal(t, users.ErrNotFound, err)
+	})
+}
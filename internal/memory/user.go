@@ -0,0 +1,374 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arnaz06/users"
+)
+
+type userRepo struct {
+	store *Store
+	// tx is the data of the unit of work the repository is part of, if any.
+	tx *data
+}
+
+// NewUserRepository is constructor for the user repository kept in the store.
+// Every query is scoped by the tenant carried in the context, as with the SQL repositories.
+func NewUserRepository(store *Store) users.UserRepository {
+	return userRepo{
+		store: store,
+	}
+}
+
+func (r userRepo) view(fn func(d *data) error) error {
+	return r.store.view(r.tx, fn)
+}
+
+func (r userRepo) update(fn func(d *data) error) error {
+	return r.store.update(r.tx, fn)
+}
+
+// active returns the user of the tenant if it is not deleted.
+func (d *data) active(tenantID, id string) (users.User, bool) {
+	row, ok := d.users[id]
+	if !ok || row.tenantID != tenantID || row.user.DeletedTime != nil {
+		return users.User{}, false
+	}
+	return row.user, true
+}
+
+// checkUnique returns the ConstraintError of the email or username of the user being used by another active
+// user of the tenant, as the unique indexes of the SQL repositories do. Both are compared regardless of their case.
+func (d *data) checkUnique(tenantID string, user users.User) error {
+	email := users.NormalizeEmail(user.Email)
+	username := users.NormalizeUsername(user.Username)
+	usernameTaken := false
+	for id, row := range d.users {
+		if id == user.ID || row.tenantID != tenantID || row.user.DeletedTime != nil {
+			continue
+		}
+		if users.NormalizeEmail(row.user.Email) == email {
+			return users.ConstraintErrorf("email %s is already used by another user", user.Email)
+		}
+		if username != "" && users.NormalizeUsername(row.user.Username) == username {
+			usernameTaken = true
+		}
+	}
+	if usernameTaken {
+		return users.ConstraintErrorf("username %s is already used by another user", user.Username)
+	}
+	return nil
+}
+
+// insertHistory records a change of the user, made by the actor carried in ctx.
+func (d *data) insertHistory(ctx context.Context, userID string, action users.HistoryAction, changes map[string]users.FieldChange, createdTime time.Time) {
+	d.lastHistoryID++
+	d.history = append(d.history, historyRow{
+		tenantID: users.TenantFromContext(ctx),
+		entry: users.HistoryEntry{
+			ID:          d.lastHistoryID,
+			UserID:      userID,
+			Action:      action,
+			Actor:       users.ActorFromContext(ctx),
+			Changes:     changes,
+			CreatedTime: unix(createdTime),
+		},
+	})
+}
+
+// insertUser inserts the user, and the history entry of its creation.
+func (d *data) insertUser(ctx context.Context, user users.User) (users.User, error) {
+	now := time.Now()
+	user.CreatedTime = now
+	user.UpdatedTime = now
+	user.Version = 1
+	user.DeletedTime = nil
+	if user.ID == "" {
+		user.ID = uuid.New().String()
+	}
+	if user.Status == "" {
+		user.Status = users.StatusActive
+	}
+
+	tenantID := users.TenantFromContext(ctx)
+	if _, ok := d.users[user.ID]; ok {
+		return users.User{}, fmt.Errorf("duplicate user id %s", user.ID)
+	}
+	if err := d.checkUnique(tenantID, user); err != nil {
+		return users.User{}, err
+	}
+
+	stored := user
+	stored.CreatedTime = unix(now)
+	stored.UpdatedTime = unix(now)
+	d.users[user.ID] = userRow{tenantID: tenantID, user: stored}
+	d.insertHistory(ctx, user.ID, users.HistoryCreate, users.DiffUsers(users.User{}, user), now)
+	return user, nil
+}
+
+func (r userRepo) Create(ctx context.Context, user users.User) (res users.User, err error) {
+	err = r.update(func(d *data) error {
+		res, err = d.insertUser(ctx, user)
+		return err
+	})
+	return res, err
+}
+
+func (r userRepo) CreateBatch(ctx context.Context, list []users.User, dryRun bool) (errs []error, err error) {
+	err = r.update(func(d *data) error {
+		// A dry run is made on a copy of the data, thrown away after it.
+		if dryRun {
+			d = d.clone()
+		}
+
+		// A failed insert only fails its own user, so the violations of the unique indexes
+		// are reported per user while the rest of the batch goes on.
+		errs = make([]error, len(list))
+		for i, user := range list {
+			_, err := d.insertUser(ctx, user)
+			if err != nil {
+				if _, ok := err.(users.ConstraintError); !ok {
+					return err
+				}
+				errs[i] = err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+func (r userRepo) Get(ctx context.Context, id string) (res users.User, err error) {
+	err = r.view(func(d *data) error {
+		user, ok := d.active(users.TenantFromContext(ctx), id)
+		if !ok {
+			return users.ErrNotFound
+		}
+		res = user
+		return nil
+	})
+	return res, err
+}
+
+func (r userRepo) GetByEmail(ctx context.Context, email string) (users.User, error) {
+	normalized := users.NormalizeEmail(email)
+	return r.find(ctx, func(user users.User) bool {
+		return users.NormalizeEmail(user.Email) == normalized
+	})
+}
+
+func (r userRepo) GetByUsername(ctx context.Context, username string) (users.User, error) {
+	normalized := users.NormalizeUsername(username)
+	return r.find(ctx, func(user users.User) bool {
+		return user.Username != "" && users.NormalizeUsername(user.Username) == normalized
+	})
+}
+
+// find returns the active user of the tenant matching, unique by the email and username it is found by.
+func (r userRepo) find(ctx context.Context, match func(user users.User) bool) (res users.User, err error) {
+	err = r.view(func(d *data) error {
+		tenantID := users.TenantFromContext(ctx)
+		for _, row := range d.users {
+			if row.tenantID == tenantID && row.user.DeletedTime == nil && match(row.user) {
+				res = row.user
+				return nil
+			}
+		}
+		return users.ErrNotFound
+	})
+	return res, err
+}
+
+func (r userRepo) Update(ctx context.Context, user users.User) error {
+	return r.update(func(d *data) error {
+		tenantID := users.TenantFromContext(ctx)
+		before, ok := d.active(tenantID, user.ID)
+		if !ok {
+			return users.ErrNotFound
+		}
+		if user.Version != 0 && user.Version != before.Version {
+			return users.ErrPreconditionFailed
+		}
+
+		after := before
+		after.Email = user.Email
+		after.Username = user.Username
+		after.Password = user.Password
+		after.Address = user.Address
+		after.UpdatedTime = time.Now()
+		if err := d.checkUnique(tenantID, after); err != nil {
+			return err
+		}
+
+		stored := after
+		stored.Version++
+		stored.UpdatedTime = unix(after.UpdatedTime)
+		d.users[user.ID] = userRow{tenantID: tenantID, user: stored}
+		d.insertHistory(ctx, user.ID, users.HistoryUpdate, users.DiffUsers(before, after), after.UpdatedTime)
+		return nil
+	})
+}
+
+func (r userRepo) Delete(ctx context.Context, id string, version int64) error {
+	return r.update(func(d *data) error {
+		tenantID := users.TenantFromContext(ctx)
+		user, ok := d.active(tenantID, id)
+		if !ok {
+			return users.ErrNotFound
+		}
+		if version != 0 && version != user.Version {
+			return users.ErrPreconditionFailed
+		}
+
+		now := time.Now()
+		deleted := unix(now)
+		user.DeletedTime = &deleted
+		user.Version++
+		d.users[id] = userRow{tenantID: tenantID, user: user}
+		d.insertHistory(ctx, id, users.HistoryDelete, map[string]users.FieldChange{}, now)
+		return nil
+	})
+}
+
+func (r userRepo) ListDeleted(ctx context.Context) (res []users.User, err error) {
+	err = r.view(func(d *data) error {
+		tenantID := users.TenantFromContext(ctx)
+		res = []users.User{}
+		for _, row := range d.users {
+			if row.tenantID == tenantID && row.user.DeletedTime != nil {
+				res = append(res, row.user)
+			}
+		}
+		return nil
+	})
+	sort.SliceStable(res, func(i, j int) bool {
+		if !res[i].DeletedTime.Equal(*res[j].DeletedTime) {
+			return res[i].DeletedTime.After(*res[j].DeletedTime)
+		}
+		return res[i].ID < res[j].ID
+	})
+	return res, err
+}
+
+func (r userRepo) Restore(ctx context.Context, id string) error {
+	return r.update(func(d *data) error {
+		tenantID := users.TenantFromContext(ctx)
+		row, ok := d.users[id]
+		if !ok || row.tenantID != tenantID || row.user.DeletedTime == nil {
+			return users.ErrNotFound
+		}
+
+		user := row.user
+		if err := d.checkUnique(tenantID, user); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		user.DeletedTime = nil
+		user.Version++
+		user.UpdatedTime = unix(now)
+		d.users[id] = userRow{tenantID: tenantID, user: user}
+		d.insertHistory(ctx, id, users.HistoryRestore, map[string]users.FieldChange{}, now)
+		return nil
+	})
+}
+
+func (r userRepo) HardDelete(ctx context.Context, id string) error {
+	return r.update(func(d *data) error {
+		row, ok := d.users[id]
+		if !ok || row.tenantID != users.TenantFromContext(ctx) || row.user.DeletedTime == nil {
+			return users.ErrNotFound
+		}
+		d.deleteUser(row.tenantID, id)
+		return nil
+	})
+}
+
+// Purge is a maintenance task removing the deleted users of every tenant.
+func (r userRepo) Purge(ctx context.Context, deletedBefore time.Time) (purged []users.PurgedUser, err error) {
+	err = r.update(func(d *data) error {
+		purged = []users.PurgedUser{}
+		for id, row := range d.users {
+			if row.user.DeletedTime != nil && row.user.DeletedTime.Unix() < deletedBefore.Unix() {
+				d.deleteUser(row.tenantID, id)
+				purged = append(purged, users.PurgedUser{TenantID: row.tenantID, ID: id})
+			}
+		}
+		return nil
+	})
+	return purged, err
+}
+
+func (r userRepo) UpdateStatus(ctx context.Context, change users.StatusChange) error {
+	return r.update(func(d *data) error {
+		if change.CreatedTime.IsZero() {
+			change.CreatedTime = time.Now()
+		}
+
+		tenantID := users.TenantFromContext(ctx)
+		user, ok := d.active(tenantID, change.UserID)
+		if !ok {
+			return users.ErrNotFound
+		}
+		if user.Status != change.From {
+			return users.ErrPreconditionFailed
+		}
+
+		user.Status = change.To
+		user.Version++
+		user.UpdatedTime = unix(change.CreatedTime)
+		d.users[change.UserID] = userRow{tenantID: tenantID, user: user}
+
+		stored := change
+		stored.CreatedTime = unix(change.CreatedTime)
+		d.statusChanges = append(d.statusChanges, statusChangeRow{tenantID: tenantID, change: stored})
+
+		changes := map[string]users.FieldChange{
+			"status": {Before: string(change.From), After: string(change.To)},
+		}
+		d.insertHistory(users.WithActor(ctx, change.Actor), change.UserID, users.HistoryStatusChange, changes, change.CreatedTime)
+		return nil
+	})
+}
+
+func (r userRepo) ListStatusChanges(ctx context.Context, userID string) (res []users.StatusChange, err error) {
+	err = r.view(func(d *data) error {
+		tenantID := users.TenantFromContext(ctx)
+		res = []users.StatusChange{}
+		for _, row := range d.statusChanges {
+			if row.tenantID == tenantID && row.change.UserID == userID {
+				res = append(res, row.change)
+			}
+		}
+		return nil
+	})
+	return res, err
+}
+
+func (r userRepo) ListHistory(ctx context.Context, userID string, beforeID int64, limit int) (res []users.HistoryEntry, err error) {
+	err = r.view(func(d *data) error {
+		tenantID := users.TenantFromContext(ctx)
+		res = []users.HistoryEntry{}
+		// The entries are appended in the order of their IDs, so they are read backwards for the newest first.
+		for i := len(d.history) - 1; i >= 0 && len(res) < limit; i-- {
+			row := d.history[i]
+			if row.tenantID != tenantID || row.entry.UserID != userID {
+				continue
+			}
+			if beforeID != 0 && row.entry.ID >= beforeID {
+				continue
+			}
+			res = append(res, row.entry)
+		}
+		return nil
+	})
+	return res, err
+}
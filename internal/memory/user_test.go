@@ -0,0 +1,62 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/memory"
+	"github.com/arnaz06/users/internal/repotest"
+)
+
+func TestUserRepositoryConformance(t *testing.T) {
+	repotest.TestUserRepository(t, func(t *testing.T) users.UserRepository {
+		return memory.NewUserRepository(memory.NewStore())
+	})
+}
+
+func TestEmailCase(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewUserRepository(memory.NewStore())
+
+	user, err := repo.Create(ctx, users.User{Email: "Jhon@doe.com", Password: "hash"})
+	require.NoError(t, err)
+
+	res, err := repo.GetByEmail(ctx, "JHON@doe.com")
+	require.NoError(t, err)
+	require.Equal(t, user.ID, res.ID)
+
+	_, err = repo.Create(ctx, users.User{Email: "jhon@doe.com", Password: "hash"})
+	require.EqualError(t, err, "email jhon@doe.com is already used by another user")
+}
+
+func TestHardDeleteRecords(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	repo := memory.NewUserRepository(store)
+	preferences := memory.NewPreferencesRepository(store)
+	emailChanges := memory.NewEmailChangeRepository(store)
+	stats := memory.NewStatsRepository(store)
+
+	user, err := repo.Create(ctx, users.User{Email: "jhon@doe.com", Password: "hash"})
+	require.NoError(t, err)
+	require.NoError(t, preferences.Save(ctx, user.ID, users.DefaultPreferences()))
+	require.NoError(t, stats.RecordLogin(ctx, user.ID, time.Now()))
+	_, err = emailChanges.Create(ctx, users.EmailChange{UserID: user.ID, OldEmail: user.Email, NewEmail: "new@doe.com"})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Delete(ctx, user.ID, 0))
+	require.NoError(t, repo.HardDelete(ctx, user.ID))
+
+	_, err = preferences.Get(ctx, user.ID)
+	require.Equal(t, users.ErrNotFound, err)
+	changes, err := emailChanges.ListByUser(ctx, user.ID)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+	logins, err := stats.CountLogins(ctx, []time.Time{time.Now().Add(-time.Hour), time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	require.Equal(t, []int64{0}, logins)
+}
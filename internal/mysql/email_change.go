@@ -0,0 +1,284 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arnaz06/users"
+)
+
+const emailChangeColumns = `id, user_id, old_email, new_email, confirm_token_hash, revert_token_hash, confirm_expires_time, revert_expires_time, confirmed_time, reverted_time, created_time`
+
+type emailChangeRepo struct {
+	db *sql.DB
+	// enc encrypts the emails at rest, if any.
+	enc *Encryption
+}
+
+// NewEmailChangeRepository is constructor for email change repository.
+// Every query is scoped by the tenant carried in the context.
+func NewEmailChangeRepository(db *sql.DB) users.EmailChangeRepository {
+	return emailChangeRepo{
+		db: db,
+	}
+}
+
+// NewEncryptedEmailChangeRepository is constructor for email change repository encrypting the emails at rest,
+// those of the changes as well as those of the users.
+// Every query is scoped by the tenant carried in the context.
+func NewEncryptedEmailChangeRepository(db *sql.DB, enc *Encryption) users.EmailChangeRepository {
+	return emailChangeRepo{
+		db:  db,
+		enc: enc,
+	}
+}
+
+// changeAAD binds the old or new email of a change to the change.
+func changeAAD(id, side string) string {
+	return id + "/" + side
+}
+
+func (r emailChangeRepo) Create(ctx context.Context, change users.EmailChange) (users.EmailChange, error) {
+	query := `INSERT email_changes SET id=?, tenant_id=?, user_id=?, old_email=?, new_email=?, confirm_token_hash=?, revert_token_hash=?, confirm_expires_time=?, revert_expires_time=?, created_time=?`
+	if change.ID == "" {
+		change.ID = uuid.New().String()
+	}
+	if change.CreatedTime.IsZero() {
+		change.CreatedTime = time.Now()
+	}
+
+	oldEmail, err := r.enc.encrypt("email", changeAAD(change.ID, "old"), change.OldEmail)
+	if err != nil {
+		return users.EmailChange{}, err
+	}
+	newEmail, err := r.enc.encrypt("email", changeAAD(change.ID, "new"), change.NewEmail)
+	if err != nil {
+		return users.EmailChange{}, err
+	}
+
+	_, err = r.db.ExecContext(ctx, query,
+		change.ID,
+		users.TenantFromContext(ctx),
+		change.UserID,
+		oldEmail,
+		newEmail,
+		change.ConfirmTokenHash,
+		change.RevertTokenHash,
+		change.ConfirmExpiresTime.Unix(),
+		change.RevertExpiresTime.Unix(),
+		change.CreatedTime.Unix(),
+	)
+	if err != nil {
+		return users.EmailChange{}, err
+	}
+	return change, nil
+}
+
+func (r emailChangeRepo) GetByConfirmToken(ctx context.Context, tokenHash string) (users.EmailChange, error) {
+	query := `SELECT ` + emailChangeColumns + ` FROM email_changes WHERE tenant_id=? AND confirm_token_hash=?`
+	return r.get(ctx, query, tokenHash)
+}
+
+func (r emailChangeRepo) GetByRevertToken(ctx context.Context, tokenHash string) (users.EmailChange, error) {
+	query := `SELECT ` + emailChangeColumns + ` FROM email_changes WHERE tenant_id=? AND revert_token_hash=?`
+	return r.get(ctx, query, tokenHash)
+}
+
+func (r emailChangeRepo) get(ctx context.Context, query, tokenHash string) (users.EmailChange, error) {
+	res, err := scanEmailChange(r.db.QueryRowContext(ctx, query, users.TenantFromContext(ctx), tokenHash), r.enc)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return users.EmailChange{}, users.ErrNotFound
+		}
+		return users.EmailChange{}, err
+	}
+
+	return res, nil
+}
+
+func (r emailChangeRepo) ListByUser(ctx context.Context, userID string) ([]users.EmailChange, error) {
+	query := `SELECT ` + emailChangeColumns + ` FROM email_changes WHERE tenant_id=? AND user_id=? ORDER BY created_time`
+	rows, err := r.db.QueryContext(ctx, query, users.TenantFromContext(ctx), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []users.EmailChange{}
+	for rows.Next() {
+		change, err := scanEmailChange(rows, r.enc)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, change)
+	}
+
+	return res, rows.Err()
+}
+
+func (r emailChangeRepo) Confirm(ctx context.Context, id string) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+		if err == nil {
+			users.MarkWritten(ctx)
+		}
+	}()
+
+	tenantID := users.TenantFromContext(ctx)
+	change, err := scanEmailChange(tx.QueryRowContext(ctx, `SELECT `+emailChangeColumns+` FROM email_changes WHERE tenant_id=? AND id=? FOR UPDATE`, tenantID, id), r.enc)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return users.ErrNotFound
+		}
+		return err
+	}
+	if change.ConfirmedTime != nil || change.RevertedTime != nil {
+		return users.ErrPreconditionFailed
+	}
+
+	now := time.Now()
+	affected, err := r.updateEmail(ctx, tx, change.UserID, change.OldEmail, change.NewEmail, now)
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return users.ConstraintErrorf("email of the user has changed since the change was requested")
+	}
+
+	changes := map[string]users.FieldChange{
+		"email": {Before: change.OldEmail, After: change.NewEmail},
+	}
+	err = insertHistory(ctx, tx, r.enc, change.UserID, users.HistoryUpdate, changes, now)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE email_changes SET confirmed_time=? WHERE tenant_id=? AND id=?`, now.Unix(), tenantID, id)
+	return err
+}
+
+func (r emailChangeRepo) Revert(ctx context.Context, id string) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+		if err == nil {
+			users.MarkWritten(ctx)
+		}
+	}()
+
+	tenantID := users.TenantFromContext(ctx)
+	change, err := scanEmailChange(tx.QueryRowContext(ctx, `SELECT `+emailChangeColumns+` FROM email_changes WHERE tenant_id=? AND id=? FOR UPDATE`, tenantID, id), r.enc)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return users.ErrNotFound
+		}
+		return err
+	}
+	if change.RevertedTime != nil {
+		return users.ErrPreconditionFailed
+	}
+
+	now := time.Now()
+	if change.ConfirmedTime != nil {
+		affected, err := r.updateEmail(ctx, tx, change.UserID, change.NewEmail, change.OldEmail, now)
+		if err != nil {
+			return err
+		}
+		if affected != 1 {
+			return users.ConstraintErrorf("email of the user has changed since the change was confirmed")
+		}
+
+		changes := map[string]users.FieldChange{
+			"email": {Before: change.NewEmail, After: change.OldEmail},
+		}
+		err = insertHistory(ctx, tx, r.enc, change.UserID, users.HistoryUpdate, changes, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE email_changes SET reverted_time=? WHERE tenant_id=? AND id=?`, now.Unix(), tenantID, id)
+	return err
+}
+
+// updateEmail changes the email of the user from the former one, returning whether the user still had it.
+func (r emailChangeRepo) updateEmail(ctx context.Context, tx *sql.Tx, userID, from, to string, now time.Time) (int64, error) {
+	email, err := r.enc.encrypt("email", userID, to)
+	if err != nil {
+		return 0, err
+	}
+
+	condition, args := r.enc.emailCondition(from)
+	query := `UPDATE users SET email=?, email_index=?, version=version+1, updated_time=? WHERE tenant_id=? AND id=? AND ` + condition + ` AND deleted_time IS NULL`
+	args = append([]interface{}{email, r.enc.emailIndex(to), now.Unix(), users.TenantFromContext(ctx), userID}, args...)
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, mapEmailError(err, to)
+	}
+	return res.RowsAffected()
+}
+
+// scanEmailChange scans the change, decrypting its emails encrypted at rest.
+func scanEmailChange(row rowScanner, enc *Encryption) (users.EmailChange, error) {
+	var res users.EmailChange
+	var confirmedTime, revertedTime sql.NullInt64
+	confirmExpiresTime := int64(0)
+	revertExpiresTime := int64(0)
+	createdTime := int64(0)
+	err := row.Scan(
+		&res.ID,
+		&res.UserID,
+		&res.OldEmail,
+		&res.NewEmail,
+		&res.ConfirmTokenHash,
+		&res.RevertTokenHash,
+		&confirmExpiresTime,
+		&revertExpiresTime,
+		&confirmedTime,
+		&revertedTime,
+		&createdTime,
+	)
+	if err != nil {
+		return users.EmailChange{}, err
+	}
+
+	if confirmedTime.Valid {
+		confirmed := time.Unix(confirmedTime.Int64, 0)
+		res.ConfirmedTime = &confirmed
+	}
+	if revertedTime.Valid {
+		reverted := time.Unix(revertedTime.Int64, 0)
+		res.RevertedTime = &reverted
+	}
+	res.ConfirmExpiresTime = time.Unix(confirmExpiresTime, 0)
+	res.RevertExpiresTime = time.Unix(revertExpiresTime, 0)
+	res.CreatedTime = time.Unix(createdTime, 0)
+
+	res.OldEmail, err = enc.decrypt("email", changeAAD(res.ID, "old"), res.OldEmail)
+	if err != nil {
+		return users.EmailChange{}, err
+	}
+	res.NewEmail, err = enc.decrypt("email", changeAAD(res.ID, "new"), res.NewEmail)
+	if err != nil {
+		return users.EmailChange{}, err
+	}
+	return res, nil
+}
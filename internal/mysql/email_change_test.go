@@ -0,0 +1,131 @@
+package mysql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/mysql"
+	"github.com/arnaz06/users/testdata"
+)
+
+type emailChangeSuite struct {
+	mysqlSuite
+}
+
+func TestEmailChangeSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipped for short testing")
+	}
+	suite.Run(t, new(emailChangeSuite))
+}
+
+func (s *emailChangeSuite) SetupTest() {
+	_, err := s.db.Exec("TRUNCATE users")
+	require.NoError(s.T(), err)
+	_, err = s.db.Exec("TRUNCATE email_changes")
+	require.NoError(s.T(), err)
+	_, err = s.db.Exec("TRUNCATE user_history")
+	require.NoError(s.T(), err)
+}
+
+func (s *emailChangeSuite) seedUser(user users.User) users.User {
+	res, err := mysql.NewUserRepository(s.db).Create(context.Background(), user)
+	require.NoError(s.T(), err)
+	return res
+}
+
+func (s *emailChangeSuite) getUser(id string) users.User {
+	res, err := mysql.NewUserRepository(s.db).Get(context.Background(), id)
+	require.NoError(s.T(), err)
+	return res
+}
+
+func (s *emailChangeSuite) createChange(user users.User, newEmail string) users.EmailChange {
+	now := time.Now()
+	repo := mysql.NewEmailChangeRepository(s.db)
+	change, err := repo.Create(context.Background(), users.EmailChange{
+		UserID:             user.ID,
+		OldEmail:           user.Email,
+		NewEmail:           newEmail,
+		ConfirmTokenHash:   "confirm-" + newEmail,
+		RevertTokenHash:    "revert-" + newEmail,
+		ConfirmExpiresTime: now.Add(time.Hour),
+		RevertExpiresTime:  now.Add(24 * time.Hour),
+	})
+	require.NoError(s.T(), err)
+	return change
+}
+
+func (s *emailChangeSuite) TestGetByToken() {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(s.T(), "user", &mockUser)
+	mockUser = s.seedUser(mockUser)
+	change := s.createChange(mockUser, "new@doe.com")
+
+	repo := mysql.NewEmailChangeRepository(s.db)
+	res, err := repo.GetByConfirmToken(context.Background(), change.ConfirmTokenHash)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), change.ID, res.ID)
+	require.Equal(s.T(), "new@doe.com", res.NewEmail)
+
+	res, err = repo.GetByRevertToken(context.Background(), change.RevertTokenHash)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), change.ID, res.ID)
+
+	_, err = repo.GetByConfirmToken(users.WithTenant(context.Background(), "other"), change.ConfirmTokenHash)
+	require.Equal(s.T(), users.ErrNotFound, err)
+
+	_, err = repo.GetByRevertToken(context.Background(), "unknown")
+	require.Equal(s.T(), users.ErrNotFound, err)
+}
+
+func (s *emailChangeSuite) TestConfirmAndRevert() {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(s.T(), "user", &mockUser)
+	mockUser = s.seedUser(mockUser)
+	change := s.createChange(mockUser, "new@doe.com")
+
+	repo := mysql.NewEmailChangeRepository(s.db)
+	err := repo.Confirm(context.Background(), change.ID)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "new@doe.com", s.getUser(mockUser.ID).Email)
+	require.Equal(s.T(), mockUser.Version+1, s.getUser(mockUser.ID).Version)
+
+	err = repo.Confirm(context.Background(), change.ID)
+	require.Equal(s.T(), users.ErrPreconditionFailed, err)
+
+	err = repo.Revert(context.Background(), change.ID)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), mockUser.Email, s.getUser(mockUser.ID).Email)
+
+	err = repo.Revert(context.Background(), change.ID)
+	require.Equal(s.T(), users.ErrPreconditionFailed, err)
+
+	list, err := repo.ListByUser(context.Background(), mockUser.ID)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), list, 1)
+	require.NotNil(s.T(), list[0].ConfirmedTime)
+	require.NotNil(s.T(), list[0].RevertedTime)
+}
+
+func (s *emailChangeSuite) TestConfirmWithEmailTaken() {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(s.T(), "user", &mockUser)
+	mockUser = s.seedUser(mockUser)
+	s.seedUser(users.User{Email: "taken@doe.com", Status: users.StatusActive})
+	change := s.createChange(mockUser, "taken@doe.com")
+
+	repo := mysql.NewEmailChangeRepository(s.db)
+	err := repo.Confirm(context.Background(), change.ID)
+	require.EqualError(s.T(), err, "email taken@doe.com is already used by another user")
+	require.Equal(s.T(), mockUser.Email, s.getUser(mockUser.ID).Email)
+
+	res, err := repo.GetByConfirmToken(context.Background(), change.ConfirmTokenHash)
+	require.NoError(s.T(), err)
+	require.Nil(s.T(), res.ConfirmedTime)
+}
@@ -0,0 +1,146 @@
+package mysql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/keyring"
+)
+
+// EncryptableFields are the fields of the users which may be encrypted at rest.
+var EncryptableFields = []string{"email", "address"}
+
+// Encryption encrypts the configured fields of the users at rest, in the users table and their history.
+// The encrypted emails are looked up by their blind index instead. A nil Encryption stores the fields as they are.
+type Encryption struct {
+	keys   *keyring.Keyring
+	fields map[string]bool
+}
+
+// NewEncryption creates the encryption of the fields with the keyring, the fields being among EncryptableFields.
+func NewEncryption(keys *keyring.Keyring, fields ...string) (*Encryption, error) {
+	e := &Encryption{
+		keys:   keys,
+		fields: map[string]bool{},
+	}
+	for _, field := range fields {
+		if !isEncryptable(field) {
+			return nil, fmt.Errorf("field %s can not be encrypted, only %s can", field, strings.Join(EncryptableFields, ", "))
+		}
+		e.fields[field] = true
+	}
+	return e, nil
+}
+
+func isEncryptable(field string) bool {
+	for _, f := range EncryptableFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Encryption) encrypts(field string) bool {
+	return e != nil && e.fields[field]
+}
+
+// aad binds the encrypted value to the field of the user storing it.
+func aad(field, userID string) string {
+	return field + "/" + userID
+}
+
+func (e *Encryption) encrypt(field, userID, value string) (string, error) {
+	if !e.encrypts(field) {
+		return value, nil
+	}
+	return e.keys.Encrypt(value, aad(field, userID))
+}
+
+// decrypt decrypts the value of the field, which may not be encrypted yet even if the field is.
+func (e *Encryption) decrypt(field, userID, value string) (string, error) {
+	if e == nil || !keyring.IsEncrypted(value) {
+		return value, nil
+	}
+	return e.keys.Decrypt(value, aad(field, userID))
+}
+
+// encryptUser returns the user with its fields encrypted, as stored.
+func (e *Encryption) encryptUser(user users.User) (users.User, error) {
+	var err error
+	user.Email, err = e.encrypt("email", user.ID, user.Email)
+	if err != nil {
+		return users.User{}, err
+	}
+	user.Address, err = e.encrypt("address", user.ID, user.Address)
+	if err != nil {
+		return users.User{}, err
+	}
+	return user, nil
+}
+
+func (e *Encryption) decryptUser(user users.User) (users.User, error) {
+	var err error
+	user.Email, err = e.decrypt("email", user.ID, user.Email)
+	if err != nil {
+		return users.User{}, err
+	}
+	user.Address, err = e.decrypt("address", user.ID, user.Address)
+	if err != nil {
+		return users.User{}, err
+	}
+	return user, nil
+}
+
+// encryptChanges returns the changes of the history with the values of the encrypted fields encrypted.
+func (e *Encryption) encryptChanges(userID string, changes map[string]users.FieldChange) (map[string]users.FieldChange, error) {
+	return e.mapChanges(changes, func(field, value string) (string, error) {
+		return e.encrypt(field, userID, value)
+	})
+}
+
+func (e *Encryption) decryptChanges(userID string, changes map[string]users.FieldChange) (map[string]users.FieldChange, error) {
+	return e.mapChanges(changes, func(field, value string) (string, error) {
+		return e.decrypt(field, userID, value)
+	})
+}
+
+func (e *Encryption) mapChanges(changes map[string]users.FieldChange, fn func(field, value string) (string, error)) (map[string]users.FieldChange, error) {
+	if e == nil {
+		return changes, nil
+	}
+
+	res := make(map[string]users.FieldChange, len(changes))
+	for field, change := range changes {
+		if isEncryptable(field) {
+			var err error
+			if change.Before, err = fn(field, change.Before); err != nil {
+				return nil, err
+			}
+			if change.After, err = fn(field, change.After); err != nil {
+				return nil, err
+			}
+		}
+		res[field] = change
+	}
+	return res, nil
+}
+
+// emailIndex returns the blind index stored next to the encrypted email, or NULL when the emails are not encrypted.
+// The emails are compared regardless of their case, as the collation of the table does.
+func (e *Encryption) emailIndex(email string) interface{} {
+	if !e.encrypts("email") {
+		return nil
+	}
+	return e.keys.BlindIndex(strings.ToLower(email))
+}
+
+// emailCondition returns the WHERE condition matching the email, by its blind index once encrypted,
+// or by its value when it is stored before the encryption was enabled.
+func (e *Encryption) emailCondition(email string) (string, []interface{}) {
+	if !e.encrypts("email") {
+		return `email=?`, []interface{}{email}
+	}
+	return `(email_index=? OR (email_index IS NULL AND email=?))`, []interface{}{e.emailIndex(email), email}
+}
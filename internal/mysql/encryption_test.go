@@ -0,0 +1,132 @@
+package mysql_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/keyring"
+	"github.com/arnaz06/users/internal/mysql"
+	"github.com/arnaz06/users/internal/repotest"
+)
+
+func newEncryption(t *testing.T, versions ...uint32) *mysql.Encryption {
+	keys := map[uint32][]byte{}
+	for _, version := range versions {
+		keys[version] = bytes.Repeat([]byte{byte(version)}, 32)
+	}
+	k, err := keyring.New(keys, bytes.Repeat([]byte{0xff}, 32))
+	require.NoError(t, err)
+
+	enc, err := mysql.NewEncryption(k, "email", "address")
+	require.NoError(t, err)
+	return enc
+}
+
+func TestNewEncryption(t *testing.T) {
+	k, err := keyring.New(map[uint32][]byte{1: bytes.Repeat([]byte{1}, 32)}, bytes.Repeat([]byte{0xff}, 32))
+	require.NoError(t, err)
+
+	_, err = mysql.NewEncryption(k, "address")
+	require.NoError(t, err)
+
+	_, err = mysql.NewEncryption(k, "password")
+	require.EqualError(t, err, "field password can not be encrypted, only email, address can")
+}
+
+func (u *userSuite) TestEncryptedConformance() {
+	enc := newEncryption(u.T(), 1)
+	repotest.TestUserRepository(u.T(), func(t *testing.T) users.UserRepository {
+		u.clear(t)
+		return mysql.NewEncryptedUserRepository(u.db, nil, enc)
+	})
+}
+
+// storedUser reads the email, its blind index and the address of the user as stored.
+func (u *userSuite) storedUser(id string) (string, sql.NullString, string) {
+	var email, address string
+	var emailIndex sql.NullString
+	err := u.db.QueryRow(`SELECT email, email_index, address FROM users WHERE id=?`, id).Scan(&email, &emailIndex, &address)
+	require.NoError(u.T(), err)
+	return email, emailIndex, address
+}
+
+func (u *userSuite) TestEncryption() {
+	ctx := context.Background()
+	repo := mysql.NewEncryptedUserRepository(u.db, nil, newEncryption(u.T(), 1))
+
+	created, err := repo.Create(ctx, users.User{Email: "jhon@doe.com", Password: "hash", Address: "lorem ipsum"})
+	require.NoError(u.T(), err)
+
+	// The user stored before the encryption was enabled is still read.
+	u.seedUser(users.User{ID: "legacy", Email: "legacy@doe.com", Password: "hash", Address: "dolor sit amet", Status: users.StatusActive, Version: 1})
+
+	u.T().Run("with fields encrypted at rest", func(t *testing.T) {
+		email, emailIndex, address := u.storedUser(created.ID)
+		require.True(t, keyring.IsEncrypted(email))
+		require.True(t, keyring.IsEncrypted(address))
+		require.True(t, emailIndex.Valid)
+		require.NotContains(t, address, "lorem")
+	})
+
+	u.T().Run("with users read by email", func(t *testing.T) {
+		res, err := repo.GetByEmail(ctx, "JHON@doe.com")
+		require.NoError(t, err)
+		require.Equal(t, created.ID, res.ID)
+		require.Equal(t, "lorem ipsum", res.Address)
+
+		res, err = repo.GetByEmail(ctx, "legacy@doe.com")
+		require.NoError(t, err)
+		require.Equal(t, "dolor sit amet", res.Address)
+	})
+
+	u.T().Run("with email used", func(t *testing.T) {
+		_, err := repo.Create(ctx, users.User{Email: "jhon@doe.com", Password: "hash"})
+		require.EqualError(t, err, "email jhon@doe.com is already used by another user")
+	})
+
+	u.T().Run("with history decrypted", func(t *testing.T) {
+		entries, err := repo.ListHistory(ctx, created.ID, 0, 10)
+		require.NoError(t, err)
+		require.Equal(t, users.FieldChange{After: "lorem ipsum"}, entries[0].Changes["address"])
+	})
+
+	u.T().Run("with filter by email rejected", func(t *testing.T) {
+		_, err := repo.List(ctx, users.UserFilter{EmailPrefix: "jhon", Limit: 10})
+		require.Error(t, err)
+		require.IsType(t, users.ConstraintError(""), err)
+	})
+
+	u.T().Run("rotate", func(t *testing.T) {
+		_, err := u.db.Exec("TRUNCATE email_changes")
+		require.NoError(t, err)
+		enc := newEncryption(t, 1, 2)
+		before, _, _ := u.storedUser(created.ID)
+
+		rotated, err := mysql.NewKeyRotator(u.db, enc, 1, time.Millisecond).Rotate(ctx)
+		require.NoError(t, err)
+		// Both users, and the history of the one created encrypted.
+		require.Equal(t, int64(3), rotated)
+
+		after, _, _ := u.storedUser(created.ID)
+		require.NotEqual(t, before, after)
+		email, emailIndex, _ := u.storedUser("legacy")
+		require.True(t, keyring.IsEncrypted(email))
+		require.True(t, emailIndex.Valid)
+
+		repo := mysql.NewEncryptedUserRepository(u.db, nil, enc)
+		res, err := repo.GetByEmail(ctx, "legacy@doe.com")
+		require.NoError(t, err)
+		require.Equal(t, "dolor sit amet", res.Address)
+
+		// The keys are rotated already.
+		rotated, err = mysql.NewKeyRotator(u.db, enc, 1, time.Millisecond).Rotate(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(0), rotated)
+	})
+}
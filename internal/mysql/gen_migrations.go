@@ -0,0 +1,47 @@
+//go:build ignore
+// +build ignore
+
+// gen_migrations generates migrations.go from the files of the migrations directory, so that they are
+// built in the binary.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sort"
+)
+
+func main() {
+	files, err := filepath.Glob(filepath.Join("migrations", "*.sql"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	sort.Strings(files)
+
+	var buf bytes.Buffer
+	fmt.Fprint(&buf, "// Code generated by go run gen_migrations.go; DO NOT EDIT.\n\n")
+	fmt.Fprint(&buf, "package mysql\n\n")
+	fmt.Fprint(&buf, "// migrationFiles are the files of the migrations directory by name.\n")
+	fmt.Fprint(&buf, "var migrationFiles = map[string]string{\n")
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Fprintf(&buf, "%q: %q,\n", filepath.Base(file), data)
+	}
+	fmt.Fprint(&buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = ioutil.WriteFile("migrations.go", src, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
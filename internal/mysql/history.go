@@ -0,0 +1,75 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/arnaz06/users"
+)
+
+// insertHistory records a change of the user, made by the actor carried in ctx, in the transaction making it.
+// The values of the fields encrypted at rest are encrypted in the history too.
+func insertHistory(ctx context.Context, tx *sql.Tx, enc *Encryption, userID string, action users.HistoryAction, changes map[string]users.FieldChange, createdTime time.Time) error {
+	changes, err := enc.encryptChanges(userID, changes)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT user_history SET tenant_id=?, user_id=?, action=?, actor=?, changes=?, created_time=?`
+	_, err = tx.ExecContext(ctx, query, users.TenantFromContext(ctx), userID, action, users.ActorFromContext(ctx), string(data), createdTime.Unix())
+	return err
+}
+
+func (r userRepo) ListHistory(ctx context.Context, userID string, beforeID int64, limit int) ([]users.HistoryEntry, error) {
+	query := `SELECT id, user_id, action, actor, changes, created_time FROM user_history WHERE tenant_id=? AND user_id=?`
+	args := []interface{}{users.TenantFromContext(ctx), userID}
+	if beforeID != 0 {
+		query += ` AND id<?`
+		args = append(args, beforeID)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []users.HistoryEntry{}
+	for rows.Next() {
+		var entry users.HistoryEntry
+		var changes string
+		createdTime := int64(0)
+		err = rows.Scan(
+			&entry.ID,
+			&entry.UserID,
+			&entry.Action,
+			&entry.Actor,
+			&changes,
+			&createdTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal([]byte(changes), &entry.Changes)
+		if err != nil {
+			return nil, err
+		}
+		entry.Changes, err = r.enc.decryptChanges(entry.UserID, entry.Changes)
+		if err != nil {
+			return nil, err
+		}
+		entry.CreatedTime = time.Unix(createdTime, 0)
+		res = append(res, entry)
+	}
+
+	return res, rows.Err()
+}
@@ -0,0 +1,107 @@
+package mysql
+
+import (
+	"context"
+	"strings"
+
+	"github.com/arnaz06/users"
+)
+
+// likeEscaper escapes the wildcards of a LIKE pattern, with the default backslash escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// filterConditions returns the WHERE conditions matching the filter, but for its position.
+func filterConditions(ctx context.Context, filter users.UserFilter) (string, []interface{}) {
+	conditions := []string{`tenant_id=?`}
+	args := []interface{}{users.TenantFromContext(ctx)}
+
+	switch filter.Deleted {
+	case users.DeletedOnly:
+		conditions = append(conditions, `deleted_time IS NOT NULL`)
+	case users.DeletedInclude:
+	default:
+		conditions = append(conditions, `deleted_time IS NULL`)
+	}
+	if filter.EmailPrefix != "" {
+		conditions = append(conditions, `email LIKE ?`)
+		args = append(args, likeEscaper.Replace(filter.EmailPrefix)+"%")
+	}
+	if !filter.CreatedFrom.IsZero() {
+		conditions = append(conditions, `created_time>=?`)
+		args = append(args, filter.CreatedFrom.Unix())
+	}
+	if !filter.CreatedTo.IsZero() {
+		conditions = append(conditions, `created_time<?`)
+		args = append(args, filter.CreatedTo.Unix())
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, `status=?`)
+		args = append(args, filter.Status)
+	}
+
+	return strings.Join(conditions, ` AND `), args
+}
+
+// checkFilter rejects the filters on the emails once they are encrypted, as they can no longer be compared.
+func (r userRepo) checkFilter(filter users.UserFilter) error {
+	if r.enc.encrypts("email") && (filter.EmailPrefix != "" || filter.Sort == users.SortEmail) {
+		return users.ConstraintErrorf("users can not be filtered or sorted by email, as the emails are encrypted")
+	}
+	return nil
+}
+
+func (r userRepo) List(ctx context.Context, filter users.UserFilter) ([]users.User, error) {
+	if err := r.checkFilter(filter); err != nil {
+		return nil, err
+	}
+	where, args := filterConditions(ctx, filter)
+
+	column := `created_time`
+	if filter.Sort == users.SortEmail {
+		column = `email`
+	}
+	operator, order := `>`, `ASC`
+	if filter.Desc {
+		operator, order = `<`, `DESC`
+	}
+
+	if filter.After != nil {
+		var value interface{} = filter.After.CreatedTime.Unix()
+		if filter.Sort == users.SortEmail {
+			value = filter.After.Email
+		}
+		where += ` AND (` + column + operator + `? OR (` + column + `=? AND id` + operator + `?))`
+		args = append(args, value, value, filter.After.ID)
+	}
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE ` + where + ` ORDER BY ` + column + ` ` + order + `, id ` + order + ` LIMIT ?`
+	args = append(args, filter.Limit)
+
+	rows, err := r.reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []users.User{}
+	for rows.Next() {
+		user, err := scanUser(rows, r.enc)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, user)
+	}
+
+	return res, rows.Err()
+}
+
+func (r userRepo) Count(ctx context.Context, filter users.UserFilter) (int64, error) {
+	if err := r.checkFilter(filter); err != nil {
+		return 0, err
+	}
+	where, args := filterConditions(ctx, filter)
+
+	var res int64
+	err := r.reader(ctx).QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE `+where, args...).Scan(&res)
+	return res, err
+}
@@ -0,0 +1,137 @@
+package mysql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/mysql"
+)
+
+func (u *userSuite) seedListedUsers() time.Time {
+	createdTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	seeds := []struct {
+		user    users.User
+		created time.Time
+		deleted bool
+	}{
+		{user: users.User{ID: "1", Email: "anna@doe.com", Status: users.StatusActive}, created: createdTime},
+		{user: users.User{ID: "2", Email: "bob@doe.com", Status: users.StatusSuspended}, created: createdTime.Add(time.Hour)},
+		{user: users.User{ID: "3", Email: "annabel@doe.com", Status: users.StatusActive}, created: createdTime.Add(time.Hour)},
+		{user: users.User{ID: "4", Email: "carl@doe.com", Status: users.StatusActive}, created: createdTime.Add(2 * time.Hour), deleted: true},
+	}
+
+	for _, seed := range seeds {
+		if seed.deleted {
+			u.seedDeletedUser(seed.user, seed.created)
+		} else {
+			u.seedUser(seed.user)
+		}
+		_, err := u.db.Exec(`UPDATE users SET created_time=? WHERE id=?`, seed.created.Unix(), seed.user.ID)
+		require.NoError(u.T(), err)
+	}
+	return createdTime
+}
+
+func (u *userSuite) TestListUsers() {
+	createdTime := u.seedListedUsers()
+	repo := mysql.NewUserRepository(u.db)
+
+	tests := []struct {
+		testName      string
+		filter        users.UserFilter
+		expectedIDs   []string
+		expectedTotal int64
+	}{
+		{
+			testName:      "by create time",
+			filter:        users.UserFilter{Sort: users.SortCreatedTime, Deleted: users.DeletedExclude, Limit: 10},
+			expectedIDs:   []string{"1", "2", "3"},
+			expectedTotal: 3,
+		},
+		{
+			testName:      "by create time descending",
+			filter:        users.UserFilter{Sort: users.SortCreatedTime, Desc: true, Deleted: users.DeletedInclude, Limit: 10},
+			expectedIDs:   []string{"4", "3", "2", "1"},
+			expectedTotal: 4,
+		},
+		{
+			testName: "after a user with the same create time",
+			filter: users.UserFilter{
+				Sort:    users.SortCreatedTime,
+				Deleted: users.DeletedExclude,
+				After:   &users.UserKey{ID: "2", CreatedTime: createdTime.Add(time.Hour)},
+				Limit:   10,
+			},
+			expectedIDs:   []string{"3"},
+			expectedTotal: 3,
+		},
+		{
+			testName: "by email after a user",
+			filter: users.UserFilter{
+				Sort:    users.SortEmail,
+				Deleted: users.DeletedExclude,
+				After:   &users.UserKey{ID: "1", Email: "anna@doe.com"},
+				Limit:   1,
+			},
+			expectedIDs:   []string{"3"},
+			expectedTotal: 3,
+		},
+		{
+			testName:      "by email prefix",
+			filter:        users.UserFilter{EmailPrefix: "anna", Sort: users.SortEmail, Deleted: users.DeletedExclude, Limit: 10},
+			expectedIDs:   []string{"1", "3"},
+			expectedTotal: 2,
+		},
+		{
+			testName:      "by email prefix with a wildcard",
+			filter:        users.UserFilter{EmailPrefix: "ann_", Sort: users.SortEmail, Deleted: users.DeletedExclude, Limit: 10},
+			expectedIDs:   []string{},
+			expectedTotal: 0,
+		},
+		{
+			testName:      "by status",
+			filter:        users.UserFilter{Status: users.StatusSuspended, Sort: users.SortEmail, Deleted: users.DeletedExclude, Limit: 10},
+			expectedIDs:   []string{"2"},
+			expectedTotal: 1,
+		},
+		{
+			testName: "by create time range",
+			filter: users.UserFilter{
+				CreatedFrom: createdTime.Add(time.Hour),
+				CreatedTo:   createdTime.Add(2 * time.Hour),
+				Sort:        users.SortCreatedTime,
+				Deleted:     users.DeletedInclude,
+				Limit:       10,
+			},
+			expectedIDs:   []string{"2", "3"},
+			expectedTotal: 2,
+		},
+		{
+			testName:      "only deleted",
+			filter:        users.UserFilter{Sort: users.SortCreatedTime, Deleted: users.DeletedOnly, Limit: 10},
+			expectedIDs:   []string{"4"},
+			expectedTotal: 1,
+		},
+	}
+
+	for _, test := range tests {
+		u.T().Run(test.testName, func(t *testing.T) {
+			res, err := repo.List(context.Background(), test.filter)
+			require.NoError(t, err)
+
+			ids := []string{}
+			for _, user := range res {
+				ids = append(ids, user.ID)
+			}
+			require.Equal(t, test.expectedIDs, ids)
+
+			total, err := repo.Count(context.Background(), test.filter)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedTotal, total)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	driver "github.com/go-sql-driver/mysql"
+	"github.com/golang-migrate/migrate"
+	migratemysql "github.com/golang-migrate/migrate/database/mysql"
+	"github.com/golang-migrate/migrate/source"
+	bindata "github.com/golang-migrate/migrate/source/go_bindata"
+)
+
+//go:generate go run gen_migrations.go
+
+// migrationLock is the advisory lock held while the migrations are applied on startup.
+const migrationLock = "users_schema_migration"
+
+// MigrationSource returns the migrations built in the binary, as generated from the migrations directory.
+func MigrationSource() (source.Driver, error) {
+	names := make([]string, 0, len(migrationFiles))
+	for name := range migrationFiles {
+		names = append(names, name)
+	}
+	return bindata.WithInstance(bindata.Resource(names, func(name string) ([]byte, error) {
+		data, ok := migrationFiles[name]
+		if !ok {
+			return nil, &os.PathError{Op: "read", Path: name, Err: os.ErrNotExist}
+		}
+		return []byte(data), nil
+	}))
+}
+
+// MigrationDSN returns the dsn with several statements allowed per query, as the migrations checking the data
+// before changing the schema run their checks along with their changes.
+func MigrationDSN(dsn string) (string, error) {
+	cfg, err := driver.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	cfg.MultiStatements = true
+	return cfg.FormatDSN(), nil
+}
+
+// NewMigrator returns the migrator of the schema of the database, applying the migrations built in the binary.
+// Closing the migrator closes the database too, so it must be given a database of its own, opened with MigrationDSN.
+func NewMigrator(db *sql.DB) (*migrate.Migrate, error) {
+	src, err := MigrationSource()
+	if err != nil {
+		return nil, err
+	}
+	driver, err := migratemysql.WithInstance(db, &migratemysql.Config{})
+	if err != nil {
+		return nil, err
+	}
+	return migrate.NewWithInstance("go-bindata", src, "mysql", driver)
+}
+
+// MigrateUp applies the migrations not applied yet, holding an advisory lock so that the servers starting at once
+// apply them one at a time, the others finding them applied. It waits for the lock until the ctx is done.
+// The database is closed once done, so it must not be shared.
+func MigrateUp(ctx context.Context, db *sql.DB) (err error) {
+	m, err := NewMigrator(db)
+	if err != nil {
+		_ = db.Close()
+		return err
+	}
+	defer func() {
+		srcErr, dbErr := m.Close()
+		if err == nil && srcErr != nil {
+			err = srcErr
+		}
+		if err == nil && dbErr != nil {
+			err = dbErr
+		}
+	}()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// The lock is waited for forever with a negative timeout.
+	timeout := -1
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = int(time.Until(deadline).Seconds())
+	}
+	var locked sql.NullInt64
+	err = conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, ?)`, migrationLock, timeout).Scan(&locked)
+	if err != nil {
+		return err
+	}
+	if locked.Int64 != 1 {
+		return fmt.Errorf("timed out waiting for the lock %s", migrationLock)
+	}
+	// The lock is released before the database is closed by the migrator.
+	defer func() {
+		_, unlockErr := conn.ExecContext(context.Background(), `SELECT RELEASE_LOCK(?)`, migrationLock)
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+
+	err = m.Up()
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
@@ -2,22 +2,73 @@ package mysql_test
 
 import (
 	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
 
-	_ "github.com/go-sql-driver/mysql"
+	driver "github.com/go-sql-driver/mysql"
 	"github.com/golang-migrate/migrate"
-	"github.com/golang-migrate/migrate/database/mysql"
-	_ "github.com/golang-migrate/migrate/source/file"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users/internal/mysql"
 )
 
 func MigrateDB(db *sql.DB) (m *migrate.Migrate, err error) {
-	driver, err := mysql.WithInstance(db, &mysql.Config{})
-	if err != nil {
-		return nil, err
-	}
-	m, err = migrate.NewWithDatabaseInstance("file://migrations/", "mysql", driver)
+	m, err = mysql.NewMigrator(db)
 	if err != nil {
 		return nil, err
 	}
 	err = m.Up()
 	return
 }
+
+// The migrations built in the binary must be generated again with go generate when the directory changes.
+func TestMigrationSource(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("migrations", "*.sql"))
+	require.NoError(t, err)
+
+	src, err := mysql.MigrationSource()
+	require.NoError(t, err)
+
+	read := 0
+	version, err := src.First()
+	for ; err == nil; version, err = src.Next(version) {
+		up, name, err := src.ReadUp(version)
+		require.NoError(t, err)
+		requireMigrationFile(t, fmt.Sprintf("%d_%s.up.sql", version, name), up)
+
+		down, name, err := src.ReadDown(version)
+		require.NoError(t, err)
+		requireMigrationFile(t, fmt.Sprintf("%d_%s.down.sql", version, name), down)
+		read += 2
+	}
+	require.True(t, os.IsNotExist(err))
+	require.Len(t, files, read)
+}
+
+func TestMigrationDSN(t *testing.T) {
+	dsn, err := mysql.MigrationDSN("users:users-pass@tcp(localhost:3306)/users?parseTime=1")
+	require.NoError(t, err)
+
+	cfg, err := driver.ParseDSN(dsn)
+	require.NoError(t, err)
+	require.True(t, cfg.MultiStatements)
+	require.True(t, cfg.ParseTime)
+	require.Equal(t, "users", cfg.DBName)
+
+	_, err = mysql.MigrationDSN("invalid")
+	require.Error(t, err)
+}
+
+func requireMigrationFile(t *testing.T, name string, migration io.ReadCloser) {
+	defer migration.Close()
+	data, err := ioutil.ReadAll(migration)
+	require.NoError(t, err)
+
+	file, err := ioutil.ReadFile(filepath.Join("migrations", name))
+	require.NoError(t, err, "migration %s is not in the directory", name)
+	require.Equal(t, string(file), string(data), "migration %s is out of date", name)
+}
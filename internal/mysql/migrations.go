@@ -0,0 +1,43 @@
+// Code generated by go run gen_migrations.go; DO NOT EDIT.
+
+package mysql
+
+// migrationFiles are the files of the migrations directory by name.
+var migrationFiles = map[string]string{
+	"1609154182_add_table_user.down.sql":                     "DROP TABLE IF EXISTS `users`;\n",
+	"1609154182_add_table_user.up.sql":                       "CREATE TABLE IF NOT EXISTS `users` (\n    `id` varchar(50) NOT NULL,\n    `email` varchar(255) NOT NULL,\n    `password` varchar(255) NOT NULL,\n    `address` varchar(255) NOT NULL DEFAULT '',\n    `deleted_time` bigint(20) unsigned DEFAULT NULL,\n    `created_time` bigint(20) unsigned NOT NULL DEFAULT '0',\n    `updated_time` bigint(20) unsigned NOT NULL DEFAULT '0',\n    PRIMARY KEY (`id`),\n    KEY `email_idx` (`email`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;\n",
+	"1792368000_add_version_to_users.down.sql":               "ALTER TABLE `users` DROP COLUMN `version`;\n",
+	"1792368000_add_version_to_users.up.sql":                 "ALTER TABLE `users` ADD COLUMN `version` bigint(20) unsigned NOT NULL DEFAULT '1' AFTER `address`;\n",
+	"1792454400_add_status_to_users.down.sql":                "ALTER TABLE `users` DROP COLUMN `status`;\n",
+	"1792454400_add_status_to_users.up.sql":                  "ALTER TABLE `users` ADD COLUMN `status` varchar(20) NOT NULL DEFAULT 'active' AFTER `address`;\n",
+	"1792454401_add_table_user_status_changes.down.sql":      "DROP TABLE IF EXISTS `user_status_changes`;\n",
+	"1792454401_add_table_user_status_changes.up.sql":        "CREATE TABLE IF NOT EXISTS `user_status_changes` (\n    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,\n    `user_id` varchar(50) NOT NULL,\n    `from_status` varchar(20) NOT NULL,\n    `to_status` varchar(20) NOT NULL,\n    `reason` varchar(255) NOT NULL DEFAULT '',\n    `actor` varchar(255) NOT NULL DEFAULT '',\n    `created_time` bigint(20) unsigned NOT NULL DEFAULT '0',\n    PRIMARY KEY (`id`),\n    KEY `user_id_idx` (`user_id`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;\n",
+	"1792540800_add_tenant_to_users.down.sql":                "ALTER TABLE `users`\n    DROP KEY `tenant_active_email_idx`,\n    DROP KEY `tenant_email_idx`,\n    ADD KEY `email_idx` (`email`),\n    DROP COLUMN `active_email`,\n    DROP COLUMN `tenant_id`;\n",
+	"1792540800_add_tenant_to_users.up.sql":                  "ALTER TABLE `users`\n    ADD COLUMN `tenant_id` varchar(50) NOT NULL DEFAULT 'default' AFTER `id`,\n    ADD COLUMN `active_email` varchar(255) GENERATED ALWAYS AS (IF(`deleted_time` IS NULL, `email`, NULL)) VIRTUAL,\n    DROP KEY `email_idx`,\n    ADD KEY `tenant_email_idx` (`tenant_id`, `email`),\n    ADD UNIQUE KEY `tenant_active_email_idx` (`tenant_id`, `active_email`);\n",
+	"1792540801_add_tenant_to_user_status_changes.down.sql":  "ALTER TABLE `user_status_changes`\n    DROP KEY `tenant_user_id_idx`,\n    ADD KEY `user_id_idx` (`user_id`),\n    DROP COLUMN `tenant_id`;\n",
+	"1792540801_add_tenant_to_user_status_changes.up.sql":    "ALTER TABLE `user_status_changes`\n    ADD COLUMN `tenant_id` varchar(50) NOT NULL DEFAULT 'default' AFTER `id`,\n    DROP KEY `user_id_idx`,\n    ADD KEY `tenant_user_id_idx` (`tenant_id`, `user_id`);\n",
+	"1792627200_add_table_email_changes.down.sql":            "DROP TABLE IF EXISTS `email_changes`;\n",
+	"1792627200_add_table_email_changes.up.sql":              "CREATE TABLE IF NOT EXISTS `email_changes` (\n    `id` varchar(50) NOT NULL,\n    `tenant_id` varchar(50) NOT NULL DEFAULT 'default',\n    `user_id` varchar(50) NOT NULL,\n    `old_email` varchar(255) NOT NULL,\n    `new_email` varchar(255) NOT NULL,\n    `confirm_token_hash` char(64) NOT NULL,\n    `revert_token_hash` char(64) NOT NULL,\n    `confirm_expires_time` bigint(20) unsigned NOT NULL DEFAULT '0',\n    `revert_expires_time` bigint(20) unsigned NOT NULL DEFAULT '0',\n    `confirmed_time` bigint(20) unsigned DEFAULT NULL,\n    `reverted_time` bigint(20) unsigned DEFAULT NULL,\n    `created_time` bigint(20) unsigned NOT NULL DEFAULT '0',\n    PRIMARY KEY (`id`),\n    UNIQUE KEY `confirm_token_hash_idx` (`confirm_token_hash`),\n    UNIQUE KEY `revert_token_hash_idx` (`revert_token_hash`),\n    KEY `tenant_user_id_idx` (`tenant_id`, `user_id`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;\n",
+	"1792713600_add_username_to_users.down.sql":              "ALTER TABLE `users`\n    DROP KEY `tenant_active_username_idx`,\n    DROP COLUMN `active_username`,\n    DROP COLUMN `username`;\n",
+	"1792713600_add_username_to_users.up.sql":                "ALTER TABLE `users`\n    ADD COLUMN `username` varchar(30) DEFAULT NULL AFTER `email`,\n    ADD COLUMN `active_username` varchar(30) GENERATED ALWAYS AS (IF(`deleted_time` IS NULL, LOWER(`username`), NULL)) VIRTUAL,\n    ADD UNIQUE KEY `tenant_active_username_idx` (`tenant_id`, `active_username`);\n",
+	"1792800000_add_table_user_preferences.down.sql":         "DROP TABLE IF EXISTS `user_preferences`;\n",
+	"1792800000_add_table_user_preferences.up.sql":           "CREATE TABLE IF NOT EXISTS `user_preferences` (\n    `tenant_id` varchar(50) NOT NULL DEFAULT 'default',\n    `user_id` varchar(50) NOT NULL,\n    `preferences` text NOT NULL,\n    `updated_time` bigint(20) unsigned NOT NULL DEFAULT '0',\n    PRIMARY KEY (`tenant_id`, `user_id`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;\n",
+	"1792886400_add_table_user_history.down.sql":             "DROP TABLE IF EXISTS `user_history`;\n",
+	"1792886400_add_table_user_history.up.sql":               "CREATE TABLE IF NOT EXISTS `user_history` (\n    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,\n    `tenant_id` varchar(50) NOT NULL DEFAULT 'default',\n    `user_id` varchar(50) NOT NULL,\n    `action` varchar(20) NOT NULL,\n    `actor` varchar(50) NOT NULL DEFAULT '',\n    `changes` text NOT NULL,\n    `created_time` bigint(20) unsigned NOT NULL DEFAULT '0',\n    PRIMARY KEY (`id`),\n    KEY `tenant_user_id_idx` (`tenant_id`, `user_id`, `id`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;\n",
+	"1792972800_add_list_indexes_to_users.down.sql":          "ALTER TABLE `users`\n    DROP KEY `tenant_status_created_time_idx`,\n    DROP KEY `tenant_created_time_idx`;\n",
+	"1792972800_add_list_indexes_to_users.up.sql":            "ALTER TABLE `users`\n    ADD KEY `tenant_created_time_idx` (`tenant_id`, `created_time`, `id`),\n    ADD KEY `tenant_status_created_time_idx` (`tenant_id`, `status`, `created_time`, `id`);\n",
+	"1793059200_add_search_index_to_users.down.sql":          "ALTER TABLE `users` DROP KEY `search_idx`;\n",
+	"1793059200_add_search_index_to_users.up.sql":            "ALTER TABLE `users` ADD FULLTEXT KEY `search_idx` (`email`, `username`, `address`);\n",
+	"1793145600_add_table_user_logins.down.sql":              "DROP TABLE IF EXISTS `user_logins`;\n",
+	"1793145600_add_table_user_logins.up.sql":                "CREATE TABLE IF NOT EXISTS `user_logins` (\n    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,\n    `tenant_id` varchar(50) NOT NULL DEFAULT 'default',\n    `user_id` varchar(50) NOT NULL,\n    `created_time` bigint(20) unsigned NOT NULL DEFAULT '0',\n    PRIMARY KEY (`id`),\n    KEY `tenant_created_time_idx` (`tenant_id`, `created_time`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;\n",
+	"1793232000_add_deleted_time_index_to_users.down.sql":    "ALTER TABLE `users`\n    DROP KEY `tenant_deleted_time_idx`;\n",
+	"1793232000_add_deleted_time_index_to_users.up.sql":      "ALTER TABLE `users`\n    ADD KEY `tenant_deleted_time_idx` (`tenant_id`, `deleted_time`);\n",
+	"1793404800_add_email_index_to_users.down.sql":           "-- The columns are only narrowed back once no user is left encrypted, as the encrypted values would not be read back.\nDROP PROCEDURE IF EXISTS `users_require_decrypted`;\nCREATE PROCEDURE `users_require_decrypted`()\nBEGIN\n    IF EXISTS (SELECT 1 FROM `users` WHERE `email_index` IS NOT NULL OR `email` LIKE 'enc:%' OR `address` LIKE 'enc:%'\n        OR CHAR_LENGTH(`email`) > 255 OR CHAR_LENGTH(`address`) > 255) THEN\n        SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'users are encrypted or too long to narrow their email and address back';\n    END IF;\nEND;\nCALL `users_require_decrypted`();\nDROP PROCEDURE `users_require_decrypted`;\nALTER TABLE `users`\n    DROP KEY `tenant_email_index_idx`,\n    DROP KEY `tenant_email_idx`,\n    MODIFY COLUMN `active_email` varchar(255) GENERATED ALWAYS AS (IF(`deleted_time` IS NULL, `email`, NULL)) VIRTUAL,\n    DROP COLUMN `email_index`,\n    MODIFY COLUMN `email` varchar(255) NOT NULL,\n    MODIFY COLUMN `address` varchar(255) NOT NULL DEFAULT '',\n    ADD KEY `tenant_email_idx` (`tenant_id`, `email`);\n",
+	"1793404800_add_email_index_to_users.up.sql":             "ALTER TABLE `users`\n    MODIFY COLUMN `email` varchar(1536) NOT NULL,\n    MODIFY COLUMN `address` varchar(1536) NOT NULL DEFAULT '',\n    ADD COLUMN `email_index` char(64) DEFAULT NULL AFTER `email`,\n    MODIFY COLUMN `active_email` varchar(255) GENERATED ALWAYS AS (IF(`deleted_time` IS NULL, COALESCE(`email_index`, `email`), NULL)) VIRTUAL,\n    DROP KEY `tenant_email_idx`,\n    ADD KEY `tenant_email_idx` (`tenant_id`, `email`(191)),\n    ADD KEY `tenant_email_index_idx` (`tenant_id`, `email_index`);\n",
+	"1793404801_widen_emails_of_email_changes.down.sql":      "ALTER TABLE `email_changes`\n    MODIFY COLUMN `old_email` varchar(255) NOT NULL,\n    MODIFY COLUMN `new_email` varchar(255) NOT NULL;\n",
+	"1793404801_widen_emails_of_email_changes.up.sql":        "ALTER TABLE `email_changes`\n    MODIFY COLUMN `old_email` varchar(1536) NOT NULL,\n    MODIFY COLUMN `new_email` varchar(1536) NOT NULL;\n",
+	"1793491200_add_user_index_to_user_logins.down.sql":      "ALTER TABLE `user_logins` DROP KEY `tenant_user_id_idx`;\n",
+	"1793491200_add_user_index_to_user_logins.up.sql":        "ALTER TABLE `user_logins` ADD KEY `tenant_user_id_idx` (`tenant_id`, `user_id`);\n",
+	"1793577600_add_username_search_index_to_users.down.sql": "ALTER TABLE `users` DROP KEY `search_username_idx`;\n",
+	"1793577600_add_username_search_index_to_users.up.sql":   "ALTER TABLE `users` ADD FULLTEXT KEY `search_username_idx` (`username`);\n",
+}
@@ -0,0 +1 @@
+ALTER TABLE `users` ADD COLUMN `version` bigint(20) unsigned NOT NULL DEFAULT '1' AFTER `address`;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS `user_status_changes` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `user_id` varchar(50) NOT NULL,
+    `from_status` varchar(20) NOT NULL,
+    `to_status` varchar(20) NOT NULL,
+    `reason` varchar(255) NOT NULL DEFAULT '',
+    `actor` varchar(255) NOT NULL DEFAULT '',
+    `created_time` bigint(20) unsigned NOT NULL DEFAULT '0',
+    PRIMARY KEY (`id`),
+    KEY `user_id_idx` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
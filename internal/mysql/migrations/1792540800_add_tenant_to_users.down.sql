@@ -0,0 +1,6 @@
+ALTER TABLE `users`
+    DROP KEY `tenant_active_email_idx`,
+    DROP KEY `tenant_email_idx`,
+    ADD KEY `email_idx` (`email`),
+    DROP COLUMN `active_email`,
+    DROP COLUMN `tenant_id`;
@@ -0,0 +1,6 @@
+ALTER TABLE `users`
+    ADD COLUMN `tenant_id` varchar(50) NOT NULL DEFAULT 'default' AFTER `id`,
+    ADD COLUMN `active_email` varchar(255) GENERATED ALWAYS AS (IF(`deleted_time` IS NULL, `email`, NULL)) VIRTUAL,
+    DROP KEY `email_idx`,
+    ADD KEY `tenant_email_idx` (`tenant_id`, `email`),
+    ADD UNIQUE KEY `tenant_active_email_idx` (`tenant_id`, `active_email`);
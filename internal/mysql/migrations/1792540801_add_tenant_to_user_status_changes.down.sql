@@ -0,0 +1,4 @@
+ALTER TABLE `user_status_changes`
+    DROP KEY `tenant_user_id_idx`,
+    ADD KEY `user_id_idx` (`user_id`),
+    DROP COLUMN `tenant_id`;
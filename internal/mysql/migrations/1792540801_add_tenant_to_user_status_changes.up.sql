@@ -0,0 +1,4 @@
+ALTER TABLE `user_status_changes`
+    ADD COLUMN `tenant_id` varchar(50) NOT NULL DEFAULT 'default' AFTER `id`,
+    DROP KEY `user_id_idx`,
+    ADD KEY `tenant_user_id_idx` (`tenant_id`, `user_id`);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS `email_changes` (
+    `id` varchar(50) NOT NULL,
+    `tenant_id` varchar(50) NOT NULL DEFAULT 'default',
+    `user_id` varchar(50) NOT NULL,
+    `old_email` varchar(255) NOT NULL,
+    `new_email` varchar(255) NOT NULL,
+    `confirm_token_hash` char(64) NOT NULL,
+    `revert_token_hash` char(64) NOT NULL,
+    `confirm_expires_time` bigint(20) unsigned NOT NULL DEFAULT '0',
+    `revert_expires_time` bigint(20) unsigned NOT NULL DEFAULT '0',
+    `confirmed_time` bigint(20) unsigned DEFAULT NULL,
+    `reverted_time` bigint(20) unsigned DEFAULT NULL,
+    `created_time` bigint(20) unsigned NOT NULL DEFAULT '0',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `confirm_token_hash_idx` (`confirm_token_hash`),
+    UNIQUE KEY `revert_token_hash_idx` (`revert_token_hash`),
+    KEY `tenant_user_id_idx` (`tenant_id`, `user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,4 @@
+ALTER TABLE `users`
+    DROP KEY `tenant_active_username_idx`,
+    DROP COLUMN `active_username`,
+    DROP COLUMN `username`;
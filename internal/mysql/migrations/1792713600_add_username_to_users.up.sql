@@ -0,0 +1,4 @@
+ALTER TABLE `users`
+    ADD COLUMN `username` varchar(30) DEFAULT NULL AFTER `email`,
+    ADD COLUMN `active_username` varchar(30) GENERATED ALWAYS AS (IF(`deleted_time` IS NULL, LOWER(`username`), NULL)) VIRTUAL,
+    ADD UNIQUE KEY `tenant_active_username_idx` (`tenant_id`, `active_username`);
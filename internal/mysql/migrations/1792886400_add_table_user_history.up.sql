@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS `user_history` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `tenant_id` varchar(50) NOT NULL DEFAULT 'default',
+    `user_id` varchar(50) NOT NULL,
+    `action` varchar(20) NOT NULL,
+    `actor` varchar(50) NOT NULL DEFAULT '',
+    `changes` text NOT NULL,
+    `created_time` bigint(20) unsigned NOT NULL DEFAULT '0',
+    PRIMARY KEY (`id`),
+    KEY `tenant_user_id_idx` (`tenant_id`, `user_id`, `id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
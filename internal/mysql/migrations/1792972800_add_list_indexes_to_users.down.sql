@@ -0,0 +1,3 @@
+ALTER TABLE `users`
+    DROP KEY `tenant_status_created_time_idx`,
+    DROP KEY `tenant_created_time_idx`;
@@ -0,0 +1,3 @@
+ALTER TABLE `users`
+    ADD KEY `tenant_created_time_idx` (`tenant_id`, `created_time`, `id`),
+    ADD KEY `tenant_status_created_time_idx` (`tenant_id`, `status`, `created_time`, `id`);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS `user_logins` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `tenant_id` varchar(50) NOT NULL DEFAULT 'default',
+    `user_id` varchar(50) NOT NULL,
+    `created_time` bigint(20) unsigned NOT NULL DEFAULT '0',
+    PRIMARY KEY (`id`),
+    KEY `tenant_created_time_idx` (`tenant_id`, `created_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
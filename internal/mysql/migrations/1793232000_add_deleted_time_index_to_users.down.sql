@@ -0,0 +1,2 @@
+ALTER TABLE `users`
+    DROP KEY `tenant_deleted_time_idx`;
@@ -0,0 +1,2 @@
+ALTER TABLE `users`
+    ADD KEY `tenant_deleted_time_idx` (`tenant_id`, `deleted_time`);
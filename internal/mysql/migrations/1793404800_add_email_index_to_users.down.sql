@@ -0,0 +1,19 @@
+-- The columns are only narrowed back once no user is left encrypted, as the encrypted values would not be read back.
+DROP PROCEDURE IF EXISTS `users_require_decrypted`;
+CREATE PROCEDURE `users_require_decrypted`()
+BEGIN
+    IF EXISTS (SELECT 1 FROM `users` WHERE `email_index` IS NOT NULL OR `email` LIKE 'enc:%' OR `address` LIKE 'enc:%'
+        OR CHAR_LENGTH(`email`) > 255 OR CHAR_LENGTH(`address`) > 255) THEN
+        SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'users are encrypted or too long to narrow their email and address back';
+    END IF;
+END;
+CALL `users_require_decrypted`();
+DROP PROCEDURE `users_require_decrypted`;
+ALTER TABLE `users`
+    DROP KEY `tenant_email_index_idx`,
+    DROP KEY `tenant_email_idx`,
+    MODIFY COLUMN `active_email` varchar(255) GENERATED ALWAYS AS (IF(`deleted_time` IS NULL, `email`, NULL)) VIRTUAL,
+    DROP COLUMN `email_index`,
+    MODIFY COLUMN `email` varchar(255) NOT NULL,
+    MODIFY COLUMN `address` varchar(255) NOT NULL DEFAULT '',
+    ADD KEY `tenant_email_idx` (`tenant_id`, `email`);
@@ -0,0 +1,8 @@
+ALTER TABLE `users`
+    MODIFY COLUMN `email` varchar(1536) NOT NULL,
+    MODIFY COLUMN `address` varchar(1536) NOT NULL DEFAULT '',
+    ADD COLUMN `email_index` char(64) DEFAULT NULL AFTER `email`,
+    MODIFY COLUMN `active_email` varchar(255) GENERATED ALWAYS AS (IF(`deleted_time` IS NULL, COALESCE(`email_index`, `email`), NULL)) VIRTUAL,
+    DROP KEY `tenant_email_idx`,
+    ADD KEY `tenant_email_idx` (`tenant_id`, `email`(191)),
+    ADD KEY `tenant_email_index_idx` (`tenant_id`, `email_index`);
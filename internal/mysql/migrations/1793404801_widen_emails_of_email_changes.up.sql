@@ -0,0 +1,3 @@
+ALTER TABLE `email_changes`
+    MODIFY COLUMN `old_email` varchar(1536) NOT NULL,
+    MODIFY COLUMN `new_email` varchar(1536) NOT NULL;
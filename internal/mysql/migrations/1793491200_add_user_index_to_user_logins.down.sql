@@ -0,0 +1 @@
+ALTER TABLE `user_logins` DROP KEY `tenant_user_id_idx`;
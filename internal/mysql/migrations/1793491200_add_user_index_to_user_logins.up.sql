@@ -0,0 +1 @@
+ALTER TABLE `user_logins` ADD KEY `tenant_user_id_idx` (`tenant_id`, `user_id`);
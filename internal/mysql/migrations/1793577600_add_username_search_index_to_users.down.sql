@@ -0,0 +1 @@
+ALTER TABLE `users` DROP KEY `search_username_idx`;
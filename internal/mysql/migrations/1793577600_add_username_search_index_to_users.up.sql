@@ -0,0 +1 @@
+ALTER TABLE `users` ADD FULLTEXT KEY `search_username_idx` (`username`);
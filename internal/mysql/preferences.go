@@ -0,0 +1,54 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/arnaz06/users"
+)
+
+type preferencesRepo struct {
+	db *sql.DB
+}
+
+// NewPreferencesRepository is constructor for preferences repository.
+// Every query is scoped by the tenant carried in the context.
+func NewPreferencesRepository(db *sql.DB) users.PreferencesRepository {
+	return preferencesRepo{
+		db: db,
+	}
+}
+
+func (r preferencesRepo) Get(ctx context.Context, userID string) (users.Preferences, error) {
+	var data string
+	query := `SELECT preferences FROM user_preferences WHERE tenant_id=? AND user_id=?`
+	err := r.db.QueryRowContext(ctx, query, users.TenantFromContext(ctx), userID).Scan(&data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return users.Preferences{}, users.ErrNotFound
+		}
+		return users.Preferences{}, err
+	}
+
+	// The saved preferences are read over the defaults, for the keys added since they were saved.
+	res := users.DefaultPreferences()
+	err = json.Unmarshal([]byte(data), &res)
+	if err != nil {
+		return users.Preferences{}, err
+	}
+
+	return res, nil
+}
+
+func (r preferencesRepo) Save(ctx context.Context, userID string, preferences users.Preferences) error {
+	data, err := json.Marshal(preferences)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT user_preferences SET tenant_id=?, user_id=?, preferences=?, updated_time=? ON DUPLICATE KEY UPDATE preferences=VALUES(preferences), updated_time=VALUES(updated_time)`
+	_, err = r.db.ExecContext(ctx, query, users.TenantFromContext(ctx), userID, string(data), time.Now().Unix())
+	return err
+}
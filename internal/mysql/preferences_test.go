@@ -0,0 +1,66 @@
+package mysql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/mysql"
+)
+
+type preferencesSuite struct {
+	mysqlSuite
+}
+
+func TestPreferencesSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipped for short testing")
+	}
+	suite.Run(t, new(preferencesSuite))
+}
+
+func (s *preferencesSuite) SetupTest() {
+	_, err := s.db.Exec("TRUNCATE user_preferences")
+	require.NoError(s.T(), err)
+}
+
+func (s *preferencesSuite) TestSaveAndGet() {
+	repo := mysql.NewPreferencesRepository(s.db)
+	ctx := context.Background()
+
+	_, err := repo.Get(ctx, "123")
+	require.Equal(s.T(), users.ErrNotFound, err)
+
+	preferences := users.DefaultPreferences()
+	preferences.Theme = users.ThemeDark
+	require.NoError(s.T(), repo.Save(ctx, "123", preferences))
+
+	res, err := repo.Get(ctx, "123")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), preferences, res)
+
+	preferences.Locale = "id-ID"
+	require.NoError(s.T(), repo.Save(ctx, "123", preferences))
+
+	res, err = repo.Get(ctx, "123")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), preferences, res)
+
+	_, err = repo.Get(users.WithTenant(ctx, "other"), "123")
+	require.Equal(s.T(), users.ErrNotFound, err)
+}
+
+func (s *preferencesSuite) TestGetWithMissingKeys() {
+	_, err := s.db.Exec(`INSERT user_preferences SET user_id=?, preferences=?`, "123", `{"theme":"light"}`)
+	require.NoError(s.T(), err)
+
+	expected := users.DefaultPreferences()
+	expected.Theme = users.ThemeLight
+
+	res, err := mysql.NewPreferencesRepository(s.db).Get(context.Background(), "123")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), expected, res)
+}
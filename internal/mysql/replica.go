@@ -0,0 +1,77 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ReplicaSet spreads the reads over the replicas of the database in turn, skipping the ones failing their health check.
+// It is safe for concurrent use.
+type ReplicaSet struct {
+	replicas []*sql.DB
+	healthy  []int32
+	next     uint32
+}
+
+// NewReplicaSet creates the set of the replicas, which are all deemed healthy until checked.
+func NewReplicaSet(replicas ...*sql.DB) *ReplicaSet {
+	healthy := make([]int32, len(replicas))
+	for i := range healthy {
+		healthy[i] = 1
+	}
+	return &ReplicaSet{
+		replicas: replicas,
+		healthy:  healthy,
+	}
+}
+
+// Next returns the next healthy replica in turn, or nil if none is healthy.
+func (s *ReplicaSet) Next() *sql.DB {
+	n := uint32(len(s.replicas))
+	for i := uint32(0); i < n; i++ {
+		j := (atomic.AddUint32(&s.next, 1) - 1) % n
+		if atomic.LoadInt32(&s.healthy[j]) == 1 {
+			return s.replicas[j]
+		}
+	}
+	return nil
+}
+
+// Check pings every replica, and marks each one healthy or not.
+func (s *ReplicaSet) Check(ctx context.Context) {
+	for i, db := range s.replicas {
+		healthy := int32(1)
+		if err := db.PingContext(ctx); err != nil {
+			healthy = 0
+		}
+
+		if previous := atomic.SwapInt32(&s.healthy[i], healthy); previous != healthy {
+			if healthy == 1 {
+				log.Infof("MySQL replica %d is healthy again", i)
+			} else {
+				log.Warnf("MySQL replica %d is unhealthy, its reads go to the other replicas", i)
+			}
+		}
+	}
+}
+
+// Watch checks the replicas every interval, each check timing out after the interval, until ctx is done.
+func (s *ReplicaSet) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, interval)
+			s.Check(checkCtx)
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package mysql_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users/internal/mysql"
+)
+
+// fakeDriver opens connections to the replicas named by their DSN, failing for the ones which are down.
+type fakeDriver struct {
+	mu   sync.Mutex
+	down map[string]bool
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.down[name] {
+		return nil, errors.New("connection refused")
+	}
+	return fakeConn{}, nil
+}
+
+func (d *fakeDriver) setDown(name string, down bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.down[name] = down
+}
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+var replicaDriver = &fakeDriver{down: map[string]bool{}}
+
+func init() {
+	sql.Register("fake-replica", replicaDriver)
+}
+
+func openReplica(t *testing.T, name string) *sql.DB {
+	db, err := sql.Open("fake-replica", name)
+	require.NoError(t, err)
+	// No connection is kept, so every ping opens a new one.
+	db.SetMaxIdleConns(0)
+	return db
+}
+
+func TestReplicaSet(t *testing.T) {
+	first, second, third := openReplica(t, "first"), openReplica(t, "second"), openReplica(t, "third")
+	replicas := mysql.NewReplicaSet(first, second, third)
+
+	t.Run("with every replica in turn", func(t *testing.T) {
+		require.Equal(t, []*sql.DB{first, second, third, first}, []*sql.DB{replicas.Next(), replicas.Next(), replicas.Next(), replicas.Next()})
+	})
+
+	t.Run("with unhealthy replica skipped", func(t *testing.T) {
+		replicaDriver.setDown("second", true)
+		replicas.Check(context.Background())
+		require.Equal(t, []*sql.DB{third, first, third}, []*sql.DB{replicas.Next(), replicas.Next(), replicas.Next()})
+	})
+
+	t.Run("with no healthy replica", func(t *testing.T) {
+		replicaDriver.setDown("first", true)
+		replicaDriver.setDown("third", true)
+		replicas.Check(context.Background())
+		require.Nil(t, replicas.Next())
+	})
+
+	t.Run("with replica healthy again", func(t *testing.T) {
+		replicaDriver.setDown("second", false)
+		replicas.Check(context.Background())
+		require.Equal(t, []*sql.DB{second, second}, []*sql.DB{replicas.Next(), replicas.Next()})
+	})
+}
@@ -0,0 +1,263 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/keyring"
+)
+
+// KeyRotator encrypts again the fields encrypted with a former key, or stored before their encryption was enabled,
+// with the current key. Only the data keys of the values already encrypted are encrypted again.
+type KeyRotator struct {
+	db  *sql.DB
+	enc *Encryption
+	// batchSize is the number of rows read at once, and pause the wait between the batches,
+	// so that the rotation runs along the other queries of the database.
+	batchSize int
+	pause     time.Duration
+}
+
+// NewKeyRotator is constructor for the rotation of the keys of the fields encrypted at rest.
+func NewKeyRotator(db *sql.DB, enc *Encryption, batchSize int, pause time.Duration) *KeyRotator {
+	return &KeyRotator{
+		db:        db,
+		enc:       enc,
+		batchSize: batchSize,
+		pause:     pause,
+	}
+}
+
+// Rotate rotates the keys of the users of every tenant, then of their history and email changes, and returns the
+// number of rows changed. The rows are changed one at a time, and left as they are if written meanwhile, as they are
+// then written with the current key. It can be run again, to resume a rotation which was stopped.
+func (k *KeyRotator) Rotate(ctx context.Context) (int64, error) {
+	tables := []struct {
+		name   string
+		rotate func(ctx context.Context, afterID string) (string, int64, error)
+	}{
+		{name: "users", rotate: k.rotateUsers},
+		{name: "user_history", rotate: k.rotateHistory},
+		{name: "email_changes", rotate: k.rotateEmailChanges},
+	}
+
+	var total int64
+	for _, table := range tables {
+		var rotated int64
+		afterID := ""
+		for {
+			lastID, changed, err := table.rotate(ctx, afterID)
+			if err != nil {
+				return total + rotated, err
+			}
+			rotated += changed
+			if lastID == "" {
+				break
+			}
+			afterID = lastID
+
+			select {
+			case <-ctx.Done():
+				return total + rotated, ctx.Err()
+			case <-time.After(k.pause):
+			}
+		}
+		log.Infof("Rotated the keys of %d rows of %s", rotated, table.name)
+		total += rotated
+	}
+	return total, nil
+}
+
+// rotate rotates the key of the value of the field, if it is encrypted.
+func (k *KeyRotator) rotate(field, id, value string) (string, bool, error) {
+	if !k.enc.encrypts(field) {
+		return value, false, nil
+	}
+	return k.enc.keys.Rotate(value, aad(field, id))
+}
+
+// rotateUsers rotates the keys of the batch of users after the ID, and returns the last ID of the batch,
+// empty once there are no more users.
+func (k *KeyRotator) rotateUsers(ctx context.Context, afterID string) (string, int64, error) {
+	type row struct {
+		id, email, address string
+	}
+	rows := []row{}
+	err := k.query(ctx, `SELECT id, email, address FROM users WHERE id>? ORDER BY id LIMIT ?`, afterID, func(scan func(dest ...interface{}) error) error {
+		var r row
+		if err := scan(&r.id, &r.email, &r.address); err != nil {
+			return err
+		}
+		rows = append(rows, r)
+		return nil
+	})
+	if err != nil || len(rows) == 0 {
+		return "", 0, err
+	}
+
+	var changed int64
+	for _, r := range rows {
+		email, emailChanged, err := k.rotate("email", r.id, r.email)
+		if err != nil {
+			return "", changed, err
+		}
+		address, addressChanged, err := k.rotate("address", r.id, r.address)
+		if err != nil {
+			return "", changed, err
+		}
+		if !emailChanged && !addressChanged {
+			continue
+		}
+
+		// The emails stored before their encryption was enabled are indexed along.
+		query := `UPDATE users SET email=?, address=? WHERE id=? AND email=? AND address=?`
+		args := []interface{}{email, address, r.id, r.email, r.address}
+		if emailChanged && !keyring.IsEncrypted(r.email) {
+			query = `UPDATE users SET email=?, email_index=?, address=? WHERE id=? AND email=? AND address=?`
+			args = []interface{}{email, k.enc.emailIndex(r.email), address, r.id, r.email, r.address}
+		}
+		res, err := k.db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return "", changed, mapEmailError(err, r.email)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return "", changed, err
+		}
+		changed += affected
+	}
+	return rows[len(rows)-1].id, changed, nil
+}
+
+// rotateHistory rotates the keys of the batch of history entries after the ID.
+func (k *KeyRotator) rotateHistory(ctx context.Context, afterID string) (string, int64, error) {
+	type row struct {
+		id      int64
+		userID  string
+		changes string
+	}
+	// The cursor of the history is its numeric ID.
+	after, _ := strconv.ParseInt(afterID, 10, 64)
+
+	rows := []row{}
+	err := k.query(ctx, `SELECT id, user_id, changes FROM user_history WHERE id>? ORDER BY id LIMIT ?`, after, func(scan func(dest ...interface{}) error) error {
+		var r row
+		if err := scan(&r.id, &r.userID, &r.changes); err != nil {
+			return err
+		}
+		rows = append(rows, r)
+		return nil
+	})
+	if err != nil || len(rows) == 0 {
+		return "", 0, err
+	}
+
+	var changed int64
+	for _, r := range rows {
+		var changes map[string]users.FieldChange
+		if err := json.Unmarshal([]byte(r.changes), &changes); err != nil {
+			return "", changed, err
+		}
+
+		rotated := false
+		for field, change := range changes {
+			before, beforeChanged, err := k.rotate(field, r.userID, change.Before)
+			if err != nil {
+				return "", changed, err
+			}
+			afterValue, afterChanged, err := k.rotate(field, r.userID, change.After)
+			if err != nil {
+				return "", changed, err
+			}
+			if beforeChanged || afterChanged {
+				changes[field] = users.FieldChange{Before: before, After: afterValue}
+				rotated = true
+			}
+		}
+		if !rotated {
+			continue
+		}
+
+		data, err := json.Marshal(changes)
+		if err != nil {
+			return "", changed, err
+		}
+		res, err := k.db.ExecContext(ctx, `UPDATE user_history SET changes=? WHERE id=? AND changes=?`, string(data), r.id, r.changes)
+		if err != nil {
+			return "", changed, err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return "", changed, err
+		}
+		changed += affected
+	}
+	return strconv.FormatInt(rows[len(rows)-1].id, 10), changed, nil
+}
+
+// rotateEmailChanges rotates the keys of the batch of email changes after the ID.
+func (k *KeyRotator) rotateEmailChanges(ctx context.Context, afterID string) (string, int64, error) {
+	type row struct {
+		id, oldEmail, newEmail string
+	}
+	rows := []row{}
+	err := k.query(ctx, `SELECT id, old_email, new_email FROM email_changes WHERE id>? ORDER BY id LIMIT ?`, afterID, func(scan func(dest ...interface{}) error) error {
+		var r row
+		if err := scan(&r.id, &r.oldEmail, &r.newEmail); err != nil {
+			return err
+		}
+		rows = append(rows, r)
+		return nil
+	})
+	if err != nil || len(rows) == 0 {
+		return "", 0, err
+	}
+
+	var changed int64
+	for _, r := range rows {
+		oldEmail, oldChanged, err := k.rotate("email", changeAAD(r.id, "old"), r.oldEmail)
+		if err != nil {
+			return "", changed, err
+		}
+		newEmail, newChanged, err := k.rotate("email", changeAAD(r.id, "new"), r.newEmail)
+		if err != nil {
+			return "", changed, err
+		}
+		if !oldChanged && !newChanged {
+			continue
+		}
+
+		res, err := k.db.ExecContext(ctx, `UPDATE email_changes SET old_email=?, new_email=? WHERE id=? AND old_email=? AND new_email=?`, oldEmail, newEmail, r.id, r.oldEmail, r.newEmail)
+		if err != nil {
+			return "", changed, err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return "", changed, err
+		}
+		changed += affected
+	}
+	return rows[len(rows)-1].id, changed, nil
+}
+
+// query runs the query of a batch after the ID, calling fn with the scan of each row.
+func (k *KeyRotator) query(ctx context.Context, query string, afterID interface{}, fn func(scan func(dest ...interface{}) error) error) error {
+	rows, err := k.db.QueryContext(ctx, query, afterID, k.batchSize)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows.Scan); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,80 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"unicode"
+
+	"github.com/arnaz06/users"
+)
+
+// The columns of the FULLTEXT indexes, which MATCH must name exactly.
+const (
+	searchColumns         = "email, username, address"
+	usernameSearchColumns = "username"
+)
+
+type searcher struct {
+	db      *sql.DB
+	columns string
+}
+
+// NewSearcher is constructor for the search of users over the FULLTEXT index of MySQL, used when no search index is set up.
+// Unlike the search index, it only matches the words starting with the words of the query.
+func NewSearcher(db *sql.DB) users.Searcher {
+	return searcher{
+		db:      db,
+		columns: searchColumns,
+	}
+}
+
+// NewEncryptedSearcher is constructor for the search of users whose fields may be encrypted at rest. The ciphertexts
+// can not be matched, so only the usernames are searched once a field is encrypted, and every field otherwise.
+func NewEncryptedSearcher(db *sql.DB, enc *Encryption) users.Searcher {
+	if enc.encrypts("email") || enc.encrypts("address") {
+		return searcher{
+			db:      db,
+			columns: usernameSearchColumns,
+		}
+	}
+	return NewSearcher(db)
+}
+
+// booleanQuery turns the query into a FULLTEXT query in boolean mode, requiring a word starting with each of its words.
+// Anything but letters and digits is dropped, so the operators of the boolean mode can not be injected.
+func booleanQuery(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = "+" + word + "*"
+	}
+	return strings.Join(words, " ")
+}
+
+func (s searcher) Search(ctx context.Context, query string, limit int) ([]string, error) {
+	against := booleanQuery(query)
+	if against == "" {
+		return []string{}, nil
+	}
+
+	q := `SELECT id FROM users WHERE tenant_id=? AND deleted_time IS NULL AND MATCH(` + s.columns + `) AGAINST(? IN BOOLEAN MODE)
+		ORDER BY MATCH(` + s.columns + `) AGAINST(? IN BOOLEAN MODE) DESC LIMIT ?`
+	rows, err := s.db.QueryContext(ctx, q, users.TenantFromContext(ctx), against, against, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+
+	return res, rows.Err()
+}
@@ -0,0 +1,72 @@
+package mysql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/mysql"
+)
+
+func (u *userSuite) TestSearch() {
+	u.seedUser(users.User{ID: "1", Email: "jhon@doe.com", Username: "jhon.doe", Address: "Jalan Sudirman, Jakarta", Status: users.StatusActive})
+	u.seedUser(users.User{ID: "2", Email: "jane@smith.com", Address: "Baker Street, London", Status: users.StatusActive})
+	u.seedDeletedUser(users.User{ID: "3", Email: "jhon@acme.com", Status: users.StatusActive}, time.Now())
+	searcher := mysql.NewSearcher(u.db)
+
+	tests := []struct {
+		testName    string
+		query       string
+		expectedIDs []string
+	}{
+		{
+			testName:    "by the start of a word",
+			query:       "jak",
+			expectedIDs: []string{"1"},
+		},
+		{
+			testName:    "with every word required",
+			query:       "baker lond",
+			expectedIDs: []string{"2"},
+		},
+		{
+			testName:    "with boolean operators",
+			query:       `"smith -(`,
+			expectedIDs: []string{"2"},
+		},
+		{
+			testName:    "with no word",
+			query:       "@@",
+			expectedIDs: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		u.T().Run(test.testName, func(t *testing.T) {
+			ids, err := searcher.Search(context.Background(), test.query, 10)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedIDs, ids)
+		})
+	}
+}
+
+func (u *userSuite) TestEncryptedSearch() {
+	repo := mysql.NewEncryptedUserRepository(u.db, nil, newEncryption(u.T(), 1))
+	_, err := repo.Create(context.Background(), users.User{ID: "1", Email: "jhon@doe.com", Username: "jhon.doe", Address: "Jakarta", Password: "hash", Status: users.StatusActive})
+	require.NoError(u.T(), err)
+	_, err = repo.Create(context.Background(), users.User{ID: "2", Email: "jane@doe.com", Address: "Jakarta", Password: "hash", Status: users.StatusActive})
+	require.NoError(u.T(), err)
+	searcher := mysql.NewEncryptedSearcher(u.db, newEncryption(u.T(), 1))
+
+	// Only the usernames are searched, as the encrypted emails and addresses can not be matched.
+	ids, err := searcher.Search(context.Background(), "jhon", 10)
+	require.NoError(u.T(), err)
+	require.Equal(u.T(), []string{"1"}, ids)
+
+	ids, err = searcher.Search(context.Background(), "jakarta", 10)
+	require.NoError(u.T(), err)
+	require.Equal(u.T(), []string{}, ids)
+}
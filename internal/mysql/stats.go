@@ -0,0 +1,118 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/arnaz06/users"
+)
+
+type statsRepo struct {
+	db *sql.DB
+}
+
+// NewStatsRepository is constructor for the statistics repository.
+func NewStatsRepository(db *sql.DB) users.StatsRepository {
+	return statsRepo{
+		db: db,
+	}
+}
+
+func (r statsRepo) RecordLogin(ctx context.Context, userID string, loginTime time.Time) error {
+	query := `INSERT user_logins SET tenant_id=?, user_id=?, created_time=?`
+	_, err := r.db.ExecContext(ctx, query, users.TenantFromContext(ctx), userID, loginTime.Unix())
+	return err
+}
+
+func (r statsRepo) ListLogins(ctx context.Context, userID string) ([]time.Time, error) {
+	query := `SELECT created_time FROM user_logins WHERE tenant_id=? AND user_id=? ORDER BY id`
+	rows, err := r.db.QueryContext(ctx, query, users.TenantFromContext(ctx), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []time.Time{}
+	for rows.Next() {
+		createdTime := int64(0)
+		if err := rows.Scan(&createdTime); err != nil {
+			return nil, err
+		}
+		res = append(res, time.Unix(createdTime, 0))
+	}
+
+	return res, rows.Err()
+}
+
+func (r statsRepo) CountByStatus(ctx context.Context) (map[users.Status]int64, error) {
+	query := `SELECT status, COUNT(*) FROM users WHERE tenant_id=? AND deleted_time IS NULL GROUP BY status`
+	rows, err := r.db.QueryContext(ctx, query, users.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := map[users.Status]int64{}
+	for rows.Next() {
+		var status users.Status
+		var count int64
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		res[status] = count
+	}
+
+	return res, rows.Err()
+}
+
+func (r statsRepo) CountSignups(ctx context.Context, bounds []time.Time) ([]int64, error) {
+	return r.countByPeriod(ctx, "users", "created_time", bounds)
+}
+
+func (r statsRepo) CountDeletions(ctx context.Context, bounds []time.Time) ([]int64, error) {
+	return r.countByPeriod(ctx, "users", "deleted_time", bounds)
+}
+
+func (r statsRepo) CountLogins(ctx context.Context, bounds []time.Time) ([]int64, error) {
+	return r.countByPeriod(ctx, "user_logins", "created_time", bounds)
+}
+
+// countByPeriod counts the rows of the table by the period their time column falls in, in one scan of the range.
+// INTERVAL gives the number of bounds not after the time, which is the period of the time counted from 1.
+func (r statsRepo) countByPeriod(ctx context.Context, table, column string, bounds []time.Time) ([]int64, error) {
+	periods := len(bounds) - 1
+	if periods < 1 {
+		return []int64{}, nil
+	}
+
+	args := make([]interface{}, 0, len(bounds)+2)
+	for _, bound := range bounds[:periods] {
+		args = append(args, bound.Unix())
+	}
+	args = append(args, users.TenantFromContext(ctx), bounds[0].Unix(), bounds[periods].Unix())
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", periods), ", ")
+	query := `SELECT INTERVAL(` + column + `, ` + placeholders + `) AS period, COUNT(*) FROM ` + table +
+		` WHERE tenant_id=? AND ` + column + `>=? AND ` + column + `<? GROUP BY period`
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]int64, periods)
+	for rows.Next() {
+		var period int
+		var count int64
+		if err := rows.Scan(&period, &count); err != nil {
+			return nil, err
+		}
+		if period >= 1 && period <= periods {
+			res[period-1] = count
+		}
+	}
+
+	return res, rows.Err()
+}
@@ -0,0 +1,97 @@
+package mysql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/mysql"
+)
+
+type statsSuite struct {
+	mysqlSuite
+}
+
+func TestStatsSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipped for short testing")
+	}
+	suite.Run(t, new(statsSuite))
+}
+
+func (s *statsSuite) SetupTest() {
+	_, err := s.db.Exec("TRUNCATE users")
+	require.NoError(s.T(), err)
+	_, err = s.db.Exec("TRUNCATE user_logins")
+	require.NoError(s.T(), err)
+}
+
+func (s *statsSuite) seedUser(tenantID string, status users.Status, createdTime time.Time, deletedTime *time.Time) string {
+	id := uuid.New().String()
+	var deleted interface{}
+	if deletedTime != nil {
+		deleted = deletedTime.Unix()
+	}
+	query := `INSERT users SET id=?, tenant_id=?, email=?, password='', address='', status=?, version=1, updated_time=?, created_time=?, deleted_time=?`
+	_, err := s.db.Exec(query, id, tenantID, id+"@doe.com", status, createdTime.Unix(), createdTime.Unix(), deleted)
+	require.NoError(s.T(), err)
+	return id
+}
+
+func (s *statsSuite) TestCountByStatus() {
+	day := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	s.seedUser(users.DefaultTenant, users.StatusActive, day, nil)
+	s.seedUser(users.DefaultTenant, users.StatusActive, day, nil)
+	s.seedUser(users.DefaultTenant, users.StatusLocked, day, nil)
+	s.seedUser(users.DefaultTenant, users.StatusActive, day, &day)
+	s.seedUser("acme", users.StatusActive, day, nil)
+
+	res, err := mysql.NewStatsRepository(s.db).CountByStatus(context.Background())
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), map[users.Status]int64{users.StatusActive: 2, users.StatusLocked: 1}, res)
+}
+
+func (s *statsSuite) TestCountByPeriod() {
+	day := func(d int) time.Time {
+		return time.Date(2021, 3, d, 0, 0, 0, 0, time.UTC)
+	}
+	deleted := day(2).Add(time.Hour)
+	s.seedUser(users.DefaultTenant, users.StatusActive, day(1), nil)
+	s.seedUser(users.DefaultTenant, users.StatusActive, day(1).Add(23*time.Hour), nil)
+	id := s.seedUser(users.DefaultTenant, users.StatusActive, day(3), &deleted)
+	s.seedUser(users.DefaultTenant, users.StatusActive, day(4), nil)
+	s.seedUser("acme", users.StatusActive, day(2), nil)
+
+	repo := mysql.NewStatsRepository(s.db)
+	ctx := context.Background()
+	require.NoError(s.T(), repo.RecordLogin(ctx, id, day(3).Add(time.Minute)))
+	require.NoError(s.T(), repo.RecordLogin(ctx, id, day(3).Add(time.Hour)))
+	require.NoError(s.T(), repo.RecordLogin(users.WithTenant(ctx, "acme"), id, day(3)))
+
+	bounds := []time.Time{day(1), day(2), day(3), day(4)}
+
+	signups, err := repo.CountSignups(ctx, bounds)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []int64{2, 0, 1}, signups)
+
+	deletions, err := repo.CountDeletions(ctx, bounds)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []int64{0, 1, 0}, deletions)
+
+	logins, err := repo.CountLogins(ctx, bounds)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []int64{0, 0, 2}, logins)
+
+	times, err := repo.ListLogins(ctx, id)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []time.Time{time.Unix(day(3).Add(time.Minute).Unix(), 0), time.Unix(day(3).Add(time.Hour).Unix(), 0)}, times)
+
+	acme, err := repo.CountSignups(users.WithTenant(ctx, "acme"), bounds[:2])
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []int64{0}, acme)
+}
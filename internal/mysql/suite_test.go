@@ -16,7 +16,7 @@ type mysqlSuite struct {
 }
 
 func (m *mysqlSuite) SetupSuite() {
-	dsnDB := "users:users-pass@tcp(localhost:3306)/users?parseTime=1&loc=Asia%2FJakarta&charset=utf8mb4&collation=utf8mb4_unicode_ci"
+	dsnDB := "users:users-pass@tcp(localhost:3306)/users?parseTime=1&loc=Asia%2FJakarta&charset=utf8mb4&collation=utf8mb4_unicode_ci&multiStatements=true"
 
 	db, err := sql.Open("mysql", dsnDB)
 	require.NoError(m.T(), err)
@@ -0,0 +1,49 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/arnaz06/users"
+)
+
+type unitOfWork struct {
+	db *sql.DB
+	// enc encrypts the fields of the users at rest, if any.
+	enc *Encryption
+}
+
+// NewUnitOfWork is constructor for the unit of work over the user repository.
+func NewUnitOfWork(db *sql.DB) users.UnitOfWork {
+	return unitOfWork{
+		db: db,
+	}
+}
+
+// NewEncryptedUnitOfWork is constructor for the unit of work over the user repository encrypting the fields
+// of the users at rest.
+func NewEncryptedUnitOfWork(db *sql.DB, enc *Encryption) users.UnitOfWork {
+	return unitOfWork{
+		db:  db,
+		enc: enc,
+	}
+}
+
+func (u unitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repo users.UserRepository) error) (err error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+		if err == nil {
+			users.MarkWritten(ctx)
+		}
+	}()
+
+	return fn(ctx, userRepo{db: u.db, tx: tx, enc: u.enc})
+}
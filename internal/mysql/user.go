@@ -3,34 +3,193 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"time"
 
+	driver "github.com/go-sql-driver/mysql"
 	"github.com/google/uuid"
 
 	"github.com/arnaz06/users"
 )
 
+const userColumns = `id, email, username, password, address, status, version, deleted_time, updated_time, created_time`
+
+// errDuplicateEntry is the MySQL error number of a unique key violation.
+const errDuplicateEntry = 1062
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// querier is the part of *sql.DB and *sql.Tx the queries outside of a write transaction are run with.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 type userRepo struct {
 	db *sql.DB
+	// tx is the transaction of the unit of work the repository is part of, if any.
+	tx *sql.Tx
+	// replicas serve the reads, if any.
+	replicas *ReplicaSet
+	// enc encrypts the fields at rest, if any.
+	enc *Encryption
 }
 
 // NewUserRepository is constructor for user repository.
+// Every query is scoped by the tenant carried in the context.
 func NewUserRepository(db *sql.DB) users.UserRepository {
 	return userRepo{
 		db: db,
 	}
 }
 
-func (r userRepo) Create(ctx context.Context, user users.User) (users.User, error) {
-	query := `INSERT users SET id=?, email=?, password=?, address=?, updated_time=?, created_time=?`
+// NewReplicatedUserRepository is constructor for user repository writing to the primary database, and reading
+// from its replicas but within a unit of work, or after a write made with the context of the read.
+// Every query is scoped by the tenant carried in the context.
+func NewReplicatedUserRepository(primary *sql.DB, replicas *ReplicaSet) users.UserRepository {
+	return userRepo{
+		db:       primary,
+		replicas: replicas,
+	}
+}
+
+// NewEncryptedUserRepository is constructor for user repository encrypting the fields of the users at rest,
+// and reading from the replicas unless they are nil.
+// Every query is scoped by the tenant carried in the context.
+func NewEncryptedUserRepository(primary *sql.DB, replicas *ReplicaSet, enc *Encryption) users.UserRepository {
+	return userRepo{
+		db:       primary,
+		replicas: replicas,
+		enc:      enc,
+	}
+}
+
+// conn returns the transaction of the unit of work, or else the database.
+func (r userRepo) conn() querier {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// reader returns the connection of the reads, which is the next healthy replica unless the reads must see
+// the latest writes, or none is healthy.
+func (r userRepo) reader(ctx context.Context) querier {
+	if r.tx != nil || r.replicas == nil || users.HasWritten(ctx) {
+		return r.conn()
+	}
+	if replica := r.replicas.Next(); replica != nil {
+		return replica
+	}
+	return r.db
+}
+
+// primary returns the repository reading from the primary database, for the reads made to complete a write.
+func (r userRepo) primary() userRepo {
+	r.replicas = nil
+	return r
+}
+
+// beginTx starts the transaction of a write, or joins the one of the unit of work.
+// The returned function ends a started transaction, committing it unless the write failed,
+// and leaves a joined one to be ended by the unit of work.
+func (r userRepo) beginTx(ctx context.Context) (*sql.Tx, func(err error) error, error) {
+	if r.tx != nil {
+		return r.tx, func(err error) error { return err }, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tx, func(err error) error {
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		users.MarkWritten(ctx)
+		return nil
+	}, nil
+}
+
+func (r userRepo) Create(ctx context.Context, user users.User) (res users.User, err error) {
+	tx, end, err := r.beginTx(ctx)
+	if err != nil {
+		return users.User{}, err
+	}
+	defer func() { err = end(err) }()
+
+	return insertUser(ctx, tx, r.enc, user)
+}
+
+func (r userRepo) CreateBatch(ctx context.Context, list []users.User, dryRun bool) (errs []error, err error) {
+	tx, end, err := r.beginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = end(err) }()
+
+	// A dry run is rolled back to a savepoint, so it is also undone within a unit of work.
+	if dryRun {
+		_, err = tx.ExecContext(ctx, `SAVEPOINT create_batch`)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_, rollbackErr := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT create_batch`)
+			if err == nil && rollbackErr != nil {
+				errs, err = nil, rollbackErr
+			}
+		}()
+	}
+
+	// A failed insert only rolls back its own statement, so the violations of the unique keys
+	// are reported per user while the rest of the batch goes on.
+	errs = make([]error, len(list))
+	for i, user := range list {
+		_, err = insertUser(ctx, tx, r.enc, user)
+		if err != nil {
+			if _, ok := err.(users.ConstraintError); !ok {
+				return nil, err
+			}
+			errs[i] = err
+			err = nil
+		}
+	}
+	return errs, nil
+}
+
+// insertUser inserts the user, and the history entry of its creation, in the transaction.
+func insertUser(ctx context.Context, tx *sql.Tx, enc *Encryption, user users.User) (users.User, error) {
+	query := `INSERT users SET id=?, tenant_id=?, email=?, email_index=?, username=?, password=?, address=?, status=?, version=?, updated_time=?, created_time=?`
 	now := time.Now()
 	user.CreatedTime = now
 	user.UpdatedTime = now
+	user.Version = 1
 	if user.ID == "" {
 		user.ID = uuid.New().String()
 	}
+	if user.Status == "" {
+		user.Status = users.StatusActive
+	}
 
-	_, err := r.db.ExecContext(ctx, query, user.ID, user.Email, user.Password, user.Address, user.UpdatedTime.Unix(), user.CreatedTime.Unix())
+	stored, err := enc.encryptUser(user)
+	if err != nil {
+		return users.User{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, query, user.ID, users.TenantFromContext(ctx), stored.Email, enc.emailIndex(user.Email), nullString(user.Username), user.Password, stored.Address, user.Status, user.Version, user.UpdatedTime.Unix(), user.CreatedTime.Unix())
+	if err != nil {
+		return users.User{}, mapUniqueError(err, user)
+	}
+
+	err = insertHistory(ctx, tx, enc, user.ID, users.HistoryCreate, users.DiffUsers(users.User{}, user), now)
 	if err != nil {
 		return users.User{}, err
 	}
@@ -38,20 +197,8 @@ func (r userRepo) Create(ctx context.Context, user users.User) (users.User, erro
 }
 
 func (r userRepo) Get(ctx context.Context, id string) (users.User, error) {
-	query := `SELECT id, email, password, address, updated_time, created_time FROM users WHERE id=? AND deleted_time IS NULL`
-	row := r.db.QueryRowContext(ctx, query, id)
-
-	var res users.User
-	updatedTime := int64(0)
-	createdTime := int64(0)
-	err := row.Scan(
-		&res.ID,
-		&res.Email,
-		&res.Password,
-		&res.Address,
-		&updatedTime,
-		&createdTime,
-	)
+	query := `SELECT ` + userColumns + ` FROM users WHERE tenant_id=? AND id=? AND deleted_time IS NULL`
+	res, err := scanUser(r.reader(ctx).QueryRowContext(ctx, query, users.TenantFromContext(ctx), id), r.enc)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return users.User{}, users.ErrNotFound
@@ -59,26 +206,27 @@ func (r userRepo) Get(ctx context.Context, id string) (users.User, error) {
 		return users.User{}, err
 	}
 
-	res.UpdatedTime = time.Unix(updatedTime, 0)
-	res.CreatedTime = time.Unix(createdTime, 0)
 	return res, nil
 }
 
 func (r userRepo) GetByEmail(ctx context.Context, email string) (users.User, error) {
-	query := `SELECT id, email, password, address, updated_time, created_time FROM users WHERE email=? AND deleted_time IS NULL`
-	row := r.db.QueryRowContext(ctx, query, email)
+	condition, args := r.enc.emailCondition(email)
+	query := `SELECT ` + userColumns + ` FROM users WHERE tenant_id=? AND ` + condition + ` AND deleted_time IS NULL`
+	args = append([]interface{}{users.TenantFromContext(ctx)}, args...)
+	res, err := scanUser(r.reader(ctx).QueryRowContext(ctx, query, args...), r.enc)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return users.User{}, users.ErrNotFound
+		}
+		return users.User{}, err
+	}
 
-	var res users.User
-	updatedTime := int64(0)
-	createdTime := int64(0)
-	err := row.Scan(
-		&res.ID,
-		&res.Email,
-		&res.Password,
-		&res.Address,
-		&updatedTime,
-		&createdTime,
-	)
+	return res, nil
+}
+
+func (r userRepo) GetByUsername(ctx context.Context, username string) (users.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE tenant_id=? AND active_username=? AND deleted_time IS NULL`
+	res, err := scanUser(r.reader(ctx).QueryRowContext(ctx, query, users.TenantFromContext(ctx), users.NormalizeUsername(username)), r.enc)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return users.User{}, users.ErrNotFound
@@ -86,16 +234,67 @@ func (r userRepo) GetByEmail(ctx context.Context, email string) (users.User, err
 		return users.User{}, err
 	}
 
-	res.UpdatedTime = time.Unix(updatedTime, 0)
-	res.CreatedTime = time.Unix(createdTime, 0)
 	return res, nil
 }
 
-func (r userRepo) Update(ctx context.Context, user users.User) error {
-	query := `UPDATE users SET email=?, password=?, address=?, updated_time=? WHERE id=? AND deleted_time IS NULL`
-	user.UpdatedTime = time.Now()
+func (r userRepo) Update(ctx context.Context, user users.User) (err error) {
+	tx, end, err := r.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = end(err) }()
+
+	tenantID := users.TenantFromContext(ctx)
 
-	res, err := r.db.ExecContext(ctx, query, user.Email, user.Password, user.Address, user.UpdatedTime.Unix(), user.ID)
+	// The user is read first for the history to record the values before the update.
+	before, err := scanUser(tx.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE tenant_id=? AND id=? AND deleted_time IS NULL FOR UPDATE`, tenantID, user.ID), r.enc)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return users.ErrNotFound
+		}
+		return err
+	}
+	if user.Version != 0 && user.Version != before.Version {
+		return users.ErrPreconditionFailed
+	}
+
+	after := before
+	after.Email = user.Email
+	after.Username = user.Username
+	after.Password = user.Password
+	after.Address = user.Address
+	after.UpdatedTime = time.Now()
+
+	stored, err := r.enc.encryptUser(after)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE users SET email=?, email_index=?, username=?, password=?, address=?, version=version+1, updated_time=? WHERE tenant_id=? AND id=?`
+	_, err = tx.ExecContext(ctx, query, stored.Email, r.enc.emailIndex(after.Email), nullString(after.Username), after.Password, stored.Address, after.UpdatedTime.Unix(), tenantID, user.ID)
+	if err != nil {
+		return mapUniqueError(err, user)
+	}
+
+	return insertHistory(ctx, tx, r.enc, user.ID, users.HistoryUpdate, users.DiffUsers(before, after), after.UpdatedTime)
+}
+
+func (r userRepo) Delete(ctx context.Context, id string, version int64) (err error) {
+	tx, end, err := r.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = end(err) }()
+
+	now := time.Now()
+	query := `UPDATE users SET deleted_time=?, version=version+1 WHERE tenant_id=? AND id=? AND deleted_time IS NULL`
+	args := []interface{}{now.Unix(), users.TenantFromContext(ctx), id}
+	if version != 0 {
+		query += ` AND version=?`
+		args = append(args, version)
+	}
+
+	res, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -106,15 +305,109 @@ func (r userRepo) Update(ctx context.Context, user users.User) error {
 	}
 
 	if affected != 1 {
+		return r.notAffectedError(ctx, id, version)
+	}
+
+	return insertHistory(ctx, tx, r.enc, id, users.HistoryDelete, map[string]users.FieldChange{}, now)
+}
+
+// notAffectedError tells apart a missing user from a version mismatch after a write affected no rows.
+func (r userRepo) notAffectedError(ctx context.Context, id string, version int64) error {
+	if version == 0 {
 		return users.ErrNotFound
 	}
 
-	return nil
+	_, err := r.primary().Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return users.ErrPreconditionFailed
+}
+
+func (r userRepo) ListDeleted(ctx context.Context) ([]users.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE tenant_id=? AND deleted_time IS NOT NULL ORDER BY deleted_time DESC`
+	rows, err := r.reader(ctx).QueryContext(ctx, query, users.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []users.User{}
+	for rows.Next() {
+		user, err := scanUser(rows, r.enc)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, user)
+	}
+
+	return res, rows.Err()
 }
 
-func (r userRepo) Delete(ctx context.Context, id string) error {
-	query := `UPDATE users SET deleted_time=? WHERE id=?`
-	res, err := r.db.ExecContext(ctx, query, time.Now().Unix(), id)
+func (r userRepo) Restore(ctx context.Context, id string) (err error) {
+	tx, end, err := r.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = end(err) }()
+
+	tenantID := users.TenantFromContext(ctx)
+
+	var user users.User
+	var username sql.NullString
+	err = tx.QueryRowContext(ctx, `SELECT email, username FROM users WHERE tenant_id=? AND id=? AND deleted_time IS NOT NULL FOR UPDATE`, tenantID, id).Scan(&user.Email, &username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return users.ErrNotFound
+		}
+		return err
+	}
+	user.Username = username.String
+	user.Email, err = r.enc.decrypt("email", id, user.Email)
+	if err != nil {
+		return err
+	}
+
+	var taken int
+	condition, args := r.enc.emailCondition(user.Email)
+	args = append([]interface{}{tenantID}, args...)
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE tenant_id=? AND `+condition+` AND deleted_time IS NULL FOR UPDATE`, args...).Scan(&taken)
+	if err != nil {
+		return err
+	}
+	if taken > 0 {
+		return users.ConstraintErrorf("email %s is already used by another user", user.Email)
+	}
+
+	if username.Valid {
+		err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE tenant_id=? AND active_username=? FOR UPDATE`, tenantID, users.NormalizeUsername(user.Username)).Scan(&taken)
+		if err != nil {
+			return err
+		}
+		if taken > 0 {
+			return users.ConstraintErrorf("username %s is already used by another user", user.Username)
+		}
+	}
+
+	now := time.Now()
+	query := `UPDATE users SET deleted_time=NULL, version=version+1, updated_time=? WHERE tenant_id=? AND id=?`
+	_, err = tx.ExecContext(ctx, query, now.Unix(), tenantID, id)
+	if err != nil {
+		return mapUniqueError(err, user)
+	}
+
+	return insertHistory(ctx, tx, r.enc, id, users.HistoryRestore, map[string]users.FieldChange{}, now)
+}
+
+func (r userRepo) HardDelete(ctx context.Context, id string) (err error) {
+	tx, end, err := r.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = end(err) }()
+
+	tenantID := users.TenantFromContext(ctx)
+	res, err := tx.ExecContext(ctx, `DELETE FROM users WHERE tenant_id=? AND id=? AND deleted_time IS NOT NULL`, tenantID, id)
 	if err != nil {
 		return err
 	}
@@ -128,5 +421,191 @@ func (r userRepo) Delete(ctx context.Context, id string) error {
 		return users.ErrNotFound
 	}
 
+	return deleteRecords(ctx, tx, tenantID, id)
+}
+
+// Purge is a maintenance task removing the deleted users of every tenant.
+func (r userRepo) Purge(ctx context.Context, deletedBefore time.Time) (purged []users.PurgedUser, err error) {
+	tx, end, err := r.beginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = end(err) }()
+
+	rows, err := tx.QueryContext(ctx, `SELECT tenant_id, id FROM users WHERE deleted_time IS NOT NULL AND deleted_time < ? FOR UPDATE`, deletedBefore.Unix())
+	if err != nil {
+		return nil, err
+	}
+	purged = []users.PurgedUser{}
+	for rows.Next() {
+		var user users.PurgedUser
+		if err = rows.Scan(&user.TenantID, &user.ID); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		purged = append(purged, user)
+	}
+	// The rows are closed before the deletes, which run on the same connection.
+	_ = rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, user := range purged {
+		_, err = tx.ExecContext(ctx, `DELETE FROM users WHERE tenant_id=? AND id=?`, user.TenantID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		err = deleteRecords(ctx, tx, user.TenantID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return purged, nil
+}
+
+// recordTables are the tables of what is recorded about the users, deleted along with them.
+var recordTables = []string{"user_history", "user_status_changes", "email_changes", "user_preferences", "user_logins"}
+
+// deleteRecords deletes everything recorded about the permanently deleted user, in the transaction deleting it.
+func deleteRecords(ctx context.Context, tx *sql.Tx, tenantID, userID string) error {
+	for _, table := range recordTables {
+		_, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE tenant_id=? AND user_id=?`, tenantID, userID)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+func (r userRepo) UpdateStatus(ctx context.Context, change users.StatusChange) (err error) {
+	tx, end, err := r.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = end(err) }()
+
+	if change.CreatedTime.IsZero() {
+		change.CreatedTime = time.Now()
+	}
+
+	tenantID := users.TenantFromContext(ctx)
+
+	query := `UPDATE users SET status=?, version=version+1, updated_time=? WHERE tenant_id=? AND id=? AND status=? AND deleted_time IS NULL`
+	res, err := tx.ExecContext(ctx, query, change.To, change.CreatedTime.Unix(), tenantID, change.UserID, change.From)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		_, err = r.primary().Get(ctx, change.UserID)
+		if err != nil {
+			return err
+		}
+		return users.ErrPreconditionFailed
+	}
+
+	query = `INSERT user_status_changes SET tenant_id=?, user_id=?, from_status=?, to_status=?, reason=?, actor=?, created_time=?`
+	_, err = tx.ExecContext(ctx, query, tenantID, change.UserID, change.From, change.To, change.Reason, change.Actor, change.CreatedTime.Unix())
+	if err != nil {
+		return err
+	}
+
+	changes := map[string]users.FieldChange{
+		"status": {Before: string(change.From), After: string(change.To)},
+	}
+	return insertHistory(users.WithActor(ctx, change.Actor), tx, r.enc, change.UserID, users.HistoryStatusChange, changes, change.CreatedTime)
+}
+
+func (r userRepo) ListStatusChanges(ctx context.Context, userID string) ([]users.StatusChange, error) {
+	query := `SELECT user_id, from_status, to_status, reason, actor, created_time FROM user_status_changes WHERE tenant_id=? AND user_id=? ORDER BY id`
+	rows, err := r.reader(ctx).QueryContext(ctx, query, users.TenantFromContext(ctx), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []users.StatusChange{}
+	for rows.Next() {
+		var change users.StatusChange
+		createdTime := int64(0)
+		err = rows.Scan(
+			&change.UserID,
+			&change.From,
+			&change.To,
+			&change.Reason,
+			&change.Actor,
+			&createdTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		change.CreatedTime = time.Unix(createdTime, 0)
+		res = append(res, change)
+	}
+
+	return res, rows.Err()
+}
+
+// scanUser scans the user, decrypting its fields encrypted at rest.
+func scanUser(row rowScanner, enc *Encryption) (users.User, error) {
+	var res users.User
+	var username sql.NullString
+	var deletedTime sql.NullInt64
+	updatedTime := int64(0)
+	createdTime := int64(0)
+	err := row.Scan(
+		&res.ID,
+		&res.Email,
+		&username,
+		&res.Password,
+		&res.Address,
+		&res.Status,
+		&res.Version,
+		&deletedTime,
+		&updatedTime,
+		&createdTime,
+	)
+	if err != nil {
+		return users.User{}, err
+	}
+
+	if deletedTime.Valid {
+		deleted := time.Unix(deletedTime.Int64, 0)
+		res.DeletedTime = &deleted
+	}
+	res.Username = username.String
+	res.UpdatedTime = time.Unix(updatedTime, 0)
+	res.CreatedTime = time.Unix(createdTime, 0)
+	return enc.decryptUser(res)
+}
+
+// mapEmailError maps the violation of the per-tenant unique email to a ConstraintError.
+func mapEmailError(err error, email string) error {
+	if e, ok := err.(*driver.MySQLError); ok && e.Number == errDuplicateEntry && strings.Contains(e.Message, "tenant_active_email_idx") {
+		return users.ConstraintErrorf("email %s is already used by another user", email)
+	}
+	return err
+}
+
+// mapUniqueError maps the violation of the per-tenant unique email or username to a ConstraintError.
+func mapUniqueError(err error, user users.User) error {
+	if e, ok := err.(*driver.MySQLError); ok && e.Number == errDuplicateEntry && strings.Contains(e.Message, "tenant_active_username_idx") {
+		return users.ConstraintErrorf("username %s is already used by another user", user.Username)
+	}
+	return mapEmailError(err, user.Email)
+}
+
+// nullString stores the empty string as NULL, so it is not subject to unique keys.
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
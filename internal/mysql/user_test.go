@@ -12,7 +12,7 @@ import (
 
 	"github.com/arnaz06/users"
 	"github.com/arnaz06/users/internal/mysql"
-	"github.com/arnaz06/users/testdata"
+	"github.com/arnaz06/users/internal/repotest"
 )
 
 type userSuite struct {
@@ -27,34 +27,61 @@ func TestUserSuite(t *testing.T) {
 }
 
 func (u *userSuite) SetupTest() {
+	u.clear(u.T())
+}
+
+// clear removes the users, with their status changes and history.
+func (u *userSuite) clear(t *testing.T) {
 	_, err := u.db.Exec("TRUNCATE users")
-	require.NoError(u.T(), err)
+	require.NoError(t, err)
+	_, err = u.db.Exec("TRUNCATE user_status_changes")
+	require.NoError(t, err)
+	_, err = u.db.Exec("TRUNCATE user_history")
+	require.NoError(t, err)
 }
 
 func (u *userSuite) seedUser(user users.User) {
-	query := `INSERT users SET id=?, email=?, password=?, address=?, updated_time=?, created_time=?`
+	query := `INSERT users SET id=?, email=?, username=?, password=?, address=?, status=?, version=?, updated_time=?, created_time=?`
 	user.CreatedTime = time.Now()
 	user.UpdatedTime = user.CreatedTime
 	if user.ID == "" {
 		user.ID = uuid.New().String()
 	}
+	username := sql.NullString{String: user.Username, Valid: user.Username != ""}
+
+	_, err := u.db.Exec(query, user.ID, user.Email, username, user.Password, user.Address, user.Status, user.Version, user.UpdatedTime.Unix(), user.CreatedTime.Unix())
+	require.NoError(u.T(), err)
+}
 
-	_, err := u.db.Exec(query, user.ID, user.Email, user.Password, user.Address, user.UpdatedTime.Unix(), user.CreatedTime.Unix())
+func (u *userSuite) seedDeletedUser(user users.User, deletedTime time.Time) {
+	u.seedUser(user)
+	_, err := u.db.Exec(`UPDATE users SET deleted_time=? WHERE id=?`, deletedTime.Unix(), user.ID)
 	require.NoError(u.T(), err)
 }
 
+func (u *userSuite) countUsers() int {
+	var count int
+	err := u.db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count)
+	require.NoError(u.T(), err)
+	return count
+}
+
 func (u *userSuite) getUser(id string) users.User {
-	query := `SELECT id, email, password, address, updated_time, created_time FROM users WHERE id=? AND deleted_time IS NULL`
+	query := `SELECT id, email, username, password, address, status, version, updated_time, created_time FROM users WHERE id=? AND deleted_time IS NULL`
 	row := u.db.QueryRowContext(context.Background(), query, id)
 
 	var res users.User
+	var username sql.NullString
 	updatedTime := int64(0)
 	createdTime := int64(0)
 	err := row.Scan(
 		&res.ID,
 		&res.Email,
+		&username,
 		&res.Password,
 		&res.Address,
+		&res.Status,
+		&res.Version,
 		&updatedTime,
 		&createdTime,
 	)
@@ -65,192 +92,23 @@ func (u *userSuite) getUser(id string) users.User {
 		require.NoError(u.T(), err)
 	}
 
+	res.Username = username.String
 	res.UpdatedTime = time.Unix(updatedTime, 0)
 	res.CreatedTime = time.Unix(createdTime, 0)
 	return res
 }
 
-func (u *userSuite) TestCreateUser() {
-	var mockUser users.User
-	testdata.GoldenJSONUnmarshal(u.T(), "user", &mockUser)
-	repo := mysql.NewUserRepository(u.db)
-
-	u.T().Run("success", func(t *testing.T) {
-		res, err := repo.Create(context.Background(), mockUser)
-		require.NoError(t, err)
-
-		tz := time.UTC
-		if loc, err := time.LoadLocation("Asia/Jakarta"); err == nil {
-			tz = loc
-		}
-
-		res.UpdatedTime = res.UpdatedTime.In(tz)
-		res.CreatedTime = res.CreatedTime.In(tz)
-
-		inserted := u.getUser(mockUser.ID)
-		inserted.UpdatedTime = res.UpdatedTime.In(tz)
-		inserted.CreatedTime = res.CreatedTime.In(tz)
-		require.Equal(t, inserted, res)
+func (u *userSuite) TestConformance() {
+	repotest.TestUserRepository(u.T(), func(t *testing.T) users.UserRepository {
+		u.clear(t)
+		return mysql.NewUserRepository(u.db)
 	})
 }
 
-func (u *userSuite) TestGetUser() {
-	var mockUser users.User
-	testdata.GoldenJSONUnmarshal(u.T(), "user", &mockUser)
-	u.seedUser(mockUser)
-
-	tests := []struct {
-		testName       string
-		input          string
-		expectedResult users.User
-		expectedError  error
-	}{
-		{
-			testName:       "success",
-			input:          mockUser.ID,
-			expectedResult: mockUser,
-		},
-		{
-			testName:      "error not found",
-			input:         "user-404",
-			expectedError: users.ErrNotFound,
-		},
-	}
-
-	for _, test := range tests {
-		u.T().Run(test.testName, func(t *testing.T) {
-			repo := mysql.NewUserRepository(u.db)
-			res, err := repo.Get(context.Background(), test.input)
-
-			if test.expectedError != nil {
-				require.EqualError(t, err, test.expectedError.Error())
-				return
-			}
-
-			test.expectedResult.UpdatedTime = time.Time{}
-			test.expectedResult.CreatedTime = time.Time{}
-			res.UpdatedTime = time.Time{}
-			res.CreatedTime = time.Time{}
-			require.Equal(t, test.expectedResult, res)
-		})
-	}
-}
-
-func (u *userSuite) TestGetUserByEmail() {
-	var mockUser users.User
-	testdata.GoldenJSONUnmarshal(u.T(), "user", &mockUser)
-	u.seedUser(mockUser)
-
-	tests := []struct {
-		testName       string
-		input          string
-		expectedResult users.User
-		expectedError  error
-	}{
-		{
-			testName:       "success",
-			input:          mockUser.Email,
-			expectedResult: mockUser,
-		},
-		{
-			testName:      "error not found",
-			input:         "email-404",
-			expectedError: users.ErrNotFound,
-		},
-	}
-
-	for _, test := range tests {
-		u.T().Run(test.testName, func(t *testing.T) {
-			repo := mysql.NewUserRepository(u.db)
-			res, err := repo.GetByEmail(context.Background(), test.input)
-
-			if test.expectedError != nil {
-				require.EqualError(t, err, test.expectedError.Error())
-				return
-			}
-			require.NoError(t, err)
-
-			test.expectedResult.UpdatedTime = time.Time{}
-			test.expectedResult.CreatedTime = time.Time{}
-			res.UpdatedTime = time.Time{}
-			res.CreatedTime = time.Time{}
-			require.Equal(t, test.expectedResult, res)
-		})
-	}
-}
-
-func (u *userSuite) TestUpdateUser() {
-	var mockUser users.User
-	testdata.GoldenJSONUnmarshal(u.T(), "user", &mockUser)
-	u.seedUser(mockUser)
-
-	updatedUser := users.User(mockUser)
-	updatedUser.Address = "updated address"
-
-	userNotFound := users.User(mockUser)
-	userNotFound.ID = "404"
-	tests := []struct {
-		testName      string
-		input         users.User
-		expectedError error
-	}{
-		{
-			testName: "success",
-			input:    updatedUser,
-		},
-		{
-			testName:      "success",
-			input:         userNotFound,
-			expectedError: users.ErrNotFound,
-		},
-	}
-
-	for _, test := range tests {
-		u.T().Run(test.testName, func(t *testing.T) {
-			repo := mysql.NewUserRepository(u.db)
-
-			err := repo.Update(context.Background(), test.input)
-
-			if test.expectedError != nil {
-				require.EqualError(t, err, test.expectedError.Error())
-				return
-			}
-			require.NoError(t, err)
-		})
-	}
-}
-
-func (u *userSuite) TestDeleteUser() {
-	var mockUser users.User
-	testdata.GoldenJSONUnmarshal(u.T(), "user", &mockUser)
-	u.seedUser(mockUser)
-	tests := []struct {
-		testName      string
-		input         string
-		expectedError error
-	}{
-		{
-			testName: "success",
-			input:    mockUser.ID,
-		},
-		{
-			testName:      "user not found",
-			input:         "404",
-			expectedError: users.ErrNotFound,
-		},
-	}
-
-	for _, test := range tests {
-		u.T().Run(test.testName, func(t *testing.T) {
-			repo := mysql.NewUserRepository(u.db)
-
-			err := repo.Delete(context.Background(), test.input)
-
-			if test.expectedError != nil {
-				require.EqualError(t, err, test.expectedError.Error())
-				return
-			}
-			require.NoError(t, err)
-		})
-	}
+func (u *userSuite) TestReplicatedConformance() {
+	// The primary is its own replica here, which still runs the reads through the replica routing.
+	repotest.TestUserRepository(u.T(), func(t *testing.T) users.UserRepository {
+		u.clear(t)
+		return mysql.NewReplicatedUserRepository(u.db, mysql.NewReplicaSet(u.db))
+	})
 }
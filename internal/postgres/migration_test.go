@@ -0,0 +1,23 @@
+package postgres_test
+
+import (
+	"database/sql"
+
+	"github.com/golang-migrate/migrate"
+	"github.com/golang-migrate/migrate/database/postgres"
+	_ "github.com/golang-migrate/migrate/source/file"
+	_ "github.com/lib/pq"
+)
+
+func MigrateDB(db *sql.DB) (m *migrate.Migrate, err error) {
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	if err != nil {
+		return nil, err
+	}
+	m, err = migrate.NewWithDatabaseInstance("file://migrations/", "postgres", driver)
+	if err != nil {
+		return nil, err
+	}
+	err = m.Up()
+	return
+}
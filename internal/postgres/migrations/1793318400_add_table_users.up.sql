@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS users (
+    id varchar(50) NOT NULL,
+    tenant_id varchar(50) NOT NULL DEFAULT 'default',
+    email varchar(255) COLLATE "C" NOT NULL,
+    username varchar(30) DEFAULT NULL,
+    password varchar(255) NOT NULL,
+    address varchar(255) NOT NULL DEFAULT '',
+    status varchar(20) NOT NULL DEFAULT 'active',
+    version bigint NOT NULL DEFAULT 1,
+    deleted_time bigint DEFAULT NULL,
+    created_time bigint NOT NULL DEFAULT 0,
+    updated_time bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS users_tenant_email_idx ON users (tenant_id, email);
+CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_active_email_idx ON users (tenant_id, email) WHERE deleted_time IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_active_username_idx ON users (tenant_id, LOWER(username)) WHERE deleted_time IS NULL;
+CREATE INDEX IF NOT EXISTS users_tenant_created_time_idx ON users (tenant_id, created_time, id);
+CREATE INDEX IF NOT EXISTS users_tenant_status_created_time_idx ON users (tenant_id, status, created_time, id);
+CREATE INDEX IF NOT EXISTS users_tenant_deleted_time_idx ON users (tenant_id, deleted_time);
+CREATE INDEX IF NOT EXISTS users_search_idx ON users USING GIN (to_tsvector('simple', regexp_replace(email || ' ' || COALESCE(username, '') || ' ' || address, '[^[:alnum:]]+', ' ', 'g')));
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS user_status_changes (
+    id bigserial NOT NULL,
+    tenant_id varchar(50) NOT NULL DEFAULT 'default',
+    user_id varchar(50) NOT NULL,
+    from_status varchar(20) NOT NULL,
+    to_status varchar(20) NOT NULL,
+    reason varchar(255) NOT NULL DEFAULT '',
+    actor varchar(255) NOT NULL DEFAULT '',
+    created_time bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS user_status_changes_tenant_user_id_idx ON user_status_changes (tenant_id, user_id);
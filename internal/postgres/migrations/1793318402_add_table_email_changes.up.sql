@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS email_changes (
+    id varchar(50) NOT NULL,
+    tenant_id varchar(50) NOT NULL DEFAULT 'default',
+    user_id varchar(50) NOT NULL,
+    old_email varchar(255) NOT NULL,
+    new_email varchar(255) NOT NULL,
+    confirm_token_hash varchar(64) NOT NULL,
+    revert_token_hash varchar(64) NOT NULL,
+    confirm_expires_time bigint NOT NULL DEFAULT 0,
+    revert_expires_time bigint NOT NULL DEFAULT 0,
+    confirmed_time bigint DEFAULT NULL,
+    reverted_time bigint DEFAULT NULL,
+    created_time bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS email_changes_confirm_token_hash_idx ON email_changes (confirm_token_hash);
+CREATE UNIQUE INDEX IF NOT EXISTS email_changes_revert_token_hash_idx ON email_changes (revert_token_hash);
+CREATE INDEX IF NOT EXISTS email_changes_tenant_user_id_idx ON email_changes (tenant_id, user_id);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS user_preferences (
+    tenant_id varchar(50) NOT NULL DEFAULT 'default',
+    user_id varchar(50) NOT NULL,
+    preferences text NOT NULL,
+    updated_time bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (tenant_id, user_id)
+);
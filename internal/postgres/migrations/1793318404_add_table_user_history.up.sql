@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS user_history (
+    id bigserial NOT NULL,
+    tenant_id varchar(50) NOT NULL DEFAULT 'default',
+    user_id varchar(50) NOT NULL,
+    action varchar(20) NOT NULL,
+    actor varchar(50) NOT NULL DEFAULT '',
+    changes text NOT NULL,
+    created_time bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS user_history_tenant_user_id_idx ON user_history (tenant_id, user_id, id);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS user_logins (
+    id bigserial NOT NULL,
+    tenant_id varchar(50) NOT NULL DEFAULT 'default',
+    user_id varchar(50) NOT NULL,
+    created_time bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS user_logins_tenant_created_time_idx ON user_logins (tenant_id, created_time);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS user_logins_tenant_user_id_idx;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS user_logins_tenant_user_id_idx ON user_logins (tenant_id, user_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS users_tenant_active_email_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_active_email_idx ON users (tenant_id, LOWER(email)) WHERE deleted_time IS NULL;
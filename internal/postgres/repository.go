@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/sqldb"
+)
+
+// errUniqueViolation is the PostgreSQL error code of a unique index violation.
+const errUniqueViolation = "23505"
+
+// Dialect is the SQL dialect of PostgreSQL.
+var Dialect = sqldb.Dialect{
+	NumberedPlaceholders: true,
+	ForUpdate:            ` FOR UPDATE`,
+	UniqueViolation:      uniqueViolation,
+}
+
+// uniqueViolation tells the unique index of the active users violated by the error.
+func uniqueViolation(err error) string {
+	e, ok := err.(*pq.Error)
+	if !ok || e.Code != errUniqueViolation {
+		return ""
+	}
+	switch e.Constraint {
+	case "users_tenant_active_email_idx":
+		return sqldb.EmailIndex
+	case "users_tenant_active_username_idx":
+		return sqldb.UsernameIndex
+	}
+	return ""
+}
+
+// NewUserRepository is constructor for user repository.
+// Every query is scoped by the tenant carried in the context.
+func NewUserRepository(db *sql.DB) users.UserRepository {
+	return sqldb.NewUserRepository(db, Dialect)
+}
+
+// NewEmailChangeRepository is constructor for email change repository.
+// Every query is scoped by the tenant carried in the context.
+func NewEmailChangeRepository(db *sql.DB) users.EmailChangeRepository {
+	return sqldb.NewEmailChangeRepository(db, Dialect)
+}
+
+// NewPreferencesRepository is constructor for preferences repository.
+// Every query is scoped by the tenant carried in the context.
+func NewPreferencesRepository(db *sql.DB) users.PreferencesRepository {
+	return sqldb.NewPreferencesRepository(db, Dialect)
+}
+
+// NewStatsRepository is constructor for the statistics repository.
+func NewStatsRepository(db *sql.DB) users.StatsRepository {
+	return sqldb.NewStatsRepository(db, Dialect)
+}
+
+// NewUnitOfWork is constructor for the unit of work over the user repository.
+func NewUnitOfWork(db *sql.DB) users.UnitOfWork {
+	return sqldb.NewUnitOfWork(db, Dialect)
+}
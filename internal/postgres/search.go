@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"unicode"
+
+	"github.com/arnaz06/users"
+)
+
+// searchDocument is the text search document of a user. It must be the expression of the users_search_idx index,
+// for the index to be used. Anything but letters and digits separates the words, so the parts of the emails are found.
+const searchDocument = `to_tsvector('simple', regexp_replace(email || ' ' || COALESCE(username, '') || ' ' || address, '[^[:alnum:]]+', ' ', 'g'))`
+
+type searcher struct {
+	db *sql.DB
+}
+
+// NewSearcher is constructor for the search of users over the text search index of PostgreSQL, used when no search index is set up.
+// Unlike the search index, it only matches the words starting with the words of the query.
+func NewSearcher(db *sql.DB) users.Searcher {
+	return searcher{
+		db: db,
+	}
+}
+
+// prefixQuery turns the query into a text search query, requiring a word starting with each of its words.
+// Anything but letters and digits is dropped, so the operators of the text search queries can not be injected.
+func prefixQuery(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+func (s searcher) Search(ctx context.Context, query string, limit int) ([]string, error) {
+	tsquery := prefixQuery(query)
+	if tsquery == "" {
+		return []string{}, nil
+	}
+
+	q := `SELECT id FROM users WHERE tenant_id=$1 AND deleted_time IS NULL AND ` + searchDocument + ` @@ to_tsquery('simple', $2)
+		ORDER BY ts_rank(` + searchDocument + `, to_tsquery('simple', $2)) DESC, id LIMIT $3`
+	rows, err := s.db.QueryContext(ctx, q, users.TenantFromContext(ctx), tsquery, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+
+	return res, rows.Err()
+}
@@ -0,0 +1,642 @@
+// Package repotest holds the conformance tests every implementation of the repositories must pass,
+// so that the backends all behave the same.
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+)
+
+// UserRepositoryFactory returns a repository with no users, for a test to run against.
+type UserRepositoryFactory func(t *testing.T) users.UserRepository
+
+// TestUserRepository runs the conformance tests of the users repository, each against a new repository of the factory.
+func TestUserRepository(t *testing.T, newRepo UserRepositoryFactory) {
+	tests := []struct {
+		testName string
+		test     func(t *testing.T, repo users.UserRepository)
+	}{
+		{testName: "create", test: testCreate},
+		{testName: "create batch", test: testCreateBatch},
+		{testName: "get", test: testGet},
+		{testName: "update", test: testUpdate},
+		{testName: "delete", test: testDelete},
+		{testName: "soft-deleted visibility", test: testSoftDeleted},
+		{testName: "update status", test: testUpdateStatus},
+		{testName: "history", test: testHistory},
+		{testName: "tenant scope", test: testTenantScope},
+		{testName: "concurrent create", test: testConcurrentCreate},
+		{testName: "concurrent update", test: testConcurrentUpdate},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			test.test(t, newRepo(t))
+		})
+	}
+}
+
+func newUser(email, username string) users.User {
+	return users.User{
+		Email:    email,
+		Username: username,
+		Password: "hash",
+		Address:  "lorem ipsum",
+	}
+}
+
+// requireSameUser compares the users with their times at the second, as the repositories store them.
+func requireSameUser(t *testing.T, expected, actual users.User) {
+	require.Equal(t, expected.CreatedTime.Unix(), actual.CreatedTime.Unix(), "created time")
+	require.Equal(t, expected.UpdatedTime.Unix(), actual.UpdatedTime.Unix(), "updated time")
+	expected.CreatedTime, expected.UpdatedTime = time.Time{}, time.Time{}
+	actual.CreatedTime, actual.UpdatedTime = time.Time{}, time.Time{}
+	require.Equal(t, expected, actual)
+}
+
+// requireNow checks the time is the current one, at the second.
+func requireNow(t *testing.T, before, actual time.Time) {
+	require.False(t, actual.Unix() < before.Unix(), "time %v is before %v", actual, before)
+	require.False(t, actual.Unix() > time.Now().Unix(), "time %v is in the future", actual)
+}
+
+func testCreate(t *testing.T, repo users.UserRepository) {
+	ctx := context.Background()
+	before := time.Now()
+
+	created, err := repo.Create(ctx, newUser("jhon@doe.com", "Jhon"))
+	require.NoError(t, err)
+	require.NotEmpty(t, created.ID)
+	require.Equal(t, users.StatusActive, created.Status)
+	require.Equal(t, int64(1), created.Version)
+	require.Nil(t, created.DeletedTime)
+	requireNow(t, before, created.CreatedTime)
+	require.Equal(t, created.CreatedTime.Unix(), created.UpdatedTime.Unix())
+
+	res, err := repo.Get(ctx, created.ID)
+	require.NoError(t, err)
+	requireSameUser(t, created, res)
+
+	t.Run("with given id and status", func(t *testing.T) {
+		user := newUser("jane@doe.com", "")
+		user.ID = "jane"
+		user.Status = users.StatusPending
+		created, err := repo.Create(ctx, user)
+		require.NoError(t, err)
+		require.Equal(t, "jane", created.ID)
+		require.Equal(t, users.StatusPending, created.Status)
+	})
+
+	t.Run("with email already used", func(t *testing.T) {
+		_, err := repo.Create(ctx, newUser("jhon@doe.com", ""))
+		require.EqualError(t, err, "email jhon@doe.com is already used by another user")
+	})
+
+	t.Run("with email already used in another case", func(t *testing.T) {
+		_, err := repo.Create(ctx, newUser("JHON@doe.com", ""))
+		require.IsType(t, users.ConstraintError(""), err)
+		require.EqualError(t, err, "email JHON@doe.com is already used by another user")
+	})
+
+	t.Run("with username already used in another case", func(t *testing.T) {
+		_, err := repo.Create(ctx, newUser("another@doe.com", "JHON"))
+		require.EqualError(t, err, "username JHON is already used by another user")
+	})
+
+	t.Run("with users without username", func(t *testing.T) {
+		_, err := repo.Create(ctx, newUser("first@doe.com", ""))
+		require.NoError(t, err)
+		_, err = repo.Create(ctx, newUser("second@doe.com", ""))
+		require.NoError(t, err)
+	})
+}
+
+func testCreateBatch(t *testing.T, repo users.UserRepository) {
+	ctx := context.Background()
+	_, err := repo.Create(ctx, newUser("jhon@doe.com", ""))
+	require.NoError(t, err)
+
+	list := []users.User{
+		newUser("jane@doe.com", "jane"),
+		newUser("jhon@doe.com", ""),
+		newUser("another@doe.com", "JANE"),
+		newUser("bob@doe.com", ""),
+	}
+	for i := range list {
+		list[i].ID = fmt.Sprintf("batch-%d", i)
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		errs, err := repo.CreateBatch(ctx, list, true)
+		require.NoError(t, err)
+		require.Len(t, errs, len(list))
+		require.NoError(t, errs[0])
+		require.EqualError(t, errs[1], "email jhon@doe.com is already used by another user")
+		require.EqualError(t, errs[2], "username JANE is already used by another user")
+		require.NoError(t, errs[3])
+
+		for _, user := range list {
+			_, err := repo.Get(ctx, user.ID)
+			require.EqualError(t, err, users.ErrNotFound.Error())
+		}
+	})
+
+	t.Run("success", func(t *testing.T) {
+		errs, err := repo.CreateBatch(ctx, list, false)
+		require.NoError(t, err)
+		require.NoError(t, errs[0])
+		require.Error(t, errs[1])
+		require.Error(t, errs[2])
+		require.NoError(t, errs[3])
+
+		inserted, err := repo.Get(ctx, list[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, "jane@doe.com", inserted.Email)
+		require.Equal(t, users.StatusActive, inserted.Status)
+		require.Equal(t, int64(1), inserted.Version)
+
+		_, err = repo.Get(ctx, list[1].ID)
+		require.EqualError(t, err, users.ErrNotFound.Error())
+		_, err = repo.Get(ctx, list[3].ID)
+		require.NoError(t, err)
+	})
+}
+
+func testGet(t *testing.T, repo users.UserRepository) {
+	ctx := context.Background()
+	created, err := repo.Create(ctx, newUser("jhon@doe.com", "Jhon.Doe"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		testName      string
+		get           func() (users.User, error)
+		expectedError error
+	}{
+		{
+			testName: "by id",
+			get:      func() (users.User, error) { return repo.Get(ctx, created.ID) },
+		},
+		{
+			testName: "by email",
+			get:      func() (users.User, error) { return repo.GetByEmail(ctx, "jhon@doe.com") },
+		},
+		{
+			testName: "by email in another case",
+			get:      func() (users.User, error) { return repo.GetByEmail(ctx, "Jhon@DOE.com") },
+		},
+		{
+			testName: "by username in another case",
+			get:      func() (users.User, error) { return repo.GetByUsername(ctx, "JHON.doe") },
+		},
+		{
+			testName:      "with id not found",
+			get:           func() (users.User, error) { return repo.Get(ctx, "user-404") },
+			expectedError: users.ErrNotFound,
+		},
+		{
+			testName:      "with email not found",
+			get:           func() (users.User, error) { return repo.GetByEmail(ctx, "404@doe.com") },
+			expectedError: users.ErrNotFound,
+		},
+		{
+			testName:      "with username not found",
+			get:           func() (users.User, error) { return repo.GetByUsername(ctx, "username-404") },
+			expectedError: users.ErrNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			res, err := test.get()
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+			require.NoError(t, err)
+			requireSameUser(t, created, res)
+		})
+	}
+}
+
+func testUpdate(t *testing.T, repo users.UserRepository) {
+	ctx := context.Background()
+	created, err := repo.Create(ctx, newUser("jhon@doe.com", "jhon"))
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, newUser("jane@doe.com", "jane"))
+	require.NoError(t, err)
+
+	updated := users.User(created)
+	updated.Email = "jhon-2@doe.com"
+	updated.Address = "updated address"
+
+	// The version 0 updates whatever the version is.
+	emailTaken := users.User(created)
+	emailTaken.Email = "jane@doe.com"
+	emailTaken.Version = 0
+
+	usernameTaken := users.User(created)
+	usernameTaken.Username = "JANE"
+	usernameTaken.Version = 0
+
+	notFound := users.User(created)
+	notFound.ID = "user-404"
+	notFound.Version = 0
+
+	tests := []struct {
+		testName      string
+		input         users.User
+		expectedError error
+	}{
+		{
+			testName: "success",
+			input:    updated,
+		},
+		{
+			testName:      "with outdated version",
+			input:         updated,
+			expectedError: users.ErrPreconditionFailed,
+		},
+		{
+			testName:      "with email already used",
+			input:         emailTaken,
+			expectedError: users.ConstraintErrorf("email jane@doe.com is already used by another user"),
+		},
+		{
+			testName:      "with username already used",
+			input:         usernameTaken,
+			expectedError: users.ConstraintErrorf("username JANE is already used by another user"),
+		},
+		{
+			testName:      "user not found",
+			input:         notFound,
+			expectedError: users.ErrNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			err := repo.Update(ctx, test.input)
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	res, err := repo.Get(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, "jhon-2@doe.com", res.Email)
+	require.Equal(t, "updated address", res.Address)
+	require.Equal(t, created.Version+1, res.Version)
+	require.Equal(t, created.CreatedTime.Unix(), res.CreatedTime.Unix())
+	require.False(t, res.UpdatedTime.Before(res.CreatedTime))
+
+	t.Run("with former email free", func(t *testing.T) {
+		_, err := repo.GetByEmail(ctx, "jhon@doe.com")
+		require.EqualError(t, err, users.ErrNotFound.Error())
+		_, err = repo.Create(ctx, newUser("jhon@doe.com", ""))
+		require.NoError(t, err)
+	})
+}
+
+func testDelete(t *testing.T, repo users.UserRepository) {
+	ctx := context.Background()
+	created, err := repo.Create(ctx, newUser("jhon@doe.com", "jhon"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		testName      string
+		input         string
+		version       int64
+		expectedError error
+	}{
+		{
+			testName:      "with outdated version",
+			input:         created.ID,
+			version:       created.Version + 1,
+			expectedError: users.ErrPreconditionFailed,
+		},
+		{
+			testName: "success",
+			input:    created.ID,
+			version:  created.Version,
+		},
+		{
+			testName:      "with user already deleted",
+			input:         created.ID,
+			expectedError: users.ErrNotFound,
+		},
+		{
+			testName:      "user not found",
+			input:         "user-404",
+			expectedError: users.ErrNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			err := repo.Delete(ctx, test.input, test.version)
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("hard delete", func(t *testing.T) {
+		active, err := repo.Create(ctx, newUser("active@doe.com", ""))
+		require.NoError(t, err)
+		require.EqualError(t, repo.HardDelete(ctx, active.ID), users.ErrNotFound.Error())
+
+		require.NoError(t, repo.HardDelete(ctx, created.ID))
+		deleted, err := repo.ListDeleted(ctx)
+		require.NoError(t, err)
+		require.Empty(t, deleted)
+		require.EqualError(t, repo.Restore(ctx, created.ID), users.ErrNotFound.Error())
+
+		// What is recorded about the user is deleted along with it.
+		history, err := repo.ListHistory(ctx, created.ID, 0, 10)
+		require.NoError(t, err)
+		require.Empty(t, history)
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		acme := users.WithTenant(ctx, "acme")
+		user, err := repo.Create(acme, newUser("purged@doe.com", ""))
+		require.NoError(t, err)
+		require.NoError(t, repo.UpdateStatus(acme, users.StatusChange{UserID: user.ID, From: users.StatusActive, To: users.StatusLocked}))
+		require.NoError(t, repo.Delete(acme, user.ID, 0))
+
+		purged, err := repo.Purge(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		require.Empty(t, purged)
+
+		purged, err = repo.Purge(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, []users.PurgedUser{{TenantID: "acme", ID: user.ID}}, purged)
+
+		deleted, err := repo.ListDeleted(acme)
+		require.NoError(t, err)
+		require.Empty(t, deleted)
+
+		// What is recorded about the user is deleted along with it.
+		history, err := repo.ListHistory(acme, user.ID, 0, 10)
+		require.NoError(t, err)
+		require.Empty(t, history)
+		changes, err := repo.ListStatusChanges(acme, user.ID)
+		require.NoError(t, err)
+		require.Empty(t, changes)
+	})
+}
+
+func testSoftDeleted(t *testing.T, repo users.UserRepository) {
+	ctx := context.Background()
+	created, err := repo.Create(ctx, newUser("jhon@doe.com", "jhon"))
+	require.NoError(t, err)
+	before := time.Now()
+	require.NoError(t, repo.Delete(ctx, created.ID, 0))
+
+	t.Run("with reads not found", func(t *testing.T) {
+		_, err := repo.Get(ctx, created.ID)
+		require.EqualError(t, err, users.ErrNotFound.Error())
+		_, err = repo.GetByEmail(ctx, created.Email)
+		require.EqualError(t, err, users.ErrNotFound.Error())
+		_, err = repo.GetByUsername(ctx, created.Username)
+		require.EqualError(t, err, users.ErrNotFound.Error())
+		require.EqualError(t, repo.Update(ctx, created), users.ErrNotFound.Error())
+	})
+
+	t.Run("with deleted listed", func(t *testing.T) {
+		deleted, err := repo.ListDeleted(ctx)
+		require.NoError(t, err)
+		require.Len(t, deleted, 1)
+		require.Equal(t, created.ID, deleted[0].ID)
+		require.NotNil(t, deleted[0].DeletedTime)
+		requireNow(t, before, *deleted[0].DeletedTime)
+	})
+
+	var reused users.User
+	t.Run("with email and username free", func(t *testing.T) {
+		reused, err = repo.Create(ctx, newUser(created.Email, created.Username))
+		require.NoError(t, err)
+	})
+
+	t.Run("restore with email used", func(t *testing.T) {
+		err := repo.Restore(ctx, created.ID)
+		require.EqualError(t, err, "email jhon@doe.com is already used by another user")
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		require.NoError(t, repo.Delete(ctx, reused.ID, 0))
+		require.NoError(t, repo.Restore(ctx, created.ID))
+
+		res, err := repo.GetByEmail(ctx, created.Email)
+		require.NoError(t, err)
+		require.Equal(t, created.ID, res.ID)
+		require.Nil(t, res.DeletedTime)
+		require.EqualError(t, repo.Restore(ctx, created.ID), users.ErrNotFound.Error())
+	})
+}
+
+func testUpdateStatus(t *testing.T, repo users.UserRepository) {
+	ctx := context.Background()
+	created, err := repo.Create(ctx, newUser("jhon@doe.com", ""))
+	require.NoError(t, err)
+
+	suspend := users.StatusChange{
+		UserID: created.ID,
+		From:   users.StatusActive,
+		To:     users.StatusSuspended,
+		Reason: "spam",
+		Actor:  "admin-1",
+	}
+
+	notFound := users.StatusChange(suspend)
+	notFound.UserID = "user-404"
+
+	tests := []struct {
+		testName      string
+		input         users.StatusChange
+		expectedError error
+	}{
+		{
+			testName: "success",
+			input:    suspend,
+		},
+		{
+			testName:      "with status changed concurrently",
+			input:         suspend,
+			expectedError: users.ErrPreconditionFailed,
+		},
+		{
+			testName:      "user not found",
+			input:         notFound,
+			expectedError: users.ErrNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			err := repo.UpdateStatus(ctx, test.input)
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	res, err := repo.Get(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, users.StatusSuspended, res.Status)
+	require.Equal(t, created.Version+1, res.Version)
+
+	changes, err := repo.ListStatusChanges(ctx, created.ID)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, users.StatusActive, changes[0].From)
+	require.Equal(t, users.StatusSuspended, changes[0].To)
+	require.Equal(t, suspend.Reason, changes[0].Reason)
+	require.Equal(t, suspend.Actor, changes[0].Actor)
+}
+
+func testHistory(t *testing.T, repo users.UserRepository) {
+	ctx := users.WithActor(context.Background(), "admin-1")
+
+	created, err := repo.Create(context.Background(), newUser("jhon@doe.com", ""))
+	require.NoError(t, err)
+
+	updated := users.User(created)
+	updated.Address = "updated address"
+	updated.Password = "new-hash"
+	require.NoError(t, repo.Update(ctx, updated))
+
+	require.NoError(t, repo.UpdateStatus(ctx, users.StatusChange{
+		UserID: created.ID,
+		From:   users.StatusActive,
+		To:     users.StatusSuspended,
+		Actor:  "admin-2",
+	}))
+	require.NoError(t, repo.Delete(ctx, created.ID, 0))
+
+	entries, err := repo.ListHistory(context.Background(), created.ID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+
+	require.Equal(t, users.HistoryDelete, entries[0].Action)
+	require.Equal(t, "admin-1", entries[0].Actor)
+
+	require.Equal(t, users.HistoryStatusChange, entries[1].Action)
+	require.Equal(t, "admin-2", entries[1].Actor)
+	require.Equal(t, users.FieldChange{Before: "active", After: "suspended"}, entries[1].Changes["status"])
+
+	require.Equal(t, users.HistoryUpdate, entries[2].Action)
+	require.Equal(t, "admin-1", entries[2].Actor)
+	require.Equal(t, map[string]users.FieldChange{
+		"address":  {Before: "lorem ipsum", After: "updated address"},
+		"password": {Before: users.RedactedValue, After: users.RedactedValue},
+	}, entries[2].Changes)
+
+	require.Equal(t, users.HistoryCreate, entries[3].Action)
+	require.Empty(t, entries[3].Actor)
+	require.Equal(t, users.RedactedValue, entries[3].Changes["password"].After)
+	require.Equal(t, users.FieldChange{After: "jhon@doe.com"}, entries[3].Changes["email"])
+
+	t.Run("page before an entry", func(t *testing.T) {
+		page, err := repo.ListHistory(context.Background(), created.ID, entries[1].ID, 10)
+		require.NoError(t, err)
+		require.Equal(t, entries[2:], page)
+	})
+
+	t.Run("with other tenant", func(t *testing.T) {
+		page, err := repo.ListHistory(users.WithTenant(context.Background(), "other"), created.ID, 0, 10)
+		require.NoError(t, err)
+		require.Empty(t, page)
+	})
+}
+
+func testTenantScope(t *testing.T, repo users.UserRepository) {
+	acme := users.WithTenant(context.Background(), "acme")
+	umbrella := users.WithTenant(context.Background(), "umbrella")
+
+	created, err := repo.Create(acme, newUser("jhon@doe.com", "jhon"))
+	require.NoError(t, err)
+
+	_, err = repo.Get(umbrella, created.ID)
+	require.EqualError(t, err, users.ErrNotFound.Error())
+	_, err = repo.GetByEmail(umbrella, created.Email)
+	require.EqualError(t, err, users.ErrNotFound.Error())
+	require.EqualError(t, repo.Delete(umbrella, created.ID, 0), users.ErrNotFound.Error())
+
+	_, err = repo.Create(umbrella, newUser("jhon@doe.com", "jhon"))
+	require.NoError(t, err)
+	res, err := repo.GetByEmail(acme, created.Email)
+	require.NoError(t, err)
+	require.Equal(t, created.ID, res.ID)
+}
+
+// concurrently runs fn n times at once, and returns the errors of the calls.
+func concurrently(n int, fn func() error) []error {
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = fn()
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
+
+func testConcurrentCreate(t *testing.T, repo users.UserRepository) {
+	errs := concurrently(10, func() error {
+		_, err := repo.Create(context.Background(), newUser("jhon@doe.com", ""))
+		return err
+	})
+
+	created := 0
+	for _, err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+		require.EqualError(t, err, "email jhon@doe.com is already used by another user")
+	}
+	require.Equal(t, 1, created)
+}
+
+func testConcurrentUpdate(t *testing.T, repo users.UserRepository) {
+	created, err := repo.Create(context.Background(), newUser("jhon@doe.com", ""))
+	require.NoError(t, err)
+
+	errs := concurrently(10, func() error {
+		updated := users.User(created)
+		updated.Address = "updated address"
+		return repo.Update(context.Background(), updated)
+	})
+
+	applied := 0
+	for _, err := range errs {
+		if err == nil {
+			applied++
+			continue
+		}
+		require.EqualError(t, err, users.ErrPreconditionFailed.Error())
+	}
+	require.Equal(t, 1, applied)
+
+	res, err := repo.Get(context.Background(), created.ID)
+	require.NoError(t, err)
+	require.Equal(t, created.Version+1, res.Version)
+}
@@ -0,0 +1,210 @@
+package search
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/analysis/token/ngram"
+	"github.com/blevesearch/bleve/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/mapping"
+	"github.com/blevesearch/bleve/search/query"
+
+	"github.com/arnaz06/users"
+)
+
+const (
+	// fragmentAnalyzer indexes every fragment of the words, so they are found by any part of them.
+	fragmentAnalyzer = "fragment"
+	// termAnalyzer splits the search queries into words, to be matched against the fragments.
+	termAnalyzer = "term"
+
+	minFragmentLength = 2
+	maxFragmentLength = 20
+
+	resetBatchSize = 1000
+)
+
+var searchFields = []string{"email", "username", "address"}
+
+type document struct {
+	Tenant   string `json:"tenant"`
+	Email    string `json:"email"`
+	Username string `json:"username"`
+	Address  string `json:"address"`
+}
+
+type bleveIndex struct {
+	index bleve.Index
+}
+
+// NewBleveIndex opens the search index stored in dir, creating it if it does not exist.
+// The index is only kept in memory when dir is empty. It can only be opened by one process at a time.
+func NewBleveIndex(dir string) (users.SearchIndex, error) {
+	if dir == "" {
+		m, err := indexMapping()
+		if err != nil {
+			return nil, err
+		}
+		index, err := bleve.NewMemOnly(m)
+		if err != nil {
+			return nil, err
+		}
+		return bleveIndex{index: index}, nil
+	}
+
+	if _, err := os.Stat(dir); err == nil {
+		index, err := bleve.Open(dir)
+		if err != nil {
+			return nil, err
+		}
+		return bleveIndex{index: index}, nil
+	}
+
+	m, err := indexMapping()
+	if err != nil {
+		return nil, err
+	}
+	index, err := bleve.New(dir, m)
+	if err != nil {
+		return nil, err
+	}
+	return bleveIndex{index: index}, nil
+}
+
+func indexMapping() (mapping.IndexMapping, error) {
+	m := bleve.NewIndexMapping()
+	err := m.AddCustomTokenFilter(fragmentAnalyzer, map[string]interface{}{
+		"type": ngram.Name,
+		"min":  float64(minFragmentLength),
+		"max":  float64(maxFragmentLength),
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = m.AddCustomAnalyzer(fragmentAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{lowercase.Name, fragmentAnalyzer},
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = m.AddCustomAnalyzer(termAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{lowercase.Name},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	doc := bleve.NewDocumentMapping()
+	tenant := bleve.NewTextFieldMapping()
+	tenant.Analyzer = keyword.Name
+	tenant.Store = false
+	doc.AddFieldMappingsAt("tenant", tenant)
+	for _, field := range searchFields {
+		text := bleve.NewTextFieldMapping()
+		text.Analyzer = fragmentAnalyzer
+		text.Store = false
+		text.IncludeTermVectors = false
+		doc.AddFieldMappingsAt(field, text)
+	}
+
+	m.DefaultMapping = doc
+	return m, nil
+}
+
+// documentID keys the documents by tenant, as the IDs of the users are only unique within a tenant.
+func documentID(ctx context.Context, userID string) string {
+	return users.TenantFromContext(ctx) + "/" + userID
+}
+
+func tenantQuery(ctx context.Context) query.Query {
+	q := bleve.NewTermQuery(users.TenantFromContext(ctx))
+	q.SetField("tenant")
+	return q
+}
+
+func (i bleveIndex) Search(ctx context.Context, text string, limit int) ([]string, error) {
+	conjuncts := []query.Query{tenantQuery(ctx)}
+	for _, term := range strings.Fields(text) {
+		// The words shorter than any fragment can not match, and the longer ones are matched by their start.
+		runes := []rune(term)
+		if len(runes) < minFragmentLength {
+			continue
+		}
+		if len(runes) > maxFragmentLength {
+			term = string(runes[:maxFragmentLength])
+		}
+
+		disjuncts := make([]query.Query, 0, len(searchFields))
+		for _, field := range searchFields {
+			q := bleve.NewMatchQuery(term)
+			q.SetField(field)
+			q.Analyzer = termAnalyzer
+			disjuncts = append(disjuncts, q)
+		}
+		conjuncts = append(conjuncts, bleve.NewDisjunctionQuery(disjuncts...))
+	}
+	if len(conjuncts) == 1 {
+		return []string{}, nil
+	}
+
+	req := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(conjuncts...), limit, 0, false)
+	res, err := i.index.SearchInContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := documentID(ctx, "")
+	ids := make([]string, 0, len(res.Hits))
+	for _, hit := range res.Hits {
+		ids = append(ids, strings.TrimPrefix(hit.ID, prefix))
+	}
+	return ids, nil
+}
+
+func (i bleveIndex) Index(ctx context.Context, user users.User) error {
+	return i.index.Index(documentID(ctx, user.ID), document{
+		Tenant:   users.TenantFromContext(ctx),
+		Email:    user.Email,
+		Username: user.Username,
+		Address:  user.Address,
+	})
+}
+
+func (i bleveIndex) Remove(ctx context.Context, id string) error {
+	return i.index.Delete(documentID(ctx, id))
+}
+
+func (i bleveIndex) Reset(ctx context.Context) error {
+	for {
+		req := bleve.NewSearchRequestOptions(tenantQuery(ctx), resetBatchSize, 0, false)
+		res, err := i.index.SearchInContext(ctx, req)
+		if err != nil {
+			return err
+		}
+		if len(res.Hits) == 0 {
+			return nil
+		}
+
+		batch := i.index.NewBatch()
+		for _, hit := range res.Hits {
+			batch.Delete(hit.ID)
+		}
+		err = i.index.Batch(batch)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (i bleveIndex) Close() error {
+	return i.index.Close()
+}
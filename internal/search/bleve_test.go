@@ -0,0 +1,113 @@
+package search_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/search"
+)
+
+func TestBleveIndex(t *testing.T) {
+	index, err := search.NewBleveIndex("")
+	require.NoError(t, err)
+	defer index.Close()
+
+	ctx := context.Background()
+	acme := users.WithTenant(ctx, "acme")
+
+	require.NoError(t, index.Index(ctx, users.User{ID: "1", Email: "jhon@doe.com", Username: "jhon.doe", Address: "Jalan Sudirman 1, Jakarta"}))
+	require.NoError(t, index.Index(ctx, users.User{ID: "2", Email: "jane@smith.com", Address: "Baker Street 221b, London"}))
+	require.NoError(t, index.Index(acme, users.User{ID: "3", Email: "jhon@acme.com"}))
+
+	tests := []struct {
+		testName    string
+		ctx         context.Context
+		query       string
+		expectedIDs []string
+	}{
+		{
+			testName:    "by email fragment",
+			ctx:         ctx,
+			query:       "hon",
+			expectedIDs: []string{"1"},
+		},
+		{
+			testName:    "by address fragment, case-insensitively",
+			ctx:         ctx,
+			query:       "BAKER lond",
+			expectedIDs: []string{"2"},
+		},
+		{
+			testName:    "with every word required",
+			ctx:         ctx,
+			query:       "jhon london",
+			expectedIDs: []string{},
+		},
+		{
+			testName:    "with words too short",
+			ctx:         ctx,
+			query:       "j",
+			expectedIDs: []string{},
+		},
+		{
+			testName:    "in another tenant",
+			ctx:         acme,
+			query:       "jhon",
+			expectedIDs: []string{"3"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			ids, err := index.Search(test.ctx, test.query, 10)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedIDs, ids)
+		})
+	}
+
+	t.Run("remove", func(t *testing.T) {
+		require.NoError(t, index.Remove(ctx, "1"))
+
+		ids, err := index.Search(ctx, "jhon", 10)
+		require.NoError(t, err)
+		require.Empty(t, ids)
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		require.NoError(t, index.Reset(ctx))
+
+		ids, err := index.Search(ctx, "jane", 10)
+		require.NoError(t, err)
+		require.Empty(t, ids)
+
+		ids, err = index.Search(acme, "jhon", 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"3"}, ids)
+	})
+}
+
+func TestBleveIndexPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "search")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "users.bleve")
+
+	index, err := search.NewBleveIndex(path)
+	require.NoError(t, err)
+	require.NoError(t, index.Index(context.Background(), users.User{ID: "1", Email: "jhon@doe.com"}))
+	require.NoError(t, index.Close())
+
+	index, err = search.NewBleveIndex(path)
+	require.NoError(t, err)
+	defer index.Close()
+
+	ids, err := index.Search(context.Background(), "doe", 10)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1"}, ids)
+}
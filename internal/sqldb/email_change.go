@@ -0,0 +1,233 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arnaz06/users"
+)
+
+const emailChangeColumns = `id, user_id, old_email, new_email, confirm_token_hash, revert_token_hash, confirm_expires_time, revert_expires_time, confirmed_time, reverted_time, created_time`
+
+type emailChangeRepo struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewEmailChangeRepository is constructor for email change repository.
+// Every query is scoped by the tenant carried in the context.
+func NewEmailChangeRepository(db *sql.DB, dialect Dialect) users.EmailChangeRepository {
+	return emailChangeRepo{
+		db:      db,
+		dialect: dialect,
+	}
+}
+
+// conn returns the database, running the queries of the dialect.
+func (r emailChangeRepo) conn() conn {
+	return conn{q: r.db, dialect: r.dialect}
+}
+
+func (r emailChangeRepo) Create(ctx context.Context, change users.EmailChange) (users.EmailChange, error) {
+	query := `INSERT INTO email_changes (id, tenant_id, user_id, old_email, new_email, confirm_token_hash, revert_token_hash, confirm_expires_time, revert_expires_time, created_time)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	if change.ID == "" {
+		change.ID = uuid.New().String()
+	}
+	if change.CreatedTime.IsZero() {
+		change.CreatedTime = time.Now()
+	}
+
+	_, err := r.conn().ExecContext(ctx, query,
+		change.ID,
+		users.TenantFromContext(ctx),
+		change.UserID,
+		change.OldEmail,
+		change.NewEmail,
+		change.ConfirmTokenHash,
+		change.RevertTokenHash,
+		change.ConfirmExpiresTime.Unix(),
+		change.RevertExpiresTime.Unix(),
+		change.CreatedTime.Unix(),
+	)
+	if err != nil {
+		return users.EmailChange{}, err
+	}
+	return change, nil
+}
+
+func (r emailChangeRepo) GetByConfirmToken(ctx context.Context, tokenHash string) (users.EmailChange, error) {
+	query := `SELECT ` + emailChangeColumns + ` FROM email_changes WHERE tenant_id=? AND confirm_token_hash=?`
+	return r.get(ctx, query, tokenHash)
+}
+
+func (r emailChangeRepo) GetByRevertToken(ctx context.Context, tokenHash string) (users.EmailChange, error) {
+	query := `SELECT ` + emailChangeColumns + ` FROM email_changes WHERE tenant_id=? AND revert_token_hash=?`
+	return r.get(ctx, query, tokenHash)
+}
+
+func (r emailChangeRepo) get(ctx context.Context, query, tokenHash string) (users.EmailChange, error) {
+	res, err := scanEmailChange(r.conn().QueryRowContext(ctx, query, users.TenantFromContext(ctx), tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return users.EmailChange{}, users.ErrNotFound
+		}
+		return users.EmailChange{}, err
+	}
+
+	return res, nil
+}
+
+func (r emailChangeRepo) ListByUser(ctx context.Context, userID string) ([]users.EmailChange, error) {
+	query := `SELECT ` + emailChangeColumns + ` FROM email_changes WHERE tenant_id=? AND user_id=? ORDER BY created_time`
+	rows, err := r.conn().QueryContext(ctx, query, users.TenantFromContext(ctx), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []users.EmailChange{}
+	for rows.Next() {
+		change, err := scanEmailChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, change)
+	}
+
+	return res, rows.Err()
+}
+
+func (r emailChangeRepo) Confirm(ctx context.Context, id string) (err error) {
+	t, err := beginTx(ctx, r.db, r.dialect)
+	if err != nil {
+		return err
+	}
+	defer func() { err = t.end(err) }()
+
+	tenantID := users.TenantFromContext(ctx)
+	change, err := scanEmailChange(t.QueryRowContext(ctx, `SELECT `+emailChangeColumns+` FROM email_changes WHERE tenant_id=? AND id=?`+r.dialect.ForUpdate, tenantID, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return users.ErrNotFound
+		}
+		return err
+	}
+	if change.ConfirmedTime != nil || change.RevertedTime != nil {
+		return users.ErrPreconditionFailed
+	}
+
+	now := time.Now()
+	query := `UPDATE users SET email=?, version=version+1, updated_time=? WHERE tenant_id=? AND id=? AND email=? AND deleted_time IS NULL`
+	res, err := t.ExecContext(ctx, query, change.NewEmail, now.Unix(), tenantID, change.UserID, change.OldEmail)
+	if err != nil {
+		return mapEmailError(r.dialect, err, change.NewEmail)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return users.ConstraintErrorf("email of the user has changed since the change was requested")
+	}
+
+	changes := map[string]users.FieldChange{
+		"email": {Before: change.OldEmail, After: change.NewEmail},
+	}
+	err = insertHistory(ctx, t, change.UserID, users.HistoryUpdate, changes, now)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.ExecContext(ctx, `UPDATE email_changes SET confirmed_time=? WHERE tenant_id=? AND id=?`, now.Unix(), tenantID, id)
+	return err
+}
+
+func (r emailChangeRepo) Revert(ctx context.Context, id string) (err error) {
+	t, err := beginTx(ctx, r.db, r.dialect)
+	if err != nil {
+		return err
+	}
+	defer func() { err = t.end(err) }()
+
+	tenantID := users.TenantFromContext(ctx)
+	change, err := scanEmailChange(t.QueryRowContext(ctx, `SELECT `+emailChangeColumns+` FROM email_changes WHERE tenant_id=? AND id=?`+r.dialect.ForUpdate, tenantID, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return users.ErrNotFound
+		}
+		return err
+	}
+	if change.RevertedTime != nil {
+		return users.ErrPreconditionFailed
+	}
+
+	now := time.Now()
+	if change.ConfirmedTime != nil {
+		query := `UPDATE users SET email=?, version=version+1, updated_time=? WHERE tenant_id=? AND id=? AND email=? AND deleted_time IS NULL`
+		res, err := t.ExecContext(ctx, query, change.OldEmail, now.Unix(), tenantID, change.UserID, change.NewEmail)
+		if err != nil {
+			return mapEmailError(r.dialect, err, change.OldEmail)
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected != 1 {
+			return users.ConstraintErrorf("email of the user has changed since the change was confirmed")
+		}
+
+		changes := map[string]users.FieldChange{
+			"email": {Before: change.NewEmail, After: change.OldEmail},
+		}
+		err = insertHistory(ctx, t, change.UserID, users.HistoryUpdate, changes, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = t.ExecContext(ctx, `UPDATE email_changes SET reverted_time=? WHERE tenant_id=? AND id=?`, now.Unix(), tenantID, id)
+	return err
+}
+
+func scanEmailChange(row rowScanner) (users.EmailChange, error) {
+	var res users.EmailChange
+	var confirmedTime, revertedTime sql.NullInt64
+	confirmExpiresTime := int64(0)
+	revertExpiresTime := int64(0)
+	createdTime := int64(0)
+	err := row.Scan(
+		&res.ID,
+		&res.UserID,
+		&res.OldEmail,
+		&res.NewEmail,
+		&res.ConfirmTokenHash,
+		&res.RevertTokenHash,
+		&confirmExpiresTime,
+		&revertExpiresTime,
+		&confirmedTime,
+		&revertedTime,
+		&createdTime,
+	)
+	if err != nil {
+		return users.EmailChange{}, err
+	}
+
+	if confirmedTime.Valid {
+		confirmed := time.Unix(confirmedTime.Int64, 0)
+		res.ConfirmedTime = &confirmed
+	}
+	if revertedTime.Valid {
+		reverted := time.Unix(revertedTime.Int64, 0)
+		res.RevertedTime = &reverted
+	}
+	res.ConfirmExpiresTime = time.Unix(confirmExpiresTime, 0)
+	res.RevertExpiresTime = time.Unix(revertExpiresTime, 0)
+	res.CreatedTime = time.Unix(createdTime, 0)
+	return res, nil
+}
@@ -0,0 +1,103 @@
+package sqldb_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/sqldb"
+	"github.com/arnaz06/users/testdata"
+)
+
+func (s *sqlSuite) createUser(user users.User) users.User {
+	res, err := sqldb.NewUserRepository(s.db, s.dialect).Create(context.Background(), user)
+	require.NoError(s.T(), err)
+	return res
+}
+
+func (s *sqlSuite) createChange(user users.User, newEmail string) users.EmailChange {
+	now := time.Now()
+	repo := sqldb.NewEmailChangeRepository(s.db, s.dialect)
+	change, err := repo.Create(context.Background(), users.EmailChange{
+		UserID:             user.ID,
+		OldEmail:           user.Email,
+		NewEmail:           newEmail,
+		ConfirmTokenHash:   "confirm-" + newEmail,
+		RevertTokenHash:    "revert-" + newEmail,
+		ConfirmExpiresTime: now.Add(time.Hour),
+		RevertExpiresTime:  now.Add(24 * time.Hour),
+	})
+	require.NoError(s.T(), err)
+	return change
+}
+
+func (s *sqlSuite) TestEmailChangeGetByToken() {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(s.T(), "user", &mockUser)
+	mockUser = s.createUser(mockUser)
+	change := s.createChange(mockUser, "new@doe.com")
+
+	repo := sqldb.NewEmailChangeRepository(s.db, s.dialect)
+	res, err := repo.GetByConfirmToken(context.Background(), change.ConfirmTokenHash)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), change.ID, res.ID)
+	require.Equal(s.T(), "new@doe.com", res.NewEmail)
+
+	res, err = repo.GetByRevertToken(context.Background(), change.RevertTokenHash)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), change.ID, res.ID)
+
+	_, err = repo.GetByConfirmToken(users.WithTenant(context.Background(), "other"), change.ConfirmTokenHash)
+	require.Equal(s.T(), users.ErrNotFound, err)
+
+	_, err = repo.GetByRevertToken(context.Background(), "unknown")
+	require.Equal(s.T(), users.ErrNotFound, err)
+}
+
+func (s *sqlSuite) TestEmailChangeConfirmAndRevert() {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(s.T(), "user", &mockUser)
+	mockUser = s.createUser(mockUser)
+	change := s.createChange(mockUser, "new@doe.com")
+
+	repo := sqldb.NewEmailChangeRepository(s.db, s.dialect)
+	err := repo.Confirm(context.Background(), change.ID)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "new@doe.com", s.getUser(mockUser.ID).Email)
+	require.Equal(s.T(), mockUser.Version+1, s.getUser(mockUser.ID).Version)
+
+	err = repo.Confirm(context.Background(), change.ID)
+	require.Equal(s.T(), users.ErrPreconditionFailed, err)
+
+	err = repo.Revert(context.Background(), change.ID)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), mockUser.Email, s.getUser(mockUser.ID).Email)
+
+	err = repo.Revert(context.Background(), change.ID)
+	require.Equal(s.T(), users.ErrPreconditionFailed, err)
+
+	list, err := repo.ListByUser(context.Background(), mockUser.ID)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), list, 1)
+	require.NotNil(s.T(), list[0].ConfirmedTime)
+	require.NotNil(s.T(), list[0].RevertedTime)
+}
+
+func (s *sqlSuite) TestEmailChangeConfirmWithEmailTaken() {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(s.T(), "user", &mockUser)
+	mockUser = s.createUser(mockUser)
+	s.createUser(users.User{Email: "taken@doe.com", Status: users.StatusActive})
+	change := s.createChange(mockUser, "taken@doe.com")
+
+	repo := sqldb.NewEmailChangeRepository(s.db, s.dialect)
+	err := repo.Confirm(context.Background(), change.ID)
+	require.EqualError(s.T(), err, "email taken@doe.com is already used by another user")
+	require.Equal(s.T(), mockUser.Email, s.getUser(mockUser.ID).Email)
+
+	res, err := repo.GetByConfirmToken(context.Background(), change.ConfirmTokenHash)
+	require.NoError(s.T(), err)
+	require.Nil(s.T(), res.ConfirmedTime)
+}
@@ -0,0 +1,47 @@
+package sqldb_test
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/sqldb"
+)
+
+func (s *sqlSuite) TestPreferencesSaveAndGet() {
+	repo := sqldb.NewPreferencesRepository(s.db, s.dialect)
+	ctx := context.Background()
+
+	_, err := repo.Get(ctx, "123")
+	require.Equal(s.T(), users.ErrNotFound, err)
+
+	preferences := users.DefaultPreferences()
+	preferences.Theme = users.ThemeDark
+	require.NoError(s.T(), repo.Save(ctx, "123", preferences))
+
+	res, err := repo.Get(ctx, "123")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), preferences, res)
+
+	preferences.Locale = "id-ID"
+	require.NoError(s.T(), repo.Save(ctx, "123", preferences))
+
+	res, err = repo.Get(ctx, "123")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), preferences, res)
+
+	_, err = repo.Get(users.WithTenant(ctx, "other"), "123")
+	require.Equal(s.T(), users.ErrNotFound, err)
+}
+
+func (s *sqlSuite) TestPreferencesWithMissingKeys() {
+	s.exec(`INSERT INTO user_preferences (user_id, preferences) VALUES (?, ?)`, "123", `{"theme":"light"}`)
+
+	expected := users.DefaultPreferences()
+	expected.Theme = users.ThemeLight
+
+	res, err := sqldb.NewPreferencesRepository(s.db, s.dialect).Get(context.Background(), "123")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), expected, res)
+}
@@ -0,0 +1,118 @@
+// Package sqldb holds the repositories shared by the SQL databases whose queries only differ by their Dialect,
+// which are PostgreSQL and SQLite. The queries are written with ? placeholders, rewritten for the dialect when run.
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+// Unique indexes of the active users, whose violations are reported by the dialects.
+const (
+	EmailIndex    = "email"
+	UsernameIndex = "username"
+)
+
+// Dialect tells apart the SQL databases the repositories run against.
+type Dialect struct {
+	// NumberedPlaceholders tells the placeholders are written $1, $2..., rather than ?.
+	NumberedPlaceholders bool
+	// ForUpdate is the clause, with its leading space, locking the selected rows until the end of the transaction,
+	// empty for the databases locking the whole database on a write.
+	ForUpdate string
+	// UniqueViolation returns the unique index of the active users violated by the error,
+	// EmailIndex or UsernameIndex, or else an empty string.
+	UniqueViolation func(err error) string
+}
+
+// Rebind rewrites the ? placeholders of the query in the syntax of the dialect.
+func (d Dialect) Rebind(query string) string {
+	if !d.NumberedPlaceholders || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		b.WriteString("$" + strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+// querier is the part of *sql.DB and *sql.Tx the queries are run with.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn runs the queries of the dialect over the database or a transaction.
+type conn struct {
+	q       querier
+	dialect Dialect
+}
+
+func (c conn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.q.ExecContext(ctx, c.dialect.Rebind(query), args...)
+}
+
+func (c conn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return c.q.QueryContext(ctx, c.dialect.Rebind(query), args...)
+}
+
+func (c conn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return c.q.QueryRowContext(ctx, c.dialect.Rebind(query), args...)
+}
+
+// tx is a transaction running the queries of the dialect.
+type tx struct {
+	conn
+	tx *sql.Tx
+}
+
+// beginTx starts a transaction over the database.
+func beginTx(ctx context.Context, db *sql.DB, dialect Dialect) (tx, error) {
+	sqlTx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return tx{}, err
+	}
+	return tx{conn: conn{q: sqlTx, dialect: dialect}, tx: sqlTx}, nil
+}
+
+// end commits the transaction unless the write failed, in which case it is rolled back.
+func (t tx) end(err error) error {
+	if err != nil {
+		_ = t.tx.Rollback()
+		return err
+	}
+	return t.tx.Commit()
+}
+
+// savepoint sets a savepoint in the transaction. PostgreSQL aborts the whole transaction on a failed statement,
+// so the statements which may fail are undone alone by rolling back to a savepoint.
+// The returned function rolls back to the savepoint when given an error, and releases it otherwise.
+func (t tx) savepoint(ctx context.Context, name string) (func(err error) error, error) {
+	_, err := t.ExecContext(ctx, `SAVEPOINT `+name)
+	if err != nil {
+		return nil, err
+	}
+	return func(err error) error {
+		if err != nil {
+			_, _ = t.ExecContext(ctx, `ROLLBACK TO SAVEPOINT `+name)
+			return err
+		}
+		_, err = t.ExecContext(ctx, `RELEASE SAVEPOINT `+name)
+		return err
+	}, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
@@ -0,0 +1,16 @@
+package sqldb_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users/internal/sqldb"
+)
+
+func TestRebind(t *testing.T) {
+	query := `SELECT id FROM users WHERE tenant_id=? AND id=?`
+
+	require.Equal(t, query, sqldb.Dialect{}.Rebind(query))
+	require.Equal(t, `SELECT id FROM users WHERE tenant_id=$1 AND id=$2`, sqldb.Dialect{NumberedPlaceholders: true}.Rebind(query))
+}
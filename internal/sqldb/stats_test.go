@@ -0,0 +1,77 @@
+package sqldb_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/sqldb"
+)
+
+func (s *sqlSuite) seedStatsUser(tenantID string, status users.Status, createdTime time.Time, deletedTime *time.Time) string {
+	id := uuid.New().String()
+	var deleted interface{}
+	if deletedTime != nil {
+		deleted = deletedTime.Unix()
+	}
+	query := `INSERT INTO users (id, tenant_id, email, password, address, status, version, updated_time, created_time, deleted_time)
+		VALUES (?, ?, ?, '', '', ?, 1, ?, ?, ?)`
+	s.exec(query, id, tenantID, id+"@doe.com", status, createdTime.Unix(), createdTime.Unix(), deleted)
+	return id
+}
+
+func (s *sqlSuite) TestCountByStatus() {
+	day := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	s.seedStatsUser(users.DefaultTenant, users.StatusActive, day, nil)
+	s.seedStatsUser(users.DefaultTenant, users.StatusActive, day, nil)
+	s.seedStatsUser(users.DefaultTenant, users.StatusLocked, day, nil)
+	s.seedStatsUser(users.DefaultTenant, users.StatusActive, day, &day)
+	s.seedStatsUser("acme", users.StatusActive, day, nil)
+
+	res, err := sqldb.NewStatsRepository(s.db, s.dialect).CountByStatus(context.Background())
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), map[users.Status]int64{users.StatusActive: 2, users.StatusLocked: 1}, res)
+}
+
+func (s *sqlSuite) TestCountByPeriod() {
+	day := func(d int) time.Time {
+		return time.Date(2021, 3, d, 0, 0, 0, 0, time.UTC)
+	}
+	deleted := day(2).Add(time.Hour)
+	s.seedStatsUser(users.DefaultTenant, users.StatusActive, day(1), nil)
+	s.seedStatsUser(users.DefaultTenant, users.StatusActive, day(1).Add(23*time.Hour), nil)
+	id := s.seedStatsUser(users.DefaultTenant, users.StatusActive, day(3), &deleted)
+	s.seedStatsUser(users.DefaultTenant, users.StatusActive, day(4), nil)
+	s.seedStatsUser("acme", users.StatusActive, day(2), nil)
+
+	repo := sqldb.NewStatsRepository(s.db, s.dialect)
+	ctx := context.Background()
+	require.NoError(s.T(), repo.RecordLogin(ctx, id, day(3).Add(time.Minute)))
+	require.NoError(s.T(), repo.RecordLogin(ctx, id, day(3).Add(time.Hour)))
+	require.NoError(s.T(), repo.RecordLogin(users.WithTenant(ctx, "acme"), id, day(3)))
+
+	bounds := []time.Time{day(1), day(2), day(3), day(4)}
+
+	signups, err := repo.CountSignups(ctx, bounds)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []int64{2, 0, 1}, signups)
+
+	deletions, err := repo.CountDeletions(ctx, bounds)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []int64{0, 1, 0}, deletions)
+
+	logins, err := repo.CountLogins(ctx, bounds)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []int64{0, 0, 2}, logins)
+
+	times, err := repo.ListLogins(ctx, id)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []time.Time{time.Unix(day(3).Add(time.Minute).Unix(), 0), time.Unix(day(3).Add(time.Hour).Unix(), 0)}, times)
+
+	acme, err := repo.CountSignups(users.WithTenant(ctx, "acme"), bounds[:2])
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []int64{0}, acme)
+}
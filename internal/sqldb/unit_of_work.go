@@ -0,0 +1,31 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/arnaz06/users"
+)
+
+type unitOfWork struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewUnitOfWork is constructor for the unit of work over the user repository.
+func NewUnitOfWork(db *sql.DB, dialect Dialect) users.UnitOfWork {
+	return unitOfWork{
+		db:      db,
+		dialect: dialect,
+	}
+}
+
+func (u unitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repo users.UserRepository) error) (err error) {
+	t, err := beginTx(ctx, u.db, u.dialect)
+	if err != nil {
+		return err
+	}
+	defer func() { err = t.end(err) }()
+
+	return fn(ctx, userRepo{db: u.db, dialect: u.dialect, tx: &t})
+}
@@ -0,0 +1,68 @@
+package sqldb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/sqldb"
+	"github.com/arnaz06/users/testdata"
+)
+
+func (u *sqlSuite) TestUnitOfWork() {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(u.T(), "user", &mockUser)
+	u.seedUser(mockUser)
+	unit := sqldb.NewUnitOfWork(u.db, u.dialect)
+
+	u.T().Run("with rollback", func(t *testing.T) {
+		err := unit.Do(context.Background(), func(ctx context.Context, repo users.UserRepository) error {
+			_, err := repo.Create(ctx, users.User{Email: "jane@doe.com", Password: mockUser.Password})
+			require.NoError(t, err)
+
+			// The writes of the unit of work are seen by its own reads.
+			_, err = repo.GetByEmail(ctx, "jane@doe.com")
+			require.NoError(t, err)
+
+			err = repo.Delete(ctx, mockUser.ID, mockUser.Version)
+			require.NoError(t, err)
+			return errors.New("unexpected error")
+		})
+		require.EqualError(t, err, "unexpected error")
+		require.Equal(t, 1, u.countUsers())
+		require.Equal(t, mockUser.ID, u.getUser(mockUser.ID).ID)
+	})
+
+	u.T().Run("with dry run batch", func(t *testing.T) {
+		err := unit.Do(context.Background(), func(ctx context.Context, repo users.UserRepository) error {
+			errs, err := repo.CreateBatch(ctx, []users.User{{Email: "bob@doe.com", Password: mockUser.Password}}, true)
+			require.NoError(t, err)
+			require.NoError(t, errs[0])
+
+			_, err = repo.Create(ctx, users.User{Email: "jane@doe.com", Password: mockUser.Password})
+			return err
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, u.countUsers())
+
+		_, err = sqldb.NewUserRepository(u.db, u.dialect).GetByEmail(context.Background(), "bob@doe.com")
+		require.Equal(t, users.ErrNotFound, err)
+	})
+
+	u.T().Run("success", func(t *testing.T) {
+		var created users.User
+		err := unit.Do(context.Background(), func(ctx context.Context, repo users.UserRepository) (err error) {
+			created, err = repo.Create(ctx, users.User{Email: "alice@doe.com", Password: mockUser.Password})
+			if err != nil {
+				return err
+			}
+			return repo.Delete(ctx, mockUser.ID, mockUser.Version)
+		})
+		require.NoError(t, err)
+		require.Equal(t, created.ID, u.getUser(created.ID).ID)
+		require.Empty(t, u.getUser(mockUser.ID).ID)
+	})
+}
@@ -0,0 +1,534 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arnaz06/users"
+)
+
+const userColumns = `id, email, username, password, address, status, version, deleted_time, updated_time, created_time`
+
+type userRepo struct {
+	db      *sql.DB
+	dialect Dialect
+	// tx is the transaction of the unit of work the repository is part of, if any.
+	tx *tx
+}
+
+// NewUserRepository is constructor for user repository.
+// Every query is scoped by the tenant carried in the context.
+func NewUserRepository(db *sql.DB, dialect Dialect) users.UserRepository {
+	return userRepo{
+		db:      db,
+		dialect: dialect,
+	}
+}
+
+// conn returns the transaction of the unit of work, or else the database.
+func (r userRepo) conn() conn {
+	if r.tx != nil {
+		return r.tx.conn
+	}
+	return conn{q: r.db, dialect: r.dialect}
+}
+
+// beginTx starts the transaction of a write, or joins the one of the unit of work.
+// The returned function ends a started transaction, committing it unless the write failed.
+// A joined transaction is left to be ended by the unit of work, the write being made in a savepoint
+// so that its failure does not abort the whole transaction.
+func (r userRepo) beginTx(ctx context.Context) (tx, func(err error) error, error) {
+	if r.tx != nil {
+		release, err := r.tx.savepoint(ctx, "write")
+		if err != nil {
+			return tx{}, nil, err
+		}
+		return *r.tx, release, nil
+	}
+
+	t, err := beginTx(ctx, r.db, r.dialect)
+	if err != nil {
+		return tx{}, nil, err
+	}
+	return t, t.end, nil
+}
+
+func (r userRepo) Create(ctx context.Context, user users.User) (res users.User, err error) {
+	t, end, err := r.beginTx(ctx)
+	if err != nil {
+		return users.User{}, err
+	}
+	defer func() { err = end(err) }()
+
+	return insertUser(ctx, t, user)
+}
+
+func (r userRepo) CreateBatch(ctx context.Context, list []users.User, dryRun bool) (errs []error, err error) {
+	t, end, err := r.beginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = end(err) }()
+
+	// A dry run is rolled back to a savepoint, so it is also undone within a unit of work.
+	if dryRun {
+		_, err = t.ExecContext(ctx, `SAVEPOINT create_batch`)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_, rollbackErr := t.ExecContext(ctx, `ROLLBACK TO SAVEPOINT create_batch`)
+			if err == nil && rollbackErr != nil {
+				errs, err = nil, rollbackErr
+			}
+		}()
+	}
+
+	// Each insert is made in its own savepoint, so the violations of the unique indexes
+	// are reported per user while the rest of the batch goes on.
+	errs = make([]error, len(list))
+	for i, user := range list {
+		var release func(err error) error
+		release, err = t.savepoint(ctx, "create_user")
+		if err != nil {
+			return nil, err
+		}
+		_, err = insertUser(ctx, t, user)
+		err = release(err)
+		if err != nil {
+			if _, ok := err.(users.ConstraintError); !ok {
+				return nil, err
+			}
+			errs[i] = err
+			err = nil
+		}
+	}
+	return errs, nil
+}
+
+// insertUser inserts the user, and the history entry of its creation, in the transaction.
+func insertUser(ctx context.Context, t tx, user users.User) (users.User, error) {
+	query := `INSERT INTO users (id, tenant_id, email, username, password, address, status, version, updated_time, created_time)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	now := time.Now()
+	user.CreatedTime = now
+	user.UpdatedTime = now
+	user.Version = 1
+	if user.ID == "" {
+		user.ID = uuid.New().String()
+	}
+	if user.Status == "" {
+		user.Status = users.StatusActive
+	}
+
+	_, err := t.ExecContext(ctx, query, user.ID, users.TenantFromContext(ctx), user.Email, nullString(user.Username), user.Password, user.Address, user.Status, user.Version, user.UpdatedTime.Unix(), user.CreatedTime.Unix())
+	if err != nil {
+		return users.User{}, mapUniqueError(t.dialect, err, user)
+	}
+
+	err = insertHistory(ctx, t, user.ID, users.HistoryCreate, users.DiffUsers(users.User{}, user), now)
+	if err != nil {
+		return users.User{}, err
+	}
+	return user, nil
+}
+
+func (r userRepo) Get(ctx context.Context, id string) (users.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE tenant_id=? AND id=? AND deleted_time IS NULL`
+	return r.get(ctx, query, id)
+}
+
+// GetByEmail matches the email regardless of its case, as the unique index of the emails does.
+func (r userRepo) GetByEmail(ctx context.Context, email string) (users.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE tenant_id=? AND LOWER(email)=LOWER(?) AND deleted_time IS NULL`
+	return r.get(ctx, query, email)
+}
+
+func (r userRepo) GetByUsername(ctx context.Context, username string) (users.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE tenant_id=? AND LOWER(username)=? AND deleted_time IS NULL`
+	return r.get(ctx, query, users.NormalizeUsername(username))
+}
+
+func (r userRepo) get(ctx context.Context, query, value string) (users.User, error) {
+	res, err := scanUser(r.conn().QueryRowContext(ctx, query, users.TenantFromContext(ctx), value))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return users.User{}, users.ErrNotFound
+		}
+		return users.User{}, err
+	}
+
+	return res, nil
+}
+
+func (r userRepo) Update(ctx context.Context, user users.User) (err error) {
+	t, end, err := r.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = end(err) }()
+
+	tenantID := users.TenantFromContext(ctx)
+
+	// The user is read first for the history to record the values before the update.
+	before, err := scanUser(t.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE tenant_id=? AND id=? AND deleted_time IS NULL`+r.dialect.ForUpdate, tenantID, user.ID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return users.ErrNotFound
+		}
+		return err
+	}
+	if user.Version != 0 && user.Version != before.Version {
+		return users.ErrPreconditionFailed
+	}
+
+	after := before
+	after.Email = user.Email
+	after.Username = user.Username
+	after.Password = user.Password
+	after.Address = user.Address
+	after.UpdatedTime = time.Now()
+
+	query := `UPDATE users SET email=?, username=?, password=?, address=?, version=version+1, updated_time=? WHERE tenant_id=? AND id=?`
+	_, err = t.ExecContext(ctx, query, after.Email, nullString(after.Username), after.Password, after.Address, after.UpdatedTime.Unix(), tenantID, user.ID)
+	if err != nil {
+		return mapUniqueError(r.dialect, err, user)
+	}
+
+	return insertHistory(ctx, t, user.ID, users.HistoryUpdate, users.DiffUsers(before, after), after.UpdatedTime)
+}
+
+func (r userRepo) Delete(ctx context.Context, id string, version int64) (err error) {
+	t, end, err := r.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = end(err) }()
+
+	now := time.Now()
+	query := `UPDATE users SET deleted_time=?, version=version+1 WHERE tenant_id=? AND id=? AND deleted_time IS NULL`
+	args := []interface{}{now.Unix(), users.TenantFromContext(ctx), id}
+	if version != 0 {
+		query += ` AND version=?`
+		args = append(args, version)
+	}
+
+	res, err := t.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return r.notAffectedError(ctx, t, id, version)
+	}
+
+	return insertHistory(ctx, t, id, users.HistoryDelete, map[string]users.FieldChange{}, now)
+}
+
+// notAffectedError tells apart a missing user from a version mismatch after a write affected no rows.
+func (r userRepo) notAffectedError(ctx context.Context, t tx, id string, version int64) error {
+	if version == 0 {
+		return users.ErrNotFound
+	}
+
+	_, err := r.inTx(t).Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return users.ErrPreconditionFailed
+}
+
+// inTx returns the repository running its queries in the transaction.
+func (r userRepo) inTx(t tx) userRepo {
+	r.tx = &t
+	return r
+}
+
+func (r userRepo) ListDeleted(ctx context.Context) ([]users.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE tenant_id=? AND deleted_time IS NOT NULL ORDER BY deleted_time DESC`
+	rows, err := r.conn().QueryContext(ctx, query, users.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []users.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, user)
+	}
+
+	return res, rows.Err()
+}
+
+func (r userRepo) Restore(ctx context.Context, id string) (err error) {
+	t, end, err := r.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = end(err) }()
+
+	tenantID := users.TenantFromContext(ctx)
+
+	var user users.User
+	var username sql.NullString
+	err = t.QueryRowContext(ctx, `SELECT email, username FROM users WHERE tenant_id=? AND id=? AND deleted_time IS NOT NULL`+r.dialect.ForUpdate, tenantID, id).Scan(&user.Email, &username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return users.ErrNotFound
+		}
+		return err
+	}
+	user.Username = username.String
+
+	// The email is checked before the username, as the databases do not tell the same index apart
+	// when both were taken since the deletion. A concurrent restore still violates the unique indexes.
+	var taken int
+	err = t.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE tenant_id=? AND LOWER(email)=LOWER(?) AND deleted_time IS NULL`, tenantID, user.Email).Scan(&taken)
+	if err != nil {
+		return err
+	}
+	if taken > 0 {
+		return users.ConstraintErrorf("email %s is already used by another user", user.Email)
+	}
+
+	if username.Valid {
+		err = t.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE tenant_id=? AND LOWER(username)=? AND deleted_time IS NULL`, tenantID, users.NormalizeUsername(user.Username)).Scan(&taken)
+		if err != nil {
+			return err
+		}
+		if taken > 0 {
+			return users.ConstraintErrorf("username %s is already used by another user", user.Username)
+		}
+	}
+
+	now := time.Now()
+	query := `UPDATE users SET deleted_time=NULL, version=version+1, updated_time=? WHERE tenant_id=? AND id=?`
+	_, err = t.ExecContext(ctx, query, now.Unix(), tenantID, id)
+	if err != nil {
+		return mapUniqueError(r.dialect, err, user)
+	}
+
+	return insertHistory(ctx, t, id, users.HistoryRestore, map[string]users.FieldChange{}, now)
+}
+
+func (r userRepo) HardDelete(ctx context.Context, id string) (err error) {
+	t, end, err := r.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = end(err) }()
+
+	tenantID := users.TenantFromContext(ctx)
+	res, err := t.ExecContext(ctx, `DELETE FROM users WHERE tenant_id=? AND id=? AND deleted_time IS NOT NULL`, tenantID, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return users.ErrNotFound
+	}
+
+	return deleteRecords(ctx, t, tenantID, id)
+}
+
+// Purge is a maintenance task removing the deleted users of every tenant.
+func (r userRepo) Purge(ctx context.Context, deletedBefore time.Time) (purged []users.PurgedUser, err error) {
+	t, end, err := r.beginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = end(err) }()
+
+	rows, err := t.QueryContext(ctx, `SELECT tenant_id, id FROM users WHERE deleted_time IS NOT NULL AND deleted_time < ?`+r.dialect.ForUpdate, deletedBefore.Unix())
+	if err != nil {
+		return nil, err
+	}
+	purged = []users.PurgedUser{}
+	for rows.Next() {
+		var user users.PurgedUser
+		if err = rows.Scan(&user.TenantID, &user.ID); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		purged = append(purged, user)
+	}
+	// The rows are closed before the deletes, which run on the same connection.
+	_ = rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, user := range purged {
+		_, err = t.ExecContext(ctx, `DELETE FROM users WHERE tenant_id=? AND id=?`, user.TenantID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		err = deleteRecords(ctx, t, user.TenantID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return purged, nil
+}
+
+// recordTables are the tables of what is recorded about the users, deleted along with them.
+var recordTables = []string{"user_history", "user_status_changes", "email_changes", "user_preferences", "user_logins"}
+
+// deleteRecords deletes everything recorded about the permanently deleted user, in the transaction deleting it.
+func deleteRecords(ctx context.Context, t tx, tenantID, userID string) error {
+	for _, table := range recordTables {
+		_, err := t.ExecContext(ctx, `DELETE FROM `+table+` WHERE tenant_id=? AND user_id=?`, tenantID, userID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r userRepo) UpdateStatus(ctx context.Context, change users.StatusChange) (err error) {
+	t, end, err := r.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = end(err) }()
+
+	if change.CreatedTime.IsZero() {
+		change.CreatedTime = time.Now()
+	}
+
+	tenantID := users.TenantFromContext(ctx)
+
+	query := `UPDATE users SET status=?, version=version+1, updated_time=? WHERE tenant_id=? AND id=? AND status=? AND deleted_time IS NULL`
+	res, err := t.ExecContext(ctx, query, change.To, change.CreatedTime.Unix(), tenantID, change.UserID, change.From)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		_, err = r.inTx(t).Get(ctx, change.UserID)
+		if err != nil {
+			return err
+		}
+		return users.ErrPreconditionFailed
+	}
+
+	query = `INSERT INTO user_status_changes (tenant_id, user_id, from_status, to_status, reason, actor, created_time) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err = t.ExecContext(ctx, query, tenantID, change.UserID, change.From, change.To, change.Reason, change.Actor, change.CreatedTime.Unix())
+	if err != nil {
+		return err
+	}
+
+	changes := map[string]users.FieldChange{
+		"status": {Before: string(change.From), After: string(change.To)},
+	}
+	return insertHistory(users.WithActor(ctx, change.Actor), t, change.UserID, users.HistoryStatusChange, changes, change.CreatedTime)
+}
+
+func (r userRepo) ListStatusChanges(ctx context.Context, userID string) ([]users.StatusChange, error) {
+	query := `SELECT user_id, from_status, to_status, reason, actor, created_time FROM user_status_changes WHERE tenant_id=? AND user_id=? ORDER BY id`
+	rows, err := r.conn().QueryContext(ctx, query, users.TenantFromContext(ctx), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []users.StatusChange{}
+	for rows.Next() {
+		var change users.StatusChange
+		createdTime := int64(0)
+		err = rows.Scan(
+			&change.UserID,
+			&change.From,
+			&change.To,
+			&change.Reason,
+			&change.Actor,
+			&createdTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		change.CreatedTime = time.Unix(createdTime, 0)
+		res = append(res, change)
+	}
+
+	return res, rows.Err()
+}
+
+func scanUser(row rowScanner) (users.User, error) {
+	var res users.User
+	var username sql.NullString
+	var deletedTime sql.NullInt64
+	updatedTime := int64(0)
+	createdTime := int64(0)
+	err := row.Scan(
+		&res.ID,
+		&res.Email,
+		&username,
+		&res.Password,
+		&res.Address,
+		&res.Status,
+		&res.Version,
+		&deletedTime,
+		&updatedTime,
+		&createdTime,
+	)
+	if err != nil {
+		return users.User{}, err
+	}
+
+	if deletedTime.Valid {
+		deleted := time.Unix(deletedTime.Int64, 0)
+		res.DeletedTime = &deleted
+	}
+	res.Username = username.String
+	res.UpdatedTime = time.Unix(updatedTime, 0)
+	res.CreatedTime = time.Unix(createdTime, 0)
+	return res, nil
+}
+
+// mapEmailError maps the violation of the per-tenant unique email to a ConstraintError.
+func mapEmailError(dialect Dialect, err error, email string) error {
+	if dialect.UniqueViolation(err) == EmailIndex {
+		return users.ConstraintErrorf("email %s is already used by another user", email)
+	}
+	return err
+}
+
+// mapUniqueError maps the violation of the per-tenant unique email or username to a ConstraintError.
+func mapUniqueError(dialect Dialect, err error, user users.User) error {
+	if dialect.UniqueViolation(err) == UsernameIndex {
+		return users.ConstraintErrorf("username %s is already used by another user", user.Username)
+	}
+	return mapEmailError(dialect, err, user.Email)
+}
+
+// nullString stores the empty string as NULL, so it is not subject to unique indexes.
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,46 @@
+package sqldb_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/repotest"
+	"github.com/arnaz06/users/internal/sqldb"
+)
+
+func (s *sqlSuite) TestConformance() {
+	repotest.TestUserRepository(s.T(), func(t *testing.T) users.UserRepository {
+		s.clear(t)
+		return sqldb.NewUserRepository(s.db, s.dialect)
+	})
+}
+
+func (s *sqlSuite) TestHardDeleteRecords() {
+	ctx := context.Background()
+	repo := sqldb.NewUserRepository(s.db, s.dialect)
+	user := s.createUser(users.User{Email: "jhon@doe.com", Password: "hash"})
+	other := s.createUser(users.User{Email: "jane@doe.com", Password: "hash"})
+
+	for _, id := range []string{user.ID, other.ID} {
+		require.NoError(s.T(), sqldb.NewPreferencesRepository(s.db, s.dialect).Save(ctx, id, users.DefaultPreferences()))
+		require.NoError(s.T(), sqldb.NewStatsRepository(s.db, s.dialect).RecordLogin(ctx, id, time.Now()))
+	}
+	s.createChange(user, "new@doe.com")
+	require.NoError(s.T(), repo.Delete(ctx, user.ID, 0))
+	require.NoError(s.T(), repo.HardDelete(ctx, user.ID))
+
+	for _, table := range []string{"user_history", "user_status_changes", "email_changes", "user_preferences", "user_logins"} {
+		var count int
+		err := s.db.QueryRow(s.dialect.Rebind(`SELECT COUNT(*) FROM `+table+` WHERE user_id=?`), user.ID).Scan(&count)
+		require.NoError(s.T(), err)
+		require.Zero(s.T(), count, table)
+	}
+
+	// The records of the other users are kept.
+	_, err := sqldb.NewPreferencesRepository(s.db, s.dialect).Get(ctx, other.ID)
+	require.NoError(s.T(), err)
+}
@@ -0,0 +1,180 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	// Imported for connecting to SQLite DB, with no cgo
+	_ "modernc.org/sqlite"
+)
+
+// migrations create the schema, one migration per step. The version of the schema is the user_version of the
+// database, which is the number of migrations applied, so migrations are only ever appended.
+var migrations = []string{
+	// The users are keyed by seq for the search index, as the implicit rowids may be changed by a VACUUM.
+	`CREATE TABLE IF NOT EXISTS users (
+		seq INTEGER PRIMARY KEY AUTOINCREMENT,
+		id TEXT NOT NULL UNIQUE,
+		tenant_id TEXT NOT NULL DEFAULT 'default',
+		email TEXT NOT NULL,
+		username TEXT DEFAULT NULL,
+		password TEXT NOT NULL,
+		address TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT 'active',
+		version INTEGER NOT NULL DEFAULT 1,
+		deleted_time INTEGER DEFAULT NULL,
+		created_time INTEGER NOT NULL DEFAULT 0,
+		updated_time INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS users_tenant_email_idx ON users (tenant_id, email);
+	CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_active_email_idx ON users (tenant_id, email) WHERE deleted_time IS NULL;
+	CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_active_username_idx ON users (tenant_id, LOWER(username)) WHERE deleted_time IS NULL;
+	CREATE INDEX IF NOT EXISTS users_tenant_created_time_idx ON users (tenant_id, created_time, id);
+	CREATE INDEX IF NOT EXISTS users_tenant_status_created_time_idx ON users (tenant_id, status, created_time, id);
+	CREATE INDEX IF NOT EXISTS users_tenant_deleted_time_idx ON users (tenant_id, deleted_time);`,
+
+	`CREATE VIRTUAL TABLE IF NOT EXISTS users_search USING fts5(email, username, address, content='users', content_rowid='seq');
+	CREATE TRIGGER IF NOT EXISTS users_search_insert AFTER INSERT ON users BEGIN
+		INSERT INTO users_search (rowid, email, username, address) VALUES (new.seq, new.email, COALESCE(new.username, ''), new.address);
+	END;
+	CREATE TRIGGER IF NOT EXISTS users_search_delete AFTER DELETE ON users BEGIN
+		INSERT INTO users_search (users_search, rowid, email, username, address) VALUES ('delete', old.seq, old.email, COALESCE(old.username, ''), old.address);
+	END;
+	CREATE TRIGGER IF NOT EXISTS users_search_update AFTER UPDATE OF email, username, address ON users BEGIN
+		INSERT INTO users_search (users_search, rowid, email, username, address) VALUES ('delete', old.seq, old.email, COALESCE(old.username, ''), old.address);
+		INSERT INTO users_search (rowid, email, username, address) VALUES (new.seq, new.email, COALESCE(new.username, ''), new.address);
+	END;`,
+
+	`CREATE TABLE IF NOT EXISTS user_status_changes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		tenant_id TEXT NOT NULL DEFAULT 'default',
+		user_id TEXT NOT NULL,
+		from_status TEXT NOT NULL,
+		to_status TEXT NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		actor TEXT NOT NULL DEFAULT '',
+		created_time INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS user_status_changes_tenant_user_id_idx ON user_status_changes (tenant_id, user_id);`,
+
+	`CREATE TABLE IF NOT EXISTS email_changes (
+		id TEXT NOT NULL PRIMARY KEY,
+		tenant_id TEXT NOT NULL DEFAULT 'default',
+		user_id TEXT NOT NULL,
+		old_email TEXT NOT NULL,
+		new_email TEXT NOT NULL,
+		confirm_token_hash TEXT NOT NULL UNIQUE,
+		revert_token_hash TEXT NOT NULL UNIQUE,
+		confirm_expires_time INTEGER NOT NULL DEFAULT 0,
+		revert_expires_time INTEGER NOT NULL DEFAULT 0,
+		confirmed_time INTEGER DEFAULT NULL,
+		reverted_time INTEGER DEFAULT NULL,
+		created_time INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS email_changes_tenant_user_id_idx ON email_changes (tenant_id, user_id);`,
+
+	`CREATE TABLE IF NOT EXISTS user_preferences (
+		tenant_id TEXT NOT NULL DEFAULT 'default',
+		user_id TEXT NOT NULL,
+		preferences TEXT NOT NULL,
+		updated_time INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (tenant_id, user_id)
+	);`,
+
+	`CREATE TABLE IF NOT EXISTS user_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		tenant_id TEXT NOT NULL DEFAULT 'default',
+		user_id TEXT NOT NULL,
+		action TEXT NOT NULL,
+		actor TEXT NOT NULL DEFAULT '',
+		changes TEXT NOT NULL,
+		created_time INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS user_history_tenant_user_id_idx ON user_history (tenant_id, user_id, id);`,
+
+	`CREATE TABLE IF NOT EXISTS user_logins (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		tenant_id TEXT NOT NULL DEFAULT 'default',
+		user_id TEXT NOT NULL,
+		created_time INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS user_logins_tenant_created_time_idx ON user_logins (tenant_id, created_time);`,
+
+	// The logins are deleted and exported by user.
+	`CREATE INDEX IF NOT EXISTS user_logins_tenant_user_id_idx ON user_logins (tenant_id, user_id);`,
+
+	// The emails are unique regardless of their case, as with MySQL.
+	`DROP INDEX IF EXISTS users_tenant_active_email_idx;
+	CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_active_email_idx ON users (tenant_id, LOWER(email)) WHERE deleted_time IS NULL;`,
+}
+
+// Open opens the SQLite database stored in the file, or kept in memory when the file is :memory:,
+// and applies the migrations not applied yet.
+// The pool has a single connection, so that the writes are not rejected while another one is made,
+// and the database kept in memory is not lost with the connection. A write must thus never query
+// the database outside of its own transaction.
+func Open(file string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", file)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+
+	// The other processes using the file, such as the commands run next to the server, are waited for.
+	_, err = db.Exec(`PRAGMA busy_timeout = 5000`)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	err = Migrate(context.Background(), db)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Migrate applies the migrations not applied yet to the database, each one in its own transaction.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	var version int
+	err := db.QueryRowContext(ctx, `PRAGMA user_version`).Scan(&version)
+	if err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than the latest migration %d", version, len(migrations))
+	}
+
+	for ; version < len(migrations); version++ {
+		err = migrate(ctx, db, version)
+		if err != nil {
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+	}
+	return nil
+}
+
+func migrate(ctx context.Context, db *sql.DB, version int) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	_, err = tx.ExecContext(ctx, migrations[version])
+	if err != nil {
+		return err
+	}
+	// The pragmas take no parameters, the version being formatted as a plain integer.
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`PRAGMA user_version = %d`, version+1))
+	return err
+}
@@ -0,0 +1,59 @@
+package sqlite_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/sqlite"
+)
+
+func TestOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "users-sqlite")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "users.db")
+
+	db, err := sqlite.Open(file)
+	require.NoError(t, err)
+	created, err := sqlite.NewUserRepository(db).Create(context.Background(), users.User{Email: "jhon@doe.com", Password: "hash"})
+	require.NoError(t, err)
+
+	var version int
+	require.NoError(t, db.QueryRow(`PRAGMA user_version`).Scan(&version))
+	require.NoError(t, db.Close())
+
+	// The migrations already applied are not applied again.
+	db, err = sqlite.Open(file)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var reopened int
+	require.NoError(t, db.QueryRow(`PRAGMA user_version`).Scan(&reopened))
+	require.Equal(t, version, reopened)
+
+	res, err := sqlite.NewUserRepository(db).Get(context.Background(), created.ID)
+	require.NoError(t, err)
+	require.Equal(t, created.Email, res.Email)
+}
+
+func TestOpenWithNewerSchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "users-sqlite")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "users.db")
+
+	db, err := sqlite.Open(file)
+	require.NoError(t, err)
+	_, err = db.Exec(`PRAGMA user_version = 1000`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	_, err = sqlite.Open(file)
+	require.Error(t, err)
+}
@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"database/sql"
+	"strings"
+
+	"modernc.org/sqlite"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/sqldb"
+)
+
+// errConstraintUnique is the SQLite extended result code of a unique index violation.
+const errConstraintUnique = 2067
+
+// Dialect is the SQL dialect of SQLite. A write locks the whole database, so the rows read are not locked.
+var Dialect = sqldb.Dialect{
+	UniqueViolation: uniqueViolation,
+}
+
+// uniqueViolation tells the unique index of the active users violated by the error, named by SQLite after the index
+// when it is on an expression, or else after its columns.
+func uniqueViolation(err error) string {
+	e, ok := err.(*sqlite.Error)
+	if !ok || e.Code() != errConstraintUnique {
+		return ""
+	}
+	switch {
+	case strings.Contains(e.Error(), "users_tenant_active_email_idx"):
+		return sqldb.EmailIndex
+	case strings.Contains(e.Error(), "users_tenant_active_username_idx"):
+		return sqldb.UsernameIndex
+	}
+	return ""
+}
+
+// NewUserRepository is constructor for user repository.
+// Every query is scoped by the tenant carried in the context.
+func NewUserRepository(db *sql.DB) users.UserRepository {
+	return sqldb.NewUserRepository(db, Dialect)
+}
+
+// NewEmailChangeRepository is constructor for email change repository.
+// Every query is scoped by the tenant carried in the context.
+func NewEmailChangeRepository(db *sql.DB) users.EmailChangeRepository {
+	return sqldb.NewEmailChangeRepository(db, Dialect)
+}
+
+// NewPreferencesRepository is constructor for preferences repository.
+// Every query is scoped by the tenant carried in the context.
+func NewPreferencesRepository(db *sql.DB) users.PreferencesRepository {
+	return sqldb.NewPreferencesRepository(db, Dialect)
+}
+
+// NewStatsRepository is constructor for the statistics repository.
+func NewStatsRepository(db *sql.DB) users.StatsRepository {
+	return sqldb.NewStatsRepository(db, Dialect)
+}
+
+// NewUnitOfWork is constructor for the unit of work over the user repository.
+func NewUnitOfWork(db *sql.DB) users.UnitOfWork {
+	return sqldb.NewUnitOfWork(db, Dialect)
+}
@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"unicode"
+
+	"github.com/arnaz06/users"
+)
+
+type searcher struct {
+	db *sql.DB
+}
+
+// NewSearcher is constructor for the search of users over the FTS5 index of SQLite, used when no search index is set up.
+// Unlike the search index, it only matches the words starting with the words of the query.
+func NewSearcher(db *sql.DB) users.Searcher {
+	return searcher{
+		db: db,
+	}
+}
+
+// prefixQuery turns the query into a FTS5 query, requiring a word starting with each of its words.
+// Anything but letters and digits is dropped, so the operators of the FTS5 queries can not be injected.
+func prefixQuery(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = `"` + word + `"*`
+	}
+	return strings.Join(words, " ")
+}
+
+func (s searcher) Search(ctx context.Context, query string, limit int) ([]string, error) {
+	match := prefixQuery(query)
+	if match == "" {
+		return []string{}, nil
+	}
+
+	q := `SELECT users.id FROM users_search JOIN users ON users.seq=users_search.rowid
+		WHERE users_search MATCH ? AND users.tenant_id=? AND users.deleted_time IS NULL ORDER BY users_search.rank, users.id LIMIT ?`
+	rows, err := s.db.QueryContext(ctx, q, match, users.TenantFromContext(ctx), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+
+	return res, rows.Err()
+}
@@ -0,0 +1,82 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/sqlite"
+)
+
+type searchSuite struct {
+	sqliteSuite
+}
+
+func TestSearchSuite(t *testing.T) {
+	suite.Run(t, new(searchSuite))
+}
+
+func (u *searchSuite) SetupTest() {
+	_, err := u.db.Exec("DELETE FROM users")
+	require.NoError(u.T(), err)
+}
+
+func (u *searchSuite) seedUser(user users.User) {
+	query := `INSERT INTO users (id, email, username, password, address, status, updated_time, created_time) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	username := sql.NullString{String: user.Username, Valid: user.Username != ""}
+	_, err := u.db.Exec(query, user.ID, user.Email, username, user.Password, user.Address, user.Status, time.Now().Unix(), time.Now().Unix())
+	require.NoError(u.T(), err)
+}
+
+func (u *searchSuite) seedDeletedUser(user users.User, deletedTime time.Time) {
+	u.seedUser(user)
+	_, err := u.db.Exec(`UPDATE users SET deleted_time=? WHERE id=?`, deletedTime.Unix(), user.ID)
+	require.NoError(u.T(), err)
+}
+
+func (u *searchSuite) TestSearch() {
+	u.seedUser(users.User{ID: "1", Email: "jhon@doe.com", Username: "jhon.doe", Address: "Jalan Sudirman, Jakarta", Status: users.StatusActive})
+	u.seedUser(users.User{ID: "2", Email: "jane@smith.com", Address: "Baker Street, London", Status: users.StatusActive})
+	u.seedDeletedUser(users.User{ID: "3", Email: "jhon@acme.com", Status: users.StatusActive}, time.Now())
+	searcher := sqlite.NewSearcher(u.db)
+
+	tests := []struct {
+		testName    string
+		query       string
+		expectedIDs []string
+	}{
+		{
+			testName:    "by the start of a word",
+			query:       "jak",
+			expectedIDs: []string{"1"},
+		},
+		{
+			testName:    "with every word required",
+			query:       "baker lond",
+			expectedIDs: []string{"2"},
+		},
+		{
+			testName:    "with boolean operators",
+			query:       `"smith -(`,
+			expectedIDs: []string{"2"},
+		},
+		{
+			testName:    "with no word",
+			query:       "@@",
+			expectedIDs: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		u.T().Run(test.testName, func(t *testing.T) {
+			ids, err := searcher.Search(context.Background(), test.query, 10)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedIDs, ids)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package sqlite_test
+
+import (
+	"database/sql"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/arnaz06/users/internal/sqlite"
+)
+
+type sqliteSuite struct {
+	suite.Suite
+	db *sql.DB
+}
+
+func (s *sqliteSuite) SetupSuite() {
+	db, err := sqlite.Open(":memory:")
+	require.NoError(s.T(), err)
+	s.db = db
+}
+
+func (s *sqliteSuite) TearDownSuite() {
+	require.NoError(s.T(), s.db.Close())
+}
@@ -0,0 +1,58 @@
+package users
+
+import "time"
+
+// UserSort is a column the users can be listed by. Every sort is backed by an index.
+type UserSort string
+
+const (
+	// SortCreatedTime sorts the users by their create time.
+	SortCreatedTime UserSort = "created_time"
+	// SortEmail sorts the users by their email.
+	SortEmail UserSort = "email"
+)
+
+// DeletedFilter tells whether soft-deleted users are listed.
+type DeletedFilter string
+
+const (
+	// DeletedExclude only lists the users not deleted.
+	DeletedExclude DeletedFilter = "exclude"
+	// DeletedOnly only lists the soft-deleted users.
+	DeletedOnly DeletedFilter = "only"
+	// DeletedInclude lists the users whether they are deleted or not.
+	DeletedInclude DeletedFilter = "include"
+)
+
+// UserKey is the position of a user in a listing, the sort key of the last user of a page.
+type UserKey struct {
+	ID          string
+	Email       string
+	CreatedTime time.Time
+}
+
+// UserFilter is the struct represent the criteria of a listing of users.
+// The zero values match every user, but for Deleted, which excludes the deleted users.
+// CreatedFrom is inclusive and CreatedTo is exclusive. The users are listed after the
+// position After, if not nil, ordered by Sort then by ID, descending when Desc is set.
+type UserFilter struct {
+	EmailPrefix string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	Status      Status
+	Deleted     DeletedFilter
+	Sort        UserSort
+	Desc        bool
+	After       *UserKey
+	Limit       int
+	WithTotal   bool
+}
+
+// UserPage is the struct represent a page of a listing of users.
+// NextCursor is empty on the last page. Total is only set when requested, and counts
+// every user matching the filter, regardless of the page.
+type UserPage struct {
+	Users      []User `json:"users"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+}
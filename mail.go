@@ -0,0 +1,8 @@
+package users
+
+import "context"
+
+// Mailer is interface of email sender.
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
@@ -4,6 +4,7 @@ package mocks
 
 import (
 	context "context"
+	time "time"
 
 	users "github.com/arnaz06/users"
 	mock "github.com/stretchr/testify/mock"
@@ -14,6 +15,27 @@ type UserRepository struct {
 	mock.Mock
 }
 
+// Count provides a mock function with given fields: ctx, filter
+func (_m *UserRepository) Count(ctx context.Context, filter users.UserFilter) (int64, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, users.UserFilter) int64); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, users.UserFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: ctx, user
 func (_m *UserRepository) Create(ctx context.Context, user users.User) (users.User, error) {
 	ret := _m.Called(ctx, user)
@@ -35,13 +57,36 @@ func (_m *UserRepository) Create(ctx context.Context, user users.User) (users.Us
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: ctx, id
-func (_m *UserRepository) Delete(ctx context.Context, id string) error {
-	ret := _m.Called(ctx, id)
+// CreateBatch provides a mock function with given fields: ctx, list, dryRun
+func (_m *UserRepository) CreateBatch(ctx context.Context, list []users.User, dryRun bool) ([]error, error) {
+	ret := _m.Called(ctx, list, dryRun)
+
+	var r0 []error
+	if rf, ok := ret.Get(0).(func(context.Context, []users.User, bool) []error); ok {
+		r0 = rf(ctx, list, dryRun)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]error)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []users.User, bool) error); ok {
+		r1 = rf(ctx, list, dryRun)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: ctx, id, version
+func (_m *UserRepository) Delete(ctx context.Context, id string, version int64) error {
+	ret := _m.Called(ctx, id, version)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, id, version)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -91,6 +136,170 @@ func (_m *UserRepository) GetByEmail(ctx context.Context, email string) (users.U
 	return r0, r1
 }
 
+// GetByUsername provides a mock function with given fields: ctx, username
+func (_m *UserRepository) GetByUsername(ctx context.Context, username string) (users.User, error) {
+	ret := _m.Called(ctx, username)
+
+	var r0 users.User
+	if rf, ok := ret.Get(0).(func(context.Context, string) users.User); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Get(0).(users.User)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HardDelete provides a mock function with given fields: ctx, id
+func (_m *UserRepository) HardDelete(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// List provides a mock function with given fields: ctx, filter
+func (_m *UserRepository) List(ctx context.Context, filter users.UserFilter) ([]users.User, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []users.User
+	if rf, ok := ret.Get(0).(func(context.Context, users.UserFilter) []users.User); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]users.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, users.UserFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListDeleted provides a mock function with given fields: ctx
+func (_m *UserRepository) ListDeleted(ctx context.Context) ([]users.User, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []users.User
+	if rf, ok := ret.Get(0).(func(context.Context) []users.User); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]users.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListHistory provides a mock function with given fields: ctx, userID, beforeID, limit
+func (_m *UserRepository) ListHistory(ctx context.Context, userID string, beforeID int64, limit int) ([]users.HistoryEntry, error) {
+	ret := _m.Called(ctx, userID, beforeID, limit)
+
+	var r0 []users.HistoryEntry
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int) []users.HistoryEntry); ok {
+		r0 = rf(ctx, userID, beforeID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]users.HistoryEntry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64, int) error); ok {
+		r1 = rf(ctx, userID, beforeID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListStatusChanges provides a mock function with given fields: ctx, userID
+func (_m *UserRepository) ListStatusChanges(ctx context.Context, userID string) ([]users.StatusChange, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []users.StatusChange
+	if rf, ok := ret.Get(0).(func(context.Context, string) []users.StatusChange); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]users.StatusChange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Purge provides a mock function with given fields: ctx, deletedBefore
+func (_m *UserRepository) Purge(ctx context.Context, deletedBefore time.Time) ([]users.PurgedUser, error) {
+	ret := _m.Called(ctx, deletedBefore)
+
+	var r0 []users.PurgedUser
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) []users.PurgedUser); ok {
+		r0 = rf(ctx, deletedBefore)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]users.PurgedUser)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, deletedBefore)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: ctx, id
+func (_m *UserRepository) Restore(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: ctx, user
 func (_m *UserRepository) Update(ctx context.Context, user users.User) error {
 	ret := _m.Called(ctx, user)
@@ -104,3 +313,17 @@ func (_m *UserRepository) Update(ctx context.Context, user users.User) error {
 
 	return r0
 }
+
+// UpdateStatus provides a mock function with given fields: ctx, change
+func (_m *UserRepository) UpdateStatus(ctx context.Context, change users.StatusChange) error {
+	ret := _m.Called(ctx, change)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, users.StatusChange) error); ok {
+		r0 = rf(ctx, change)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
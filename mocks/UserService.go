@@ -4,6 +4,7 @@ package mocks
 
 import (
 	context "context"
+	time "time"
 
 	users "github.com/arnaz06/users"
 	mock "github.com/stretchr/testify/mock"
@@ -14,6 +15,20 @@ type UserService struct {
 	mock.Mock
 }
 
+// ChangeStatus provides a mock function with given fields: ctx, id, to, reason, actor
+func (_m *UserService) ChangeStatus(ctx context.Context, id string, to users.Status, reason string, actor string) error {
+	ret := _m.Called(ctx, id, to, reason, actor)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, users.Status, string, string) error); ok {
+		r0 = rf(ctx, id, to, reason, actor)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Create provides a mock function with given fields: ctx, user
 func (_m *UserService) Create(ctx context.Context, user users.User) (users.User, error) {
 	ret := _m.Called(ctx, user)
@@ -35,13 +50,13 @@ func (_m *UserService) Create(ctx context.Context, user users.User) (users.User,
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: ctx, id
-func (_m *UserService) Delete(ctx context.Context, id string) error {
-	ret := _m.Called(ctx, id)
+// Delete provides a mock function with given fields: ctx, id, version
+func (_m *UserService) Delete(ctx context.Context, id string, version int64) error {
+	ret := _m.Called(ctx, id, version)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, id, version)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -70,13 +85,169 @@ func (_m *UserService) Get(ctx context.Context, id string) (users.User, error) {
 	return r0, r1
 }
 
-// Login provides a mock function with given fields: ctx, email, password
-func (_m *UserService) Login(ctx context.Context, email string, password string) error {
-	ret := _m.Called(ctx, email, password)
+// GetByEmail provides a mock function with given fields: ctx, email
+func (_m *UserService) GetByEmail(ctx context.Context, email string) (users.User, error) {
+	ret := _m.Called(ctx, email)
+
+	var r0 users.User
+	if rf, ok := ret.Get(0).(func(context.Context, string) users.User); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Get(0).(users.User)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HardDelete provides a mock function with given fields: ctx, id
+func (_m *UserService) HardDelete(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// History provides a mock function with given fields: ctx, id, cursor, limit
+func (_m *UserService) History(ctx context.Context, id string, cursor string, limit int) (users.HistoryPage, error) {
+	ret := _m.Called(ctx, id, cursor, limit)
+
+	var r0 users.HistoryPage
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) users.HistoryPage); ok {
+		r0 = rf(ctx, id, cursor, limit)
+	} else {
+		r0 = ret.Get(0).(users.HistoryPage)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int) error); ok {
+		r1 = rf(ctx, id, cursor, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: ctx, filter, cursor
+func (_m *UserService) List(ctx context.Context, filter users.UserFilter, cursor string) (users.UserPage, error) {
+	ret := _m.Called(ctx, filter, cursor)
+
+	var r0 users.UserPage
+	if rf, ok := ret.Get(0).(func(context.Context, users.UserFilter, string) users.UserPage); ok {
+		r0 = rf(ctx, filter, cursor)
+	} else {
+		r0 = ret.Get(0).(users.UserPage)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, users.UserFilter, string) error); ok {
+		r1 = rf(ctx, filter, cursor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListDeleted provides a mock function with given fields: ctx
+func (_m *UserService) ListDeleted(ctx context.Context) ([]users.User, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []users.User
+	if rf, ok := ret.Get(0).(func(context.Context) []users.User); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]users.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Login provides a mock function with given fields: ctx, identifier, password
+func (_m *UserService) Login(ctx context.Context, identifier string, password string) (users.User, error) {
+	ret := _m.Called(ctx, identifier, password)
+
+	var r0 users.User
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) users.User); ok {
+		r0 = rf(ctx, identifier, password)
+	} else {
+		r0 = ret.Get(0).(users.User)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, identifier, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Patch provides a mock function with given fields: ctx, id, patch, version
+func (_m *UserService) Patch(ctx context.Context, id string, patch users.UserPatch, version int64) error {
+	ret := _m.Called(ctx, id, patch, version)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, users.UserPatch, int64) error); ok {
+		r0 = rf(ctx, id, patch, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PurgeDeleted provides a mock function with given fields: ctx, retention
+func (_m *UserService) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	ret := _m.Called(ctx, retention)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) int64); ok {
+		r0 = rf(ctx, retention)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Duration) error); ok {
+		r1 = rf(ctx, retention)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: ctx, id
+func (_m *UserService) Restore(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
-		r0 = rf(ctx, email, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Error(0)
 	}
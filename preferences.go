@@ -0,0 +1,79 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// Theme is the color scheme of the frontends.
+type Theme string
+
+const (
+	// ThemeSystem follows the color scheme of the user's device.
+	ThemeSystem Theme = "system"
+	// ThemeLight is the light color scheme.
+	ThemeLight Theme = "light"
+	// ThemeDark is the dark color scheme.
+	ThemeDark Theme = "dark"
+)
+
+// Preferences is the struct represent the user's settings shared by the frontends.
+type Preferences struct {
+	Locale        string                  `json:"locale"`
+	TimeZone      string                  `json:"time_zone"`
+	Theme         Theme                   `json:"theme"`
+	Notifications NotificationPreferences `json:"notifications"`
+}
+
+// NotificationPreferences is the struct represent the notifications the user agreed to receive.
+type NotificationPreferences struct {
+	Email      bool `json:"email"`
+	Push       bool `json:"push"`
+	Newsletter bool `json:"newsletter"`
+}
+
+// DefaultPreferences returns the preferences of a user who never saved any.
+// Saved preferences are read over the defaults, so keys added later take their default value.
+func DefaultPreferences() Preferences {
+	return Preferences{
+		Locale:   "en",
+		TimeZone: "UTC",
+		Theme:    ThemeSystem,
+		Notifications: NotificationPreferences{
+			Email:      true,
+			Push:       true,
+			Newsletter: false,
+		},
+	}
+}
+
+// Validate checks that every preference holds a known value.
+func (p Preferences) Validate() error {
+	if _, err := language.Parse(p.Locale); err != nil {
+		return ConstraintErrorf("invalid locale: %s", p.Locale)
+	}
+	if _, err := time.LoadLocation(p.TimeZone); err != nil || p.TimeZone == "" || p.TimeZone == "Local" {
+		return ConstraintErrorf("invalid time zone: %s", p.TimeZone)
+	}
+	switch p.Theme {
+	case ThemeSystem, ThemeLight, ThemeDark:
+	default:
+		return ConstraintErrorf("invalid theme: %s", p.Theme)
+	}
+	return nil
+}
+
+// PreferencesRepository is interface of preferences repository.
+// Get returns ErrNotFound if the user never saved any preferences.
+type PreferencesRepository interface {
+	Get(ctx context.Context, userID string) (Preferences, error)
+	Save(ctx context.Context, userID string, preferences Preferences) error
+}
+
+// PreferencesService is interface of preferences service.
+type PreferencesService interface {
+	Get(ctx context.Context, userID string) (Preferences, error)
+	Update(ctx context.Context, userID string, preferences Preferences) error
+}
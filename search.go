@@ -0,0 +1,32 @@
+package users
+
+import "context"
+
+// Searcher is interface of the full-text search of users, by fragments of their email, username or address.
+// Search returns the IDs of at most limit users of the tenant carried in ctx, best match first.
+type Searcher interface {
+	Search(ctx context.Context, query string, limit int) ([]string, error)
+}
+
+// SearchIndex is interface of a Searcher over an index maintained apart from the users.
+// Index adds or replaces the document of the user, and Reset removes every document of the tenant.
+// Close releases the index once it is no longer used.
+type SearchIndex interface {
+	Searcher
+	Index(ctx context.Context, user User) error
+	Remove(ctx context.Context, id string) error
+	Reset(ctx context.Context) error
+	Close() error
+}
+
+// SearchIndexer is interface of the handler keeping a SearchIndex in sync with the user events.
+// Rebuild indexes every user of the tenant carried in ctx from scratch, and returns their number.
+type SearchIndexer interface {
+	UserEventHandler
+	Rebuild(ctx context.Context) (int64, error)
+}
+
+// SearchService is interface of the search service.
+type SearchService interface {
+	Search(ctx context.Context, query string, limit int) ([]User, error)
+}
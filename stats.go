@@ -0,0 +1,63 @@
+package users
+
+import (
+	"context"
+	"time"
+)
+
+// StatsInterval is the length of the periods the statistics are bucketed by.
+type StatsInterval string
+
+const (
+	// IntervalDay buckets the statistics by day.
+	IntervalDay StatsInterval = "day"
+	// IntervalWeek buckets the statistics by week, starting on Monday.
+	IntervalWeek StatsInterval = "week"
+	// IntervalMonth buckets the statistics by calendar month.
+	IntervalMonth StatsInterval = "month"
+)
+
+// StatsFilter is the struct represent the time range of the statistics, in UTC.
+// The range is extended to whole periods of the interval.
+type StatsFilter struct {
+	From     time.Time
+	To       time.Time
+	Interval StatsInterval
+}
+
+// StatsBucket is the struct represent the count of a period starting at Start.
+type StatsBucket struct {
+	Start time.Time `json:"start"`
+	Count int64     `json:"count"`
+}
+
+// UserStats is the struct represent the statistics of the users of a tenant.
+// ByStatus and Total count the users which are not deleted, while the buckets count the events of their periods.
+// The deletions are the ones of the soft-deleted users not purged yet.
+type UserStats struct {
+	From      time.Time        `json:"from"`
+	To        time.Time        `json:"to"`
+	Interval  StatsInterval    `json:"interval"`
+	Total     int64            `json:"total"`
+	ByStatus  map[Status]int64 `json:"by_status"`
+	Signups   []StatsBucket    `json:"signups"`
+	Deletions []StatsBucket    `json:"deletions"`
+	Logins    []StatsBucket    `json:"logins"`
+}
+
+// StatsRepository is interface of the statistics repository.
+// The counts of the periods are given for the bounds of the periods, with one count less than there are bounds.
+// ListLogins returns the times the user logged in, oldest first.
+type StatsRepository interface {
+	RecordLogin(ctx context.Context, userID string, loginTime time.Time) error
+	ListLogins(ctx context.Context, userID string) ([]time.Time, error)
+	CountByStatus(ctx context.Context) (map[Status]int64, error)
+	CountSignups(ctx context.Context, bounds []time.Time) ([]int64, error)
+	CountDeletions(ctx context.Context, bounds []time.Time) ([]int64, error)
+	CountLogins(ctx context.Context, bounds []time.Time) ([]int64, error)
+}
+
+// StatsService is interface of the statistics service.
+type StatsService interface {
+	Stats(ctx context.Context, filter StatsFilter) (UserStats, error)
+}
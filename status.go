@@ -0,0 +1,46 @@
+package users
+
+import "time"
+
+// Status is the lifecycle state of a user account.
+type Status string
+
+const (
+	// StatusPending is the status of an account waiting for activation.
+	StatusPending Status = "pending"
+	// StatusActive is the status of an account allowed to use the service.
+	StatusActive Status = "active"
+	// StatusSuspended is the status of an account temporarily banned by an admin.
+	StatusSuspended Status = "suspended"
+	// StatusLocked is the status of an account locked for security reasons.
+	StatusLocked Status = "locked"
+	// StatusDeactivated is the status of an account closed by its owner or an admin.
+	StatusDeactivated Status = "deactivated"
+)
+
+// Err returns the error thrown when a user with the status accesses the service, or nil if the user is active.
+func (s Status) Err() error {
+	switch s {
+	case StatusActive:
+		return nil
+	case StatusPending:
+		return ErrUserPending
+	case StatusSuspended:
+		return ErrUserSuspended
+	case StatusLocked:
+		return ErrUserLocked
+	case StatusDeactivated:
+		return ErrUserDeactivated
+	}
+	return UnauthorizedErrorf("invalid user status: %s", s)
+}
+
+// StatusChange is the struct represent a transition of the user's status.
+type StatusChange struct {
+	UserID      string    `json:"user_id"`
+	From        Status    `json:"from"`
+	To          Status    `json:"to"`
+	Reason      string    `json:"reason"`
+	Actor       string    `json:"actor"`
+	CreatedTime time.Time `json:"created_time"`
+}
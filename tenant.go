@@ -0,0 +1,21 @@
+package users
+
+import "context"
+
+// DefaultTenant is the tenant of the requests which are not resolved to any tenant.
+const DefaultTenant = "default"
+
+type tenantContextKey struct{}
+
+// WithTenant returns a copy of ctx carrying the tenant ID.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant ID carried by ctx, or DefaultTenant if there is none.
+func TenantFromContext(ctx context.Context) string {
+	if tenantID, ok := ctx.Value(tenantContextKey{}).(string); ok && tenantID != "" {
+		return tenantID
+	}
+	return DefaultTenant
+}
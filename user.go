@@ -2,33 +2,105 @@ package users
 
 import (
 	"context"
+	"strings"
 	"time"
 )
 
 // User is the struct represent the user's data
 type User struct {
-	ID          string    `json:"id"`
-	Email       string    `json:"email" validate:"required"`
-	Address     string    `json:"address"`
-	Password    string    `json:"password" validate:"required"`
-	CreatedTime time.Time `json:"created_time"`
-	UpdatedTime time.Time `json:"updated_time"`
+	ID          string     `json:"id"`
+	Email       string     `json:"email" validate:"required"`
+	Username    string     `json:"username,omitempty"`
+	Address     string     `json:"address"`
+	Password    string     `json:"password" validate:"required"`
+	Status      Status     `json:"status"`
+	Version     int64      `json:"version"`
+	CreatedTime time.Time  `json:"created_time"`
+	UpdatedTime time.Time  `json:"updated_time"`
+	DeletedTime *time.Time `json:"deleted_time,omitempty"`
+}
+
+// SignUp is the struct represent the fields a user signs up with, validated by its tags.
+// The sign-ups of the API and the rows of an import are held to the same rules.
+type SignUp struct {
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Username string `json:"username" validate:"omitempty,min=3,max=30"`
+	Address  string `json:"address" validate:"max=255"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+// Validator validates a struct by its validate tags.
+type Validator interface {
+	Validate(i interface{}) error
+}
+
+// NormalizeEmail returns the form emails are compared by, as they are case-insensitive.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(email)
+}
+
+// UserPatch holds the fields of a partial update of a user. The nil fields are left unchanged.
+type UserPatch struct {
+	Email    *string
+	Username *string
+	Address  *string
+	Password *string
+}
+
+// PurgedUser is the struct represent a user permanently deleted by a purge, which spans the tenants.
+type PurgedUser struct {
+	TenantID string
+	ID       string
 }
 
 // UserRepository is interface of user repository.
+// Update and Delete only apply when the stored version matches the given one,
+// unless the given version is zero. ListDeleted, Restore, HardDelete and Purge
+// only operate on soft-deleted users, and also delete everything recorded about them in the same transaction.
+// Purge returns the users it deleted. UpdateStatus only applies when the stored
+// status is still the one the change is made from. Usernames are matched case-insensitively.
+// Every write but the permanent deletions records a HistoryEntry, made by the actor carried
+// in the context, in the same transaction. ListHistory returns the newest entries with an ID lower than beforeID, if not zero.
+// List returns at most filter.Limit users, and Count the number of users matching the filter, ignoring its position and limit.
+// CreateBatch creates the users in one transaction, returning the error of each user that could not be created,
+// and rolls the transaction back when dryRun is set.
 type UserRepository interface {
 	Create(ctx context.Context, user User) (User, error)
+	CreateBatch(ctx context.Context, list []User, dryRun bool) ([]error, error)
 	Get(ctx context.Context, id string) (User, error)
 	GetByEmail(ctx context.Context, email string) (User, error)
+	GetByUsername(ctx context.Context, username string) (User, error)
 	Update(ctx context.Context, user User) error
-	Delete(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string, version int64) error
+	ListDeleted(ctx context.Context) ([]User, error)
+	Restore(ctx context.Context, id string) error
+	HardDelete(ctx context.Context, id string) error
+	Purge(ctx context.Context, deletedBefore time.Time) ([]PurgedUser, error)
+	UpdateStatus(ctx context.Context, change StatusChange) error
+	ListStatusChanges(ctx context.Context, userID string) ([]StatusChange, error)
+	ListHistory(ctx context.Context, userID string, beforeID int64, limit int) ([]HistoryEntry, error)
+	List(ctx context.Context, filter UserFilter) ([]User, error)
+	Count(ctx context.Context, filter UserFilter) (int64, error)
 }
 
 // UserService is interface of user service.
+// Login accepts either the email or the username of the user as identifier.
+// Create, Update and Patch receive the password in plaintext, and store it hashed.
+// Patch only changes the fields set in the patch, under the same version precondition as Update.
+// List continues from the opaque cursor of the previous page, if not empty.
 type UserService interface {
 	Create(ctx context.Context, user User) (User, error)
 	Get(ctx context.Context, id string) (User, error)
-	Login(ctx context.Context, email, password string)  error
+	GetByEmail(ctx context.Context, email string) (User, error)
+	Login(ctx context.Context, identifier, password string) (User, error)
 	Update(ctx context.Context, user User) error
-	Delete(ctx context.Context, id string) error
+	Patch(ctx context.Context, id string, patch UserPatch, version int64) error
+	Delete(ctx context.Context, id string, version int64) error
+	ListDeleted(ctx context.Context) ([]User, error)
+	Restore(ctx context.Context, id string) error
+	HardDelete(ctx context.Context, id string) error
+	PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error)
+	ChangeStatus(ctx context.Context, id string, to Status, reason, actor string) error
+	History(ctx context.Context, id, cursor string, limit int) (HistoryPage, error)
+	List(ctx context.Context, filter UserFilter, cursor string) (UserPage, error)
 }
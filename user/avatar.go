@@ -0,0 +1,151 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	// Register the formats accepted as avatar.
+	_ "image/gif"
+	_ "image/jpeg"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/image/draw"
+
+	"github.com/arnaz06/users"
+)
+
+// avatarMaxPixels bounds the dimensions of an uploaded image, so a small file can not decode into a huge one.
+const avatarMaxPixels = 25000000
+
+// avatarContentTypes are the sniffed content types accepted as avatar.
+var avatarContentTypes = map[string]bool{
+	"image/gif":  true,
+	"image/jpeg": true,
+	"image/png":  true,
+}
+
+type avatarService struct {
+	repo    users.UserRepository
+	store   users.BlobStore
+	maxSize int64
+}
+
+// NewAvatarService creates a new avatar service.
+// Uploaded images larger than maxSize bytes are rejected.
+func NewAvatarService(repo users.UserRepository, store users.BlobStore, maxSize int64) users.AvatarService {
+	return avatarService{
+		repo:    repo,
+		store:   store,
+		maxSize: maxSize,
+	}
+}
+
+func (s avatarService) Upload(ctx context.Context, userID string, r io.Reader) error {
+	_, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) > s.maxSize {
+		return users.ConstraintErrorf("avatar must not be larger than %d bytes", s.maxSize)
+	}
+
+	contentType := http.DetectContentType(data)
+	if !avatarContentTypes[contentType] {
+		return users.ConstraintErrorf("avatar must be a GIF, JPEG or PNG image, got %s", contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return users.ConstraintErrorf("invalid avatar image: %s", err)
+	}
+	if config.Width*config.Height > avatarMaxPixels {
+		return users.ConstraintErrorf("avatar must not be larger than %d pixels", avatarMaxPixels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return users.ConstraintErrorf("invalid avatar image: %s", err)
+	}
+
+	for size, pixels := range users.AvatarSizes {
+		var buf bytes.Buffer
+		err = png.Encode(&buf, thumbnail(img, pixels))
+		if err != nil {
+			return err
+		}
+
+		err = s.store.Put(ctx, avatarKey(ctx, userID, size), &buf, int64(buf.Len()), "image/png")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s avatarService) Get(ctx context.Context, userID string, size users.AvatarSize) (users.Blob, error) {
+	if _, ok := users.AvatarSizes[size]; !ok {
+		return users.Blob{}, users.ConstraintErrorf("invalid avatar size: %s", size)
+	}
+
+	_, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		return users.Blob{}, err
+	}
+
+	return s.store.Get(ctx, avatarKey(ctx, userID, size))
+}
+
+type avatarRemover struct {
+	store users.BlobStore
+}
+
+// NewAvatarRemover creates the handler deleting the avatar of the permanently deleted users from the blob store,
+// which is not part of the transaction deleting the users.
+func NewAvatarRemover(store users.BlobStore) users.UserEventHandler {
+	return avatarRemover{
+		store: store,
+	}
+}
+
+func (r avatarRemover) HandleUserEvent(ctx context.Context, event users.UserEvent) {
+	if event.Type != users.UserPurged {
+		return
+	}
+	for size := range users.AvatarSizes {
+		if err := r.store.Delete(ctx, avatarKey(ctx, event.UserID, size)); err != nil {
+			log.Errorf("Failed to delete the %s avatar of user %s: %+v", size, event.UserID, err)
+		}
+	}
+}
+
+// avatarKey is the key of the avatar thumbnail in the blob store, scoped by the tenant carried in the context.
+func avatarKey(ctx context.Context, userID string, size users.AvatarSize) string {
+	return fmt.Sprintf("avatars/%s/%s/%s.png", users.TenantFromContext(ctx), userID, size)
+}
+
+// thumbnail crops the largest centered square of img and scales it to pixels wide and high.
+func thumbnail(img image.Image, pixels int) image.Image {
+	bounds := img.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, pixels, pixels))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, image.Rect(x, y, x+side, y+side), draw.Src, nil)
+	return dst
+}
@@ -0,0 +1,278 @@
+package user_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
+	"github.com/arnaz06/users/user"
+)
+
+const avatarMaxSize = 1 << 20
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestUploadAvatarService(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	thumbnail := func(pixels int) interface{} {
+		return mock.MatchedBy(func(buf *bytes.Buffer) bool {
+			config, format, err := image.DecodeConfig(bytes.NewReader(buf.Bytes()))
+			return err == nil && format == "png" && config.Width == pixels && config.Height == pixels
+		})
+	}
+
+	tests := []struct {
+		testName      string
+		input         []byte
+		get           testdata.FuncCall
+		put           bool
+		putError      error
+		expectedError error
+	}{
+		{
+			testName: "success",
+			input:    encodePNG(t, 300, 200),
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			put: true,
+		},
+		{
+			testName: "with file too large",
+			input:    make([]byte, avatarMaxSize+1),
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			expectedError: users.ConstraintErrorf("avatar must not be larger than %d bytes", avatarMaxSize),
+		},
+		{
+			testName: "with file not an image",
+			input:    []byte("just some text"),
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			expectedError: users.ConstraintErrorf("avatar must be a GIF, JPEG or PNG image, got text/plain; charset=utf-8"),
+		},
+		{
+			testName: "with truncated image",
+			input:    encodePNG(t, 300, 200)[:100],
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			expectedError: users.ConstraintErrorf("invalid avatar image: png: invalid format: not enough pixel data"),
+		},
+		{
+			testName: "with user not found",
+			input:    encodePNG(t, 300, 200),
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.User{}, users.ErrNotFound},
+			},
+			expectedError: users.ErrNotFound,
+		},
+		{
+			testName: "error from store",
+			input:    encodePNG(t, 300, 200),
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			put:           true,
+			putError:      errors.New("unexpected error"),
+			expectedError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			mockStore := new(mocks.BlobStore)
+			if test.get.Called {
+				mockRepo.On("Get", test.get.Input...).
+					Return(test.get.Output...).Once()
+			}
+			if test.put {
+				for size, pixels := range users.AvatarSizes {
+					call := mockStore.On("Put", mock.Anything, "avatars/default/"+mockUser.ID+"/"+string(size)+".png", thumbnail(pixels), mock.Anything, "image/png").
+						Return(test.putError)
+					if test.putError == nil {
+						call.Once()
+					}
+				}
+			}
+
+			service := user.NewAvatarService(mockRepo, mockStore, avatarMaxSize)
+			err := service.Upload(context.Background(), mockUser.ID, bytes.NewReader(test.input))
+			mockRepo.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetAvatarService(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	blob := users.Blob{
+		Body:        ioutil.NopCloser(strings.NewReader("avatar")),
+		ContentType: "image/png",
+		Size:        6,
+	}
+
+	tests := []struct {
+		testName      string
+		size          users.AvatarSize
+		get           testdata.FuncCall
+		store         testdata.FuncCall
+		expectedError error
+	}{
+		{
+			testName: "success",
+			size:     users.AvatarSmall,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			store: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "avatars/default/" + mockUser.ID + "/small.png"},
+				Output: []interface{}{blob, nil},
+			},
+		},
+		{
+			testName:      "with invalid size",
+			size:          users.AvatarSize("huge"),
+			expectedError: users.ConstraintErrorf("invalid avatar size: huge"),
+		},
+		{
+			testName: "with avatar not uploaded",
+			size:     users.AvatarMedium,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			store: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "avatars/default/" + mockUser.ID + "/medium.png"},
+				Output: []interface{}{users.Blob{}, users.ErrNotFound},
+			},
+			expectedError: users.ErrNotFound,
+		},
+		{
+			testName: "with user not found",
+			size:     users.AvatarMedium,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.User{}, users.ErrNotFound},
+			},
+			expectedError: users.ErrNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			mockStore := new(mocks.BlobStore)
+			if test.get.Called {
+				mockRepo.On("Get", test.get.Input...).
+					Return(test.get.Output...).Once()
+			}
+			if test.store.Called {
+				mockStore.On("Get", test.store.Input...).
+					Return(test.store.Output...).Once()
+			}
+
+			service := user.NewAvatarService(mockRepo, mockStore, avatarMaxSize)
+			res, err := service.Get(context.Background(), mockUser.ID, test.size)
+			mockRepo.AssertExpectations(t)
+			mockStore.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, blob, res)
+		})
+	}
+}
+
+func TestAvatarRemover(t *testing.T) {
+	ctx := users.WithTenant(context.Background(), "acme")
+
+	tests := []struct {
+		testName     string
+		event        users.UserEvent
+		expectDelete bool
+	}{
+		{
+			testName:     "purged",
+			event:        users.UserEvent{Type: users.UserPurged, UserID: "123"},
+			expectDelete: true,
+		},
+		{
+			testName: "soft-deleted",
+			event:    users.UserEvent{Type: users.UserDeleted, UserID: "123"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockStore := new(mocks.BlobStore)
+			if test.expectDelete {
+				mockStore.On("Delete", mock.Anything, "avatars/acme/123/small.png").Return(nil).Once()
+				mockStore.On("Delete", mock.Anything, "avatars/acme/123/medium.png").Return(errors.New("unexpected error")).Once()
+				mockStore.On("Delete", mock.Anything, "avatars/acme/123/large.png").Return(nil).Once()
+			}
+
+			user.NewAvatarRemover(mockStore).HandleUserEvent(ctx, test.event)
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
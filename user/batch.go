@@ -0,0 +1,174 @@
+package user
+
+import (
+	"context"
+
+	"github.com/arnaz06/users"
+)
+
+const maxBatchOperations = 1000
+
+// eventRecorder holds the events of the changes made in a unit of work, to be published once it is committed.
+type eventRecorder struct {
+	events []users.UserEvent
+}
+
+func (r *eventRecorder) HandleUserEvent(ctx context.Context, event users.UserEvent) {
+	r.events = append(r.events, event)
+}
+
+type batchService struct {
+	repo     users.UserRepository
+	uow      users.UnitOfWork
+	handlers []users.UserEventHandler
+}
+
+// NewBatchService creates a new batch service.
+// The operations are applied through the user service, so they follow the same rules as one at a time.
+// The handlers are given the events of the applied operations, once committed.
+func NewBatchService(repo users.UserRepository, uow users.UnitOfWork, handlers ...users.UserEventHandler) users.BatchService {
+	return batchService{
+		repo:     repo,
+		uow:      uow,
+		handlers: handlers,
+	}
+}
+
+func validateOperation(op users.BatchOperation) error {
+	switch op.Type {
+	case users.BatchCreate:
+		if op.User.Email == "" || op.User.Password == "" {
+			return users.ConstraintErrorf("email and password are required to create a user")
+		}
+	case users.BatchUpdate:
+		if op.User.ID == "" || op.User.Email == "" || op.User.Password == "" {
+			return users.ConstraintErrorf("id, email and password are required to update a user")
+		}
+	case users.BatchDelete:
+		if op.User.ID == "" {
+			return users.ConstraintErrorf("id is required to delete a user")
+		}
+	default:
+		return users.ConstraintErrorf("invalid operation: %s", op.Type)
+	}
+	return nil
+}
+
+// applyOperation applies the operation through the service, returning the ID of its user.
+func applyOperation(ctx context.Context, service users.UserService, op users.BatchOperation) (string, error) {
+	if err := validateOperation(op); err != nil {
+		return op.User.ID, err
+	}
+
+	switch op.Type {
+	case users.BatchCreate:
+		user := op.User
+		user.ID = ""
+		user.Status = users.StatusActive
+		res, err := service.Create(ctx, user)
+		return res.ID, err
+	case users.BatchUpdate:
+		return op.User.ID, service.Update(ctx, op.User)
+	default:
+		return op.User.ID, service.Delete(ctx, op.User.ID, op.User.Version)
+	}
+}
+
+// isOperationError tells the failures of an operation, reported in the batch report, from the unexpected errors.
+func isOperationError(err error) bool {
+	if _, ok := err.(users.ConstraintError); ok {
+		return true
+	}
+	return err == users.ErrNotFound || err == users.ErrPreconditionFailed
+}
+
+func (s batchService) Execute(ctx context.Context, operations []users.BatchOperation, mode users.BatchMode) (users.BatchReport, error) {
+	if len(operations) == 0 {
+		return users.BatchReport{}, users.ConstraintErrorf("batch has no operations")
+	}
+	if len(operations) > maxBatchOperations {
+		return users.BatchReport{}, users.ConstraintErrorf("batch has more than %d operations", maxBatchOperations)
+	}
+
+	report := users.BatchReport{
+		Mode:    mode,
+		Results: make([]users.BatchResult, len(operations)),
+	}
+	for i := range report.Results {
+		report.Results[i].Index = i
+	}
+
+	switch mode {
+	case users.BatchAbortOnError:
+		return s.executeAll(ctx, operations, report)
+	case users.BatchContinueOnError:
+		return s.executeEach(ctx, operations, report)
+	default:
+		return users.BatchReport{}, users.ConstraintErrorf("invalid batch mode: %s", mode)
+	}
+}
+
+// executeAll applies the operations in one unit of work, rolled back on the first failure.
+func (s batchService) executeAll(ctx context.Context, operations []users.BatchOperation, report users.BatchReport) (users.BatchReport, error) {
+	recorder := &eventRecorder{}
+	failed := -1
+	err := s.uow.Do(ctx, func(ctx context.Context, repo users.UserRepository) error {
+		service := NewUserService(repo, recorder)
+		for i, op := range operations {
+			id, err := applyOperation(ctx, service, op)
+			report.Results[i].ID = id
+			if err != nil {
+				failed = i
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if failed < 0 || !isOperationError(err) {
+			return users.BatchReport{}, err
+		}
+
+		// Nothing is kept, and the created users were never given their IDs.
+		for i := 0; i < failed; i++ {
+			if operations[i].Type == users.BatchCreate {
+				report.Results[i].ID = ""
+			}
+		}
+		report.Results[failed].Error = err.Error()
+		report.Failed = 1
+		return report, nil
+	}
+
+	report.Committed = true
+	report.Applied = len(operations)
+	for i := range report.Results {
+		report.Results[i].Applied = true
+	}
+	for _, event := range recorder.events {
+		publish(ctx, s.handlers, event.Type, event.UserID)
+	}
+	return report, nil
+}
+
+// executeEach applies every operation on its own, going on after the failures.
+func (s batchService) executeEach(ctx context.Context, operations []users.BatchOperation, report users.BatchReport) (users.BatchReport, error) {
+	// Every operation is committed on its own, so the report of the ones applied so far is returned with an unexpected error.
+	report.Committed = true
+	service := NewUserService(s.repo, s.handlers...)
+	for i, op := range operations {
+		id, err := applyOperation(ctx, service, op)
+		report.Results[i].ID = id
+		if err != nil {
+			if !isOperationError(err) {
+				return report, err
+			}
+			report.Results[i].Error = err.Error()
+			report.Failed++
+			continue
+		}
+		report.Results[i].Applied = true
+		report.Applied++
+	}
+	return report, nil
+}
@@ -0,0 +1,225 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/user"
+)
+
+// runUnitOfWork makes the mocked unit of work run its function with the repository, failing as the function does.
+func runUnitOfWork(repo users.UserRepository) func(context.Context, func(context.Context, users.UserRepository) error) error {
+	return func(ctx context.Context, fn func(context.Context, users.UserRepository) error) error {
+		return fn(ctx, repo)
+	}
+}
+
+func TestBatchServiceAbortOnError(t *testing.T) {
+	savedUser := users.User{ID: "123", Email: "jhon@doe.com", Password: importHash, Status: users.StatusActive, Version: 1}
+	operations := []users.BatchOperation{
+		{Type: users.BatchCreate, User: users.User{Email: "jane@doe.com", Password: "secret-123"}},
+		{Type: users.BatchUpdate, User: users.User{ID: "123", Email: "jhon@doe.com", Address: "Jakarta", Password: "secret-123", Version: 1}},
+		{Type: users.BatchDelete, User: users.User{ID: "456", Version: 2}},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.UserRepository)
+		txRepo := new(mocks.UserRepository)
+		txRepo.On("Create", mock.Anything, mock.MatchedBy(func(u users.User) bool {
+			return u.Email == "jane@doe.com" && users.CompareHash(u.Password, "secret-123") == nil
+		})).Return(users.User{ID: "789", Email: "jane@doe.com"}, nil).Once()
+		txRepo.On("Get", mock.Anything, "123").Return(savedUser, nil).Once()
+		txRepo.On("Update", mock.Anything, mock.MatchedBy(func(u users.User) bool {
+			return u.ID == "123" && u.Address == "Jakarta" && u.Password == importHash && u.Version == 1
+		})).Return(nil).Once()
+		txRepo.On("Delete", mock.Anything, "456", int64(2)).Return(nil).Once()
+		mockUnit := new(mocks.UnitOfWork)
+		mockUnit.On("Do", mock.Anything, mock.Anything).Return(runUnitOfWork(txRepo)).Once()
+
+		var events []users.UserEvent
+		mockHandler := new(mocks.UserEventHandler)
+		mockHandler.On("HandleUserEvent", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			events = append(events, args.Get(1).(users.UserEvent))
+		}).Times(3)
+
+		service := user.NewBatchService(mockRepo, mockUnit, mockHandler)
+		res, err := service.Execute(context.Background(), operations, users.BatchAbortOnError)
+		require.NoError(t, err)
+		require.Equal(t, users.BatchReport{
+			Mode:      users.BatchAbortOnError,
+			Committed: true,
+			Applied:   3,
+			Results: []users.BatchResult{
+				{Index: 0, ID: "789", Applied: true},
+				{Index: 1, ID: "123", Applied: true},
+				{Index: 2, ID: "456", Applied: true},
+			},
+		}, res)
+		require.Equal(t, []users.UserEvent{
+			{Type: users.UserCreated, UserID: "789"},
+			{Type: users.UserUpdated, UserID: "123"},
+			{Type: users.UserDeleted, UserID: "456"},
+		}, events)
+		mockRepo.AssertExpectations(t)
+		txRepo.AssertExpectations(t)
+		mockUnit.AssertExpectations(t)
+		mockHandler.AssertExpectations(t)
+	})
+
+	t.Run("with failed operation", func(t *testing.T) {
+		mockRepo := new(mocks.UserRepository)
+		txRepo := new(mocks.UserRepository)
+		txRepo.On("Create", mock.Anything, mock.Anything).Return(users.User{ID: "789", Email: "jane@doe.com"}, nil).Once()
+		txRepo.On("Get", mock.Anything, "123").Return(savedUser, nil).Once()
+		txRepo.On("Update", mock.Anything, mock.Anything).Return(users.ErrPreconditionFailed).Once()
+		mockUnit := new(mocks.UnitOfWork)
+		mockUnit.On("Do", mock.Anything, mock.Anything).Return(runUnitOfWork(txRepo)).Once()
+		mockHandler := new(mocks.UserEventHandler)
+
+		service := user.NewBatchService(mockRepo, mockUnit, mockHandler)
+		res, err := service.Execute(context.Background(), operations, users.BatchAbortOnError)
+		require.NoError(t, err)
+		require.Equal(t, users.BatchReport{
+			Mode:   users.BatchAbortOnError,
+			Failed: 1,
+			Results: []users.BatchResult{
+				{Index: 0},
+				{Index: 1, ID: "123", Error: users.ErrPreconditionFailed.Error()},
+				{Index: 2},
+			},
+		}, res)
+		txRepo.AssertExpectations(t)
+		mockUnit.AssertExpectations(t)
+		mockHandler.AssertExpectations(t)
+	})
+
+	t.Run("with invalid operation", func(t *testing.T) {
+		mockRepo := new(mocks.UserRepository)
+		txRepo := new(mocks.UserRepository)
+		mockUnit := new(mocks.UnitOfWork)
+		mockUnit.On("Do", mock.Anything, mock.Anything).Return(runUnitOfWork(txRepo)).Once()
+
+		service := user.NewBatchService(mockRepo, mockUnit)
+		res, err := service.Execute(context.Background(), []users.BatchOperation{
+			{Type: "upsert", User: users.User{ID: "123"}},
+			operations[2],
+		}, users.BatchAbortOnError)
+		require.NoError(t, err)
+		require.False(t, res.Committed)
+		require.Equal(t, "invalid operation: upsert", res.Results[0].Error)
+		txRepo.AssertExpectations(t)
+	})
+
+	t.Run("error from repository", func(t *testing.T) {
+		mockRepo := new(mocks.UserRepository)
+		txRepo := new(mocks.UserRepository)
+		txRepo.On("Create", mock.Anything, mock.Anything).Return(users.User{}, errors.New("unexpected error")).Once()
+		mockUnit := new(mocks.UnitOfWork)
+		mockUnit.On("Do", mock.Anything, mock.Anything).Return(runUnitOfWork(txRepo)).Once()
+
+		service := user.NewBatchService(mockRepo, mockUnit)
+		_, err := service.Execute(context.Background(), operations, users.BatchAbortOnError)
+		require.EqualError(t, err, "unexpected error")
+		txRepo.AssertExpectations(t)
+	})
+}
+
+func TestBatchServiceContinueOnError(t *testing.T) {
+	operations := []users.BatchOperation{
+		{Type: users.BatchCreate, User: users.User{Email: "jane@doe.com", Password: "secret-123"}},
+		{Type: users.BatchDelete, User: users.User{ID: "456"}},
+		{Type: users.BatchUpdate, User: users.User{ID: "123", Email: "jhon@doe.com"}},
+		{Type: users.BatchDelete, User: users.User{ID: "789"}},
+	}
+
+	t.Run("success with failed operations", func(t *testing.T) {
+		mockRepo := new(mocks.UserRepository)
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(users.User{ID: "789", Email: "jane@doe.com"}, nil).Once()
+		mockRepo.On("Delete", mock.Anything, "456", int64(0)).Return(users.ErrNotFound).Once()
+		mockRepo.On("Delete", mock.Anything, "789", int64(0)).Return(nil).Once()
+		mockUnit := new(mocks.UnitOfWork)
+		mockHandler := new(mocks.UserEventHandler)
+		mockHandler.On("HandleUserEvent", mock.Anything, users.UserEvent{Type: users.UserCreated, UserID: "789"}).Once()
+		mockHandler.On("HandleUserEvent", mock.Anything, users.UserEvent{Type: users.UserDeleted, UserID: "789"}).Once()
+
+		service := user.NewBatchService(mockRepo, mockUnit, mockHandler)
+		res, err := service.Execute(context.Background(), operations, users.BatchContinueOnError)
+		require.NoError(t, err)
+		require.Equal(t, users.BatchReport{
+			Mode:      users.BatchContinueOnError,
+			Committed: true,
+			Applied:   2,
+			Failed:    2,
+			Results: []users.BatchResult{
+				{Index: 0, ID: "789", Applied: true},
+				{Index: 1, ID: "456", Error: users.ErrNotFound.Error()},
+				{Index: 2, ID: "123", Error: "id, email and password are required to update a user"},
+				{Index: 3, ID: "789", Applied: true},
+			},
+		}, res)
+		mockRepo.AssertExpectations(t)
+		mockUnit.AssertExpectations(t)
+		mockHandler.AssertExpectations(t)
+	})
+
+	t.Run("error from repository", func(t *testing.T) {
+		mockRepo := new(mocks.UserRepository)
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(users.User{ID: "789", Email: "jane@doe.com"}, nil).Once()
+		mockRepo.On("Delete", mock.Anything, "456", int64(0)).Return(errors.New("unexpected error")).Once()
+
+		service := user.NewBatchService(mockRepo, new(mocks.UnitOfWork))
+		res, err := service.Execute(context.Background(), operations, users.BatchContinueOnError)
+		require.EqualError(t, err, "unexpected error")
+		require.Equal(t, 1, res.Applied)
+		require.True(t, res.Results[0].Applied)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestBatchServiceValidation(t *testing.T) {
+	tooMany := make([]users.BatchOperation, 1001)
+
+	tests := []struct {
+		testName      string
+		operations    []users.BatchOperation
+		mode          users.BatchMode
+		expectedError error
+	}{
+		{
+			testName:      "without operations",
+			mode:          users.BatchAbortOnError,
+			expectedError: users.ConstraintErrorf("batch has no operations"),
+		},
+		{
+			testName:      "with too many operations",
+			operations:    tooMany,
+			mode:          users.BatchAbortOnError,
+			expectedError: users.ConstraintErrorf("batch has more than 1000 operations"),
+		},
+		{
+			testName:      "with invalid mode",
+			operations:    []users.BatchOperation{{Type: users.BatchDelete, User: users.User{ID: "123"}}},
+			mode:          "maybe",
+			expectedError: users.ConstraintErrorf("invalid batch mode: maybe"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			mockUnit := new(mocks.UnitOfWork)
+
+			service := user.NewBatchService(mockRepo, mockUnit)
+			_, err := service.Execute(context.Background(), test.operations, test.mode)
+			require.EqualError(t, err, test.expectedError.Error())
+			mockRepo.AssertExpectations(t)
+			mockUnit.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package user
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	pwriter "github.com/xitongsys/parquet-go-source/writer"
+	"github.com/xitongsys/parquet-go/writer"
+
+	"github.com/arnaz06/users"
+)
+
+const (
+	bulkExportBatchSize = 500
+	// parquetRowGroupSize bounds the rows held in memory by the parquet writer before they are written out.
+	parquetRowGroupSize = 16 << 20
+)
+
+// bulkExportColumn is a column of the exports, with its parquet type and its value for a user,
+// which is a string, an int64, a time.Time or a *time.Time.
+type bulkExportColumn struct {
+	parquetType string
+	value       func(user users.User) interface{}
+}
+
+var bulkExportColumns = map[string]bulkExportColumn{
+	"id": {
+		parquetType: "type=UTF8, repetitiontype=REQUIRED",
+		value:       func(user users.User) interface{} { return user.ID },
+	},
+	"email": {
+		parquetType: "type=UTF8, repetitiontype=REQUIRED",
+		value:       func(user users.User) interface{} { return user.Email },
+	},
+	"username": {
+		parquetType: "type=UTF8, repetitiontype=REQUIRED",
+		value:       func(user users.User) interface{} { return user.Username },
+	},
+	"address": {
+		parquetType: "type=UTF8, repetitiontype=REQUIRED",
+		value:       func(user users.User) interface{} { return user.Address },
+	},
+	"status": {
+		parquetType: "type=UTF8, repetitiontype=REQUIRED",
+		value:       func(user users.User) interface{} { return string(user.Status) },
+	},
+	"version": {
+		parquetType: "type=INT64, repetitiontype=REQUIRED",
+		value:       func(user users.User) interface{} { return user.Version },
+	},
+	"created_time": {
+		parquetType: "type=TIMESTAMP_MILLIS, repetitiontype=REQUIRED",
+		value:       func(user users.User) interface{} { return user.CreatedTime },
+	},
+	"updated_time": {
+		parquetType: "type=TIMESTAMP_MILLIS, repetitiontype=REQUIRED",
+		value:       func(user users.User) interface{} { return user.UpdatedTime },
+	},
+	"deleted_time": {
+		parquetType: "type=TIMESTAMP_MILLIS, repetitiontype=OPTIONAL",
+		value:       func(user users.User) interface{} { return user.DeletedTime },
+	},
+}
+
+// formatText formats the value of a column as text, leaving the times that are not set empty.
+func formatText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
+
+// rowWriter writes the exported users, one row at a time, with the given columns.
+type rowWriter interface {
+	Write(user users.User) error
+	Close() error
+}
+
+type csvRowWriter struct {
+	writer  *csv.Writer
+	columns []string
+	record  []string
+}
+
+func newCSVRowWriter(w io.Writer, columns []string) (rowWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return nil, err
+	}
+	return &csvRowWriter{
+		writer:  writer,
+		columns: columns,
+		record:  make([]string, len(columns)),
+	}, nil
+}
+
+func (w *csvRowWriter) Write(user users.User) error {
+	for i, column := range w.columns {
+		w.record[i] = formatText(bulkExportColumns[column].value(user))
+	}
+	return w.writer.Write(w.record)
+}
+
+func (w *csvRowWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type ndjsonRowWriter struct {
+	encoder *json.Encoder
+	columns []string
+}
+
+func newNDJSONRowWriter(w io.Writer, columns []string) rowWriter {
+	return &ndjsonRowWriter{
+		encoder: json.NewEncoder(w),
+		columns: columns,
+	}
+}
+
+func (w *ndjsonRowWriter) Write(user users.User) error {
+	row := make(map[string]interface{}, len(w.columns))
+	for _, column := range w.columns {
+		row[column] = bulkExportColumns[column].value(user)
+	}
+	return w.encoder.Encode(row)
+}
+
+func (w *ndjsonRowWriter) Close() error {
+	return nil
+}
+
+type parquetRowWriter struct {
+	writer  *writer.CSVWriter
+	columns []string
+	record  []*string
+}
+
+func newParquetRowWriter(w io.Writer, columns []string) (rowWriter, error) {
+	schema := make([]string, 0, len(columns))
+	for _, column := range columns {
+		schema = append(schema, fmt.Sprintf("name=%s, %s", column, bulkExportColumns[column].parquetType))
+	}
+
+	pw, err := writer.NewCSVWriter(schema, pwriter.NewWriterFile(w), 1)
+	if err != nil {
+		return nil, err
+	}
+	pw.RowGroupSize = parquetRowGroupSize
+
+	return &parquetRowWriter{
+		writer:  pw,
+		columns: columns,
+		record:  make([]*string, len(columns)),
+	}, nil
+}
+
+func (w *parquetRowWriter) Write(user users.User) error {
+	for i, column := range w.columns {
+		var value string
+		switch v := bulkExportColumns[column].value(user).(type) {
+		case time.Time:
+			value = strconv.FormatInt(v.UnixNano()/int64(time.Millisecond), 10)
+		case *time.Time:
+			if v == nil {
+				w.record[i] = nil
+				continue
+			}
+			value = strconv.FormatInt(v.UnixNano()/int64(time.Millisecond), 10)
+		default:
+			value = formatText(v)
+		}
+		w.record[i] = &value
+	}
+	return w.writer.WriteString(w.record)
+}
+
+func (w *parquetRowWriter) Close() error {
+	return w.writer.WriteStop()
+}
+
+type bulkExportService struct {
+	repo users.UserRepository
+}
+
+// NewBulkExportService creates a new bulk export service.
+func NewBulkExportService(repo users.UserRepository) users.BulkExportService {
+	return bulkExportService{
+		repo: repo,
+	}
+}
+
+// exportColumns validates the requested columns, defaulting to all of them.
+func exportColumns(columns []string) ([]string, error) {
+	if len(columns) == 0 {
+		return users.BulkExportColumns, nil
+	}
+
+	res := make([]string, 0, len(columns))
+	seen := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if _, ok := bulkExportColumns[column]; !ok {
+			return nil, users.ConstraintErrorf("unknown column: %s", column)
+		}
+		if seen[column] {
+			return nil, users.ConstraintErrorf("duplicate column: %s", column)
+		}
+		seen[column] = true
+		res = append(res, column)
+	}
+	return res, nil
+}
+
+func (s bulkExportService) Export(ctx context.Context, w io.Writer, options users.BulkExportOptions) (int64, error) {
+	columns, err := exportColumns(options.Columns)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := options.Filter
+	if filter.Sort == "" {
+		filter.Sort = users.SortCreatedTime
+	}
+	if filter.Deleted == "" {
+		filter.Deleted = users.DeletedExclude
+	}
+	if err := validateFilter(filter); err != nil {
+		return 0, err
+	}
+	filter.After = nil
+	filter.Limit = bulkExportBatchSize
+	filter.WithTotal = false
+
+	var rows rowWriter
+	switch options.Format {
+	case users.BulkExportCSV:
+		rows, err = newCSVRowWriter(w, columns)
+	case users.BulkExportNDJSON:
+		rows = newNDJSONRowWriter(w, columns)
+	case users.BulkExportParquet:
+		rows, err = newParquetRowWriter(w, columns)
+	default:
+		return 0, users.ConstraintErrorf("invalid export format: %s", options.Format)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var exported int64
+	for {
+		list, err := s.repo.List(ctx, filter)
+		if err != nil {
+			return exported, err
+		}
+
+		for _, user := range list {
+			if err := rows.Write(user); err != nil {
+				return exported, err
+			}
+			exported++
+		}
+
+		if len(list) < filter.Limit {
+			return exported, rows.Close()
+		}
+		last := list[len(list)-1]
+		filter.After = &users.UserKey{
+			ID:          last.ID,
+			Email:       last.Email,
+			CreatedTime: last.CreatedTime,
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package user_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/user"
+)
+
+func TestBulkExportService(t *testing.T) {
+	createdTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	deletedTime := createdTime.Add(time.Hour)
+	list := []users.User{
+		{ID: "1", Email: "jhon@doe.com", Username: "jhon", Address: "Jakarta, Indonesia", Password: importHash, Status: users.StatusActive, Version: 1, CreatedTime: createdTime, UpdatedTime: createdTime},
+		{ID: "2", Email: "jane@doe.com", Password: importHash, Status: users.StatusSuspended, Version: 3, CreatedTime: createdTime, UpdatedTime: createdTime, DeletedTime: &deletedTime},
+	}
+
+	tests := []struct {
+		testName       string
+		options        users.BulkExportOptions
+		listCalled     bool
+		listError      error
+		expectedResult string
+		expectedError  error
+	}{
+		{
+			testName:   "success with csv",
+			options:    users.BulkExportOptions{Format: users.BulkExportCSV},
+			listCalled: true,
+			expectedResult: "id,email,username,address,status,version,created_time,updated_time,deleted_time\n" +
+				`1,jhon@doe.com,jhon,"Jakarta, Indonesia",active,1,2021-01-01T00:00:00Z,2021-01-01T00:00:00Z,` + "\n" +
+				"2,jane@doe.com,,,suspended,3,2021-01-01T00:00:00Z,2021-01-01T00:00:00Z,2021-01-01T01:00:00Z\n",
+		},
+		{
+			testName: "success with ndjson and columns",
+			options: users.BulkExportOptions{
+				Format:  users.BulkExportNDJSON,
+				Columns: []string{"email", "deleted_time"},
+			},
+			listCalled: true,
+			expectedResult: `{"deleted_time":null,"email":"jhon@doe.com"}` + "\n" +
+				`{"deleted_time":"2021-01-01T01:00:00Z","email":"jane@doe.com"}` + "\n",
+		},
+		{
+			testName: "with password column",
+			options: users.BulkExportOptions{
+				Format:  users.BulkExportCSV,
+				Columns: []string{"email", "password"},
+			},
+			expectedError: users.ConstraintErrorf("unknown column: password"),
+		},
+		{
+			testName: "with duplicate column",
+			options: users.BulkExportOptions{
+				Format:  users.BulkExportCSV,
+				Columns: []string{"email", "EMAIL"},
+			},
+			expectedError: users.ConstraintErrorf("duplicate column: email"),
+		},
+		{
+			testName:      "with invalid format",
+			options:       users.BulkExportOptions{Format: "xlsx"},
+			expectedError: users.ConstraintErrorf("invalid export format: xlsx"),
+		},
+		{
+			testName: "with invalid filter",
+			options: users.BulkExportOptions{
+				Format: users.BulkExportCSV,
+				Filter: users.UserFilter{Sort: "password"},
+			},
+			expectedError: users.ConstraintErrorf("invalid sort: password"),
+		},
+		{
+			testName:      "error from repository",
+			options:       users.BulkExportOptions{Format: users.BulkExportNDJSON},
+			listCalled:    true,
+			listError:     errors.New("unexpected error"),
+			expectedError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			if test.listCalled {
+				filter := users.UserFilter{
+					Sort:    users.SortCreatedTime,
+					Deleted: users.DeletedExclude,
+					Limit:   500,
+				}
+				if test.listError != nil {
+					mockRepo.On("List", mock.Anything, filter).Return(nil, test.listError).Once()
+				} else {
+					mockRepo.On("List", mock.Anything, filter).Return(list, nil).Once()
+				}
+			}
+
+			var buf bytes.Buffer
+			service := user.NewBulkExportService(mockRepo)
+			exported, err := service.Export(context.Background(), &buf, test.options)
+			mockRepo.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				require.Zero(t, exported)
+				require.Empty(t, buf.String())
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, int64(len(list)), exported)
+			require.Equal(t, test.expectedResult, buf.String())
+			require.NotContains(t, buf.String(), importHash)
+		})
+	}
+}
+
+func TestBulkExportServicePages(t *testing.T) {
+	createdTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := make([]users.User, 500)
+	for i := range first {
+		first[i] = users.User{ID: fmt.Sprintf("%03d", i), Email: fmt.Sprintf("%03d@doe.com", i), CreatedTime: createdTime}
+	}
+	second := []users.User{{ID: "500", Email: "500@doe.com", CreatedTime: createdTime}}
+
+	mockRepo := new(mocks.UserRepository)
+	mockRepo.On("List", mock.Anything, mock.MatchedBy(func(filter users.UserFilter) bool {
+		return filter.After == nil && filter.Sort == users.SortEmail && filter.Status == users.StatusActive
+	})).Return(first, nil).Once()
+	mockRepo.On("List", mock.Anything, mock.MatchedBy(func(filter users.UserFilter) bool {
+		return filter.After != nil && filter.After.ID == "499" && filter.After.Email == "499@doe.com"
+	})).Return(second, nil).Once()
+
+	var buf bytes.Buffer
+	service := user.NewBulkExportService(mockRepo)
+	exported, err := service.Export(context.Background(), &buf, users.BulkExportOptions{
+		Format:  users.BulkExportCSV,
+		Columns: []string{"id"},
+		Filter:  users.UserFilter{Sort: users.SortEmail, Status: users.StatusActive},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(501), exported)
+	require.Equal(t, 502, strings.Count(buf.String(), "\n"))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBulkExportServiceParquet(t *testing.T) {
+	createdTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	deletedTime := createdTime.Add(time.Hour)
+	list := []users.User{
+		{ID: "1", Email: "jhon@doe.com", Password: importHash, Status: users.StatusActive, Version: 1, CreatedTime: createdTime, UpdatedTime: createdTime},
+		{ID: "2", Email: "jane@doe.com", Password: importHash, Status: users.StatusActive, Version: 2, CreatedTime: createdTime, UpdatedTime: createdTime, DeletedTime: &deletedTime},
+	}
+
+	mockRepo := new(mocks.UserRepository)
+	mockRepo.On("List", mock.Anything, mock.AnythingOfType("users.UserFilter")).Return(list, nil).Once()
+
+	var buf bytes.Buffer
+	service := user.NewBulkExportService(mockRepo)
+	exported, err := service.Export(context.Background(), &buf, users.BulkExportOptions{
+		Format:  users.BulkExportParquet,
+		Columns: []string{"email", "version", "deleted_time"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), exported)
+	require.NotContains(t, buf.String(), importHash)
+
+	f, err := buffer.NewBufferFile(buf.Bytes())
+	require.NoError(t, err)
+	r, err := reader.NewParquetColumnReader(f, 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), r.GetNumRows())
+
+	emails, _, _, err := r.ReadColumnByIndex(0, 2)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"jhon@doe.com", "jane@doe.com"}, emails)
+	versions, _, _, err := r.ReadColumnByIndex(1, 2)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{int64(1), int64(2)}, versions)
+	deleted, _, _, err := r.ReadColumnByIndex(2, 2)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{nil, deletedTime.UnixNano() / int64(time.Millisecond)}, deleted)
+}
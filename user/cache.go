@@ -0,0 +1,381 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/arnaz06/users"
+)
+
+// cachedUser is a user as cached, with its times at the second as stored by the repositories.
+// Only the users which are not deleted are cached, and without their password hash.
+type cachedUser struct {
+	ID          string       `json:"id"`
+	Email       string       `json:"email"`
+	Username    string       `json:"username"`
+	Address     string       `json:"address"`
+	Status      users.Status `json:"status"`
+	Version     int64        `json:"version"`
+	CreatedTime int64        `json:"created_time"`
+	UpdatedTime int64        `json:"updated_time"`
+}
+
+// cacheEntry is the value cached under a key: the user found by its ID, its password hash, the ID of the user
+// found by its email or username, or nothing found.
+type cacheEntry struct {
+	NotFound bool        `json:"not_found,omitempty"`
+	ID       string      `json:"id,omitempty"`
+	User     *cachedUser `json:"user,omitempty"`
+	Password string      `json:"password,omitempty"`
+}
+
+type cachingUserRepo struct {
+	users.UserRepository
+	cache       users.Cache
+	ttl         time.Duration
+	negativeTTL time.Duration
+	loadTimeout time.Duration
+	group       *singleflight.Group
+}
+
+// NewCachingUserRepository wraps the repository to read the users by ID, email and username through the cache.
+// The users found are cached for ttl, and the ones not found for negativeTTL. The concurrent reads missing
+// the cache for the same key share a single read of the repository, bounded by loadTimeout.
+// The users read through the cache have no password hash. The hashes are cached apart, under keys of their own,
+// and only read back by the reads made with users.WithPasswordReads, such as the ones of the logins.
+// The cached users are dropped on the writes made through it, and on the user events for the others.
+// The users missing the cache are read as of the latest writes, so that no replica lagging behind gets cached.
+// A read racing a write may still cache what it read before the write, until its TTL is over.
+func NewCachingUserRepository(repo users.UserRepository, cache users.Cache, ttl, negativeTTL, loadTimeout time.Duration) users.CachingUserRepository {
+	return cachingUserRepo{
+		UserRepository: repo,
+		cache:          cache,
+		ttl:            ttl,
+		negativeTTL:    negativeTTL,
+		loadTimeout:    loadTimeout,
+		group:          &singleflight.Group{},
+	}
+}
+
+// The keys are scoped by the tenant, as the users are. The emails and usernames are keyed by their normalized form,
+// as the repositories match them regardless of their case.
+func idKey(ctx context.Context, id string) string {
+	return "user:" + users.TenantFromContext(ctx) + ":id:" + id
+}
+
+func passwordKey(ctx context.Context, id string) string {
+	return "user:" + users.TenantFromContext(ctx) + ":password:" + id
+}
+
+func emailKey(ctx context.Context, email string) string {
+	return "user:" + users.TenantFromContext(ctx) + ":email:" + users.NormalizeEmail(email)
+}
+
+func usernameKey(ctx context.Context, username string) string {
+	return "user:" + users.TenantFromContext(ctx) + ":username:" + users.NormalizeUsername(username)
+}
+
+// userKeys returns the keys of the user cached by its ID, along with its password hash,
+// and by its email and username when given.
+func userKeys(ctx context.Context, id, email, username string) []string {
+	keys := []string{idKey(ctx, id), passwordKey(ctx, id)}
+	if email != "" {
+		keys = append(keys, emailKey(ctx, email))
+	}
+	if username != "" {
+		keys = append(keys, usernameKey(ctx, username))
+	}
+	return keys
+}
+
+func (r cachingUserRepo) Get(ctx context.Context, id string) (users.User, error) {
+	entry, ok := r.get(ctx, idKey(ctx, id))
+	if ok && (entry.NotFound || entry.User == nil) {
+		return users.User{}, users.ErrNotFound
+	}
+	if ok {
+		if user, ok := r.withPassword(ctx, entry.User.user()); ok {
+			return user, nil
+		}
+	}
+
+	return r.load(ctx, idKey(ctx, id), func(ctx context.Context) (users.User, error) {
+		return r.UserRepository.Get(ctx, id)
+	})
+}
+
+func (r cachingUserRepo) GetByEmail(ctx context.Context, email string) (users.User, error) {
+	matches := func(user users.User) bool {
+		return users.NormalizeEmail(user.Email) == users.NormalizeEmail(email)
+	}
+	return r.getBy(ctx, emailKey(ctx, email), matches, func(ctx context.Context) (users.User, error) {
+		return r.UserRepository.GetByEmail(ctx, email)
+	})
+}
+
+func (r cachingUserRepo) GetByUsername(ctx context.Context, username string) (users.User, error) {
+	matches := func(user users.User) bool {
+		return user.Username != "" && users.NormalizeUsername(user.Username) == users.NormalizeUsername(username)
+	}
+	return r.getBy(ctx, usernameKey(ctx, username), matches, func(ctx context.Context) (users.User, error) {
+		return r.UserRepository.GetByUsername(ctx, username)
+	})
+}
+
+// getBy reads the ID of the user cached under the key, then the user from its ID. The user is checked to still
+// match what it is read by, so the ID left cached for the former email or username of a user is only a miss.
+func (r cachingUserRepo) getBy(ctx context.Context, key string, matches func(user users.User) bool, read func(ctx context.Context) (users.User, error)) (users.User, error) {
+	entry, ok := r.get(ctx, key)
+	if ok && entry.NotFound {
+		return users.User{}, users.ErrNotFound
+	}
+	if ok {
+		cached, ok := r.get(ctx, idKey(ctx, entry.ID))
+		if ok && cached.User != nil && matches(cached.User.user()) {
+			if user, ok := r.withPassword(ctx, cached.User.user()); ok {
+				return user, nil
+			}
+		}
+	}
+
+	return r.load(ctx, key, read)
+}
+
+// withPassword returns the user with its cached password hash for the password reads, or tells it is a miss.
+// The other reads get the user as it is.
+func (r cachingUserRepo) withPassword(ctx context.Context, user users.User) (users.User, bool) {
+	if !users.ReadsPassword(ctx) {
+		return user, true
+	}
+
+	entry, ok := r.get(ctx, passwordKey(ctx, user.ID))
+	if !ok || entry.Password == "" {
+		return users.User{}, false
+	}
+	user.Password = entry.Password
+	return user, true
+}
+
+// get returns the entry cached under the key, if any. The failures of the cache are only misses.
+func (r cachingUserRepo) get(ctx context.Context, key string) (cacheEntry, bool) {
+	data, err := r.cache.Get(ctx, key)
+	if err != nil {
+		if err != users.ErrNotFound {
+			log.Warnf("Failed to read cached user %s: %+v", key, err)
+		}
+		return cacheEntry{}, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		log.Warnf("Failed to decode cached user %s: %+v", key, err)
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// load reads the user missing the cache under the key from the repository, once for the concurrent misses,
+// and caches it under its ID, email and username, with its password hash apart, or caches it is not found
+// under the key. The user is returned without its password hash but to the password reads, as when cached.
+// The shared read is not canceled with the context of the caller starting it, which the others do not share,
+// but bounded by loadTimeout. Each caller still stops waiting for it once its own context is done.
+func (r cachingUserRepo) load(ctx context.Context, key string, read func(ctx context.Context) (users.User, error)) (users.User, error) {
+	loaded := r.group.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(detachedContext{ctx}, r.loadTimeout)
+		defer cancel()
+
+		user, err := read(users.WithLatestReads(ctx))
+		if err == users.ErrNotFound {
+			r.set(ctx, key, cacheEntry{NotFound: true}, r.negativeTTL)
+			return users.User{}, err
+		}
+		if err != nil {
+			return users.User{}, err
+		}
+
+		cached := newCachedUser(user)
+		r.set(ctx, idKey(ctx, user.ID), cacheEntry{User: cached}, r.ttl)
+		r.set(ctx, passwordKey(ctx, user.ID), cacheEntry{Password: user.Password}, r.ttl)
+		r.set(ctx, emailKey(ctx, user.Email), cacheEntry{ID: user.ID}, r.ttl)
+		if user.Username != "" {
+			r.set(ctx, usernameKey(ctx, user.Username), cacheEntry{ID: user.ID}, r.ttl)
+		}
+
+		res := cached.user()
+		res.Password = user.Password
+		return res, nil
+	})
+
+	select {
+	case res := <-loaded:
+		user := res.Val.(users.User)
+		if !users.ReadsPassword(ctx) {
+			user.Password = ""
+		}
+		return user, res.Err
+	case <-ctx.Done():
+		return users.User{}, ctx.Err()
+	}
+}
+
+// detachedContext holds the values of its parent, such as the tenant, but neither its deadline nor its cancellation.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (r cachingUserRepo) set(ctx context.Context, key string, entry cacheEntry, ttl time.Duration) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Warnf("Failed to encode cached user %s: %+v", key, err)
+		return
+	}
+	if err := r.cache.Set(ctx, key, data, ttl); err != nil {
+		log.Warnf("Failed to cache user %s: %+v", key, err)
+	}
+}
+
+// drop removes the keys from the cache. The write is made already, so a failure is only logged.
+func (r cachingUserRepo) drop(ctx context.Context, keys ...string) {
+	if err := r.cache.Delete(ctx, keys...); err != nil {
+		log.Errorf("Failed to drop cached users %v: %+v", keys, err)
+	}
+}
+
+// invalidate drops the user cached under its ID, and whatever is cached under its current email and username.
+func (r cachingUserRepo) invalidate(ctx context.Context, id string) {
+	user, err := r.UserRepository.Get(users.WithLatestReads(ctx), id)
+	if err != nil && err != users.ErrNotFound {
+		log.Errorf("Failed to read user %s to drop it from the cache: %+v", id, err)
+	}
+	if err != nil {
+		r.drop(ctx, userKeys(ctx, id, "", "")...)
+		return
+	}
+	r.drop(ctx, userKeys(ctx, id, user.Email, user.Username)...)
+}
+
+func (r cachingUserRepo) Create(ctx context.Context, user users.User) (users.User, error) {
+	res, err := r.UserRepository.Create(ctx, user)
+	if err != nil {
+		return users.User{}, err
+	}
+
+	// The user may be cached as not found under its ID, email or username.
+	r.drop(ctx, userKeys(ctx, res.ID, res.Email, res.Username)...)
+	return res, nil
+}
+
+func (r cachingUserRepo) CreateBatch(ctx context.Context, list []users.User, dryRun bool) ([]error, error) {
+	errs, err := r.UserRepository.CreateBatch(ctx, list, dryRun)
+	if err != nil || dryRun {
+		return errs, err
+	}
+
+	keys := []string{}
+	for i, user := range list {
+		if errs[i] != nil {
+			continue
+		}
+		keys = append(keys, emailKey(ctx, user.Email))
+		if user.Username != "" {
+			keys = append(keys, usernameKey(ctx, user.Username))
+		}
+		if user.ID != "" {
+			keys = append(keys, idKey(ctx, user.ID))
+		}
+	}
+	r.drop(ctx, keys...)
+	return errs, nil
+}
+
+func (r cachingUserRepo) Update(ctx context.Context, user users.User) error {
+	err := r.UserRepository.Update(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	// Whatever is left cached under the former email or username only points to the user, which is checked.
+	r.drop(ctx, userKeys(ctx, user.ID, user.Email, user.Username)...)
+	return nil
+}
+
+func (r cachingUserRepo) Delete(ctx context.Context, id string, version int64) error {
+	err := r.UserRepository.Delete(ctx, id, version)
+	if err != nil {
+		return err
+	}
+
+	r.drop(ctx, userKeys(ctx, id, "", "")...)
+	return nil
+}
+
+func (r cachingUserRepo) Restore(ctx context.Context, id string) error {
+	err := r.UserRepository.Restore(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, id)
+	return nil
+}
+
+func (r cachingUserRepo) UpdateStatus(ctx context.Context, change users.StatusChange) error {
+	err := r.UserRepository.UpdateStatus(ctx, change)
+	if err != nil {
+		return err
+	}
+
+	r.drop(ctx, idKey(ctx, change.UserID))
+	return nil
+}
+
+// HandleUserEvent drops the user changed without going through the repository, such as by a confirmed
+// email change or a unit of work.
+func (r cachingUserRepo) HandleUserEvent(ctx context.Context, event users.UserEvent) {
+	if event.Type == users.UserLoggedIn {
+		return
+	}
+	r.invalidate(ctx, event.UserID)
+}
+
+func newCachedUser(user users.User) *cachedUser {
+	return &cachedUser{
+		ID:          user.ID,
+		Email:       user.Email,
+		Username:    user.Username,
+		Address:     user.Address,
+		Status:      user.Status,
+		Version:     user.Version,
+		CreatedTime: user.CreatedTime.Unix(),
+		UpdatedTime: user.UpdatedTime.Unix(),
+	}
+}
+
+func (u cachedUser) user() users.User {
+	return users.User{
+		ID:          u.ID,
+		Email:       u.Email,
+		Username:    u.Username,
+		Address:     u.Address,
+		Status:      u.Status,
+		Version:     u.Version,
+		CreatedTime: time.Unix(u.CreatedTime, 0),
+		UpdatedTime: time.Unix(u.UpdatedTime, 0),
+	}
+}
@@ -0,0 +1,392 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal/cache"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
+	"github.com/arnaz06/users/user"
+)
+
+func goldenCachedUser(t *testing.T) users.User {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+	// The users are cached with their times at the second, as the repositories store them.
+	mockUser.CreatedTime = time.Unix(mockUser.CreatedTime.Unix(), 0)
+	mockUser.UpdatedTime = time.Unix(mockUser.UpdatedTime.Unix(), 0)
+	// The users are read through the cache without their password hash.
+	mockUser.Password = ""
+	return mockUser
+}
+
+func TestCachingUserRepositoryGet(t *testing.T) {
+	mockUser := goldenCachedUser(t)
+
+	tests := []struct {
+		testName       string
+		reads          func(repo users.UserRepository) (users.User, error)
+		get            []testdata.FuncCall
+		getByEmail     []testdata.FuncCall
+		expectedResult users.User
+		expectedError  error
+	}{
+		{
+			testName: "with user cached by id",
+			reads: func(repo users.UserRepository) (users.User, error) {
+				_, _ = repo.Get(context.Background(), mockUser.ID)
+				return repo.Get(context.Background(), mockUser.ID)
+			},
+			get: []testdata.FuncCall{
+				{
+					Called: true,
+					Input:  []interface{}{mock.Anything, mockUser.ID},
+					Output: []interface{}{mockUser, nil},
+				},
+			},
+			expectedResult: mockUser,
+		},
+		{
+			testName: "with user cached by email then read by id",
+			reads: func(repo users.UserRepository) (users.User, error) {
+				_, _ = repo.GetByEmail(context.Background(), mockUser.Email)
+				_, _ = repo.GetByEmail(context.Background(), mockUser.Email)
+				return repo.Get(context.Background(), mockUser.ID)
+			},
+			getByEmail: []testdata.FuncCall{
+				{
+					Called: true,
+					Input:  []interface{}{mock.Anything, mockUser.Email},
+					Output: []interface{}{mockUser, nil},
+				},
+			},
+			expectedResult: mockUser,
+		},
+		{
+			testName: "with user cached as not found",
+			reads: func(repo users.UserRepository) (users.User, error) {
+				_, _ = repo.GetByEmail(context.Background(), "404@doe.com")
+				return repo.GetByEmail(context.Background(), "404@doe.com")
+			},
+			getByEmail: []testdata.FuncCall{
+				{
+					Called: true,
+					Input:  []interface{}{mock.Anything, "404@doe.com"},
+					Output: []interface{}{users.User{}, users.ErrNotFound},
+				},
+			},
+			expectedError: users.ErrNotFound,
+		},
+		{
+			testName: "with other tenant",
+			reads: func(repo users.UserRepository) (users.User, error) {
+				_, _ = repo.Get(context.Background(), mockUser.ID)
+				return repo.Get(users.WithTenant(context.Background(), "acme"), mockUser.ID)
+			},
+			get: []testdata.FuncCall{
+				{
+					Called: true,
+					Input:  []interface{}{mock.Anything, mockUser.ID},
+					Output: []interface{}{mockUser, nil},
+				},
+				{
+					Called: true,
+					Input:  []interface{}{mock.Anything, mockUser.ID},
+					Output: []interface{}{users.User{}, users.ErrNotFound},
+				},
+			},
+			expectedError: users.ErrNotFound,
+		},
+		{
+			testName: "with error not cached",
+			reads: func(repo users.UserRepository) (users.User, error) {
+				_, _ = repo.Get(context.Background(), mockUser.ID)
+				return repo.Get(context.Background(), mockUser.ID)
+			},
+			get: []testdata.FuncCall{
+				{
+					Called: true,
+					Input:  []interface{}{mock.Anything, mockUser.ID},
+					Output: []interface{}{users.User{}, errors.New("unexpected error")},
+				},
+				{
+					Called: true,
+					Input:  []interface{}{mock.Anything, mockUser.ID},
+					Output: []interface{}{users.User{}, errors.New("unexpected error")},
+				},
+			},
+			expectedError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			for _, get := range test.get {
+				mockRepo.On("Get", get.Input...).
+					Return(get.Output...).Once()
+			}
+			for _, get := range test.getByEmail {
+				mockRepo.On("GetByEmail", get.Input...).
+					Return(get.Output...).Once()
+			}
+
+			repo := user.NewCachingUserRepository(mockRepo, cache.NewLRUCache(10), time.Minute, time.Minute, time.Second)
+			res, err := test.reads(repo)
+			mockRepo.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expectedResult, res)
+		})
+	}
+}
+
+func TestCachingUserRepositoryInvalidation(t *testing.T) {
+	mockUser := goldenCachedUser(t)
+	updatedUser := users.User(mockUser)
+	updatedUser.Email = "jhon-2@doe.com"
+	updatedUser.Version++
+
+	tests := []struct {
+		testName string
+		write    func(repo users.CachingUserRepository) error
+		mock     func(mockRepo *mocks.UserRepository)
+	}{
+		{
+			testName: "update",
+			write: func(repo users.CachingUserRepository) error {
+				return repo.Update(context.Background(), updatedUser)
+			},
+			mock: func(mockRepo *mocks.UserRepository) {
+				mockRepo.On("Update", mock.Anything, updatedUser).Return(nil).Once()
+			},
+		},
+		{
+			testName: "status change",
+			write: func(repo users.CachingUserRepository) error {
+				return repo.UpdateStatus(context.Background(), users.StatusChange{UserID: mockUser.ID, From: users.StatusActive, To: users.StatusLocked})
+			},
+			mock: func(mockRepo *mocks.UserRepository) {
+				mockRepo.On("UpdateStatus", mock.Anything, mock.Anything).Return(nil).Once()
+			},
+		},
+		{
+			testName: "event of a change made around the cache",
+			write: func(repo users.CachingUserRepository) error {
+				repo.HandleUserEvent(context.Background(), users.UserEvent{Type: users.UserUpdated, UserID: mockUser.ID})
+				return nil
+			},
+			mock: func(mockRepo *mocks.UserRepository) {
+				mockRepo.On("Get", mock.Anything, mockUser.ID).Return(updatedUser, nil).Once()
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			mockRepo.On("GetByEmail", mock.Anything, mockUser.Email).Return(mockUser, nil).Once()
+			repo := user.NewCachingUserRepository(mockRepo, cache.NewLRUCache(10), time.Minute, time.Minute, time.Second)
+
+			_, err := repo.GetByEmail(context.Background(), mockUser.Email)
+			require.NoError(t, err)
+
+			test.mock(mockRepo)
+			require.NoError(t, test.write(repo))
+
+			mockRepo.On("Get", mock.Anything, mockUser.ID).Return(updatedUser, nil).Once()
+			res, err := repo.Get(context.Background(), mockUser.ID)
+			require.NoError(t, err)
+			require.Equal(t, updatedUser, res)
+
+			// The former email still points to the user, whose email no longer matches.
+			mockRepo.On("GetByEmail", mock.Anything, mockUser.Email).Return(users.User{}, users.ErrNotFound).Once()
+			_, err = repo.GetByEmail(context.Background(), mockUser.Email)
+			require.EqualError(t, err, users.ErrNotFound.Error())
+			mockRepo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("create of a user cached as not found in another case", func(t *testing.T) {
+		mockRepo := new(mocks.UserRepository)
+		mockRepo.On("GetByEmail", mock.Anything, "JHON@doe.com").Return(users.User{}, users.ErrNotFound).Once()
+		mockRepo.On("Create", mock.Anything, mockUser).Return(mockUser, nil).Once()
+		mockRepo.On("GetByEmail", mock.Anything, "JHON@doe.com").Return(mockUser, nil).Once()
+		repo := user.NewCachingUserRepository(mockRepo, cache.NewLRUCache(10), time.Minute, time.Minute, time.Second)
+
+		_, err := repo.GetByEmail(context.Background(), "JHON@doe.com")
+		require.EqualError(t, err, users.ErrNotFound.Error())
+		_, err = repo.Create(context.Background(), mockUser)
+		require.NoError(t, err)
+
+		res, err := repo.GetByEmail(context.Background(), "JHON@doe.com")
+		require.NoError(t, err)
+		require.Equal(t, mockUser, res)
+
+		// The user is then cached under the email in any case.
+		res, err = repo.GetByEmail(context.Background(), "Jhon@Doe.com")
+		require.NoError(t, err)
+		require.Equal(t, mockUser, res)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("create of a user cached as not found", func(t *testing.T) {
+		mockRepo := new(mocks.UserRepository)
+		mockRepo.On("GetByEmail", mock.Anything, mockUser.Email).Return(users.User{}, users.ErrNotFound).Once()
+		mockRepo.On("Create", mock.Anything, mockUser).Return(mockUser, nil).Once()
+		mockRepo.On("GetByEmail", mock.Anything, mockUser.Email).Return(mockUser, nil).Once()
+		repo := user.NewCachingUserRepository(mockRepo, cache.NewLRUCache(10), time.Minute, time.Minute, time.Second)
+
+		_, err := repo.GetByEmail(context.Background(), mockUser.Email)
+		require.EqualError(t, err, users.ErrNotFound.Error())
+		_, err = repo.Create(context.Background(), mockUser)
+		require.NoError(t, err)
+
+		res, err := repo.GetByEmail(context.Background(), mockUser.Email)
+		require.NoError(t, err)
+		require.Equal(t, mockUser, res)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestCachingUserRepositoryConcurrentMisses(t *testing.T) {
+	mockUser := goldenCachedUser(t)
+	mockRepo := new(mocks.UserRepository)
+	mockRepo.On("Get", mock.Anything, mockUser.ID).
+		Return(mockUser, nil).After(50 * time.Millisecond).Once()
+	repo := user.NewCachingUserRepository(mockRepo, cache.NewLRUCache(10), time.Minute, time.Minute, time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := repo.Get(context.Background(), mockUser.ID)
+			require.NoError(t, err)
+			require.Equal(t, mockUser, res)
+		}()
+	}
+	wg.Wait()
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCachingUserRepositoryPasswords(t *testing.T) {
+	cachedUser := goldenCachedUser(t)
+	cachedUser.Username = "jhondoe"
+	mockUser := users.User(cachedUser)
+	mockUser.Password = "hash"
+
+	mockRepo := new(mocks.UserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, mockUser.Email).Return(mockUser, nil).Once()
+	repo := user.NewCachingUserRepository(mockRepo, cache.NewLRUCache(10), time.Minute, time.Minute, time.Second)
+
+	// The password reads get the hash cached apart from the user, which the other reads never get.
+	for i := 0; i < 2; i++ {
+		res, err := repo.GetByEmail(context.Background(), mockUser.Email)
+		require.NoError(t, err)
+		require.Equal(t, cachedUser, res)
+
+		res, err = repo.GetByEmail(users.WithPasswordReads(context.Background()), mockUser.Email)
+		require.NoError(t, err)
+		require.Equal(t, mockUser, res)
+	}
+
+	res, err := repo.GetByUsername(users.WithPasswordReads(context.Background()), strings.ToUpper(mockUser.Username))
+	require.NoError(t, err)
+	require.Equal(t, mockUser, res)
+
+	res, err = repo.Get(context.Background(), mockUser.ID)
+	require.NoError(t, err)
+	require.Equal(t, cachedUser, res)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCachingUserRepositoryGetByUsername(t *testing.T) {
+	cachedUser := goldenCachedUser(t)
+	cachedUser.Username = "jhondoe"
+	mockRepo := new(mocks.UserRepository)
+	mockRepo.On("GetByUsername", mock.Anything, cachedUser.Username).Return(cachedUser, nil).Once()
+	mockRepo.On("GetByUsername", mock.Anything, "unknown").Return(users.User{}, users.ErrNotFound).Once()
+	repo := user.NewCachingUserRepository(mockRepo, cache.NewLRUCache(10), time.Minute, time.Minute, time.Second)
+
+	for i := 0; i < 2; i++ {
+		res, err := repo.GetByUsername(context.Background(), cachedUser.Username)
+		require.NoError(t, err)
+		require.Equal(t, cachedUser, res)
+
+		_, err = repo.GetByUsername(context.Background(), "unknown")
+		require.Equal(t, users.ErrNotFound, err)
+	}
+
+	// A user read by its ID is then read by its username from the cache.
+	res, err := repo.Get(context.Background(), cachedUser.ID)
+	require.NoError(t, err)
+	require.Equal(t, cachedUser, res)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCachingUserRepositoryCanceledMiss(t *testing.T) {
+	mockUser := goldenCachedUser(t)
+	mockRepo := new(mocks.UserRepository)
+	mockRepo.On("Get", mock.Anything, mockUser.ID).
+		Return(mockUser, nil).After(50 * time.Millisecond).Once()
+	repo := user.NewCachingUserRepository(mockRepo, cache.NewLRUCache(10), time.Minute, time.Minute, time.Second)
+
+	// The first miss is canceled while the read it started is shared by the second one.
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error)
+	go func() {
+		_, err := repo.Get(ctx, mockUser.ID)
+		canceled <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		res, err := repo.Get(context.Background(), mockUser.ID)
+		require.NoError(t, err)
+		require.Equal(t, mockUser, res)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	require.EqualError(t, <-canceled, context.Canceled.Error())
+	wg.Wait()
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCachingUserRepositoryWithFailingCache(t *testing.T) {
+	mockUser := goldenCachedUser(t)
+	mockRepo := new(mocks.UserRepository)
+	mockRepo.On("Get", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+	mockRepo.On("Delete", mock.Anything, mockUser.ID, int64(0)).Return(nil).Once()
+
+	mockCache := new(mocks.Cache)
+	mockCache.On("Get", mock.Anything, mock.Anything).Return(nil, errors.New("unexpected error"))
+	mockCache.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("unexpected error"))
+	mockCache.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("unexpected error"))
+	repo := user.NewCachingUserRepository(mockRepo, mockCache, time.Minute, time.Minute, time.Second)
+
+	res, err := repo.Get(context.Background(), mockUser.ID)
+	require.NoError(t, err)
+	require.Equal(t, mockUser, res)
+
+	require.NoError(t, repo.Delete(context.Background(), mockUser.ID, 0))
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,162 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/arnaz06/users"
+)
+
+type emailChangeService struct {
+	userRepo   users.UserRepository
+	repo       users.EmailChangeRepository
+	mailer     users.Mailer
+	linkURL    string
+	confirmTTL time.Duration
+	revertTTL  time.Duration
+	handlers   []users.UserEventHandler
+}
+
+// NewEmailChangeService creates a new email change service.
+// The confirmation and revert links sent by email point to linkURL, and are valid for confirmTTL and revertTTL.
+// The handlers are given the events of the emails applied to the users.
+func NewEmailChangeService(userRepo users.UserRepository, repo users.EmailChangeRepository, mailer users.Mailer, linkURL string, confirmTTL, revertTTL time.Duration, handlers ...users.UserEventHandler) users.EmailChangeService {
+	return emailChangeService{
+		userRepo:   userRepo,
+		repo:       repo,
+		mailer:     mailer,
+		linkURL:    linkURL,
+		confirmTTL: confirmTTL,
+		revertTTL:  revertTTL,
+		handlers:   handlers,
+	}
+}
+
+func (s emailChangeService) Request(ctx context.Context, userID, newEmail string) error {
+	savedUser, err := s.userRepo.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if savedUser.Email == newEmail {
+		return users.ConstraintErrorf("email %s is already the email of the user", newEmail)
+	}
+
+	_, err = s.userRepo.GetByEmail(ctx, newEmail)
+	if err == nil {
+		return users.ConstraintErrorf("email %s is already used by another user", newEmail)
+	}
+	if err != users.ErrNotFound {
+		return err
+	}
+
+	confirmToken, err := newToken()
+	if err != nil {
+		return err
+	}
+	revertToken, err := newToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	change, err := s.repo.Create(ctx, users.EmailChange{
+		UserID:             savedUser.ID,
+		OldEmail:           savedUser.Email,
+		NewEmail:           newEmail,
+		ConfirmTokenHash:   hashToken(confirmToken),
+		RevertTokenHash:    hashToken(revertToken),
+		ConfirmExpiresTime: now.Add(s.confirmTTL),
+		RevertExpiresTime:  now.Add(s.revertTTL),
+		CreatedTime:        now,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.mailer.Send(ctx, change.NewEmail, "Confirm your new email address", fmt.Sprintf(
+		"A request was made to change the email address of your account to %s.\n\nConfirm it before %s by opening:\n%s\n",
+		change.NewEmail, change.ConfirmExpiresTime.Format(time.RFC1123), s.link(ctx, "confirm", confirmToken),
+	))
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, change.OldEmail, "Your email address is being changed", fmt.Sprintf(
+		"A request was made to change the email address of your account from %s to %s.\n\nIf you did not make it, revert it before %s by opening:\n%s\n",
+		change.OldEmail, change.NewEmail, change.RevertExpiresTime.Format(time.RFC1123), s.link(ctx, "revert", revertToken),
+	))
+}
+
+// link returns the link of the action carrying the token, and the tenant of the change, which the page opened
+// by the link sends back as the X-Tenant-ID header along with the token, as the tokens are looked up in the tenant.
+func (s emailChangeService) link(ctx context.Context, action, token string) string {
+	query := url.Values{
+		"tenant": {users.TenantFromContext(ctx)},
+		"token":  {token},
+	}
+	return s.linkURL + "/" + action + "?" + query.Encode()
+}
+
+func (s emailChangeService) Confirm(ctx context.Context, token string) error {
+	change, err := s.repo.GetByConfirmToken(ctx, hashToken(token))
+	if err != nil {
+		return err
+	}
+
+	if change.ConfirmedTime != nil || change.RevertedTime != nil {
+		return users.ConstraintErrorf("email change is no longer pending")
+	}
+	if time.Now().After(change.ConfirmExpiresTime) {
+		return users.ConstraintErrorf("email change confirmation has expired")
+	}
+
+	err = s.repo.Confirm(ctx, change.ID)
+	if err != nil {
+		return err
+	}
+
+	publish(ctx, s.handlers, users.UserUpdated, change.UserID)
+	return nil
+}
+
+func (s emailChangeService) Revert(ctx context.Context, token string) error {
+	change, err := s.repo.GetByRevertToken(ctx, hashToken(token))
+	if err != nil {
+		return err
+	}
+
+	if change.RevertedTime != nil {
+		return users.ConstraintErrorf("email change has already been reverted")
+	}
+	if time.Now().After(change.RevertExpiresTime) {
+		return users.ConstraintErrorf("email change can no longer be reverted")
+	}
+
+	err = s.repo.Revert(ctx, change.ID)
+	if err != nil {
+		return err
+	}
+
+	publish(ctx, s.handlers, users.UserUpdated, change.UserID)
+	return nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken returns the hash of the token stored instead of the token itself.
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
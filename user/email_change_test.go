@@ -0,0 +1,379 @@
+package user_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
+	"github.com/arnaz06/users/user"
+)
+
+const (
+	linkURL    = "http://localhost:3000/email-change"
+	confirmTTL = time.Hour
+	revertTTL  = 24 * time.Hour
+)
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func TestRequestEmailChangeService(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+	newEmail := "new@doe.com"
+
+	var created users.EmailChange
+	change := mock.MatchedBy(func(change users.EmailChange) bool {
+		created = change
+		return change.UserID == mockUser.ID &&
+			change.OldEmail == mockUser.Email &&
+			change.NewEmail == newEmail &&
+			change.ConfirmTokenHash != "" &&
+			change.RevertTokenHash != ""
+	})
+	linkToken := func(action string, tokenHash *string) interface{} {
+		pattern := regexp.MustCompile(regexp.QuoteMeta(linkURL+"/"+action+"?tenant=acme&token=") + `([0-9a-f]+)\n`)
+		return mock.MatchedBy(func(body string) bool {
+			match := pattern.FindStringSubmatch(body)
+			return match != nil && hashToken(match[1]) == *tokenHash
+		})
+	}
+
+	tests := []struct {
+		testName      string
+		newEmail      string
+		get           testdata.FuncCall
+		getByEmail    testdata.FuncCall
+		create        testdata.FuncCall
+		sendConfirm   testdata.FuncCall
+		sendNotice    testdata.FuncCall
+		expectedError error
+	}{
+		{
+			testName: "success",
+			newEmail: newEmail,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			getByEmail: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, newEmail},
+				Output: []interface{}{users.User{}, users.ErrNotFound},
+			},
+			create: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, change},
+				Output: []interface{}{func(ctx context.Context, change users.EmailChange) users.EmailChange { return change }, nil},
+			},
+			sendConfirm: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, newEmail, mock.Anything, linkToken("confirm", &created.ConfirmTokenHash)},
+				Output: []interface{}{nil},
+			},
+			sendNotice: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.Email, mock.Anything, linkToken("revert", &created.RevertTokenHash)},
+				Output: []interface{}{nil},
+			},
+		},
+		{
+			testName: "with current email",
+			newEmail: mockUser.Email,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			expectedError: users.ConstraintErrorf("email %s is already the email of the user", mockUser.Email),
+		},
+		{
+			testName: "with email used by another user",
+			newEmail: newEmail,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			getByEmail: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, newEmail},
+				Output: []interface{}{users.User{ID: "another"}, nil},
+			},
+			expectedError: users.ConstraintErrorf("email %s is already used by another user", newEmail),
+		},
+		{
+			testName: "with user not found",
+			newEmail: newEmail,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.User{}, users.ErrNotFound},
+			},
+			expectedError: users.ErrNotFound,
+		},
+		{
+			testName: "error from mailer",
+			newEmail: newEmail,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			getByEmail: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, newEmail},
+				Output: []interface{}{users.User{}, users.ErrNotFound},
+			},
+			create: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, change},
+				Output: []interface{}{func(ctx context.Context, change users.EmailChange) users.EmailChange { return change }, nil},
+			},
+			sendConfirm: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, newEmail, mock.Anything, mock.Anything},
+				Output: []interface{}{errors.New("unexpected error")},
+			},
+			expectedError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockUserRepo := new(mocks.UserRepository)
+			mockRepo := new(mocks.EmailChangeRepository)
+			mockMailer := new(mocks.Mailer)
+			if test.get.Called {
+				mockUserRepo.On("Get", test.get.Input...).
+					Return(test.get.Output...).Once()
+			}
+			if test.getByEmail.Called {
+				mockUserRepo.On("GetByEmail", test.getByEmail.Input...).
+					Return(test.getByEmail.Output...).Once()
+			}
+			if test.create.Called {
+				mockRepo.On("Create", test.create.Input...).
+					Return(test.create.Output...).Once()
+			}
+			if test.sendConfirm.Called {
+				mockMailer.On("Send", test.sendConfirm.Input...).
+					Return(test.sendConfirm.Output...).Once()
+			}
+			if test.sendNotice.Called {
+				mockMailer.On("Send", test.sendNotice.Input...).
+					Return(test.sendNotice.Output...).Once()
+			}
+
+			service := user.NewEmailChangeService(mockUserRepo, mockRepo, mockMailer, linkURL, confirmTTL, revertTTL)
+			err := service.Request(users.WithTenant(context.Background(), "acme"), mockUser.ID, test.newEmail)
+			mockUserRepo.AssertExpectations(t)
+			mockRepo.AssertExpectations(t)
+			mockMailer.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			require.WithinDuration(t, time.Now().Add(confirmTTL), created.ConfirmExpiresTime, time.Minute)
+			require.WithinDuration(t, time.Now().Add(revertTTL), created.RevertExpiresTime, time.Minute)
+		})
+	}
+}
+
+func TestConfirmEmailChangeService(t *testing.T) {
+	token := "confirm-token"
+	now := time.Now()
+	pending := users.EmailChange{
+		ID:                 "change-1",
+		UserID:             "123",
+		ConfirmExpiresTime: now.Add(time.Hour),
+		RevertExpiresTime:  now.Add(24 * time.Hour),
+	}
+
+	expired := users.EmailChange(pending)
+	expired.ConfirmExpiresTime = now.Add(-time.Minute)
+
+	confirmed := users.EmailChange(pending)
+	confirmed.ConfirmedTime = &now
+
+	tests := []struct {
+		testName      string
+		get           testdata.FuncCall
+		confirm       testdata.FuncCall
+		expectedError error
+	}{
+		{
+			testName: "success",
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, hashToken(token)},
+				Output: []interface{}{pending, nil},
+			},
+			confirm: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, pending.ID},
+				Output: []interface{}{nil},
+			},
+		},
+		{
+			testName: "with expired confirmation",
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, hashToken(token)},
+				Output: []interface{}{expired, nil},
+			},
+			expectedError: users.ConstraintErrorf("email change confirmation has expired"),
+		},
+		{
+			testName: "with change already confirmed",
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, hashToken(token)},
+				Output: []interface{}{confirmed, nil},
+			},
+			expectedError: users.ConstraintErrorf("email change is no longer pending"),
+		},
+		{
+			testName: "with unknown token",
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, hashToken(token)},
+				Output: []interface{}{users.EmailChange{}, users.ErrNotFound},
+			},
+			expectedError: users.ErrNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.EmailChangeRepository)
+			if test.get.Called {
+				mockRepo.On("GetByConfirmToken", test.get.Input...).
+					Return(test.get.Output...).Once()
+			}
+			mockHandler := new(mocks.UserEventHandler)
+			if test.confirm.Called {
+				mockRepo.On("Confirm", test.confirm.Input...).
+					Return(test.confirm.Output...).Once()
+				if test.expectedError == nil {
+					mockHandler.On("HandleUserEvent", mock.Anything, users.UserEvent{Type: users.UserUpdated, UserID: "123"}).Once()
+				}
+			}
+
+			service := user.NewEmailChangeService(new(mocks.UserRepository), mockRepo, new(mocks.Mailer), linkURL, confirmTTL, revertTTL, mockHandler)
+			err := service.Confirm(context.Background(), token)
+			mockRepo.AssertExpectations(t)
+			mockHandler.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRevertEmailChangeService(t *testing.T) {
+	token := "revert-token"
+	now := time.Now()
+	confirmed := users.EmailChange{
+		ID:                 "change-1",
+		UserID:             "123",
+		ConfirmExpiresTime: now.Add(time.Hour),
+		RevertExpiresTime:  now.Add(24 * time.Hour),
+		ConfirmedTime:      &now,
+	}
+
+	expired := users.EmailChange(confirmed)
+	expired.RevertExpiresTime = now.Add(-time.Minute)
+
+	reverted := users.EmailChange(confirmed)
+	reverted.RevertedTime = &now
+
+	tests := []struct {
+		testName      string
+		get           testdata.FuncCall
+		revert        testdata.FuncCall
+		expectedError error
+	}{
+		{
+			testName: "success",
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, hashToken(token)},
+				Output: []interface{}{confirmed, nil},
+			},
+			revert: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, confirmed.ID},
+				Output: []interface{}{nil},
+			},
+		},
+		{
+			testName: "with expired revert",
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, hashToken(token)},
+				Output: []interface{}{expired, nil},
+			},
+			expectedError: users.ConstraintErrorf("email change can no longer be reverted"),
+		},
+		{
+			testName: "with change already reverted",
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, hashToken(token)},
+				Output: []interface{}{reverted, nil},
+			},
+			expectedError: users.ConstraintErrorf("email change has already been reverted"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.EmailChangeRepository)
+			if test.get.Called {
+				mockRepo.On("GetByRevertToken", test.get.Input...).
+					Return(test.get.Output...).Once()
+			}
+			mockHandler := new(mocks.UserEventHandler)
+			if test.revert.Called {
+				mockRepo.On("Revert", test.revert.Input...).
+					Return(test.revert.Output...).Once()
+				if test.expectedError == nil {
+					mockHandler.On("HandleUserEvent", mock.Anything, users.UserEvent{Type: users.UserUpdated, UserID: "123"}).Once()
+				}
+			}
+
+			service := user.NewEmailChangeService(new(mocks.UserRepository), mockRepo, new(mocks.Mailer), linkURL, confirmTTL, revertTTL, mockHandler)
+			err := service.Revert(context.Background(), token)
+			mockRepo.AssertExpectations(t)
+			mockHandler.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
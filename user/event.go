@@ -0,0 +1,18 @@
+package user
+
+import (
+	"context"
+
+	"github.com/arnaz06/users"
+)
+
+// publish hands the event of the change made to the user to every handler.
+func publish(ctx context.Context, handlers []users.UserEventHandler, eventType users.UserEventType, userID string) {
+	event := users.UserEvent{
+		Type:   eventType,
+		UserID: userID,
+	}
+	for _, handler := range handlers {
+		handler.HandleUserEvent(ctx, event)
+	}
+}
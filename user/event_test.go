@@ -0,0 +1,156 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
+	"github.com/arnaz06/users/user"
+)
+
+func TestUserServiceEvents(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	tests := []struct {
+		testName      string
+		setup         func(repo *mocks.UserRepository)
+		call          func(service users.UserService) error
+		expectedEvent *users.UserEvent
+	}{
+		{
+			testName: "create",
+			setup: func(repo *mocks.UserRepository) {
+				repo.On("Create", mock.Anything, mock.AnythingOfType("users.User")).Return(mockUser, nil).Once()
+			},
+			call: func(service users.UserService) error {
+				_, err := service.Create(context.Background(), mockUser)
+				return err
+			},
+			expectedEvent: &users.UserEvent{Type: users.UserCreated, UserID: mockUser.ID},
+		},
+		{
+			testName: "failed create",
+			setup: func(repo *mocks.UserRepository) {
+				repo.On("Create", mock.Anything, mock.AnythingOfType("users.User")).Return(users.User{}, errors.New("unexpected error")).Once()
+			},
+			call: func(service users.UserService) error {
+				_, err := service.Create(context.Background(), mockUser)
+				return err
+			},
+		},
+		{
+			testName: "update",
+			setup: func(repo *mocks.UserRepository) {
+				repo.On("Get", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+				repo.On("Update", mock.Anything, mock.AnythingOfType("users.User")).Return(nil).Once()
+			},
+			call: func(service users.UserService) error {
+				return service.Update(context.Background(), mockUser)
+			},
+			expectedEvent: &users.UserEvent{Type: users.UserUpdated, UserID: mockUser.ID},
+		},
+		{
+			testName: "delete",
+			setup: func(repo *mocks.UserRepository) {
+				repo.On("Delete", mock.Anything, mockUser.ID, int64(1)).Return(nil).Once()
+			},
+			call: func(service users.UserService) error {
+				return service.Delete(context.Background(), mockUser.ID, 1)
+			},
+			expectedEvent: &users.UserEvent{Type: users.UserDeleted, UserID: mockUser.ID},
+		},
+		{
+			testName: "failed delete",
+			setup: func(repo *mocks.UserRepository) {
+				repo.On("Delete", mock.Anything, mockUser.ID, int64(1)).Return(users.ErrPreconditionFailed).Once()
+			},
+			call: func(service users.UserService) error {
+				return service.Delete(context.Background(), mockUser.ID, 1)
+			},
+		},
+		{
+			testName: "restore",
+			setup: func(repo *mocks.UserRepository) {
+				repo.On("Restore", mock.Anything, mockUser.ID).Return(nil).Once()
+			},
+			call: func(service users.UserService) error {
+				return service.Restore(context.Background(), mockUser.ID)
+			},
+			expectedEvent: &users.UserEvent{Type: users.UserRestored, UserID: mockUser.ID},
+		},
+		{
+			testName: "hard delete",
+			setup: func(repo *mocks.UserRepository) {
+				repo.On("HardDelete", mock.Anything, mockUser.ID).Return(nil).Once()
+			},
+			call: func(service users.UserService) error {
+				return service.HardDelete(context.Background(), mockUser.ID)
+			},
+			expectedEvent: &users.UserEvent{Type: users.UserPurged, UserID: mockUser.ID},
+		},
+		{
+			testName: "change status",
+			setup: func(repo *mocks.UserRepository) {
+				repo.On("Get", mock.Anything, mockUser.ID).Return(mockUser, nil).Once()
+				repo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("users.StatusChange")).Return(nil).Once()
+			},
+			call: func(service users.UserService) error {
+				return service.ChangeStatus(context.Background(), mockUser.ID, users.StatusSuspended, "spam", "admin-1")
+			},
+			expectedEvent: &users.UserEvent{Type: users.UserUpdated, UserID: mockUser.ID},
+		},
+		{
+			testName: "login",
+			setup: func(repo *mocks.UserRepository) {
+				savedUser := mockUser
+				savedUser.Password = importHash
+				repo.On("GetByEmail", mock.Anything, mockUser.Email).Return(savedUser, nil).Once()
+			},
+			call: func(service users.UserService) error {
+				_, err := service.Login(context.Background(), mockUser.Email, "secret-123")
+				return err
+			},
+			expectedEvent: &users.UserEvent{Type: users.UserLoggedIn, UserID: mockUser.ID},
+		},
+		{
+			testName: "failed login",
+			setup: func(repo *mocks.UserRepository) {
+				savedUser := mockUser
+				savedUser.Password = importHash
+				repo.On("GetByEmail", mock.Anything, mockUser.Email).Return(savedUser, nil).Once()
+			},
+			call: func(service users.UserService) error {
+				_, err := service.Login(context.Background(), mockUser.Email, "wrong-password")
+				return err
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			test.setup(mockRepo)
+			mockHandler := new(mocks.UserEventHandler)
+			if test.expectedEvent != nil {
+				mockHandler.On("HandleUserEvent", mock.Anything, *test.expectedEvent).Once()
+			}
+
+			err := test.call(user.NewUserService(mockRepo, mockHandler))
+			mockRepo.AssertExpectations(t)
+			mockHandler.AssertExpectations(t)
+
+			if test.expectedEvent == nil {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
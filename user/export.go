@@ -0,0 +1,161 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/arnaz06/users"
+)
+
+type profile struct {
+	ID          string       `json:"id"`
+	Email       string       `json:"email"`
+	Username    string       `json:"username,omitempty"`
+	Address     string       `json:"address"`
+	Status      users.Status `json:"status"`
+	CreatedTime time.Time    `json:"created_time"`
+	UpdatedTime time.Time    `json:"updated_time"`
+}
+
+type profileExporter struct {
+	repo users.UserRepository
+}
+
+// NewProfileExporter creates the export contributor of the user's profile.
+func NewProfileExporter(repo users.UserRepository) users.ExportContributor {
+	return profileExporter{
+		repo: repo,
+	}
+}
+
+func (e profileExporter) Name() string {
+	return "profile"
+}
+
+func (e profileExporter) Export(ctx context.Context, userID string) (interface{}, error) {
+	user, err := e.repo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return profile{
+		ID:          user.ID,
+		Email:       user.Email,
+		Username:    user.Username,
+		Address:     user.Address,
+		Status:      user.Status,
+		CreatedTime: user.CreatedTime,
+		UpdatedTime: user.UpdatedTime,
+	}, nil
+}
+
+type statusHistoryExporter struct {
+	repo users.UserRepository
+}
+
+// NewStatusHistoryExporter creates the export contributor of the user's status changes.
+func NewStatusHistoryExporter(repo users.UserRepository) users.ExportContributor {
+	return statusHistoryExporter{
+		repo: repo,
+	}
+}
+
+func (e statusHistoryExporter) Name() string {
+	return "status_history"
+}
+
+func (e statusHistoryExporter) Export(ctx context.Context, userID string) (interface{}, error) {
+	return e.repo.ListStatusChanges(ctx, userID)
+}
+
+type emailChangeExporter struct {
+	repo users.EmailChangeRepository
+}
+
+// NewEmailChangeExporter creates the export contributor of the user's email changes.
+func NewEmailChangeExporter(repo users.EmailChangeRepository) users.ExportContributor {
+	return emailChangeExporter{
+		repo: repo,
+	}
+}
+
+func (e emailChangeExporter) Name() string {
+	return "email_changes"
+}
+
+func (e emailChangeExporter) Export(ctx context.Context, userID string) (interface{}, error) {
+	return e.repo.ListByUser(ctx, userID)
+}
+
+type preferencesExporter struct {
+	repo users.PreferencesRepository
+}
+
+// NewPreferencesExporter creates the export contributor of the user's preferences.
+func NewPreferencesExporter(repo users.PreferencesRepository) users.ExportContributor {
+	return preferencesExporter{
+		repo: repo,
+	}
+}
+
+func (e preferencesExporter) Name() string {
+	return "preferences"
+}
+
+func (e preferencesExporter) Export(ctx context.Context, userID string) (interface{}, error) {
+	return getPreferences(ctx, e.repo, userID)
+}
+
+// historyExportPageSize is the number of history entries read at once while exporting the whole history.
+const historyExportPageSize = 100
+
+type historyExporter struct {
+	repo users.UserRepository
+}
+
+// NewHistoryExporter creates the export contributor of the changes made to the user's data, newest first.
+func NewHistoryExporter(repo users.UserRepository) users.ExportContributor {
+	return historyExporter{
+		repo: repo,
+	}
+}
+
+func (e historyExporter) Name() string {
+	return "history"
+}
+
+func (e historyExporter) Export(ctx context.Context, userID string) (interface{}, error) {
+	res := []users.HistoryEntry{}
+	beforeID := int64(0)
+	for {
+		entries, err := e.repo.ListHistory(ctx, userID, beforeID, historyExportPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, entries...)
+		if len(entries) < historyExportPageSize {
+			return res, nil
+		}
+		beforeID = entries[len(entries)-1].ID
+	}
+}
+
+type loginExporter struct {
+	repo users.StatsRepository
+}
+
+// NewLoginExporter creates the export contributor of the times the user logged in.
+func NewLoginExporter(repo users.StatsRepository) users.ExportContributor {
+	return loginExporter{
+		repo: repo,
+	}
+}
+
+func (e loginExporter) Name() string {
+	return "logins"
+}
+
+func (e loginExporter) Export(ctx context.Context, userID string) (interface{}, error) {
+	return e.repo.ListLogins(ctx, userID)
+}
@@ -0,0 +1,220 @@
+package user_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
+	"github.com/arnaz06/users/user"
+)
+
+func TestProfileExporter(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	tests := []struct {
+		testName      string
+		repo          testdata.FuncCall
+		expectedError error
+	}{
+		{
+			testName: "success",
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+		},
+		{
+			testName: "error from repository",
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.User{}, errors.New("unexpected error")},
+			},
+			expectedError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			if test.repo.Called {
+				mockRepo.On("Get", test.repo.Input...).
+					Return(test.repo.Output...).Once()
+			}
+
+			exporter := user.NewProfileExporter(mockRepo)
+			res, err := exporter.Export(context.Background(), mockUser.ID)
+			mockRepo.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "profile", exporter.Name())
+
+			b, err := json.Marshal(res)
+			require.NoError(t, err)
+			require.Contains(t, string(b), mockUser.Email)
+			require.NotContains(t, string(b), "password")
+			require.NotContains(t, string(b), mockUser.Password)
+		})
+	}
+}
+
+func TestStatusHistoryExporter(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	changes := []users.StatusChange{
+		{
+			UserID: mockUser.ID,
+			From:   users.StatusActive,
+			To:     users.StatusSuspended,
+			Reason: "spam",
+			Actor:  "admin-1",
+		},
+	}
+
+	mockRepo := new(mocks.UserRepository)
+	mockRepo.On("ListStatusChanges", mock.Anything, mockUser.ID).
+		Return(changes, nil).Once()
+
+	exporter := user.NewStatusHistoryExporter(mockRepo)
+	res, err := exporter.Export(context.Background(), mockUser.ID)
+	mockRepo.AssertExpectations(t)
+
+	require.NoError(t, err)
+	require.Equal(t, "status_history", exporter.Name())
+	require.Equal(t, changes, res)
+}
+
+func TestHistoryExporter(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	firstPage := make([]users.HistoryEntry, 100)
+	for i := range firstPage {
+		firstPage[i] = users.HistoryEntry{ID: int64(101 - i), UserID: mockUser.ID, Action: users.HistoryUpdate}
+	}
+	lastPage := []users.HistoryEntry{{ID: 1, UserID: mockUser.ID, Action: users.HistoryCreate}}
+
+	mockRepo := new(mocks.UserRepository)
+	mockRepo.On("ListHistory", mock.Anything, mockUser.ID, int64(0), 100).
+		Return(firstPage, nil).Once()
+	mockRepo.On("ListHistory", mock.Anything, mockUser.ID, int64(2), 100).
+		Return(lastPage, nil).Once()
+
+	exporter := user.NewHistoryExporter(mockRepo)
+	res, err := exporter.Export(context.Background(), mockUser.ID)
+	mockRepo.AssertExpectations(t)
+
+	require.NoError(t, err)
+	require.Equal(t, "history", exporter.Name())
+	require.Equal(t, append(firstPage, lastPage...), res)
+}
+
+func TestLoginExporter(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	logins := []time.Time{time.Unix(1614556800, 0), time.Unix(1614643200, 0)}
+
+	mockRepo := new(mocks.StatsRepository)
+	mockRepo.On("ListLogins", mock.Anything, mockUser.ID).
+		Return(logins, nil).Once()
+
+	exporter := user.NewLoginExporter(mockRepo)
+	res, err := exporter.Export(context.Background(), mockUser.ID)
+	mockRepo.AssertExpectations(t)
+
+	require.NoError(t, err)
+	require.Equal(t, "logins", exporter.Name())
+	require.Equal(t, logins, res)
+}
+
+func TestEmailChangeExporter(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	changes := []users.EmailChange{
+		{
+			ID:       "change-1",
+			UserID:   mockUser.ID,
+			OldEmail: mockUser.Email,
+			NewEmail: "new@doe.com",
+		},
+	}
+
+	mockRepo := new(mocks.EmailChangeRepository)
+	mockRepo.On("ListByUser", mock.Anything, mockUser.ID).
+		Return(changes, nil).Once()
+
+	exporter := user.NewEmailChangeExporter(mockRepo)
+	res, err := exporter.Export(context.Background(), mockUser.ID)
+	mockRepo.AssertExpectations(t)
+
+	require.NoError(t, err)
+	require.Equal(t, "email_changes", exporter.Name())
+	require.Equal(t, changes, res)
+}
+
+func TestPreferencesExporter(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	saved := users.DefaultPreferences()
+	saved.Theme = users.ThemeDark
+
+	tests := []struct {
+		testName       string
+		repo           testdata.FuncCall
+		expectedResult users.Preferences
+	}{
+		{
+			testName: "with saved preferences",
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{saved, nil},
+			},
+			expectedResult: saved,
+		},
+		{
+			testName: "without saved preferences",
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.Preferences{}, users.ErrNotFound},
+			},
+			expectedResult: users.DefaultPreferences(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.PreferencesRepository)
+			mockRepo.On("Get", test.repo.Input...).
+				Return(test.repo.Output...).Once()
+
+			exporter := user.NewPreferencesExporter(mockRepo)
+			res, err := exporter.Export(context.Background(), mockUser.ID)
+			mockRepo.AssertExpectations(t)
+
+			require.NoError(t, err)
+			require.Equal(t, "preferences", exporter.Name())
+			require.Equal(t, test.expectedResult, res)
+		})
+	}
+}
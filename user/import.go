@@ -0,0 +1,314 @@
+package user
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/arnaz06/users"
+)
+
+const (
+	defaultImportBatchSize = 100
+	maxImportBatchSize     = 1000
+	maxImportLineSize      = 1 << 20
+)
+
+// rowReader reads the rows of an import one at a time, returning io.EOF after the last one.
+// The errors of a single row are ConstraintErrors, after which the next row can still be read.
+type rowReader interface {
+	Next() (users.ImportRow, error)
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns []string
+}
+
+func newCSVReader(r io.Reader) (rowReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, users.ConstraintErrorf("csv header is missing")
+		}
+		return nil, users.ConstraintErrorf("invalid csv header: %s", err)
+	}
+
+	columns := make([]string, len(header))
+	hasEmail := false
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		switch column {
+		case "email":
+			hasEmail = true
+		case "username", "address", "password", "password_hash":
+		default:
+			return nil, users.ConstraintErrorf("unknown csv column: %s", column)
+		}
+		columns[i] = column
+	}
+	if !hasEmail {
+		return nil, users.ConstraintErrorf("csv column email is missing")
+	}
+
+	return csvReader{reader: reader, columns: columns}, nil
+}
+
+func (r csvReader) Next() (users.ImportRow, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		if _, ok := err.(*csv.ParseError); ok {
+			return users.ImportRow{}, users.ConstraintErrorf("invalid csv row: %s", err)
+		}
+		return users.ImportRow{}, err
+	}
+
+	var row users.ImportRow
+	for i, value := range record {
+		switch r.columns[i] {
+		case "email":
+			row.Email = value
+		case "username":
+			row.Username = value
+		case "address":
+			row.Address = value
+		case "password":
+			row.Password = value
+		case "password_hash":
+			row.PasswordHash = value
+		}
+	}
+	return row, nil
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+}
+
+func newNDJSONReader(r io.Reader) rowReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), maxImportLineSize)
+	return ndjsonReader{scanner: scanner}
+}
+
+func (r ndjsonReader) Next() (users.ImportRow, error) {
+	var line []byte
+	for len(line) == 0 {
+		if !r.scanner.Scan() {
+			if err := r.scanner.Err(); err != nil {
+				return users.ImportRow{}, err
+			}
+			return users.ImportRow{}, io.EOF
+		}
+		line = bytes.TrimSpace(r.scanner.Bytes())
+	}
+
+	var row users.ImportRow
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&row); err != nil {
+		return users.ImportRow{}, users.ConstraintErrorf("invalid json row: %s", err)
+	}
+	return row, nil
+}
+
+// newImportedUser validates the row with the validator and the rules of a sign-up, and returns the user to create from it.
+func newImportedUser(validator users.Validator, row users.ImportRow) (users.User, error) {
+	switch {
+	case row.Password != "" && row.PasswordHash != "":
+		return users.User{}, users.ConstraintErrorf("only one of password and password_hash can be set")
+	case row.Password == "" && row.PasswordHash == "":
+		return users.User{}, users.ConstraintErrorf("password or password_hash is required")
+	}
+
+	signUp := users.SignUp{
+		Email:    row.Email,
+		Username: row.Username,
+		Address:  row.Address,
+		Password: row.Password,
+	}
+	// A bcrypt hash is within the length rules of a password, so a row with a hash is validated
+	// as a sign-up with the hash in place of the password. The hash itself is checked below.
+	if row.Password == "" {
+		signUp.Password = row.PasswordHash
+	}
+	if err := validator.Validate(signUp); err != nil {
+		return users.User{}, users.ConstraintErrorf("error validating user: %+v", err)
+	}
+	if row.Username != "" {
+		if err := users.ValidateUsername(row.Username); err != nil {
+			return users.User{}, err
+		}
+	}
+
+	user := users.User{
+		ID:       uuid.New().String(),
+		Email:    row.Email,
+		Username: row.Username,
+		Address:  row.Address,
+		Status:   users.StatusActive,
+	}
+
+	if row.PasswordHash != "" {
+		if !users.IsHash(row.PasswordHash) {
+			return users.User{}, users.ConstraintErrorf("password_hash is not a bcrypt hash")
+		}
+		user.Password = row.PasswordHash
+	} else {
+		hashedPassword, err := users.EncodeString(row.Password)
+		if err != nil {
+			return users.User{}, err
+		}
+		user.Password = hashedPassword
+	}
+
+	return user, nil
+}
+
+type importService struct {
+	repo      users.UserRepository
+	validator users.Validator
+	handlers  []users.UserEventHandler
+}
+
+// NewImportService creates a new import service. The rows are validated with the validator of the sign-ups.
+// The handlers are given the events of the imported users, unless the import is a dry run.
+func NewImportService(repo users.UserRepository, validator users.Validator, handlers ...users.UserEventHandler) users.ImportService {
+	return importService{
+		repo:      repo,
+		validator: validator,
+		handlers:  handlers,
+	}
+}
+
+func (s importService) Import(ctx context.Context, r io.Reader, format users.ImportFormat, options users.ImportOptions) (users.ImportReport, error) {
+	var rows rowReader
+	switch format {
+	case users.ImportCSV:
+		var err error
+		rows, err = newCSVReader(r)
+		if err != nil {
+			return users.ImportReport{}, err
+		}
+	case users.ImportNDJSON:
+		rows = newNDJSONReader(r)
+	default:
+		return users.ImportReport{}, users.ConstraintErrorf("invalid import format: %s", format)
+	}
+
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultImportBatchSize
+	}
+	if batchSize > maxImportBatchSize {
+		batchSize = maxImportBatchSize
+	}
+
+	report := users.ImportReport{
+		DryRun:  options.DryRun,
+		Results: []users.ImportResult{},
+	}
+
+	// The batch holds the users to create, and pending the index of their result in the report.
+	batch := make([]users.User, 0, batchSize)
+	pending := make([]int, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		errs, err := s.repo.CreateBatch(ctx, batch, options.DryRun)
+		if err != nil {
+			for _, i := range pending {
+				report.Results[i].ID = ""
+				report.Results[i].Error = err.Error()
+			}
+			return err
+		}
+
+		for i, err := range errs {
+			result := &report.Results[pending[i]]
+			if err != nil {
+				result.ID = ""
+				result.Error = err.Error()
+				continue
+			}
+			if !options.DryRun {
+				publish(ctx, s.handlers, users.UserCreated, batch[i].ID)
+			}
+		}
+
+		batch = batch[:0]
+		pending = pending[:0]
+		return nil
+	}
+
+	for n := 1; ; n++ {
+		row, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+
+		result := users.ImportResult{
+			Row:   n,
+			Email: row.Email,
+		}
+
+		var user users.User
+		if err == nil {
+			user, err = newImportedUser(s.validator, row)
+		}
+		if err == nil && options.Pending {
+			user.Status = users.StatusPending
+		}
+		if err != nil {
+			if _, ok := err.(users.ConstraintError); !ok {
+				return summarize(report), err
+			}
+			result.Error = err.Error()
+			report.Results = append(report.Results, result)
+			continue
+		}
+
+		result.ID = user.ID
+		report.Results = append(report.Results, result)
+		batch = append(batch, user)
+		pending = append(pending, len(report.Results)-1)
+
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return summarize(report), err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return summarize(report), err
+	}
+	return summarize(report), nil
+}
+
+// summarize counts the results of the report.
+func summarize(report users.ImportReport) users.ImportReport {
+	report.Total = len(report.Results)
+	report.Imported = 0
+	report.Failed = 0
+	for _, result := range report.Results {
+		if result.Error != "" {
+			report.Failed++
+		} else {
+			report.Imported++
+		}
+	}
+	return report
+}
@@ -0,0 +1,206 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/internal"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/user"
+)
+
+const importHash = "$2a$04$s06QpvFZ6QeQouJEozOLjeqtUhnCAY307dTgq.aThUH6d8/5VO89u"
+
+// importedAs matches the batches of active users with the given emails, each with a password matching secret-123.
+func importedAs(emails ...string) interface{} {
+	return importedWithStatus(users.StatusActive, emails...)
+}
+
+// importedWithStatus matches the batches of users with the given status and emails, each with a password matching secret-123.
+func importedWithStatus(status users.Status, emails ...string) interface{} {
+	return mock.MatchedBy(func(list []users.User) bool {
+		if len(list) != len(emails) {
+			return false
+		}
+		for i, u := range list {
+			if u.ID == "" || u.Email != emails[i] || u.Status != status || users.CompareHash(u.Password, "secret-123") != nil {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// withoutIDs strips the generated IDs of the results, for the reports to be compared.
+func withoutIDs(report users.ImportReport) users.ImportReport {
+	for i := range report.Results {
+		if report.Results[i].ID != "" {
+			report.Results[i].ID = "generated"
+		}
+	}
+	return report
+}
+
+func TestImportService(t *testing.T) {
+	csvFile := "email,username,password,password_hash,address\n" +
+		"jhon@doe.com,jhon.doe,secret-123,,Jakarta\n" +
+		"jane@doe.com,,," + importHash + ",\n" +
+		"bad@doe.com,,,not-a-hash,\n" +
+		"nopassword@doe.com,,,,\n" +
+		"admin@doe.com,admin,secret-123,,\n" +
+		"jhon.doe.com,,secret-123,,\n" +
+		"short@doe.com,,secret,,\n" +
+		"jhon@doe.com,,secret-123,,\n"
+
+	tests := []struct {
+		testName       string
+		input          string
+		format         users.ImportFormat
+		options        users.ImportOptions
+		batches        [][]interface{}
+		events         int
+		expectedResult users.ImportReport
+		expectedError  error
+	}{
+		{
+			testName: "success with csv in batches",
+			input:    csvFile,
+			format:   users.ImportCSV,
+			options:  users.ImportOptions{BatchSize: 2},
+			batches: [][]interface{}{
+				{importedAs("jhon@doe.com", "jane@doe.com"), []error{nil, nil}},
+				{importedAs("jhon@doe.com"), []error{users.ConstraintErrorf("email jhon@doe.com is already used by another user")}},
+			},
+			events: 2,
+			expectedResult: users.ImportReport{
+				Total:    8,
+				Imported: 2,
+				Failed:   6,
+				Results: []users.ImportResult{
+					{Row: 1, Email: "jhon@doe.com", ID: "generated"},
+					{Row: 2, Email: "jane@doe.com", ID: "generated"},
+					{Row: 3, Email: "bad@doe.com", Error: "password_hash is not a bcrypt hash"},
+					{Row: 4, Email: "nopassword@doe.com", Error: "password or password_hash is required"},
+					{Row: 5, Email: "admin@doe.com", Error: "username admin is reserved"},
+					{Row: 6, Email: "jhon.doe.com", Error: "error validating user: Key: 'SignUp.Email' Error:Field validation for 'Email' failed on the 'email' tag"},
+					{Row: 7, Email: "short@doe.com", Error: "error validating user: Key: 'SignUp.Password' Error:Field validation for 'Password' failed on the 'min' tag"},
+					{Row: 8, Email: "jhon@doe.com", Error: "email jhon@doe.com is already used by another user"},
+				},
+			},
+		},
+		{
+			testName: "success with ndjson as a dry run",
+			input: `{"email":"jhon@doe.com","password":"secret-123"}` + "\n\n" +
+				`{"email":"jane@doe.com","password":"secret-123","role":"admin"}` + "\n" +
+				`{"email":` + "\n" +
+				`{"password":"secret-123"}` + "\n",
+			format:  users.ImportNDJSON,
+			options: users.ImportOptions{DryRun: true},
+			batches: [][]interface{}{
+				{importedAs("jhon@doe.com"), []error{nil}},
+			},
+			expectedResult: users.ImportReport{
+				DryRun:   true,
+				Total:    4,
+				Imported: 1,
+				Failed:   3,
+				Results: []users.ImportResult{
+					{Row: 1, Email: "jhon@doe.com", ID: "generated"},
+					{Row: 2, Error: `invalid json row: json: unknown field "role"`},
+					{Row: 3, Error: "invalid json row: unexpected EOF"},
+					{Row: 4, Error: "error validating user: Key: 'SignUp.Email' Error:Field validation for 'Email' failed on the 'required' tag"},
+				},
+			},
+		},
+		{
+			testName: "success with pending users",
+			input:    "email,password\njhon@doe.com,secret-123\n",
+			format:   users.ImportCSV,
+			options:  users.ImportOptions{Pending: true},
+			batches: [][]interface{}{
+				{importedWithStatus(users.StatusPending, "jhon@doe.com"), []error{nil}},
+			},
+			events: 1,
+			expectedResult: users.ImportReport{
+				Total:    1,
+				Imported: 1,
+				Results: []users.ImportResult{
+					{Row: 1, Email: "jhon@doe.com", ID: "generated"},
+				},
+			},
+		},
+		{
+			testName:      "with unknown csv column",
+			input:         "email,role\njhon@doe.com,admin\n",
+			format:        users.ImportCSV,
+			expectedError: users.ConstraintErrorf("unknown csv column: role"),
+		},
+		{
+			testName:      "with missing csv header",
+			input:         "",
+			format:        users.ImportCSV,
+			expectedError: users.ConstraintErrorf("csv header is missing"),
+		},
+		{
+			testName:      "with invalid format",
+			input:         "",
+			format:        "xlsx",
+			expectedError: users.ConstraintErrorf("invalid import format: xlsx"),
+		},
+		{
+			testName: "error from repository",
+			input:    "email,password\njhon@doe.com,secret-123\n",
+			format:   users.ImportCSV,
+			batches: [][]interface{}{
+				{importedAs("jhon@doe.com"), errors.New("unexpected error")},
+			},
+			expectedResult: users.ImportReport{
+				Total:  1,
+				Failed: 1,
+				Results: []users.ImportResult{
+					{Row: 1, Email: "jhon@doe.com", Error: "unexpected error"},
+				},
+			},
+			expectedError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			for _, batch := range test.batches {
+				if err, ok := batch[1].(error); ok {
+					mockRepo.On("CreateBatch", mock.Anything, batch[0], test.options.DryRun).Return(nil, err).Once()
+					continue
+				}
+				mockRepo.On("CreateBatch", mock.Anything, batch[0], test.options.DryRun).Return(batch[1], nil).Once()
+			}
+			mockHandler := new(mocks.UserEventHandler)
+			if test.events > 0 {
+				mockHandler.On("HandleUserEvent", mock.Anything, mock.MatchedBy(func(event users.UserEvent) bool {
+					return event.Type == users.UserCreated && event.UserID != ""
+				})).Times(test.events)
+			}
+
+			service := user.NewImportService(mockRepo, internal.NewValidator(), mockHandler)
+			res, err := service.Import(context.Background(), strings.NewReader(test.input), test.format, test.options)
+			mockRepo.AssertExpectations(t)
+			mockHandler.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				require.Equal(t, test.expectedResult, withoutIDs(res))
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expectedResult, withoutIDs(res))
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package user
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/arnaz06/users"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// listCursor is the position in a listing encoded in the opaque cursors. The sort and order
+// are kept with the position, so a cursor can not be used with a different sort.
+type listCursor struct {
+	Sort        users.UserSort `json:"s"`
+	Desc        bool           `json:"d,omitempty"`
+	ID          string         `json:"i"`
+	Email       string         `json:"e,omitempty"`
+	CreatedTime int64          `json:"c,omitempty"`
+}
+
+func encodeCursor(filter users.UserFilter, user users.User) string {
+	cursor := listCursor{
+		Sort: filter.Sort,
+		Desc: filter.Desc,
+		ID:   user.ID,
+	}
+	switch filter.Sort {
+	case users.SortEmail:
+		cursor.Email = user.Email
+	case users.SortCreatedTime:
+		cursor.CreatedTime = user.CreatedTime.Unix()
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(filter users.UserFilter, value string) (*users.UserKey, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, users.ConstraintErrorf("invalid cursor: %s", value)
+	}
+
+	var cursor listCursor
+	err = json.Unmarshal(data, &cursor)
+	if err != nil || cursor.ID == "" || cursor.Sort != filter.Sort || cursor.Desc != filter.Desc {
+		return nil, users.ConstraintErrorf("invalid cursor: %s", value)
+	}
+
+	return &users.UserKey{
+		ID:          cursor.ID,
+		Email:       cursor.Email,
+		CreatedTime: time.Unix(cursor.CreatedTime, 0),
+	}, nil
+}
+
+func validateFilter(filter users.UserFilter) error {
+	switch filter.Sort {
+	case users.SortCreatedTime, users.SortEmail:
+	default:
+		return users.ConstraintErrorf("invalid sort: %s", filter.Sort)
+	}
+
+	switch filter.Deleted {
+	case users.DeletedExclude, users.DeletedOnly, users.DeletedInclude:
+	default:
+		return users.ConstraintErrorf("invalid deleted filter: %s", filter.Deleted)
+	}
+
+	if _, ok := transitions[filter.Status]; filter.Status != "" && !ok {
+		return users.ConstraintErrorf("invalid status: %s", filter.Status)
+	}
+
+	if !filter.CreatedFrom.IsZero() && !filter.CreatedTo.IsZero() && !filter.CreatedTo.After(filter.CreatedFrom) {
+		return users.ConstraintErrorf("created_to must be after created_from")
+	}
+	return nil
+}
+
+func (s userService) List(ctx context.Context, filter users.UserFilter, cursor string) (users.UserPage, error) {
+	if filter.Sort == "" {
+		filter.Sort = users.SortCreatedTime
+	}
+	if filter.Deleted == "" {
+		filter.Deleted = users.DeletedExclude
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+
+	if err := validateFilter(filter); err != nil {
+		return users.UserPage{}, err
+	}
+
+	filter.After = nil
+	if cursor != "" {
+		after, err := decodeCursor(filter, cursor)
+		if err != nil {
+			return users.UserPage{}, err
+		}
+		filter.After = after
+	}
+
+	// One more user than the limit is requested, to tell whether there is a next page.
+	limit := filter.Limit
+	filter.Limit++
+	list, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return users.UserPage{}, err
+	}
+	filter.Limit = limit
+
+	res := users.UserPage{
+		Users: list,
+	}
+	if len(list) > limit {
+		res.Users = list[:limit]
+		res.NextCursor = encodeCursor(filter, list[limit-1])
+	}
+
+	if filter.WithTotal {
+		total, err := s.repo.Count(ctx, filter)
+		if err != nil {
+			return users.UserPage{}, err
+		}
+		res.Total = &total
+	}
+
+	return res, nil
+}
@@ -0,0 +1,190 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
+	"github.com/arnaz06/users/user"
+)
+
+func TestListUserService(t *testing.T) {
+	list := []users.User{
+		{ID: "1", Email: "a@doe.com"},
+		{ID: "2", Email: "b@doe.com"},
+	}
+	total := int64(5)
+
+	tests := []struct {
+		testName       string
+		filter         users.UserFilter
+		cursor         string
+		list           testdata.FuncCall
+		count          testdata.FuncCall
+		expectedResult users.UserPage
+		expectedError  error
+	}{
+		{
+			testName: "success with default filter",
+			list: testdata.FuncCall{
+				Called: true,
+				Input: []interface{}{mock.Anything, users.UserFilter{
+					Sort:    users.SortCreatedTime,
+					Deleted: users.DeletedExclude,
+					Limit:   21,
+				}},
+				Output: []interface{}{list, nil},
+			},
+			expectedResult: users.UserPage{Users: list},
+		},
+		{
+			testName: "success with next page and total",
+			filter: users.UserFilter{
+				Sort:      users.SortEmail,
+				Status:    users.StatusActive,
+				Limit:     1,
+				WithTotal: true,
+			},
+			list: testdata.FuncCall{
+				Called: true,
+				Input: []interface{}{mock.Anything, users.UserFilter{
+					Sort:      users.SortEmail,
+					Status:    users.StatusActive,
+					Deleted:   users.DeletedExclude,
+					Limit:     2,
+					WithTotal: true,
+				}},
+				Output: []interface{}{list, nil},
+			},
+			count: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mock.AnythingOfType("users.UserFilter")},
+				Output: []interface{}{total, nil},
+			},
+			expectedResult: users.UserPage{
+				Users:      list[:1],
+				NextCursor: "eyJzIjoiZW1haWwiLCJpIjoiMSIsImUiOiJhQGRvZS5jb20ifQ",
+				Total:      &total,
+			},
+		},
+		{
+			testName: "with limit above the maximum",
+			filter:   users.UserFilter{Limit: 1000},
+			list: testdata.FuncCall{
+				Called: true,
+				Input: []interface{}{mock.Anything, users.UserFilter{
+					Sort:    users.SortCreatedTime,
+					Deleted: users.DeletedExclude,
+					Limit:   101,
+				}},
+				Output: []interface{}{list, nil},
+			},
+			expectedResult: users.UserPage{Users: list},
+		},
+		{
+			testName:      "with invalid sort",
+			filter:        users.UserFilter{Sort: "password"},
+			expectedError: users.ConstraintErrorf("invalid sort: password"),
+		},
+		{
+			testName:      "with invalid deleted filter",
+			filter:        users.UserFilter{Deleted: "maybe"},
+			expectedError: users.ConstraintErrorf("invalid deleted filter: maybe"),
+		},
+		{
+			testName:      "with invalid status",
+			filter:        users.UserFilter{Status: "unknown"},
+			expectedError: users.ConstraintErrorf("invalid status: unknown"),
+		},
+		{
+			testName: "with invalid created range",
+			filter: users.UserFilter{
+				CreatedFrom: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC),
+				CreatedTo:   time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+			expectedError: users.ConstraintErrorf("created_to must be after created_from"),
+		},
+		{
+			testName:      "with invalid cursor",
+			cursor:        "not a cursor",
+			expectedError: users.ConstraintErrorf("invalid cursor: not a cursor"),
+		},
+		{
+			testName:      "with cursor of another sort",
+			cursor:        "eyJzIjoiZW1haWwiLCJpIjoiMSIsImUiOiJhQGRvZS5jb20ifQ",
+			expectedError: users.ConstraintErrorf("invalid cursor: eyJzIjoiZW1haWwiLCJpIjoiMSIsImUiOiJhQGRvZS5jb20ifQ"),
+		},
+		{
+			testName: "error from repository",
+			list: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mock.AnythingOfType("users.UserFilter")},
+				Output: []interface{}{nil, errors.New("unexpected error")},
+			},
+			expectedError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			if test.list.Called {
+				mockRepo.On("List", test.list.Input...).
+					Return(test.list.Output...).Once()
+			}
+			if test.count.Called {
+				mockRepo.On("Count", test.count.Input...).
+					Return(test.count.Output...).Once()
+			}
+
+			service := user.NewUserService(mockRepo)
+			res, err := service.List(context.Background(), test.filter, test.cursor)
+			mockRepo.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expectedResult, res)
+		})
+	}
+}
+
+func TestListUserServiceCursor(t *testing.T) {
+	createdTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	list := []users.User{
+		{ID: "1", CreatedTime: createdTime.Add(time.Hour)},
+		{ID: "2", CreatedTime: createdTime},
+		{ID: "3", CreatedTime: createdTime},
+	}
+	filter := users.UserFilter{Desc: true, Limit: 2}
+
+	mockRepo := new(mocks.UserRepository)
+	mockRepo.On("List", mock.Anything, mock.MatchedBy(func(filter users.UserFilter) bool {
+		return filter.After == nil
+	})).Return(list, nil).Once()
+	mockRepo.On("List", mock.Anything, mock.MatchedBy(func(filter users.UserFilter) bool {
+		return filter.After != nil && filter.After.ID == "2" && filter.After.CreatedTime.Equal(createdTime)
+	})).Return(list[2:], nil).Once()
+
+	service := user.NewUserService(mockRepo)
+	first, err := service.List(context.Background(), filter, "")
+	require.NoError(t, err)
+	require.Equal(t, list[:2], first.Users)
+	require.NotEmpty(t, first.NextCursor)
+
+	second, err := service.List(context.Background(), filter, first.NextCursor)
+	require.NoError(t, err)
+	require.Equal(t, list[2:], second.Users)
+	require.Empty(t, second.NextCursor)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,51 @@
+package user
+
+import (
+	"context"
+
+	"github.com/arnaz06/users"
+)
+
+type preferencesService struct {
+	userRepo users.UserRepository
+	repo     users.PreferencesRepository
+}
+
+// NewPreferencesService creates a new preferences service.
+func NewPreferencesService(userRepo users.UserRepository, repo users.PreferencesRepository) users.PreferencesService {
+	return preferencesService{
+		userRepo: userRepo,
+		repo:     repo,
+	}
+}
+
+func (s preferencesService) Get(ctx context.Context, userID string) (users.Preferences, error) {
+	_, err := s.userRepo.Get(ctx, userID)
+	if err != nil {
+		return users.Preferences{}, err
+	}
+
+	return getPreferences(ctx, s.repo, userID)
+}
+
+func (s preferencesService) Update(ctx context.Context, userID string, preferences users.Preferences) error {
+	if err := preferences.Validate(); err != nil {
+		return err
+	}
+
+	_, err := s.userRepo.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Save(ctx, userID, preferences)
+}
+
+// getPreferences returns the saved preferences of the user, or the defaults if none were saved.
+func getPreferences(ctx context.Context, repo users.PreferencesRepository, userID string) (users.Preferences, error) {
+	res, err := repo.Get(ctx, userID)
+	if err == users.ErrNotFound {
+		return users.DefaultPreferences(), nil
+	}
+	return res, err
+}
@@ -0,0 +1,206 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
+	"github.com/arnaz06/users/user"
+)
+
+func TestGetPreferencesService(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	saved := users.DefaultPreferences()
+	saved.Locale = "id-ID"
+	saved.TimeZone = "Asia/Jakarta"
+
+	tests := []struct {
+		testName       string
+		get            testdata.FuncCall
+		repo           testdata.FuncCall
+		expectedResult users.Preferences
+		expectedError  error
+	}{
+		{
+			testName: "success",
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{saved, nil},
+			},
+			expectedResult: saved,
+		},
+		{
+			testName: "success with defaults",
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.Preferences{}, users.ErrNotFound},
+			},
+			expectedResult: users.DefaultPreferences(),
+		},
+		{
+			testName: "with user not found",
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.User{}, users.ErrNotFound},
+			},
+			expectedError: users.ErrNotFound,
+		},
+		{
+			testName: "error from repository",
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.Preferences{}, errors.New("unexpected error")},
+			},
+			expectedError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockUserRepo := new(mocks.UserRepository)
+			mockRepo := new(mocks.PreferencesRepository)
+			if test.get.Called {
+				mockUserRepo.On("Get", test.get.Input...).
+					Return(test.get.Output...).Once()
+			}
+			if test.repo.Called {
+				mockRepo.On("Get", test.repo.Input...).
+					Return(test.repo.Output...).Once()
+			}
+
+			service := user.NewPreferencesService(mockUserRepo, mockRepo)
+			res, err := service.Get(context.Background(), mockUser.ID)
+			mockUserRepo.AssertExpectations(t)
+			mockRepo.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expectedResult, res)
+		})
+	}
+}
+
+func TestUpdatePreferencesService(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	valid := users.DefaultPreferences()
+	valid.Locale = "pt-BR"
+	valid.TimeZone = "America/Sao_Paulo"
+	valid.Theme = users.ThemeDark
+
+	invalidLocale := users.Preferences(valid)
+	invalidLocale.Locale = "not a locale"
+
+	invalidTimeZone := users.Preferences(valid)
+	invalidTimeZone.TimeZone = "Mars/Olympus_Mons"
+
+	invalidTheme := users.Preferences(valid)
+	invalidTheme.Theme = users.Theme("neon")
+
+	tests := []struct {
+		testName      string
+		input         users.Preferences
+		get           testdata.FuncCall
+		save          testdata.FuncCall
+		expectedError error
+	}{
+		{
+			testName: "success",
+			input:    valid,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			save: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID, valid},
+				Output: []interface{}{nil},
+			},
+		},
+		{
+			testName:      "with invalid locale",
+			input:         invalidLocale,
+			expectedError: users.ConstraintErrorf("invalid locale: not a locale"),
+		},
+		{
+			testName:      "with invalid time zone",
+			input:         invalidTimeZone,
+			expectedError: users.ConstraintErrorf("invalid time zone: Mars/Olympus_Mons"),
+		},
+		{
+			testName:      "with invalid theme",
+			input:         invalidTheme,
+			expectedError: users.ConstraintErrorf("invalid theme: neon"),
+		},
+		{
+			testName: "with user not found",
+			input:    valid,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.User{}, users.ErrNotFound},
+			},
+			expectedError: users.ErrNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockUserRepo := new(mocks.UserRepository)
+			mockRepo := new(mocks.PreferencesRepository)
+			if test.get.Called {
+				mockUserRepo.On("Get", test.get.Input...).
+					Return(test.get.Output...).Once()
+			}
+			if test.save.Called {
+				mockRepo.On("Save", test.save.Input...).
+					Return(test.save.Output...).Once()
+			}
+
+			service := user.NewPreferencesService(mockUserRepo, mockRepo)
+			err := service.Update(context.Background(), mockUser.ID, test.input)
+			mockUserRepo.AssertExpectations(t)
+			mockRepo.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package user
+
+import (
+	"context"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/arnaz06/users"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	rebuildBatchSize   = 500
+)
+
+type searchService struct {
+	repo     users.UserRepository
+	searcher users.Searcher
+}
+
+// NewSearchService creates a new search service.
+// The users found by the searcher are loaded from the repository, skipping the ones deleted since they were indexed.
+func NewSearchService(repo users.UserRepository, searcher users.Searcher) users.SearchService {
+	return searchService{
+		repo:     repo,
+		searcher: searcher,
+	}
+}
+
+func (s searchService) Search(ctx context.Context, query string, limit int) ([]users.User, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, users.ConstraintErrorf("search query is required")
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	ids, err := s.searcher.Search(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []users.User{}
+	for _, id := range ids {
+		user, err := s.repo.Get(ctx, id)
+		if err != nil {
+			if err == users.ErrNotFound {
+				continue
+			}
+			return nil, err
+		}
+		res = append(res, user)
+	}
+
+	return res, nil
+}
+
+type searchIndexer struct {
+	repo  users.UserRepository
+	index users.SearchIndex
+}
+
+// NewSearchIndexer creates the handler keeping the search index in sync with the user events.
+func NewSearchIndexer(repo users.UserRepository, index users.SearchIndex) users.SearchIndexer {
+	return searchIndexer{
+		repo:  repo,
+		index: index,
+	}
+}
+
+func (i searchIndexer) HandleUserEvent(ctx context.Context, event users.UserEvent) {
+	// A login changes nothing of the indexed fields.
+	if event.Type == users.UserLoggedIn {
+		return
+	}
+	if err := i.sync(ctx, event); err != nil {
+		log.Errorf("Failed to index user %s on %s event: %+v", event.UserID, event.Type, err)
+	}
+}
+
+func (i searchIndexer) sync(ctx context.Context, event users.UserEvent) error {
+	if event.Type == users.UserDeleted || event.Type == users.UserPurged {
+		return i.index.Remove(ctx, event.UserID)
+	}
+
+	user, err := i.repo.Get(ctx, event.UserID)
+	if err != nil {
+		if err == users.ErrNotFound {
+			return i.index.Remove(ctx, event.UserID)
+		}
+		return err
+	}
+	return i.index.Index(ctx, user)
+}
+
+func (i searchIndexer) Rebuild(ctx context.Context) (int64, error) {
+	err := i.index.Reset(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := users.UserFilter{
+		Sort:    users.SortCreatedTime,
+		Deleted: users.DeletedExclude,
+		Limit:   rebuildBatchSize,
+	}
+
+	var indexed int64
+	for {
+		list, err := i.repo.List(ctx, filter)
+		if err != nil {
+			return indexed, err
+		}
+
+		for _, user := range list {
+			err = i.index.Index(ctx, user)
+			if err != nil {
+				return indexed, err
+			}
+			indexed++
+		}
+
+		if len(list) < filter.Limit {
+			return indexed, nil
+		}
+		last := list[len(list)-1]
+		filter.After = &users.UserKey{
+			ID:          last.ID,
+			CreatedTime: last.CreatedTime,
+		}
+	}
+}
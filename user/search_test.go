@@ -0,0 +1,246 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/testdata"
+	"github.com/arnaz06/users/user"
+)
+
+func TestSearchService(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	tests := []struct {
+		testName       string
+		query          string
+		limit          int
+		search         testdata.FuncCall
+		get            []testdata.FuncCall
+		expectedResult []users.User
+		expectedError  error
+	}{
+		{
+			testName: "success skipping the users deleted since indexed",
+			query:    " jhon ",
+			search: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "jhon", 20},
+				Output: []interface{}{[]string{"404", mockUser.ID}, nil},
+			},
+			get: []testdata.FuncCall{
+				{
+					Called: true,
+					Input:  []interface{}{mock.Anything, "404"},
+					Output: []interface{}{users.User{}, users.ErrNotFound},
+				},
+				{
+					Called: true,
+					Input:  []interface{}{mock.Anything, mockUser.ID},
+					Output: []interface{}{mockUser, nil},
+				},
+			},
+			expectedResult: []users.User{mockUser},
+		},
+		{
+			testName: "with limit above the maximum",
+			query:    "jhon",
+			limit:    1000,
+			search: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "jhon", 100},
+				Output: []interface{}{[]string{}, nil},
+			},
+			expectedResult: []users.User{},
+		},
+		{
+			testName:      "with empty query",
+			query:         "  ",
+			expectedError: users.ConstraintErrorf("search query is required"),
+		},
+		{
+			testName: "error from searcher",
+			query:    "jhon",
+			search: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "jhon", 20},
+				Output: []interface{}{nil, errors.New("unexpected error")},
+			},
+			expectedError: errors.New("unexpected error"),
+		},
+		{
+			testName: "error from repository",
+			query:    "jhon",
+			search: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "jhon", 20},
+				Output: []interface{}{[]string{mockUser.ID}, nil},
+			},
+			get: []testdata.FuncCall{
+				{
+					Called: true,
+					Input:  []interface{}{mock.Anything, mockUser.ID},
+					Output: []interface{}{users.User{}, errors.New("unexpected error")},
+				},
+			},
+			expectedError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			mockSearcher := new(mocks.Searcher)
+			if test.search.Called {
+				mockSearcher.On("Search", test.search.Input...).
+					Return(test.search.Output...).Once()
+			}
+			for _, get := range test.get {
+				mockRepo.On("Get", get.Input...).
+					Return(get.Output...).Once()
+			}
+
+			service := user.NewSearchService(mockRepo, mockSearcher)
+			res, err := service.Search(context.Background(), test.query, test.limit)
+			mockRepo.AssertExpectations(t)
+			mockSearcher.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expectedResult, res)
+		})
+	}
+}
+
+func TestSearchIndexerHandleUserEvent(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	tests := []struct {
+		testName string
+		event    users.UserEvent
+		get      testdata.FuncCall
+		index    testdata.FuncCall
+		remove   testdata.FuncCall
+	}{
+		{
+			testName: "created",
+			event:    users.UserEvent{Type: users.UserCreated, UserID: mockUser.ID},
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			index: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser},
+				Output: []interface{}{nil},
+			},
+		},
+		{
+			testName: "deleted",
+			event:    users.UserEvent{Type: users.UserDeleted, UserID: mockUser.ID},
+			remove: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{nil},
+			},
+		},
+		{
+			testName: "logged in",
+			event:    users.UserEvent{Type: users.UserLoggedIn, UserID: mockUser.ID},
+		},
+		{
+			testName: "updated but deleted since",
+			event:    users.UserEvent{Type: users.UserUpdated, UserID: mockUser.ID},
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.User{}, users.ErrNotFound},
+			},
+			remove: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{nil},
+			},
+		},
+		{
+			testName: "with error from index",
+			event:    users.UserEvent{Type: users.UserRestored, UserID: mockUser.ID},
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			index: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser},
+				Output: []interface{}{errors.New("unexpected error")},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			mockIndex := new(mocks.SearchIndex)
+			if test.get.Called {
+				mockRepo.On("Get", test.get.Input...).
+					Return(test.get.Output...).Once()
+			}
+			if test.index.Called {
+				mockIndex.On("Index", test.index.Input...).
+					Return(test.index.Output...).Once()
+			}
+			if test.remove.Called {
+				mockIndex.On("Remove", test.remove.Input...).
+					Return(test.remove.Output...).Once()
+			}
+
+			indexer := user.NewSearchIndexer(mockRepo, mockIndex)
+			indexer.HandleUserEvent(context.Background(), test.event)
+			mockRepo.AssertExpectations(t)
+			mockIndex.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSearchIndexerRebuild(t *testing.T) {
+	createdTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	firstBatch := make([]users.User, 500)
+	for i := range firstBatch {
+		firstBatch[i] = users.User{ID: strconv.Itoa(i), CreatedTime: createdTime}
+	}
+	secondBatch := []users.User{{ID: "500", CreatedTime: createdTime}}
+
+	mockRepo := new(mocks.UserRepository)
+	mockIndex := new(mocks.SearchIndex)
+	mockIndex.On("Reset", mock.Anything).Return(nil).Once()
+	mockRepo.On("List", mock.Anything, mock.MatchedBy(func(filter users.UserFilter) bool {
+		return filter.After == nil && filter.Deleted == users.DeletedExclude
+	})).Return(firstBatch, nil).Once()
+	mockRepo.On("List", mock.Anything, mock.MatchedBy(func(filter users.UserFilter) bool {
+		return filter.After != nil && filter.After.ID == "499" && filter.After.CreatedTime.Equal(createdTime)
+	})).Return(secondBatch, nil).Once()
+	mockIndex.On("Index", mock.Anything, mock.AnythingOfType("users.User")).Return(nil).Times(501)
+
+	indexer := user.NewSearchIndexer(mockRepo, mockIndex)
+	indexed, err := indexer.Rebuild(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(501), indexed)
+	mockRepo.AssertExpectations(t)
+	mockIndex.AssertExpectations(t)
+}
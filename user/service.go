@@ -2,47 +2,267 @@ package user
 
 import (
 	"context"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/arnaz06/users"
 )
 
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
 type userService struct {
-	repo users.UserRepository
+	repo     users.UserRepository
+	handlers []users.UserEventHandler
 }
 
-// NewUserService creates a new user service
-func NewUserService(repo users.UserRepository) users.UserService {
+// NewUserService creates a new user service.
+// The handlers are given the events of the changes made through the service, once committed.
+func NewUserService(repo users.UserRepository, handlers ...users.UserEventHandler) users.UserService {
 	return userService{
-		repo: repo,
+		repo:     repo,
+		handlers: handlers,
 	}
 }
 
 func (s userService) Create(ctx context.Context, user users.User) (users.User, error) {
-	return s.repo.Create(ctx, user)
+	if user.Username != "" {
+		if err := users.ValidateUsername(user.Username); err != nil {
+			return users.User{}, err
+		}
+	}
+
+	hashedPassword, err := users.EncodeString(user.Password)
+	if err != nil {
+		return users.User{}, err
+	}
+	user.Password = hashedPassword
+
+	res, err := s.repo.Create(ctx, user)
+	if err != nil {
+		return users.User{}, err
+	}
+
+	publish(ctx, s.handlers, users.UserCreated, res.ID)
+	return res, nil
 }
 
 func (s userService) Get(ctx context.Context, id string) (users.User, error) {
 	return s.repo.Get(ctx, id)
 }
 
-func (s userService) Login(ctx context.Context, email, password string) error {
-	savedUser, err := s.repo.GetByEmail(ctx, email)
+func (s userService) GetByEmail(ctx context.Context, email string) (users.User, error) {
+	return s.repo.GetByEmail(ctx, email)
+}
+
+func (s userService) Login(ctx context.Context, identifier, password string) (users.User, error) {
+	getUser := s.repo.GetByUsername
+	if strings.Contains(identifier, "@") {
+		getUser = s.repo.GetByEmail
+	}
+
+	// The users are read without their password hash, unless asked for.
+	savedUser, err := getUser(users.WithPasswordReads(ctx), identifier)
 	if err != nil {
-		return err
+		return users.User{}, err
 	}
 
 	err = users.CompareHash(savedUser.Password, password)
 	if err != nil {
-		return users.UnauthorizedErrorf("invalid password")
+		return users.User{}, users.UnauthorizedErrorf("invalid password")
 	}
 
-	return nil
+	if err := savedUser.Status.Err(); err != nil {
+		return users.User{}, err
+	}
+
+	publish(ctx, s.handlers, users.UserLoggedIn, savedUser.ID)
+	return savedUser, nil
 }
 
 func (s userService) Update(ctx context.Context, user users.User) error {
-	return s.repo.Update(ctx, user)
+	if user.Username != "" {
+		if err := users.ValidateUsername(user.Username); err != nil {
+			return err
+		}
+	}
+
+	savedUser, err := s.repo.Get(users.WithPasswordReads(ctx), user.ID)
+	if err != nil {
+		return err
+	}
+
+	if savedUser.Email != user.Email {
+		return users.ConstraintErrorf("email can only be changed through an email change request")
+	}
+
+	// The saved hash is kept when the password is unchanged, so the history only records actual changes.
+	if users.CompareHash(savedUser.Password, user.Password) == nil {
+		user.Password = savedUser.Password
+	} else {
+		hashedPassword, err := users.EncodeString(user.Password)
+		if err != nil {
+			return err
+		}
+		user.Password = hashedPassword
+	}
+
+	err = s.repo.Update(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	publish(ctx, s.handlers, users.UserUpdated, user.ID)
+	return nil
+}
+
+func (s userService) Patch(ctx context.Context, id string, patch users.UserPatch, version int64) error {
+	if patch.Username != nil && *patch.Username != "" {
+		if err := users.ValidateUsername(*patch.Username); err != nil {
+			return err
+		}
+	}
+
+	// The saved user is written back whole, so its password hash is read with it.
+	savedUser, err := s.repo.Get(users.WithPasswordReads(ctx), id)
+	if err != nil {
+		return err
+	}
+
+	if patch.Email != nil && *patch.Email != savedUser.Email {
+		return users.ConstraintErrorf("email can only be changed through an email change request")
+	}
+
+	user := savedUser
+	user.Version = version
+	if patch.Username != nil {
+		user.Username = *patch.Username
+	}
+	if patch.Address != nil {
+		user.Address = *patch.Address
+	}
+	// The saved hash is kept when the password is unchanged, so the history only records actual changes.
+	if patch.Password != nil && users.CompareHash(savedUser.Password, *patch.Password) != nil {
+		user.Password, err = users.EncodeString(*patch.Password)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.repo.Update(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	publish(ctx, s.handlers, users.UserUpdated, id)
+	return nil
 }
 
-func (s userService) Delete(ctx context.Context, id string) error {
-	return s.repo.Delete(ctx, id)
+func (s userService) Delete(ctx context.Context, id string, version int64) error {
+	err := s.repo.Delete(ctx, id, version)
+	if err != nil {
+		return err
+	}
+
+	publish(ctx, s.handlers, users.UserDeleted, id)
+	return nil
+}
+
+func (s userService) ListDeleted(ctx context.Context) ([]users.User, error) {
+	return s.repo.ListDeleted(ctx)
+}
+
+func (s userService) Restore(ctx context.Context, id string) error {
+	err := s.repo.Restore(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	publish(ctx, s.handlers, users.UserRestored, id)
+	return nil
+}
+
+func (s userService) HardDelete(ctx context.Context, id string) error {
+	err := s.repo.HardDelete(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	publish(ctx, s.handlers, users.UserPurged, id)
+	return nil
+}
+
+func (s userService) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	purged, err := s.repo.Purge(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, user := range purged {
+		publish(users.WithTenant(ctx, user.TenantID), s.handlers, users.UserPurged, user.ID)
+	}
+	return int64(len(purged)), nil
+}
+
+func (s userService) ChangeStatus(ctx context.Context, id string, to users.Status, reason, actor string) error {
+	savedUser, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !canTransition(savedUser.Status, to) {
+		return users.ConstraintErrorf("cannot change status of a %s user to %s", savedUser.Status, to)
+	}
+
+	err = s.repo.UpdateStatus(ctx, users.StatusChange{
+		UserID:      id,
+		From:        savedUser.Status,
+		To:          to,
+		Reason:      reason,
+		Actor:       actor,
+		CreatedTime: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	publish(ctx, s.handlers, users.UserUpdated, id)
+	return nil
+}
+
+func (s userService) History(ctx context.Context, id, cursor string, limit int) (users.HistoryPage, error) {
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+
+	var beforeID int64
+	if cursor != "" {
+		var err error
+		beforeID, err = strconv.ParseInt(cursor, 10, 64)
+		if err != nil || beforeID <= 0 {
+			return users.HistoryPage{}, users.ConstraintErrorf("invalid history cursor: %s", cursor)
+		}
+	}
+
+	// One more entry than the limit is requested, to tell whether there is a next page.
+	entries, err := s.repo.ListHistory(ctx, id, beforeID, limit+1)
+	if err != nil {
+		return users.HistoryPage{}, err
+	}
+
+	res := users.HistoryPage{
+		Entries: entries,
+	}
+	if len(entries) > limit {
+		res.Entries = entries[:limit]
+		res.NextCursor = strconv.FormatInt(entries[limit-1].ID, 10)
+	}
+
+	return res, nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -14,31 +15,57 @@ import (
 	"github.com/arnaz06/users/user"
 )
 
+// hashedAs matches the users equal to user, but for the password hashed from the plaintext password of user.
+func hashedAs(user users.User) interface{} {
+	return mock.MatchedBy(func(hashed users.User) bool {
+		password := hashed.Password
+		hashed.Password = user.Password
+		return hashed == user && users.CompareHash(password, user.Password) == nil
+	})
+}
+
 func TestLoginUserService(t *testing.T) {
 	var mockUser users.User
 	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
 	mockUser.Password = "$2a$04$s06QpvFZ6QeQouJEozOLjeqtUhnCAY307dTgq.aThUH6d8/5VO89u"
+
+	suspendedUser := users.User(mockUser)
+	suspendedUser.Status = users.StatusSuspended
 	tests := []struct {
 		testName      string
-		email         string
+		identifier    string
 		password      string
+		repoMethod    string
 		repo          testdata.FuncCall
 		expectedError error
 	}{
 		{
-			testName: "success",
-			email:    mockUser.Email,
-			password: "secret-123",
+			testName:   "success",
+			identifier: mockUser.Email,
+			repoMethod: "GetByEmail",
+			password:   "secret-123",
 			repo: testdata.FuncCall{
 				Called: true,
-				Input:  []interface{}{mock.Anything, mockUser.Email},
+				Input:  []interface{}{mock.MatchedBy(users.ReadsPassword), mockUser.Email},
 				Output: []interface{}{mockUser, nil},
 			},
 		},
 		{
-			testName: "invalid password",
-			email:    mockUser.Email,
-			password: "invalid-password",
+			testName:   "success with username",
+			identifier: "Jhon",
+			password:   "secret-123",
+			repoMethod: "GetByUsername",
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "Jhon"},
+				Output: []interface{}{mockUser, nil},
+			},
+		},
+		{
+			testName:   "invalid password",
+			identifier: mockUser.Email,
+			repoMethod: "GetByEmail",
+			password:   "invalid-password",
 			repo: testdata.FuncCall{
 				Called: true,
 				Input:  []interface{}{mock.Anything, mockUser.Email},
@@ -47,9 +74,22 @@ func TestLoginUserService(t *testing.T) {
 			expectedError: users.UnauthorizedErrorf("invalid password"),
 		},
 		{
-			testName: "unexpected error from service",
-			email:    mockUser.Email,
-			password: "secret-123",
+			testName:   "suspended user",
+			identifier: mockUser.Email,
+			repoMethod: "GetByEmail",
+			password:   "secret-123",
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.Email},
+				Output: []interface{}{suspendedUser, nil},
+			},
+			expectedError: users.ErrUserSuspended,
+		},
+		{
+			testName:   "unexpected error from service",
+			identifier: mockUser.Email,
+			repoMethod: "GetByEmail",
+			password:   "secret-123",
 			repo: testdata.FuncCall{
 				Called: true,
 				Input:  []interface{}{mock.Anything, mockUser.Email},
@@ -63,12 +103,12 @@ func TestLoginUserService(t *testing.T) {
 			mockRepo := new(mocks.UserRepository)
 
 			if test.repo.Called {
-				mockRepo.On("GetByEmail", test.repo.Input...).
+				mockRepo.On(test.repoMethod, test.repo.Input...).
 					Return(test.repo.Output...).Once()
 			}
 
 			service := user.NewUserService(mockRepo)
-			err := service.Login(context.Background(), test.email, test.password)
+			res, err := service.Login(context.Background(), test.identifier, test.password)
 			mockRepo.AssertExpectations(t)
 
 			if test.expectedError != nil {
@@ -77,6 +117,7 @@ func TestLoginUserService(t *testing.T) {
 			}
 
 			require.NoError(t, err)
+			require.Equal(t, mockUser, res)
 		})
 	}
 }
@@ -85,6 +126,15 @@ func TestCreateUserService(t *testing.T) {
 	var mockUser users.User
 	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
 
+	withUsername := users.User(mockUser)
+	withUsername.Username = "Jhon.Doe"
+	invalidUsername := users.User(mockUser)
+	invalidUsername.Username = "jhon doe"
+	reservedUsername := users.User(mockUser)
+	reservedUsername.Username = "Admin"
+	shortUsername := users.User(mockUser)
+	shortUsername.Username = "jd"
+
 	tests := []struct {
 		testName       string
 		input          users.User
@@ -97,17 +147,42 @@ func TestCreateUserService(t *testing.T) {
 			input:    mockUser,
 			repo: testdata.FuncCall{
 				Called: true,
-				Input:  []interface{}{mock.Anything, mockUser},
+				Input:  []interface{}{mock.Anything, hashedAs(mockUser)},
 				Output: []interface{}{mockUser, nil},
 			},
 			expectedResult: mockUser,
 		},
+		{
+			testName: "success with username",
+			input:    withUsername,
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, hashedAs(withUsername)},
+				Output: []interface{}{withUsername, nil},
+			},
+			expectedResult: withUsername,
+		},
+		{
+			testName:      "with invalid username",
+			input:         invalidUsername,
+			expectedError: users.ConstraintErrorf("username jhon doe may only contain letters, digits, and single dots, hyphens or underscores between them"),
+		},
+		{
+			testName:      "with reserved username",
+			input:         reservedUsername,
+			expectedError: users.ConstraintErrorf("username Admin is reserved"),
+		},
+		{
+			testName:      "with too short username",
+			input:         shortUsername,
+			expectedError: users.ConstraintErrorf("username must be between 3 and 30 characters"),
+		},
 		{
 			testName: "error from service",
 			input:    mockUser,
 			repo: testdata.FuncCall{
 				Called: true,
-				Input:  []interface{}{mock.Anything, mockUser},
+				Input:  []interface{}{mock.Anything, hashedAs(mockUser)},
 				Output: []interface{}{users.User{}, errors.New("unexpected error")},
 			},
 			expectedError: errors.New("unexpected error"),
@@ -141,27 +216,92 @@ func TestUpdateUserService(t *testing.T) {
 	var mockUser users.User
 	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
 
+	savedUser := users.User(mockUser)
+	savedUser.Password = "$2a$04$s06QpvFZ6QeQouJEozOLjeqtUhnCAY307dTgq.aThUH6d8/5VO89u"
+
+	unchangedPassword := users.User(mockUser)
+	unchangedPassword.Address = "new address"
+	savedPassword := users.User(unchangedPassword)
+	savedPassword.Password = savedUser.Password
+
+	changedPassword := users.User(mockUser)
+	changedPassword.Password = "new-secret"
+
+	changedEmail := users.User(mockUser)
+	changedEmail.Email = "new@doe.com"
+
 	tests := []struct {
 		testName      string
 		input         users.User
+		get           testdata.FuncCall
 		repo          testdata.FuncCall
 		expectedError error
 	}{
 		{
-			testName: "success",
-			input:    mockUser,
+			testName: "success with unchanged password",
+			input:    unchangedPassword,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{savedUser, nil},
+			},
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, savedPassword},
+				Output: []interface{}{nil},
+			},
+		},
+		{
+			testName: "success with changed password",
+			input:    changedPassword,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{savedUser, nil},
+			},
 			repo: testdata.FuncCall{
 				Called: true,
-				Input:  []interface{}{mock.Anything, mockUser},
+				Input:  []interface{}{mock.Anything, hashedAs(changedPassword)},
 				Output: []interface{}{nil},
 			},
 		},
+		{
+			testName: "with changed email",
+			input:    changedEmail,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{savedUser, nil},
+			},
+			repo: testdata.FuncCall{
+				Called: false,
+			},
+			expectedError: users.ConstraintErrorf("email can only be changed through an email change request"),
+		},
+		{
+			testName: "with user not found",
+			input:    mockUser,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.User{}, users.ErrNotFound},
+			},
+			repo: testdata.FuncCall{
+				Called: false,
+			},
+			expectedError: users.ErrNotFound,
+		},
 		{
 			testName: "error from service",
 			input:    mockUser,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{savedUser, nil},
+			},
 			repo: testdata.FuncCall{
 				Called: true,
-				Input:  []interface{}{mock.Anything, mockUser},
+				Input:  []interface{}{mock.Anything, savedUser},
 				Output: []interface{}{errors.New("unexpected error")},
 			},
 			expectedError: errors.New("unexpected error"),
@@ -171,6 +311,10 @@ func TestUpdateUserService(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.testName, func(t *testing.T) {
 			mockRepo := new(mocks.UserRepository)
+			if test.get.Called {
+				mockRepo.On("Get", test.get.Input...).
+					Return(test.get.Output...).Once()
+			}
 			if test.repo.Called {
 				mockRepo.On("Update", test.repo.Input...).
 					Return(test.repo.Output...).Once()
@@ -190,6 +334,141 @@ func TestUpdateUserService(t *testing.T) {
 	}
 }
 
+func TestPatchUserService(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	savedUser := users.User(mockUser)
+	savedUser.Password = "$2a$04$s06QpvFZ6QeQouJEozOLjeqtUhnCAY307dTgq.aThUH6d8/5VO89u"
+
+	address, password, email, username := "new address", "new-secret", "new@doe.com", "-invalid"
+
+	patchedAddress := users.User(savedUser)
+	patchedAddress.Address = address
+	patchedAddress.Version = 2
+
+	patchedPassword := users.User(savedUser)
+	patchedPassword.Password = password
+	patchedPassword.Version = 0
+
+	tests := []struct {
+		testName      string
+		patch         users.UserPatch
+		version       int64
+		get           testdata.FuncCall
+		repo          testdata.FuncCall
+		expectedError error
+	}{
+		{
+			testName: "success with the other fields unchanged",
+			patch:    users.UserPatch{Address: &address},
+			version:  2,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{savedUser, nil},
+			},
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, patchedAddress},
+				Output: []interface{}{nil},
+			},
+		},
+		{
+			testName: "success with changed password",
+			patch:    users.UserPatch{Password: &password},
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{savedUser, nil},
+			},
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, hashedAs(patchedPassword)},
+				Output: []interface{}{nil},
+			},
+		},
+		{
+			testName: "with changed email",
+			patch:    users.UserPatch{Email: &email},
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{savedUser, nil},
+			},
+			repo: testdata.FuncCall{
+				Called: false,
+			},
+			expectedError: users.ConstraintErrorf("email can only be changed through an email change request"),
+		},
+		{
+			testName: "with invalid username",
+			patch:    users.UserPatch{Username: &username},
+			get: testdata.FuncCall{
+				Called: false,
+			},
+			repo: testdata.FuncCall{
+				Called: false,
+			},
+			expectedError: users.ValidateUsername(username),
+		},
+		{
+			testName: "with outdated version",
+			patch:    users.UserPatch{Address: &address},
+			version:  2,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{savedUser, nil},
+			},
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, patchedAddress},
+				Output: []interface{}{users.ErrPreconditionFailed},
+			},
+			expectedError: users.ErrPreconditionFailed,
+		},
+		{
+			testName: "with user not found",
+			patch:    users.UserPatch{Address: &address},
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.User{}, users.ErrNotFound},
+			},
+			repo: testdata.FuncCall{
+				Called: false,
+			},
+			expectedError: users.ErrNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			if test.get.Called {
+				mockRepo.On("Get", test.get.Input...).
+					Return(test.get.Output...).Once()
+			}
+			if test.repo.Called {
+				mockRepo.On("Update", test.repo.Input...).
+					Return(test.repo.Output...).Once()
+			}
+
+			service := user.NewUserService(mockRepo)
+			err := service.Patch(context.Background(), mockUser.ID, test.patch, test.version)
+			mockRepo.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
 func TestGetUserService(t *testing.T) {
 	var mockUser users.User
 	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
@@ -246,10 +525,185 @@ func TestGetUserService(t *testing.T) {
 	}
 }
 
+func TestGetByEmailUserService(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	mockRepo := new(mocks.UserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, mockUser.Email).
+		Return(mockUser, nil).Once()
+
+	service := user.NewUserService(mockRepo)
+	res, err := service.GetByEmail(context.Background(), mockUser.Email)
+	mockRepo.AssertExpectations(t)
+
+	require.NoError(t, err)
+	require.Equal(t, mockUser, res)
+}
+
 func TestDeleteUserService(t *testing.T) {
 	var mockUser users.User
 	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
 
+	tests := []struct {
+		testName      string
+		input         string
+		repo          testdata.FuncCall
+		expectedError error
+	}{
+		{
+			testName: "success",
+			input:    mockUser.ID,
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID, mockUser.Version},
+				Output: []interface{}{nil},
+			},
+		},
+		{
+			testName: "error from service",
+			input:    mockUser.ID,
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID, mockUser.Version},
+				Output: []interface{}{errors.New("unexpected error")},
+			},
+			expectedError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			if test.repo.Called {
+				mockRepo.On("Delete", test.repo.Input...).
+					Return(test.repo.Output...).Once()
+			}
+
+			service := user.NewUserService(mockRepo)
+			err := service.Delete(context.Background(), test.input, mockUser.Version)
+			mockRepo.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestListDeletedUserService(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	tests := []struct {
+		testName       string
+		repo           testdata.FuncCall
+		expectedResult []users.User
+		expectedError  error
+	}{
+		{
+			testName: "success",
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything},
+				Output: []interface{}{[]users.User{mockUser}, nil},
+			},
+			expectedResult: []users.User{mockUser},
+		},
+		{
+			testName: "error from service",
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything},
+				Output: []interface{}{nil, errors.New("unexpected error")},
+			},
+			expectedError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			if test.repo.Called {
+				mockRepo.On("ListDeleted", test.repo.Input...).
+					Return(test.repo.Output...).Once()
+			}
+
+			service := user.NewUserService(mockRepo)
+			res, err := service.ListDeleted(context.Background())
+			mockRepo.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expectedResult, res)
+		})
+	}
+}
+
+func TestRestoreUserService(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	tests := []struct {
+		testName      string
+		input         string
+		repo          testdata.FuncCall
+		expectedError error
+	}{
+		{
+			testName: "success",
+			input:    mockUser.ID,
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{nil},
+			},
+		},
+		{
+			testName: "with email already taken",
+			input:    mockUser.ID,
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.ConstraintErrorf("email is already used")},
+			},
+			expectedError: users.ConstraintErrorf("email is already used"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			if test.repo.Called {
+				mockRepo.On("Restore", test.repo.Input...).
+					Return(test.repo.Output...).Once()
+			}
+
+			service := user.NewUserService(mockRepo)
+			err := service.Restore(context.Background(), test.input)
+			mockRepo.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestHardDeleteUserService(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
 	tests := []struct {
 		testName      string
 		input         string
@@ -281,12 +735,270 @@ func TestDeleteUserService(t *testing.T) {
 		t.Run(test.testName, func(t *testing.T) {
 			mockRepo := new(mocks.UserRepository)
 			if test.repo.Called {
-				mockRepo.On("Delete", test.repo.Input...).
+				mockRepo.On("HardDelete", test.repo.Input...).
+					Return(test.repo.Output...).Once()
+			}
+
+			service := user.NewUserService(mockRepo)
+			err := service.HardDelete(context.Background(), test.input)
+			mockRepo.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestPurgeDeletedUserService(t *testing.T) {
+	retention := 30 * 24 * time.Hour
+	deletedBefore := mock.MatchedBy(func(before time.Time) bool {
+		cutoff := time.Now().Add(-retention)
+		return !before.After(cutoff) && before.After(cutoff.Add(-time.Minute))
+	})
+
+	tests := []struct {
+		testName       string
+		repo           testdata.FuncCall
+		expectedResult int64
+		expectedError  error
+	}{
+		{
+			testName: "success",
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, deletedBefore},
+				Output: []interface{}{[]users.PurgedUser{{TenantID: users.DefaultTenant, ID: "123"}, {TenantID: "acme", ID: "456"}}, nil},
+			},
+			expectedResult: 2,
+		},
+		{
+			testName: "error from service",
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, deletedBefore},
+				Output: []interface{}{nil, errors.New("unexpected error")},
+			},
+			expectedError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			if test.repo.Called {
+				mockRepo.On("Purge", test.repo.Input...).
+					Return(test.repo.Output...).Once()
+			}
+
+			// Each purged user is published in the context of its tenant.
+			mockHandler := new(mocks.UserEventHandler)
+			if test.expectedError == nil {
+				for _, purged := range test.repo.Output[0].([]users.PurgedUser) {
+					tenantID := purged.TenantID
+					inTenant := mock.MatchedBy(func(ctx context.Context) bool { return users.TenantFromContext(ctx) == tenantID })
+					mockHandler.On("HandleUserEvent", inTenant, users.UserEvent{Type: users.UserPurged, UserID: purged.ID}).Once()
+				}
+			}
+
+			service := user.NewUserService(mockRepo, mockHandler)
+			res, err := service.PurgeDeleted(context.Background(), retention)
+			mockRepo.AssertExpectations(t)
+			mockHandler.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expectedResult, res)
+		})
+	}
+}
+
+func TestChangeStatusUserService(t *testing.T) {
+	var mockUser users.User
+	testdata.GoldenJSONUnmarshal(t, "user", &mockUser)
+
+	deactivatedUser := users.User(mockUser)
+	deactivatedUser.Status = users.StatusDeactivated
+
+	change := mock.MatchedBy(func(change users.StatusChange) bool {
+		return change.UserID == mockUser.ID &&
+			change.From == users.StatusActive &&
+			change.To == users.StatusSuspended &&
+			change.Reason == "spam" &&
+			change.Actor == "admin-1"
+	})
+
+	tests := []struct {
+		testName      string
+		to            users.Status
+		get           testdata.FuncCall
+		updateStatus  testdata.FuncCall
+		expectedError error
+	}{
+		{
+			testName: "success",
+			to:       users.StatusSuspended,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			updateStatus: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, change},
+				Output: []interface{}{nil},
+			},
+		},
+		{
+			testName: "with invalid transition",
+			to:       users.StatusSuspended,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{deactivatedUser, nil},
+			},
+			updateStatus: testdata.FuncCall{
+				Called: false,
+			},
+			expectedError: users.ConstraintErrorf("cannot change status of a deactivated user to suspended"),
+		},
+		{
+			testName: "with user not found",
+			to:       users.StatusSuspended,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{users.User{}, users.ErrNotFound},
+			},
+			updateStatus: testdata.FuncCall{
+				Called: false,
+			},
+			expectedError: users.ErrNotFound,
+		},
+		{
+			testName: "with status changed concurrently",
+			to:       users.StatusSuspended,
+			get: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, mockUser.ID},
+				Output: []interface{}{mockUser, nil},
+			},
+			updateStatus: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, change},
+				Output: []interface{}{users.ErrPreconditionFailed},
+			},
+			expectedError: users.ErrPreconditionFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			if test.get.Called {
+				mockRepo.On("Get", test.get.Input...).
+					Return(test.get.Output...).Once()
+			}
+			if test.updateStatus.Called {
+				mockRepo.On("UpdateStatus", test.updateStatus.Input...).
+					Return(test.updateStatus.Output...).Once()
+			}
+
+			service := user.NewUserService(mockRepo)
+			err := service.ChangeStatus(context.Background(), mockUser.ID, test.to, "spam", "admin-1")
+			mockRepo.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestHistoryUserService(t *testing.T) {
+	entries := []users.HistoryEntry{
+		{ID: 3, UserID: "123", Action: users.HistoryUpdate},
+		{ID: 2, UserID: "123", Action: users.HistoryStatusChange},
+		{ID: 1, UserID: "123", Action: users.HistoryCreate},
+	}
+
+	tests := []struct {
+		testName       string
+		cursor         string
+		limit          int
+		repo           testdata.FuncCall
+		expectedResult users.HistoryPage
+		expectedError  error
+	}{
+		{
+			testName: "success with default limit",
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "123", int64(0), 21},
+				Output: []interface{}{entries, nil},
+			},
+			expectedResult: users.HistoryPage{Entries: entries},
+		},
+		{
+			testName: "success with next page",
+			cursor:   "4",
+			limit:    2,
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "123", int64(4), 3},
+				Output: []interface{}{entries, nil},
+			},
+			expectedResult: users.HistoryPage{Entries: entries[:2], NextCursor: "2"},
+		},
+		{
+			testName: "with limit above the maximum",
+			limit:    1000,
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "123", int64(0), 101},
+				Output: []interface{}{entries, nil},
+			},
+			expectedResult: users.HistoryPage{Entries: entries},
+		},
+		{
+			testName: "with invalid cursor",
+			cursor:   "abc",
+			repo: testdata.FuncCall{
+				Called: false,
+			},
+			expectedError: users.ConstraintErrorf("invalid history cursor: abc"),
+		},
+		{
+			testName: "error from repository",
+			repo: testdata.FuncCall{
+				Called: true,
+				Input:  []interface{}{mock.Anything, "123", int64(0), 21},
+				Output: []interface{}{nil, errors.New("unexpected error")},
+			},
+			expectedError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			if test.repo.Called {
+				mockRepo.On("ListHistory", test.repo.Input...).
 					Return(test.repo.Output...).Once()
 			}
 
 			service := user.NewUserService(mockRepo)
-			err := service.Delete(context.Background(), test.input)
+			res, err := service.History(context.Background(), "123", test.cursor, test.limit)
 			mockRepo.AssertExpectations(t)
 
 			if test.expectedError != nil {
@@ -295,6 +1007,7 @@ func TestDeleteUserService(t *testing.T) {
 			}
 
 			require.NoError(t, err)
+			require.Equal(t, test.expectedResult, res)
 		})
 	}
 }
@@ -0,0 +1,157 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/arnaz06/users"
+)
+
+const maxStatsPeriods = 1000
+
+// defaultStatsPeriods is the number of periods of the statistics when the start of the range is not given.
+var defaultStatsPeriods = map[users.StatsInterval]int{
+	users.IntervalDay:   30,
+	users.IntervalWeek:  12,
+	users.IntervalMonth: 12,
+}
+
+// periodStart returns the start of the period of the interval the time falls in, in UTC.
+func periodStart(t time.Time, interval users.StatsInterval) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch interval {
+	case users.IntervalWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case users.IntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
+
+func addPeriods(t time.Time, interval users.StatsInterval, n int) time.Time {
+	switch interval {
+	case users.IntervalWeek:
+		return t.AddDate(0, 0, 7*n)
+	case users.IntervalMonth:
+		return t.AddDate(0, n, 0)
+	}
+	return t.AddDate(0, 0, n)
+}
+
+func newBuckets(bounds []time.Time, counts []int64) []users.StatsBucket {
+	res := make([]users.StatsBucket, 0, len(counts))
+	for i, count := range counts {
+		res = append(res, users.StatsBucket{
+			Start: bounds[i],
+			Count: count,
+		})
+	}
+	return res
+}
+
+type statsService struct {
+	repo users.StatsRepository
+}
+
+// NewStatsService creates a new statistics service.
+func NewStatsService(repo users.StatsRepository) users.StatsService {
+	return statsService{
+		repo: repo,
+	}
+}
+
+// statsBounds returns the bounds of the periods covering the range of the filter.
+func statsBounds(filter users.StatsFilter) ([]time.Time, error) {
+	to := filter.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	from := filter.From
+	if from.IsZero() {
+		from = addPeriods(periodStart(to, filter.Interval), filter.Interval, 1-defaultStatsPeriods[filter.Interval])
+	}
+	if !to.After(from) {
+		return nil, users.ConstraintErrorf("to must be after from")
+	}
+
+	bounds := []time.Time{periodStart(from, filter.Interval)}
+	for bounds[len(bounds)-1].Before(to) {
+		if len(bounds) > maxStatsPeriods {
+			return nil, users.ConstraintErrorf("time range has more than %d periods", maxStatsPeriods)
+		}
+		bounds = append(bounds, addPeriods(bounds[0], filter.Interval, len(bounds)))
+	}
+	return bounds, nil
+}
+
+func (s statsService) Stats(ctx context.Context, filter users.StatsFilter) (users.UserStats, error) {
+	if filter.Interval == "" {
+		filter.Interval = users.IntervalDay
+	}
+	if _, ok := defaultStatsPeriods[filter.Interval]; !ok {
+		return users.UserStats{}, users.ConstraintErrorf("invalid interval: %s", filter.Interval)
+	}
+
+	bounds, err := statsBounds(filter)
+	if err != nil {
+		return users.UserStats{}, err
+	}
+
+	byStatus, err := s.repo.CountByStatus(ctx)
+	if err != nil {
+		return users.UserStats{}, err
+	}
+	signups, err := s.repo.CountSignups(ctx, bounds)
+	if err != nil {
+		return users.UserStats{}, err
+	}
+	deletions, err := s.repo.CountDeletions(ctx, bounds)
+	if err != nil {
+		return users.UserStats{}, err
+	}
+	logins, err := s.repo.CountLogins(ctx, bounds)
+	if err != nil {
+		return users.UserStats{}, err
+	}
+
+	res := users.UserStats{
+		From:      bounds[0],
+		To:        bounds[len(bounds)-1],
+		Interval:  filter.Interval,
+		ByStatus:  make(map[users.Status]int64, len(transitions)),
+		Signups:   newBuckets(bounds, signups),
+		Deletions: newBuckets(bounds, deletions),
+		Logins:    newBuckets(bounds, logins),
+	}
+	for status := range transitions {
+		res.ByStatus[status] = 0
+	}
+	for status, count := range byStatus {
+		res.ByStatus[status] = count
+		res.Total += count
+	}
+	return res, nil
+}
+
+type loginRecorder struct {
+	repo users.StatsRepository
+}
+
+// NewLoginRecorder creates the handler recording the logins of the user events, for the statistics.
+func NewLoginRecorder(repo users.StatsRepository) users.UserEventHandler {
+	return loginRecorder{
+		repo: repo,
+	}
+}
+
+func (r loginRecorder) HandleUserEvent(ctx context.Context, event users.UserEvent) {
+	if event.Type != users.UserLoggedIn {
+		return
+	}
+	if err := r.repo.RecordLogin(ctx, event.UserID, time.Now()); err != nil {
+		log.Errorf("Failed to record login of user %s: %+v", event.UserID, err)
+	}
+}
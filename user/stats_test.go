@@ -0,0 +1,161 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arnaz06/users"
+	"github.com/arnaz06/users/mocks"
+	"github.com/arnaz06/users/user"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestStatsService(t *testing.T) {
+	byStatus := map[users.Status]int64{
+		users.StatusActive:    5,
+		users.StatusSuspended: 1,
+	}
+
+	tests := []struct {
+		testName       string
+		filter         users.StatsFilter
+		bounds         []time.Time
+		counts         []int64
+		countError     error
+		expectedResult users.UserStats
+		expectedError  error
+	}{
+		{
+			testName: "success by week",
+			filter: users.StatsFilter{
+				From:     time.Date(2021, 3, 3, 10, 0, 0, 0, time.UTC),
+				To:       time.Date(2021, 3, 17, 10, 0, 0, 0, time.UTC),
+				Interval: users.IntervalWeek,
+			},
+			bounds: []time.Time{date(2021, 3, 1), date(2021, 3, 8), date(2021, 3, 15), date(2021, 3, 22)},
+			counts: []int64{3, 0, 1},
+			expectedResult: users.UserStats{
+				From:     date(2021, 3, 1),
+				To:       date(2021, 3, 22),
+				Interval: users.IntervalWeek,
+				Total:    6,
+				ByStatus: map[users.Status]int64{
+					users.StatusPending:     0,
+					users.StatusActive:      5,
+					users.StatusSuspended:   1,
+					users.StatusLocked:      0,
+					users.StatusDeactivated: 0,
+				},
+				Signups:   []users.StatsBucket{{Start: date(2021, 3, 1), Count: 3}, {Start: date(2021, 3, 8), Count: 0}, {Start: date(2021, 3, 15), Count: 1}},
+				Deletions: []users.StatsBucket{{Start: date(2021, 3, 1), Count: 3}, {Start: date(2021, 3, 8), Count: 0}, {Start: date(2021, 3, 15), Count: 1}},
+				Logins:    []users.StatsBucket{{Start: date(2021, 3, 1), Count: 3}, {Start: date(2021, 3, 8), Count: 0}, {Start: date(2021, 3, 15), Count: 1}},
+			},
+		},
+		{
+			testName: "success by month ending on a period",
+			filter: users.StatsFilter{
+				From:     time.Date(2021, 1, 15, 0, 0, 0, 0, time.FixedZone("WIB", 7*3600)),
+				To:       date(2021, 3, 1),
+				Interval: users.IntervalMonth,
+			},
+			bounds: []time.Time{date(2021, 1, 1), date(2021, 2, 1), date(2021, 3, 1)},
+			counts: []int64{1, 2},
+		},
+		{
+			testName: "success with default range by day",
+			filter: users.StatsFilter{
+				To: time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC),
+			},
+			bounds: func() []time.Time {
+				res := []time.Time{}
+				for day := date(2021, 2, 9); !day.After(date(2021, 3, 11)); day = day.AddDate(0, 0, 1) {
+					res = append(res, day)
+				}
+				return res
+			}(),
+			counts: make([]int64, 30),
+		},
+		{
+			testName:      "with invalid interval",
+			filter:        users.StatsFilter{Interval: "year"},
+			expectedError: users.ConstraintErrorf("invalid interval: year"),
+		},
+		{
+			testName: "with invalid range",
+			filter: users.StatsFilter{
+				From: date(2021, 3, 1),
+				To:   date(2021, 2, 1),
+			},
+			expectedError: users.ConstraintErrorf("to must be after from"),
+		},
+		{
+			testName: "with too many periods",
+			filter: users.StatsFilter{
+				From: date(2015, 1, 1),
+				To:   date(2021, 1, 1),
+			},
+			expectedError: users.ConstraintErrorf("time range has more than 1000 periods"),
+		},
+		{
+			testName: "error from repository",
+			filter: users.StatsFilter{
+				From: date(2021, 3, 1),
+				To:   date(2021, 3, 2),
+			},
+			bounds:        []time.Time{date(2021, 3, 1), date(2021, 3, 2)},
+			countError:    errors.New("unexpected error"),
+			expectedError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			mockRepo := new(mocks.StatsRepository)
+			if test.bounds != nil {
+				mockRepo.On("CountByStatus", mock.Anything).Return(byStatus, nil).Once()
+				if test.countError != nil {
+					mockRepo.On("CountSignups", mock.Anything, test.bounds).Return(nil, test.countError).Once()
+				} else {
+					mockRepo.On("CountSignups", mock.Anything, test.bounds).Return(test.counts, nil).Once()
+					mockRepo.On("CountDeletions", mock.Anything, test.bounds).Return(test.counts, nil).Once()
+					mockRepo.On("CountLogins", mock.Anything, test.bounds).Return(test.counts, nil).Once()
+				}
+			}
+
+			service := user.NewStatsService(mockRepo)
+			res, err := service.Stats(context.Background(), test.filter)
+			mockRepo.AssertExpectations(t)
+
+			if test.expectedError != nil {
+				require.EqualError(t, err, test.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, res.Signups, len(test.bounds)-1)
+			if test.expectedResult.Interval != "" {
+				require.Equal(t, test.expectedResult, res)
+			}
+		})
+	}
+}
+
+func TestLoginRecorder(t *testing.T) {
+	mockRepo := new(mocks.StatsRepository)
+	mockRepo.On("RecordLogin", mock.Anything, "123", mock.AnythingOfType("time.Time")).Return(nil).Once()
+	mockRepo.On("RecordLogin", mock.Anything, "456", mock.AnythingOfType("time.Time")).Return(errors.New("unexpected error")).Once()
+
+	recorder := user.NewLoginRecorder(mockRepo)
+	recorder.HandleUserEvent(context.Background(), users.UserEvent{Type: users.UserLoggedIn, UserID: "123"})
+	recorder.HandleUserEvent(context.Background(), users.UserEvent{Type: users.UserLoggedIn, UserID: "456"})
+	recorder.HandleUserEvent(context.Background(), users.UserEvent{Type: users.UserUpdated, UserID: "123"})
+	mockRepo.AssertExpectations(t)
+}
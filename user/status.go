@@ -0,0 +1,21 @@
+package user
+
+import "github.com/arnaz06/users"
+
+// transitions lists the statuses a user can be moved to from each status.
+var transitions = map[users.Status][]users.Status{
+	users.StatusPending:     {users.StatusActive, users.StatusDeactivated},
+	users.StatusActive:      {users.StatusSuspended, users.StatusLocked, users.StatusDeactivated},
+	users.StatusSuspended:   {users.StatusActive, users.StatusDeactivated},
+	users.StatusLocked:      {users.StatusActive, users.StatusDeactivated},
+	users.StatusDeactivated: {users.StatusActive},
+}
+
+func canTransition(from, to users.Status) bool {
+	for _, status := range transitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package users
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// UsernameMinLength is the minimum length of a username.
+	UsernameMinLength = 3
+	// UsernameMaxLength is the maximum length of a username.
+	UsernameMaxLength = 30
+)
+
+// usernamePattern allows letters, digits, and single dots, hyphens or underscores between them.
+var usernamePattern = regexp.MustCompile(`^[a-z0-9]+([._-][a-z0-9]+)*$`)
+
+// reservedUsernames can not be taken by any user, as they could be mistaken for the service itself.
+var reservedUsernames = map[string]bool{
+	"abuse":         true,
+	"admin":         true,
+	"administrator": true,
+	"anonymous":     true,
+	"api":           true,
+	"help":          true,
+	"hostmaster":    true,
+	"login":         true,
+	"logout":        true,
+	"me":            true,
+	"moderator":     true,
+	"no-reply":      true,
+	"noreply":       true,
+	"null":          true,
+	"postmaster":    true,
+	"register":      true,
+	"root":          true,
+	"security":      true,
+	"settings":      true,
+	"signup":        true,
+	"staff":         true,
+	"support":       true,
+	"system":        true,
+	"undefined":     true,
+	"user":          true,
+	"users":         true,
+	"webmaster":     true,
+}
+
+// NormalizeUsername returns the form usernames are compared by, as they are case-insensitive.
+func NormalizeUsername(username string) string {
+	return strings.ToLower(username)
+}
+
+// ValidateUsername checks the username against the format rules and the reserved words.
+func ValidateUsername(username string) error {
+	normalized := NormalizeUsername(username)
+	if len(normalized) < UsernameMinLength || len(normalized) > UsernameMaxLength {
+		return ConstraintErrorf("username must be between %d and %d characters", UsernameMinLength, UsernameMaxLength)
+	}
+	if !usernamePattern.MatchString(normalized) {
+		return ConstraintErrorf("username %s may only contain letters, digits, and single dots, hyphens or underscores between them", username)
+	}
+	if reservedUsernames[normalized] {
+		return ConstraintErrorf("username %s is reserved", username)
+	}
+	return nil
+}